@@ -0,0 +1,195 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"gcom-backend/events"
+	"gcom-backend/models"
+	"reflect"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Log is the append-only record of state-changing API calls and drone
+// commands, so a flight can be reconstructed afterwards
+type Log struct {
+	db *gorm.DB
+}
+
+// NewLog creates a Log stored in db
+func NewLog(db *gorm.DB) *Log {
+	return &Log{db: db}
+}
+
+// Append adds entries to the log, timestamping those without a time
+func (l *Log) Append(entries ...models.AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	now := time.Now().Unix()
+	for i := range entries {
+		if entries[i].Time == 0 {
+			entries[i].Time = now
+		}
+	}
+	return l.db.Create(&entries).Error
+}
+
+// Filter narrows down a Query. Empty fields match everything.
+type Filter struct {
+	Actor      string
+	Method     string
+	Route      string
+	EntityType string
+	EntityID   string
+	//Unix times bounding the entries, inclusive
+	From int64
+	To   int64
+	//Most entries to return, 0 for all of them
+	Limit int
+}
+
+// Query returns the entries matching filter, oldest first
+func (l *Log) Query(filter Filter) ([]models.AuditEntry, error) {
+	query := l.db.Model(&models.AuditEntry{}).Order("id asc")
+	for column, value := range map[string]string{
+		"actor":       filter.Actor,
+		"method":      filter.Method,
+		"route":       filter.Route,
+		"entity_type": filter.EntityType,
+		"entity_id":   filter.EntityID,
+	} {
+		if value != "" {
+			query = query.Where(column+" = ?", value)
+		}
+	}
+	if filter.From != 0 {
+		query = query.Where("time >= ?", filter.From)
+	}
+	if filter.To != 0 {
+		query = query.Where("time <= ?", filter.To)
+	}
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	entries := []models.AuditEntry{}
+	return entries, query.Find(&entries).Error
+}
+
+// change is an entity changed by a request
+type change struct {
+	entityType string
+	entityID   string
+	diff       json.RawMessage
+	result     string
+}
+
+// Request gathers the changes made while handling one request or socket.io
+// event, which are appended together once it has been handled
+type Request struct {
+	mu      sync.Mutex
+	changes []change
+}
+
+// Change records an entity's state before and after the request. Before is
+// nil for entities which were created and after is nil for those deleted.
+func (r *Request) Change(entityType string, id any, before any, after any) {
+	r.add(change{entityType: entityType, entityID: fmt.Sprint(id), diff: Diff(before, after)})
+}
+
+// Command records a command sent to the drone, or held for confirmation,
+// along with what MPS made of it
+func (r *Request) Command(command events.Command) {
+	result := command.Status
+	if command.Error != "" {
+		result += ": " + command.Error
+	}
+	r.add(change{entityType: "command", entityID: fmt.Sprint(command.ID), diff: Diff(nil, command), result: result})
+}
+
+// Empty reports whether nothing has been recorded
+func (r *Request) Empty() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.changes) == 0
+}
+
+func (r *Request) add(c change) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.changes = append(r.changes, c)
+}
+
+// Entries returns an entry for each change, filling in the rest from base.
+// Requests which changed nothing still get one entry so failed and refused
+// calls are recorded too.
+func (r *Request) Entries(base models.AuditEntry) []models.AuditEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.changes) == 0 {
+		return []models.AuditEntry{base}
+	}
+
+	entries := make([]models.AuditEntry, 0, len(r.changes))
+	for _, c := range r.changes {
+		entry := base
+		entry.EntityType = c.entityType
+		entry.EntityID = c.entityID
+		entry.Diff = c.diff
+		entry.Result = c.result
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// Diff describes the top level JSON fields which differ between before and
+// after, as {"field": {"before": ..., "after": ...}}. Either may be nil, so
+// creations and deletions list every field.
+func Diff(before any, after any) json.RawMessage {
+	beforeFields, afterFields := fields(before), fields(after)
+
+	diff := map[string]map[string]any{}
+	for name, value := range beforeFields {
+		if other, ok := afterFields[name]; !ok || !reflect.DeepEqual(value, other) {
+			diff[name] = map[string]any{"before": value, "after": afterFields[name]}
+		}
+	}
+	for name, value := range afterFields {
+		if _, ok := beforeFields[name]; !ok {
+			diff[name] = map[string]any{"before": nil, "after": value}
+		}
+	}
+
+	data, err := json.Marshal(diff)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// fields decodes a model into its top level JSON fields
+func fields(model any) map[string]any {
+	fields := map[string]any{}
+	if model == nil {
+		return fields
+	}
+
+	data, err := json.Marshal(model)
+	if err != nil {
+		return fields
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		//Not an object, so treat it as a single value
+		var value any
+		json.Unmarshal(data, &value)
+		fields["value"] = value
+	}
+	return fields
+}
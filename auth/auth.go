@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"gcom-backend/models"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// TokenPrefix starts every token so they are easy to recognise in logs and
+// secret scanners
+const TokenPrefix = "gcom_"
+
+// SessionLifetime is how long tokens issued by Login last
+var SessionLifetime = 12 * time.Hour
+
+var (
+	ErrUnauthenticated = errors.New("missing, invalid or expired token")
+	ErrBadCredentials  = errors.New("invalid username or password")
+)
+
+// Authenticator signs users in with passwords and recognises them by their
+// tokens, which are stored hashed in the database
+type Authenticator struct {
+	db *gorm.DB
+}
+
+// NewAuthenticator creates an Authenticator backed by db
+func NewAuthenticator(db *gorm.DB) *Authenticator {
+	return &Authenticator{db: db}
+}
+
+// HashPassword hashes a password for storing on a User
+func HashPassword(password string) (string, error) {
+	if len(password) < 8 {
+		return "", errors.New("password must be at least 8 characters")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+// hashToken hashes a token for storing and lookups. Tokens are long and
+// random so a fast hash is enough.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateUser adds a user with a password and role
+func (a *Authenticator) CreateUser(username string, password string, role models.Role) (models.User, error) {
+	if strings.TrimSpace(username) == "" {
+		return models.User{}, errors.New("username is required")
+	}
+	if !role.Valid() {
+		return models.User{}, errors.New("role must be viewer, operator, pilot_in_command or admin")
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user := models.User{Username: username, Role: role, PasswordHash: hash, CreatedAt: time.Now().Unix()}
+	return user, a.db.Create(&user).Error
+}
+
+// IssueToken creates a token for a user, returning it along with the stored
+// description. expiresAt is a unix time, or 0 for a token which never expires.
+func (a *Authenticator) IssueToken(userId int, name string, expiresAt int64) (models.Token, string, error) {
+	random := make([]byte, 24)
+	if _, err := rand.Read(random); err != nil {
+		return models.Token{}, "", err
+	}
+	secret := TokenPrefix + hex.EncodeToString(random)
+
+	token := models.Token{
+		UserID:    userId,
+		Name:      name,
+		Prefix:    secret[:len(TokenPrefix)+4],
+		CreatedAt: time.Now().Unix(),
+		ExpiresAt: expiresAt,
+		Hash:      hashToken(secret),
+	}
+	return token, secret, a.db.Create(&token).Error
+}
+
+// Login checks a username and password, then issues a token which lasts
+// SessionLifetime
+func (a *Authenticator) Login(username string, password string) (models.User, models.Token, string, error) {
+	var user models.User
+	if err := a.db.Where("username = ?", username).First(&user).Error; err != nil {
+		return models.User{}, models.Token{}, "", ErrBadCredentials
+	}
+	if user.Disabled || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return models.User{}, models.Token{}, "", ErrBadCredentials
+	}
+
+	//Clear out expired sessions while we are here
+	now := time.Now()
+	a.db.Where("expires_at > 0 AND expires_at < ?", now.Unix()).Delete(&models.Token{})
+
+	token, secret, err := a.IssueToken(user.ID, "login", now.Add(SessionLifetime).Unix())
+	return user, token, secret, err
+}
+
+// Authenticate returns the user a token belongs to
+func (a *Authenticator) Authenticate(secret string) (models.User, error) {
+	if !strings.HasPrefix(secret, TokenPrefix) {
+		return models.User{}, ErrUnauthenticated
+	}
+
+	var token models.Token
+	if err := a.db.Where("hash = ?", hashToken(secret)).First(&token).Error; err != nil {
+		return models.User{}, ErrUnauthenticated
+	}
+	if token.ExpiresAt != 0 && token.ExpiresAt < time.Now().Unix() {
+		return models.User{}, ErrUnauthenticated
+	}
+
+	var user models.User
+	if err := a.db.First(&user, token.UserID).Error; err != nil || user.Disabled {
+		return models.User{}, ErrUnauthenticated
+	}
+	return user, nil
+}
+
+// Bootstrap creates an "admin" user when there are no users, so that the
+// first real users can be added. The password is taken from ADMIN_PASSWORD,
+// otherwise one is generated and returned so it can be shown once.
+func (a *Authenticator) Bootstrap() (string, error) {
+	var count int64
+	if err := a.db.Model(&models.User{}).Count(&count).Error; err != nil || count > 0 {
+		return "", err
+	}
+
+	password, fromEnv := os.LookupEnv("ADMIN_PASSWORD")
+	if !fromEnv {
+		random := make([]byte, 12)
+		if _, err := rand.Read(random); err != nil {
+			return "", err
+		}
+		password = hex.EncodeToString(random)
+	}
+
+	if _, err := a.CreateUser("admin", password, models.Admin); err != nil {
+		return "", err
+	}
+	if fromEnv {
+		return "", nil
+	}
+	return password, nil
+}
+
+// RequestToken reads the token from the Authorization header
+func RequestToken(r *http.Request) string {
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(bearer)
+	}
+	return ""
+}
+
+// StreamToken reads the token from the Authorization header, or the token
+// query param for streams such as EventSource which cannot set headers. Query
+// params end up in logs and browser history, so other routes don't accept it.
+func StreamToken(r *http.Request) string {
+	if token := RequestToken(r); token != "" {
+		return token
+	}
+	return r.URL.Query().Get("token")
+}
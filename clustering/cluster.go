@@ -0,0 +1,109 @@
+package clustering
+
+import (
+	"gcom-backend/geo"
+	"gcom-backend/models"
+	"sort"
+	"strings"
+)
+
+// DefaultRadius is how far apart in metres objects may be and still be
+// proposed as the same target
+var DefaultRadius = 15.0
+
+// Weight is how much an object counts towards the position of a merge, its
+// confidence. Objects with no confidence still count a little, so merging
+// them gives their midpoint.
+func Weight(object models.GroundObject) float64 {
+	if object.Confidence > 0 {
+		return object.Confidence
+	}
+	return 1e-6
+}
+
+// Alike reports whether two objects could be the same target judging by
+// everything but their position. Standard objects must share their shape,
+// colours and alphanumeric, emergent objects only their type.
+func Alike(a models.GroundObject, b models.GroundObject) bool {
+	if a.Type != b.Type {
+		return false
+	}
+	if a.Type != models.Standard {
+		return true
+	}
+	return a.Shape == b.Shape && a.Color == b.Color && a.TextColor == b.TextColor &&
+		strings.EqualFold(a.Text, b.Text)
+}
+
+// Centre averages the positions of objects weighted by their confidence
+func Centre(objects []models.GroundObject) (float64, float64) {
+	var lat, long, total float64
+	for _, object := range objects {
+		weight := Weight(object)
+		lat += object.Latitude * weight
+		long += object.Longitude * weight
+		total += weight
+	}
+	if total == 0 {
+		return 0, 0
+	}
+	return lat / total, long / total
+}
+
+// Best orders objects from the one to keep when merging, the most
+// confident, then the earliest created
+func Best(objects []models.GroundObject) {
+	sort.SliceStable(objects, func(i, j int) bool {
+		if objects[i].Confidence != objects[j].Confidence {
+			return objects[i].Confidence > objects[j].Confidence
+		}
+		return objects[i].ID < objects[j].ID
+	})
+}
+
+// Propose groups objects which look like the same target. Objects are taken
+// from the most confident, each joining the first group which is alike and
+// whose averaged position is within radius metres, or starting a new one.
+// Only groups of more than one object are proposed, with detections counting
+// how many detections each object has.
+func Propose(objects []models.GroundObject, detections map[int]int, radius float64) []models.MergeProposal {
+	sorted := append([]models.GroundObject(nil), objects...)
+	Best(sorted)
+
+	var groups [][]models.GroundObject
+	for _, object := range sorted {
+		joined := false
+		for i, group := range groups {
+			lat, long := Centre(group)
+			if Alike(group[0], object) && geo.Distance(lat, long, object.Latitude, object.Longitude) <= radius {
+				groups[i] = append(group, object)
+				joined = true
+				break
+			}
+		}
+		if !joined {
+			groups = append(groups, []models.GroundObject{object})
+		}
+	}
+
+	proposals := []models.MergeProposal{}
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+
+		proposal := models.MergeProposal{KeepID: group[0].ID, Confidence: group[0].Confidence}
+		proposal.Latitude, proposal.Longitude = Centre(group)
+		for _, object := range group {
+			proposal.ObjectIDs = append(proposal.ObjectIDs, object.ID)
+			proposal.Detections += detections[object.ID]
+			distance := geo.Distance(proposal.Latitude, proposal.Longitude, object.Latitude, object.Longitude)
+			if distance > proposal.Spread {
+				proposal.Spread = distance
+			}
+		}
+		sort.Ints(proposal.ObjectIDs)
+		proposals = append(proposals, proposal)
+	}
+	return proposals
+}
@@ -0,0 +1,378 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"gcom-backend/configs"
+	"gcom-backend/events"
+	"gcom-backend/flight"
+	"gcom-backend/models"
+	"gcom-backend/progress"
+	"sync"
+	"time"
+
+	"github.com/go-playground/validator"
+)
+
+// Command names, as used by socket.io events
+const (
+	Takeoff  = "takeoff"
+	Land     = "land"
+	RTL      = "rtl"
+	Lock     = "lock"
+	Unlock   = "unlock"
+	QueueSet = "queue_set"
+	Arm      = "arm"
+	Home     = "home"
+)
+
+// Command statuses
+const (
+	//Waiting for a second user to confirm it before it is sent
+	AwaitingConfirmation = "awaiting_confirmation"
+	//Accepted by the autopilot and waiting for the drone to carry it out
+	Sent = "sent"
+	//Carried out, or accepted for commands which cannot be observed
+	Completed = "completed"
+	//Refused by the autopilot
+	Failed = "failed"
+	//Replaced by a later command before it was carried out
+	Superseded = "superseded"
+	//Not carried out within Timeout
+	TimedOut = "timed_out"
+	//Refused by the second user, or withdrawn, and never sent
+	Rejected = "rejected"
+	//Not confirmed within ConfirmationTimeout and never sent
+	Expired = "expired"
+)
+
+// AltitudeTolerance is how close in metres the drone must be to a target
+// altitude for a takeoff or landing to be complete
+const AltitudeTolerance = 1.0
+
+// Timeout is how long a command may take to be carried out
+var Timeout = 10 * time.Minute
+
+// History is how many finished commands are kept for lookups
+const History = 100
+
+var validate = validator.New()
+
+// ErrNotFound is returned for commands which are unknown or too old
+var ErrNotFound = errors.New("command not found")
+
+// Commander is the single path commands take to the autopilot, from REST and
+// socket.io alike. Commands are validated, sent, numbered and published on
+// the event bus, then followed through telemetry until they are carried out.
+// Safety critical commands can be held until a second user confirms them.
+type Commander struct {
+	mu       sync.Mutex
+	mp       configs.Autopilot
+	bus      *events.Bus
+	tracker  *progress.Tracker
+	recorder *flight.Recorder
+	lastID   uint64
+	//Commands waiting to be carried out, with what carries them out
+	active map[uint64]func(models.Drone) bool
+	recent []events.Command
+	//Commands which need a second user, and those waiting for one
+	confirm map[string]bool
+	pending map[uint64]*pendingCommand
+}
+
+// pendingCommand is what is needed to send a command once it is confirmed
+type pendingCommand struct {
+	send  func() (bool, error)
+	done  func(models.Drone) bool
+	timer *time.Timer
+}
+
+// NewCommander creates a Commander. The bus, tracker and recorder may be
+// nil, in which case commands are not published or followed, queues are not
+// tracked and flights are not recorded.
+func NewCommander(mp configs.Autopilot, bus *events.Bus, tracker *progress.Tracker, recorder *flight.Recorder) *Commander {
+	commander := &Commander{
+		mp:       mp,
+		bus:      bus,
+		tracker:  tracker,
+		recorder: recorder,
+		active:   map[uint64]func(models.Drone) bool{},
+		confirm:  map[string]bool{},
+		pending:  map[uint64]*pendingCommand{},
+	}
+	if bus != nil {
+		bus.Handle("commands", events.Options{Topics: []events.Topic{events.Telemetry, events.Progress}},
+			commander.follow)
+	}
+	return commander
+}
+
+// accepted adapts an autopilot call which only reports success
+func accepted(send func() bool) func() (bool, error) {
+	return func() (bool, error) {
+		return send(), nil
+	}
+}
+
+// Takeoff tells the drone to take off to an altitude in metres
+func (c *Commander) Takeoff(by string, altitude float64) (events.Command, error) {
+	if altitude <= 0 {
+		return events.Command{}, errors.New("altitude must be positive")
+	}
+
+	return c.issue(by, Takeoff, map[string]float64{"altitude": altitude}, accepted(func() bool {
+		return c.mp.Takeoff(altitude)
+	}), func(drone models.Drone) bool {
+		return drone.Altitude >= altitude-AltitudeTolerance
+	}), nil
+}
+
+// Land tells the drone to land where it is
+func (c *Commander) Land(by string) (events.Command, error) {
+	return c.issue(by, Land, nil, accepted(c.mp.Land), landed), nil
+}
+
+// RTL tells the drone to return home at an altitude in metres and land
+func (c *Commander) RTL(by string, altitude float64) (events.Command, error) {
+	if altitude < 0 {
+		return events.Command{}, errors.New("altitude cannot be negative")
+	}
+
+	return c.issue(by, RTL, map[string]float64{"altitude": altitude}, accepted(func() bool {
+		return c.mp.ReturnHome(altitude)
+	}), landed), nil
+}
+
+// Lock halts the drone in place while preserving its queue
+func (c *Commander) Lock(by string) (events.Command, error) {
+	return c.issue(by, Lock, nil, accepted(c.mp.Lock), nil), nil
+}
+
+// Unlock resumes the queue after Lock
+func (c *Commander) Unlock(by string) (events.Command, error) {
+	return c.issue(by, Unlock, nil, accepted(c.mp.Unlock), nil), nil
+}
+
+// Arm arms the drone with 1 or disarms it with 0. Arming starts recording a
+// flight and disarming stops it.
+func (c *Commander) Arm(by string, arm int) (events.Command, error) {
+	if arm != 0 && arm != 1 {
+		return events.Command{}, errors.New("arm must be 0 or 1")
+	}
+
+	return c.issue(by, Arm, map[string]int{"arm": arm}, func() (bool, error) {
+		if !c.mp.Arm(arm) {
+			return false, nil
+		}
+		if c.recorder == nil {
+			return true, nil
+		}
+
+		var err error
+		if arm == 1 {
+			_, err = c.recorder.Start()
+		} else if _, stopErr := c.recorder.Stop(); stopErr != flight.ErrNoSession {
+			err = stopErr
+		}
+		if err != nil {
+			return true, fmt.Errorf("the flight session could not be saved: %w", err)
+		}
+		return true, nil
+	}, nil), nil
+}
+
+// SetQueue replaces the queue of waypoints the drone flies and starts
+// tracking progress through it. The command is carried out once every
+// waypoint has been reached.
+func (c *Commander) SetQueue(by string, queue []models.Waypoint) (events.Command, error) {
+	for i := range queue {
+		if err := validate.Struct(&queue[i]); err != nil {
+			return events.Command{}, err
+		}
+	}
+
+	var done func(models.Drone) bool
+	if c.tracker != nil && len(queue) > 0 {
+		done = func(models.Drone) bool {
+			snapshot := c.tracker.Snapshot()
+			return snapshot.Total > 0 && snapshot.Reached == snapshot.Total
+		}
+	}
+
+	return c.issue(by, QueueSet, queue, accepted(func() bool {
+		if !c.mp.SetQueue(queue) {
+			return false
+		}
+		if c.tracker != nil {
+			c.tracker.SetQueue(queue)
+		}
+		return true
+	}), done), nil
+}
+
+// SetHome updates the home waypoint
+func (c *Commander) SetHome(by string, waypoint models.Waypoint) (events.Command, error) {
+	if err := validate.Struct(&waypoint); err != nil {
+		return events.Command{}, err
+	}
+
+	return c.issue(by, Home, waypoint, accepted(func() bool {
+		return c.mp.SetHome(waypoint)
+	}), nil), nil
+}
+
+// Get returns a recent command by ID
+func (c *Commander) Get(id uint64) (events.Command, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	command, i := c.find(id)
+	if i < 0 {
+		return command, ErrNotFound
+	}
+	return command, nil
+}
+
+// landed reports whether the drone is on the ground
+func landed(drone models.Drone) bool {
+	return drone.Altitude <= AltitudeTolerance
+}
+
+// issue numbers a command and either holds it for confirmation or sends it
+func (c *Commander) issue(by string, name string, params any, send func() (bool, error), done func(models.Drone) bool) events.Command {
+	now := time.Now().Unix()
+
+	c.mu.Lock()
+	c.lastID++
+	command := events.Command{ID: c.lastID, Name: name, Params: params, RequestedBy: by, Issued: now, Updated: now}
+	if !c.confirm[name] {
+		c.remember(command)
+		c.mu.Unlock()
+		return c.execute(command, send, done)
+	}
+
+	command.Status = AwaitingConfirmation
+	command.ExpiresAt = time.Now().Add(ConfirmationTimeout).Unix()
+	id := command.ID
+	c.pending[id] = &pendingCommand{send: send, done: done, timer: time.AfterFunc(ConfirmationTimeout, func() {
+		c.expire(id)
+	})}
+	c.remember(command)
+	c.mu.Unlock()
+
+	fmt.Println("[COMMAND]", name, id, "requested by", by, "awaiting confirmation")
+	c.publish(events.Requested, command)
+	return command
+}
+
+// execute sends a command and publishes it. Commands with a done check are
+// followed until it passes, others are complete once sent. Queues supersede
+// earlier queues, and takeoffs and landings earlier takeoffs and landings.
+func (c *Commander) execute(command events.Command, send func() (bool, error), done func(models.Drone) bool) events.Command {
+	success, err := send()
+	now := time.Now().Unix()
+
+	c.mu.Lock()
+	command.Success = success
+	command.Updated = now
+	if err != nil {
+		command.Error = err.Error()
+	}
+	var superseded []events.Command
+	switch {
+	case !success:
+		command.Status = Failed
+	case done == nil:
+		command.Status = Completed
+	default:
+		command.Status = Sent
+		for id := range c.active {
+			if previous, _ := c.find(id); (previous.Name == QueueSet) != (command.Name == QueueSet) {
+				continue
+			}
+			superseded = append(superseded, c.finish(id, Superseded, now))
+		}
+		c.active[command.ID] = done
+	}
+	if _, i := c.find(command.ID); i >= 0 {
+		c.recent[i] = command
+	}
+	c.mu.Unlock()
+
+	c.publish(events.Sent, command)
+	for _, previous := range superseded {
+		c.publish(events.Updated, previous)
+	}
+	return command
+}
+
+// follow checks the commands being followed against telemetry and progress
+func (c *Commander) follow(event events.Event) {
+	var drone models.Drone
+	if event.Topic == events.Telemetry {
+		drone, _ = event.Model.(models.Drone)
+	}
+	now := time.Now().Unix()
+
+	c.mu.Lock()
+	var finished []events.Command
+	for id, done := range c.active {
+		command, _ := c.find(id)
+		switch {
+		case event.Topic == events.Telemetry && command.Name != QueueSet && done(drone):
+			finished = append(finished, c.finish(id, Completed, now))
+		case event.Topic == events.Progress && command.Name == QueueSet && done(drone):
+			finished = append(finished, c.finish(id, Completed, now))
+		case time.Duration(now-command.Issued)*time.Second > Timeout:
+			finished = append(finished, c.finish(id, TimedOut, now))
+		}
+	}
+	c.mu.Unlock()
+
+	for _, command := range finished {
+		c.publish(events.Updated, command)
+	}
+}
+
+// find returns a recent command, called with the lock held
+func (c *Commander) find(id uint64) (events.Command, int) {
+	for i, command := range c.recent {
+		if command.ID == id {
+			return command, i
+		}
+	}
+	return events.Command{}, -1
+}
+
+// finish stops following a command, called with the lock held
+func (c *Commander) finish(id uint64, status string, now int64) events.Command {
+	delete(c.active, id)
+	command, i := c.find(id)
+	if i < 0 {
+		return command
+	}
+	command.Status = status
+	command.Updated = now
+	c.recent[i] = command
+	return command
+}
+
+// remember keeps a command for lookups, called with the lock held. Commands
+// awaiting confirmation are kept until they are confirmed or dropped.
+func (c *Commander) remember(command events.Command) {
+	c.recent = append(c.recent, command)
+	for len(c.recent) > History {
+		old := c.recent[0]
+		if _, waiting := c.pending[old.ID]; waiting {
+			break
+		}
+		delete(c.active, old.ID)
+		c.recent = c.recent[1:]
+	}
+}
+
+func (c *Commander) publish(action events.Action, command events.Command) {
+	if c.bus != nil {
+		c.bus.Publish(events.Event{Topic: events.Commands, Action: action, Model: command})
+	}
+}
@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"gcom-backend/events"
+	"time"
+)
+
+// ConfirmationTimeout is how long a command waits for a second user before
+// it expires
+var ConfirmationTimeout = time.Minute
+
+var (
+	ErrNotPending = errors.New("command is not awaiting confirmation")
+	ErrSameUser   = errors.New("a command must be confirmed or rejected by a different user than requested it")
+)
+
+// RequireConfirmation holds the named commands until a second user confirms
+// them, as the safety procedure asks for arming, disarming and takeoff
+func (c *Commander) RequireConfirmation(names ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, name := range names {
+		c.confirm[name] = true
+	}
+}
+
+// Pending returns the commands awaiting confirmation, oldest first
+func (c *Commander) Pending() []events.Command {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pending := []events.Command{}
+	for _, command := range c.recent {
+		if _, waiting := c.pending[command.ID]; waiting {
+			pending = append(pending, command)
+		}
+	}
+	return pending
+}
+
+// take removes a command from those awaiting confirmation so only one of
+// confirming, rejecting and expiring can happen to it. allow may refuse.
+func (c *Commander) take(id uint64, allow func(events.Command) error) (events.Command, *pendingCommand, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	command, i := c.find(id)
+	if i < 0 {
+		return command, nil, ErrNotFound
+	}
+	pending, waiting := c.pending[id]
+	if !waiting {
+		return command, nil, ErrNotPending
+	}
+	if allow != nil {
+		if err := allow(command); err != nil {
+			return command, nil, err
+		}
+	}
+
+	pending.timer.Stop()
+	delete(c.pending, id)
+	return command, pending, nil
+}
+
+// secondUser refuses a command being reviewed by whoever requested it
+func secondUser(by string) func(events.Command) error {
+	return func(command events.Command) error {
+		if command.RequestedBy == by {
+			return ErrSameUser
+		}
+		return nil
+	}
+}
+
+// Confirm sends a command awaiting confirmation on behalf of a second user
+func (c *Commander) Confirm(id uint64, by string) (events.Command, error) {
+	command, pending, err := c.take(id, secondUser(by))
+	if err != nil {
+		return command, err
+	}
+
+	command.ReviewedBy = by
+	fmt.Println("[COMMAND]", command.Name, id, "requested by", command.RequestedBy, "confirmed by", by)
+	c.publish(events.Confirmed, command)
+	return c.execute(command, pending.send, pending.done), nil
+}
+
+// Reject drops a command awaiting confirmation without sending it on behalf
+// of a second user. A command nobody else reviews expires instead.
+func (c *Commander) Reject(id uint64, by string) (events.Command, error) {
+	command, _, err := c.take(id, secondUser(by))
+	if err != nil {
+		return command, err
+	}
+
+	command.ReviewedBy = by
+	command = c.drop(command, Rejected)
+	fmt.Println("[COMMAND]", command.Name, id, "requested by", command.RequestedBy, "rejected by", by)
+	c.publish(events.Rejected, command)
+	return command, nil
+}
+
+// expire drops a command nobody confirmed in time
+func (c *Commander) expire(id uint64) {
+	command, _, err := c.take(id, nil)
+	if err != nil {
+		return
+	}
+
+	command = c.drop(command, Expired)
+	fmt.Println("[COMMAND]", command.Name, id, "requested by", command.RequestedBy, "expired unconfirmed")
+	c.publish(events.Expired, command)
+}
+
+// drop records that a command will never be sent
+func (c *Commander) drop(command events.Command, status string) events.Command {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	command.Status = status
+	command.Updated = time.Now().Unix()
+	if _, i := c.find(command.ID); i >= 0 {
+		c.recent[i] = command
+	}
+	return command
+}
@@ -0,0 +1,79 @@
+package configs
+
+import (
+	"gcom-backend/models"
+	"log"
+	"os"
+	"strconv"
+)
+
+// Autopilot is implemented by every backend able to fly the drone, either
+// MissionPlanner through MPS or the built-in Simulator
+type Autopilot interface {
+	GetQueue() []models.Waypoint
+	GetStatus() models.Drone
+	ReturnHome(alt float64) bool
+	Land() bool
+	Lock() bool
+	Unlock() bool
+	SetQueue(waypoints []models.Waypoint) bool
+	Takeoff(alt float64) bool
+	Arm(arm int) bool
+	SetHome(waypoint models.Waypoint) bool
+	SetFlightMode(mode string, drone string, altStandard string) bool
+}
+
+// ConnectAutopilot creates the backend chosen by the AUTOPILOT environment
+// variable, "mps" (default) or "simulator" - this should only be in main.go
+//
+//	MPS_URL                         URL of MPS, default http://host.docker.internal:9000
+//	SIM_RATE                        Telemetry samples per second, at most 1, default 1
+//	SIM_CRUISE_SPEED                Horizontal speed in m/s, default 15
+//	SIM_CLIMB_RATE                  Vertical speed in m/s, default 3
+//	SIM_BATTERY_VOLTAGE             Voltage of a full battery, default 16.8
+//	SIM_BATTERY_DRAIN               Volts used per minute of flight, default 0.2
+//	SIM_WIND_SPEED                  Wind speed in m/s, default 0
+//	SIM_WIND_DIRECTION              Direction the wind blows from in degrees, default 0
+//	SIM_HOME_LATITUDE               Latitude the drone starts at
+//	SIM_HOME_LONGITUDE              Longitude the drone starts at
+func ConnectAutopilot() (Autopilot, error) {
+	if os.Getenv("AUTOPILOT") == "simulator" {
+		config := DefaultSimulatorConfig()
+		if rate := envFloat("SIM_RATE", config.Rate); rate > 0 && rate <= MaxSimulatorRate {
+			config.Rate = rate
+		} else {
+			log.Printf("[Config] Ignoring SIM_RATE=%g, it must be greater than 0 and at most %g", rate, MaxSimulatorRate)
+		}
+		config.CruiseSpeed = envFloat("SIM_CRUISE_SPEED", config.CruiseSpeed)
+		config.ClimbRate = envFloat("SIM_CLIMB_RATE", config.ClimbRate)
+		config.BatteryVoltage = envFloat("SIM_BATTERY_VOLTAGE", config.BatteryVoltage)
+		config.BatteryDrain = envFloat("SIM_BATTERY_DRAIN", config.BatteryDrain)
+		config.WindSpeed = envFloat("SIM_WIND_SPEED", config.WindSpeed)
+		config.WindDirection = envFloat("SIM_WIND_DIRECTION", config.WindDirection)
+		config.Home.Latitude = envFloat("SIM_HOME_LATITUDE", config.Home.Latitude)
+		config.Home.Longitude = envFloat("SIM_HOME_LONGITUDE", config.Home.Longitude)
+		return NewSimulator(config), nil
+	}
+
+	url := os.Getenv("MPS_URL")
+	if url == "" {
+		url = "http://host.docker.internal:9000"
+	}
+	return ConnectMissionPlanner(url)
+}
+
+// envFloat reads a number from the environment, keeping the default if it
+// is unset or invalid
+func envFloat(key string, fallback float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("[Config] Ignoring invalid %s=%q", key, value)
+		return fallback
+	}
+	return number
+}
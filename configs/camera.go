@@ -0,0 +1,26 @@
+package configs
+
+import "gcom-backend/imagery"
+
+// DefaultCamera is an APS-C sensor behind a 16mm lens
+func DefaultCamera() imagery.Camera {
+	return imagery.Camera{
+		FocalLength:  16,
+		SensorWidth:  23.5,
+		SensorHeight: 15.6,
+	}
+}
+
+// CameraFromEnv describes the camera on the drone, used to georeference its
+// images, from the environment - this should only be in main.go
+//
+//	CAMERA_FOCAL_LENGTH             Focal length of the lens in mm, default 16
+//	CAMERA_SENSOR_WIDTH             Width of the sensor in mm, default 23.5
+//	CAMERA_SENSOR_HEIGHT            Height of the sensor in mm, default 15.6
+func CameraFromEnv() imagery.Camera {
+	camera := DefaultCamera()
+	camera.FocalLength = envFloat("CAMERA_FOCAL_LENGTH", camera.FocalLength)
+	camera.SensorWidth = envFloat("CAMERA_SENSOR_WIDTH", camera.SensorWidth)
+	camera.SensorHeight = envFloat("CAMERA_SENSOR_HEIGHT", camera.SensorHeight)
+	return camera
+}
@@ -0,0 +1,28 @@
+package configs
+
+import (
+	"gcom-backend/interop"
+	"os"
+)
+
+// InteropFromEnv describes the competition's interop server from the
+// environment - this should only be in main.go. Interop is off unless
+// INTEROP_URL is set.
+//
+//	INTEROP_URL                     Address of the interop server, such as http://10.10.130.10:80
+//	INTEROP_USERNAME                Team username
+//	INTEROP_PASSWORD                Team password
+//	INTEROP_MISSION                 ID of the mission being flown, default 1
+//	INTEROP_TELEMETRY_RATE          Telemetry uploads a second, default 2
+//	INTEROP_HOME_ALTITUDE           Height of home above mean sea level in metres, default 0
+func InteropFromEnv() (interop.Config, bool) {
+	config := interop.Config{
+		URL:           os.Getenv("INTEROP_URL"),
+		Username:      os.Getenv("INTEROP_USERNAME"),
+		Password:      os.Getenv("INTEROP_PASSWORD"),
+		Mission:       int(envFloat("INTEROP_MISSION", 1)),
+		TelemetryRate: envFloat("INTEROP_TELEMETRY_RATE", interop.DefaultRate),
+		HomeAltitude:  envFloat("INTEROP_HOME_ALTITUDE", 0),
+	}
+	return config, config.URL != ""
+}
@@ -0,0 +1,25 @@
+package configs
+
+import (
+	"gcom-backend/processing"
+	"os"
+	"strings"
+)
+
+// ProcessorsFromEnv describes the detectors run on every uploaded image,
+// from the environment - this should only be in main.go. Go processors are
+// registered on the runner directly.
+//
+//	DETECTOR_COMMAND                Program and arguments given each image as JSON on stdin, none if unset
+//	DETECTOR_URL                    Detector each image is posted to as JSON, none if unset
+//	PROCESSING_WORKERS              How many images are processed at once, default 2
+func ProcessorsFromEnv() ([]processing.Processor, int) {
+	processors := []processing.Processor{}
+	if command := strings.Fields(os.Getenv("DETECTOR_COMMAND")); len(command) > 0 {
+		processors = append(processors, processing.NewCommand("command", command[0], command[1:]...))
+	}
+	if url := os.Getenv("DETECTOR_URL"); url != "" {
+		processors = append(processors, processing.NewHTTP("http", url))
+	}
+	return processors, int(envFloat("PROCESSING_WORKERS", 2))
+}
@@ -0,0 +1,343 @@
+package configs
+
+import (
+	"gcom-backend/geo"
+	"gcom-backend/models"
+	"log"
+	"math"
+	"sync"
+	"time"
+)
+
+// Simulator flight modes
+const (
+	SimIdle    = "idle"
+	SimTakeoff = "takeoff"
+	SimMission = "mission"
+	SimHold    = "hold"
+	SimRTL     = "rtl"
+	SimLand    = "land"
+)
+
+// MaxSimulatorRate is the most telemetry samples per second the simulator
+// sends. Telemetry is stored by whole-second timestamps, so faster samples
+// would overwrite each other.
+const MaxSimulatorRate = 1.0
+
+// SimulatorConfig describes the simulated drone
+type SimulatorConfig struct {
+	//Telemetry samples per second, greater than 0 and at most MaxSimulatorRate
+	Rate float64
+	//Horizontal airspeed in m/s
+	CruiseSpeed float64
+	//Vertical speed in m/s for takeoff, landing and altitude changes
+	ClimbRate float64
+	//Voltage of a full battery
+	BatteryVoltage float64
+	//Volts used per minute while flying
+	BatteryDrain float64
+	//Wind speed in m/s and the direction it blows from in degrees
+	WindSpeed     float64
+	WindDirection float64
+	//Where the drone starts, on the ground
+	Home models.Waypoint
+}
+
+// DefaultSimulatorConfig is a quadcopter at UBC with no wind
+func DefaultSimulatorConfig() SimulatorConfig {
+	return SimulatorConfig{
+		Rate:           1,
+		CruiseSpeed:    15,
+		ClimbRate:      3,
+		BatteryVoltage: 16.8,
+		BatteryDrain:   0.2,
+		Home:           models.Waypoint{ID: -1, Name: "Home", Latitude: 49.2606, Longitude: -123.2460},
+	}
+}
+
+// Simulator is an Autopilot flying a simple kinematic model, so the ground
+// station can be exercised without MPS or SITL. The drone flies straight at
+// each queued waypoint at cruise speed and is pushed off course by the wind.
+type Simulator struct {
+	mu     sync.Mutex
+	config SimulatorConfig
+
+	mode      string
+	armed     bool
+	locked    bool
+	home      models.Waypoint
+	queue     []models.Waypoint
+	targetAlt float64
+
+	latitude      float64
+	longitude     float64
+	altitude      float64
+	speed         float64
+	verticalSpeed float64
+	heading       float64
+	battery       float64
+	clock         time.Time
+}
+
+// NewSimulator creates a Simulator with the drone disarmed at home. A rate
+// out of range is replaced with MaxSimulatorRate.
+func NewSimulator(config SimulatorConfig) *Simulator {
+	if config.Rate <= 0 || config.Rate > MaxSimulatorRate {
+		config.Rate = MaxSimulatorRate
+	}
+	return &Simulator{
+		config:    config,
+		mode:      SimIdle,
+		home:      config.Home,
+		latitude:  config.Home.Latitude,
+		longitude: config.Home.Longitude,
+		battery:   config.BatteryVoltage,
+		clock:     time.Now(),
+	}
+}
+
+// Start advances the simulation in real time, passing telemetry to publish
+// at the configured rate until stop is closed
+func (s *Simulator) Start(publish func(models.Drone) error, stop <-chan struct{}) {
+	interval := time.Duration(float64(time.Second) / s.config.Rate)
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		last := time.Now()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				s.Step(now.Sub(last))
+				last = now
+				if err := publish(s.GetStatus()); err != nil {
+					log.Println("[Simulator] Error publishing telemetry: " + err.Error())
+				}
+			}
+		}
+	}()
+}
+
+// Step advances the simulation by dt
+func (s *Simulator) Step(dt time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seconds := dt.Seconds()
+	if seconds <= 0 {
+		return
+	}
+	s.clock = s.clock.Add(dt)
+	s.speed = 0
+	s.verticalSpeed = 0
+
+	switch s.mode {
+	case SimTakeoff:
+		if s.climb(s.targetAlt, seconds) {
+			s.mode = SimHold
+			if len(s.queue) > 0 {
+				s.mode = SimMission
+			}
+		}
+	case SimMission:
+		if s.locked {
+			break
+		}
+		if len(s.queue) == 0 {
+			s.mode = SimHold
+			break
+		}
+		target := s.queue[0]
+		s.climb(target.Altitude, seconds)
+		if s.fly(target.Latitude, target.Longitude, target.Radius, seconds) {
+			s.queue = s.queue[1:]
+		}
+	case SimRTL:
+		s.climb(s.targetAlt, seconds)
+		if s.fly(s.home.Latitude, s.home.Longitude, 0, seconds) {
+			s.mode = SimLand
+		}
+	case SimLand:
+		if s.climb(0, seconds) {
+			s.mode = SimIdle
+		}
+	}
+
+	if s.mode != SimIdle {
+		s.battery = math.Max(0, s.battery-s.config.BatteryDrain*seconds/60)
+	}
+}
+
+// climb moves towards an altitude at the climb rate, returning true once there
+func (s *Simulator) climb(altitude float64, seconds float64) bool {
+	step := s.config.ClimbRate * seconds
+	difference := altitude - s.altitude
+	if math.Abs(difference) <= step {
+		s.verticalSpeed = difference / seconds
+		s.altitude = altitude
+		return true
+	}
+
+	s.verticalSpeed = math.Copysign(s.config.ClimbRate, difference)
+	s.altitude += s.verticalSpeed * seconds
+	return false
+}
+
+// fly heads towards a point at cruise speed, crabbing into the wind to hold
+// the ground track like an autopilot would, returning true once within radius
+// of the point
+func (s *Simulator) fly(lat float64, long float64, radius float64, seconds float64) bool {
+	distance := geo.Distance(s.latitude, s.longitude, lat, long)
+	track := geo.Bearing(s.latitude, s.longitude, lat, long) * math.Pi / 180
+
+	//Wind blows towards the opposite of the direction it comes from
+	windDirection := s.config.WindDirection * math.Pi / 180
+	windNorth := -s.config.WindSpeed * math.Cos(windDirection)
+	windEast := -s.config.WindSpeed * math.Sin(windDirection)
+
+	//Ground speed along the track such that the airspeed is the cruise speed
+	tailwind := windNorth*math.Cos(track) + windEast*math.Sin(track)
+	crosswind := s.config.WindSpeed*s.config.WindSpeed - tailwind*tailwind
+	groundSpeed := tailwind + math.Sqrt(math.Max(0, s.config.CruiseSpeed*s.config.CruiseSpeed-crosswind))
+	groundSpeed = math.Max(0, math.Min(groundSpeed, distance/seconds))
+
+	airNorth := groundSpeed*math.Cos(track) - windNorth
+	airEast := groundSpeed*math.Sin(track) - windEast
+	s.heading = math.Mod(math.Atan2(airEast, airNorth)*180/math.Pi+360, 360)
+	s.speed = groundSpeed
+	s.latitude, s.longitude = geo.Destination(s.latitude, s.longitude, track*180/math.Pi, groundSpeed*seconds)
+
+	return geo.Distance(s.latitude, s.longitude, lat, long) <= math.Max(radius, 1)
+}
+
+// Mode returns the flight mode of the simulated drone
+func (s *Simulator) Mode() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.mode
+}
+
+func (s *Simulator) GetQueue() []models.Waypoint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]models.Waypoint{}, s.queue...)
+}
+
+func (s *Simulator) GetStatus() models.Drone {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return models.Drone{
+		Timestamp:      s.clock.Unix(),
+		Latitude:       s.latitude,
+		Longitude:      s.longitude,
+		Altitude:       s.altitude,
+		VerticalSpeed:  s.verticalSpeed,
+		Speed:          s.speed,
+		Heading:        s.heading,
+		BatteryVoltage: s.battery,
+	}
+}
+
+func (s *Simulator) ReturnHome(alt float64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.mode == SimIdle {
+		return false
+	}
+	s.mode = SimRTL
+	s.targetAlt = alt
+	s.locked = false
+	return true
+}
+
+func (s *Simulator) Land() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.mode == SimIdle {
+		return false
+	}
+	s.mode = SimLand
+	s.locked = false
+	return true
+}
+
+func (s *Simulator) Lock() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.mode == SimIdle || s.locked {
+		return false
+	}
+	s.locked = true
+	return true
+}
+
+func (s *Simulator) Unlock() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.locked {
+		return false
+	}
+	s.locked = false
+	return true
+}
+
+func (s *Simulator) SetQueue(waypoints []models.Waypoint) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.queue = append([]models.Waypoint{}, waypoints...)
+	if s.mode == SimHold && len(s.queue) > 0 {
+		s.mode = SimMission
+	}
+	return true
+}
+
+func (s *Simulator) Takeoff(alt float64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.armed || s.mode != SimIdle || alt <= 0 {
+		return false
+	}
+	s.mode = SimTakeoff
+	s.targetAlt = alt
+	return true
+}
+
+func (s *Simulator) Arm(arm int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	//Disarming in the air would drop the drone
+	if arm == 0 && s.mode != SimIdle {
+		return false
+	}
+	s.armed = arm == 1
+	return true
+}
+
+func (s *Simulator) SetHome(waypoint models.Waypoint) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.home = waypoint
+	//A drone on the ground is moved to its new home
+	if s.mode == SimIdle {
+		s.latitude = waypoint.Latitude
+		s.longitude = waypoint.Longitude
+	}
+	return true
+}
+
+func (s *Simulator) SetFlightMode(mode string, drone string, altStandard string) bool {
+	return true
+}
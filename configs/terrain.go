@@ -0,0 +1,26 @@
+package configs
+
+import (
+	"gcom-backend/imagery"
+	"os"
+)
+
+// TerrainFromEnv describes the ground images are projected onto, from the
+// environment - this should only be in main.go
+//
+//	DEM_PATH                        ESRI ASCII grid of ground heights in decimal degrees, flat ground if unset
+//	DEM_OFFSET                      Subtracted from DEM heights, such as the height of home, default 0
+//	GROUND_HEIGHT                   Height of flat ground, default 0
+func TerrainFromEnv() (imagery.Terrain, error) {
+	path := os.Getenv("DEM_PATH")
+	if path == "" {
+		return imagery.FlatGround{Height: envFloat("GROUND_HEIGHT", 0)}, nil
+	}
+
+	dem, err := imagery.LoadDEM(path)
+	if err != nil {
+		return nil, err
+	}
+	dem.Offset = envFloat("DEM_OFFSET", 0)
+	return dem, nil
+}
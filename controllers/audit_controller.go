@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"encoding/json"
+	"gcom-backend/audit"
+	"gcom-backend/events"
+	"gcom-backend/models"
+	"gcom-backend/responses"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// auditChange records an entity changed by this request in the audit log.
+// Before is nil for entities created and after is nil for those deleted.
+// Nothing is recorded if the request is not audited, as in most tests.
+func auditChange(c echo.Context, entityType string, id any, before any, after any) {
+	if request, ok := c.Get("audit_request").(*audit.Request); ok {
+		request.Change(entityType, id, before, after)
+	}
+}
+
+// auditCommand records a drone command sent by this request and its result
+func auditCommand(c echo.Context, command events.Command) {
+	if request, ok := c.Get("audit_request").(*audit.Request); ok && command.ID != 0 {
+		request.Command(command)
+	}
+}
+
+// auditFilter reads the filters shared by GetAuditLog and ExportAuditLog
+func auditFilter(c echo.Context) (audit.Filter, error) {
+	filter := audit.Filter{
+		Actor:      c.QueryParam("actor"),
+		Method:     c.QueryParam("method"),
+		Route:      c.QueryParam("route"),
+		EntityType: c.QueryParam("entity_type"),
+		EntityID:   c.QueryParam("entity_id"),
+	}
+
+	var err error
+	for param, value := range map[string]*int64{"from": &filter.From, "to": &filter.To} {
+		if text := c.QueryParam(param); text != "" {
+			if *value, err = strconv.ParseInt(text, 10, 64); err != nil {
+				return filter, err
+			}
+		}
+	}
+	if text := c.QueryParam("limit"); text != "" {
+		if filter.Limit, err = strconv.Atoi(text); err != nil {
+			return filter, err
+		}
+	}
+	return filter, nil
+}
+
+// GetAuditLog gets entries from the audit log
+//
+//	@Summary		Get the audit log
+//	@Description	Get the record of state-changing API calls and drone commands, oldest first, optionally filtered
+//	@Tags			Audit
+//	@Produce		json
+//	@Param			actor		query		string	false	"Username of whoever made the call"
+//	@Param			method		query		string	false	"HTTP method, or socket.io for socket events"
+//	@Param			route		query		string	false	"Route as registered, such as /waypoint/:waypointId"
+//	@Param			entity_type	query		string	false	"Kind of entity changed, such as waypoint or command"
+//	@Param			entity_id	query		string	false	"ID of the entity changed"
+//	@Param			from		query		int		false	"Earliest unix time"
+//	@Param			to			query		int		false	"Latest unix time"
+//	@Param			limit		query		int		false	"Most entries to return"
+//	@Success		200			{object}	responses.MultipleResponse[models.AuditEntry]
+//	@Failure		400			{object}	responses.ErrorResponse	"Invalid Filter"
+//	@Failure		500			{object}	responses.ErrorResponse	"Internal Error Querying Audit Log"
+//	@Security		BearerAuth
+//	@Router			/audit [get]
+func GetAuditLog(c echo.Context) error {
+	log := c.Get("audit").(*audit.Log)
+
+	filter, err := auditFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid filter",
+			Data:    err.Error()})
+	}
+
+	entries, err := log.Query(filter)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying the audit log!",
+			Data:    err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, responses.MultipleResponse[models.AuditEntry]{
+		Message: "Audit log found!",
+		Models:  entries,
+	})
+}
+
+// ExportAuditLog exports the audit log as JSON lines
+//
+//	@Summary		Export the audit log
+//	@Description	Download the audit log with one JSON entry per line, oldest first, taking the same filters as /audit
+//	@Tags			Audit
+//	@Produce		plain
+//	@Param			actor		query		string	false	"Username of whoever made the call"
+//	@Param			method		query		string	false	"HTTP method, or socket.io for socket events"
+//	@Param			route		query		string	false	"Route as registered, such as /waypoint/:waypointId"
+//	@Param			entity_type	query		string	false	"Kind of entity changed, such as waypoint or command"
+//	@Param			entity_id	query		string	false	"ID of the entity changed"
+//	@Param			from		query		int		false	"Earliest unix time"
+//	@Param			to			query		int		false	"Latest unix time"
+//	@Param			limit		query		int		false	"Most entries to return"
+//	@Success		200			{file}		file	"JSONL file attachment"
+//	@Failure		400			{object}	responses.ErrorResponse	"Invalid Filter"
+//	@Failure		500			{object}	responses.ErrorResponse	"Internal Error Querying Audit Log"
+//	@Security		BearerAuth
+//	@Router			/audit/export [get]
+func ExportAuditLog(c echo.Context) error {
+	log := c.Get("audit").(*audit.Log)
+
+	filter, err := auditFilter(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid filter",
+			Data:    err.Error()})
+	}
+
+	entries, err := log.Query(filter)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying the audit log!",
+			Data:    err.Error()})
+	}
+
+	return sendExport(c, "audit", exportFormat{"jsonl", "application/x-ndjson"}, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		for _, entry := range entries {
+			if err := encoder.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,278 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"gcom-backend/imagery"
+	"gcom-backend/models"
+	"gcom-backend/responses"
+	"image"
+	"image/png"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// CreateDetection creates a detection
+//
+//	@Summary		Create a detection
+//	@Description	Record where in an image a ground object was seen, must have sentinel ID of "-1". The ground object and image must exist, and the bounding box must lie within the image.
+//	@Tags			Detection
+//	@Accept			json
+//	@Produce		json
+//	@Param			detection	body		models.Detection							true	"Detection Data"
+//	@Success		200			{object}	responses.SingleResponse[models.Detection]	"Success"
+//	@Failure		400			{object}	responses.ErrorResponse						"Invalid JSON or Detection Data"
+//	@Failure		500			{object}	responses.ErrorResponse						"Internal Error Creating Detection"
+//	@Security		BearerAuth
+//	@Router			/detection [post]
+func CreateDetection(c echo.Context) error {
+	var detection models.Detection
+	db, _ := c.Get("db").(*gorm.DB)
+
+	if err := c.Bind(&detection); err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid JSON format",
+			Data:    err.Error()})
+	}
+
+	if validationErr := validate.Struct(&detection); validationErr != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid detection data",
+			Data:    validationErr.Error()})
+	}
+
+	if detectionErr := detection.Validate(); detectionErr != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid detection data",
+			Data:    detectionErr.Error()})
+	}
+
+	if detection.ID != -1 {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Non-sentinel ID passed"})
+	} else {
+		detection.ID = 0
+	}
+
+	var object models.GroundObject
+	if err := db.First(&object, detection.GroundObjectID).Error; err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Ground object does not exist",
+			Data:    strconv.Itoa(detection.GroundObjectID)})
+	}
+
+	var img models.Image
+	if err := db.Where("filename = ?", detection.Image).First(&img).Error; err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Image does not exist",
+			Data:    detection.Image})
+	}
+
+	//Images whose file can't be read yet are only checked when cropped
+	if size, err := imagery.Size(imgDirectory + detection.Image); err == nil && !detection.Bounds().In(size) {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Bounding box is outside the image",
+			Data:    size.String()})
+	}
+
+	if detection.Timestamp == 0 {
+		detection.Timestamp = time.Now().Unix()
+	}
+
+	if createErr := db.Create(&detection).Error; createErr != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "An error occurred creating the detection"})
+	}
+
+	auditChange(c, "detection", detection.ID, nil, detection)
+
+	return c.JSON(http.StatusOK, responses.SingleResponse[models.Detection]{
+		Message: "Detection created!",
+		Model:   detection})
+}
+
+// DeleteDetection deletes a detection
+//
+//	@Summary		Delete a detection
+//	@Description	Delete a singular detection based on path param
+//	@Tags			Detection
+//	@Produce		json
+//	@Param			id	path		int											true	"Detection ID"
+//	@Success		200	{object}	responses.SingleResponse[models.Detection]	"Success (returns a blank Detection)"
+//	@Failure		404	{object}	responses.ErrorResponse						"Detection Not Found"
+//	@Failure		500	{object}	responses.ErrorResponse						"Internal Error Deleting Detection"
+//	@Security		BearerAuth
+//	@Router			/detection/{id} [delete]
+func DeleteDetection(c echo.Context) error {
+	db, _ := c.Get("db").(*gorm.DB)
+	detectionId := c.Param("detectionId")
+	var deletedDetection models.Detection
+	if err := db.First(&deletedDetection, detectionId).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
+			Message: "No requested detection exists!"})
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst deleting detection!",
+			Data:    err.Error()})
+	}
+	if err := db.Delete(&deletedDetection).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst deleting detection!",
+			Data:    err.Error()})
+	}
+
+	auditChange(c, "detection", deletedDetection.ID, deletedDetection, nil)
+
+	return c.JSON(http.StatusOK, responses.SingleResponse[models.Detection]{
+		Message: "Detection deleted!",
+		Model:   models.Detection{},
+	})
+}
+
+// bestDetections orders detections from the best to crop a thumbnail from,
+// the most confident, then the largest, then the latest
+func bestDetections(db *gorm.DB) *gorm.DB {
+	return db.Order("confidence desc").Order("width * height desc").Order("timestamp desc").Order("id")
+}
+
+// GetGroundObjectDetections gets the detections of a ground object
+//
+//	@Summary		Get a ground object's detections
+//	@Description	List every detection of a ground object, the most confident first
+//	@Tags			Detection
+//	@Produce		json
+//	@Param			id	path		int											true	"Ground Object ID"
+//	@Success		200	{object}	responses.MultipleResponse[models.Detection]	"Success"
+//	@Failure		404	{object}	responses.ErrorResponse						"Object Not Found"
+//	@Failure		500	{object}	responses.ErrorResponse						"Internal Error Querying Detections"
+//	@Security		BearerAuth
+//	@Router			/groundobject/{id}/detections [get]
+func GetGroundObjectDetections(c echo.Context) error {
+	db, _ := c.Get("db").(*gorm.DB)
+	objectId := c.Param("objectId")
+
+	var object models.GroundObject
+	if err := db.First(&object, objectId).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
+			Message: "No such object exists!"})
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying object!"})
+	}
+
+	detections := []models.Detection{}
+	if err := bestDetections(db).Where("ground_object_id = ?", object.ID).Find(&detections).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying detections!",
+			Data:    err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, responses.MultipleResponse[models.Detection]{
+		Message: "Detections found!",
+		Models:  detections,
+	})
+}
+
+// GetImageDetections gets the detections in an image
+//
+//	@Summary		Get an image's detections
+//	@Description	List every detection in an image, in the order they were created
+//	@Tags			Detection
+//	@Produce		json
+//	@Param			filename	path		string										true	"Image Filename"
+//	@Success		200			{object}	responses.MultipleResponse[models.Detection]	"Success"
+//	@Failure		404			{object}	responses.ErrorResponse						"Image Not Found"
+//	@Failure		500			{object}	responses.ErrorResponse						"Internal Error Querying Detections"
+//	@Security		BearerAuth
+//	@Router			/image/{filename}/detections [get]
+func GetImageDetections(c echo.Context) error {
+	db, _ := c.Get("db").(*gorm.DB)
+	filename := c.Param("filename")
+
+	var img models.Image
+	if err := db.Where("filename = ?", filename).First(&img).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
+			Message: "No such image exists!"})
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying image!"})
+	}
+
+	detections := []models.Detection{}
+	if err := db.Where("image = ?", filename).Order("id").Find(&detections).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying detections!",
+			Data:    err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, responses.MultipleResponse[models.Detection]{
+		Message: "Detections found!",
+		Models:  detections,
+	})
+}
+
+// GetGroundObjectThumbnail gets a picture of a ground object
+//
+//	@Summary		Get a ground object's thumbnail
+//	@Description	Crop the best detection of a ground object from its image, the most confident then the largest, and serve it as a PNG
+//	@Tags			Detection
+//	@Produce		png
+//	@Param			id	path		int						true	"Ground Object ID"
+//	@Success		200	{file}		file					"PNG thumbnail"
+//	@Failure		404	{object}	responses.ErrorResponse	"Object Not Found or No Image Of It"
+//	@Failure		500	{object}	responses.ErrorResponse	"Internal Error Querying Detections"
+//	@Security		BearerAuth
+//	@Router			/groundobject/{id}/thumbnail [get]
+func GetGroundObjectThumbnail(c echo.Context) error {
+	db, _ := c.Get("db").(*gorm.DB)
+	objectId := c.Param("objectId")
+
+	thumbnail, err := groundObjectThumbnail(db, objectId)
+	if errors.Is(err, errNoThumbnail) {
+		response := responses.ErrorResponse{Message: "No image of the object exists!"}
+		if err != errNoThumbnail {
+			response.Data = err.Error()
+		}
+		return c.JSON(http.StatusNotFound, response)
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying detections!",
+			Data:    err.Error()})
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "image/png")
+	c.Response().WriteHeader(http.StatusOK)
+	return png.Encode(c.Response(), thumbnail)
+}
+
+// errNoThumbnail is returned when no detection of an object can be cropped,
+// wrapping why the last one couldn't be if there were any
+var errNoThumbnail = errors.New("no image of the object exists")
+
+// groundObjectThumbnail crops the best detection of an object which has a
+// readable image
+func groundObjectThumbnail(db *gorm.DB, objectId any) (image.Image, error) {
+	var detections []models.Detection
+	if err := bestDetections(db).Where("ground_object_id = ?", objectId).Find(&detections).Error; err != nil {
+		return nil, err
+	}
+
+	//Images which are missing or can't be read are passed over for the next best
+	var lastErr error
+	for _, detection := range detections {
+		thumbnail, err := imagery.Crop(imgDirectory+detection.Image, detection.Bounds())
+		if err == nil {
+			return thumbnail, nil
+		}
+		lastErr = err
+	}
+
+	if lastErr != nil {
+		return nil, fmt.Errorf("%w: %v", errNoThumbnail, lastErr)
+	}
+	return nil, errNoThumbnail
+}
@@ -1,49 +1,67 @@
 package controllers
 
 import (
+	"gcom-backend/commands"
 	"gcom-backend/configs"
-	"gcom-backend/responses"
+	"gcom-backend/events"
 	"gcom-backend/models"
+	"gcom-backend/responses"
+	"gcom-backend/telemetry"
 	"github.com/labstack/echo/v4"
 	"net/http"
 	"encoding/json"
+	"errors"
+	"strconv"
 )
 
 // GetCurrentStatus gets the current status of the drone
 //
 //	@Summary		Get drone status
-//	@Description	Get the current status of the drone
+//	@Description	Get the current status of the drone, which is replayed telemetry while a replay is running
 //	@Tags			Drone
 //	@Produce		json
-//	@Success		200	{object}	models.Drone	"Success"
+//	@Success		200	{object}	models.Drone			"Success"
+//	@Failure		404	{object}	responses.ErrorResponse	"No Telemetry Received"
+//	@Security		BearerAuth
 //	@Router			/status [get]
 func GetCurrentStatus(c echo.Context) error {
-	return c.JSON(http.StatusOK, c.Get("drone"))
+	pipeline := c.Get("telemetry").(*telemetry.Pipeline)
+
+	drone, ok := pipeline.Latest()
+	if !ok {
+		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
+			Message: "No telemetry has been received yet"})
+	}
+	return c.JSON(http.StatusOK, drone)
 }
 
 // GetStatusHistory gets the status of the drone for the last 5 minutes
 //
 //	@Summary		Get drone status history
-//	@Description	Get drone status for the last 5 minutes
+//	@Description	Get drone status for the last 5 minutes, oldest first
 //	@Tags			Drone
 //	@Produce		json
 //	@Success		200	{object}	[]models.Drone	"Success"
+//	@Security		BearerAuth
 //	@Router			/status/history [get]
 func GetStatusHistory(c echo.Context) error {
-	return c.JSON(http.StatusOK, c.Get("drone"))
+	pipeline := c.Get("telemetry").(*telemetry.Pipeline)
+	return c.JSON(http.StatusOK, pipeline.History())
 }
 
 // Takeoff tells the drone to take off to a specific altitude
 //
 //	@Summary		Take off Drone
-//	@Description	Tells Drone to takeoff
+//	@Description	Tells Drone to takeoff. Takeoff may need confirming by a second user before it is sent.
 //	@Tags			Drone
 //	@Accept			json
 //	@Param			altitude	body	number	true	"Takeoff Altitude"
 //	@Success		200
+//	@Failure		500	body	string	"Command failed to be issued"
+//	@Security		BearerAuth
 //	@Router			/drone/takeoff [post]
 func Takeoff(c echo.Context) error {
-	mp := c.Get("mp").(*configs.MissionPlanner)
+	commander := c.Get("commands").(*commands.Commander)
 
 	var altitude float64
 	json_map := make(map[string]interface{})
@@ -54,20 +72,32 @@ func Takeoff(c echo.Context) error {
 		altitude = json_map["altitude"].(float64)
 	}
 
-	mp.Takeoff(altitude)
-	return c.HTML(http.StatusAccepted, "")
+	command, err := commander.Takeoff(requestAuthor(c), altitude)
+	if err != nil {
+		return invalidCommand(c, err)
+	}
+	setCommandID(c, command)
+	auditCommand(c, command)
+	if command.Status != commands.Failed {
+		return c.HTML(http.StatusAccepted, "")
+	} else {
+		return c.HTML(http.StatusInternalServerError, "")
+	}
 }
 
-// Arm arms the drone 
+// Arm arms or disarms the drone
 //
 //	@Summary		Arm drone
-//	@Description	Arms the drone after takeoff request
+//	@Description	Arms the drone after takeoff request, or disarms it. Arming starts a flight session which archives telemetry until the drone is disarmed. Arming and disarming may need confirming by a second user before they are sent.
 //	@Tags			Drone
-//	@Produce		json
-//	@Success		200	{object}	models.Drone	"Success"
-//	@Router			/status [get]
+//	@Accept			json
+//	@Param			arm	body	number	true	"1 to arm, 0 to disarm"
+//	@Success		200
+//	@Failure		500	body	string	"Command failed to be issued"
+//	@Security		BearerAuth
+//	@Router			/drone/arm [post]
 func Arm(c echo.Context) error {
-	mp := c.Get("mp").(*configs.MissionPlanner)
+	commander := c.Get("commands").(*commands.Commander)
 
 	var arm float64
 	json_map := make(map[string]interface{})
@@ -78,7 +108,21 @@ func Arm(c echo.Context) error {
 		arm = json_map["arm"].(float64)
 	}
 
-	mp.Arm(int(arm))
+	command, err := commander.Arm(requestAuthor(c), int(arm))
+	if err != nil {
+		return invalidCommand(c, err)
+	}
+	setCommandID(c, command)
+	auditCommand(c, command)
+	if command.Status == commands.Failed {
+		return c.HTML(http.StatusInternalServerError, "")
+	}
+	if command.Error != "" {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Drone armed state changed but the flight session could not be saved",
+			Data:    command.Error})
+	}
+
 	return c.HTML(http.StatusAccepted, "")
 }
 
@@ -89,10 +133,14 @@ func Arm(c echo.Context) error {
 //	@Tags			Drone
 //	@Success		200	body	string	"Command issued successfully"
 //	@Failure		500	body	string	"Command failed to be issued"
+//	@Security		BearerAuth
 //	@Router			/drone/land [get]
 func Land(c echo.Context) error {
-	mp := c.Get("mp").(*configs.MissionPlanner)
-	if mp.Land() {
+	commander := c.Get("commands").(*commands.Commander)
+	command, _ := commander.Land(requestAuthor(c))
+	setCommandID(c, command)
+	auditCommand(c, command)
+	if command.Status != commands.Failed {
 		return c.HTML(http.StatusAccepted, "")
 	} else {
 		return c.HTML(http.StatusInternalServerError, "")
@@ -106,9 +154,10 @@ func Land(c echo.Context) error {
 //	@Tags			Drone
 //	@Success		200	body	string	"RTL command issued successfully"
 //	@Failure		500	body	string	"RTL command encountered an error"
+//	@Security		BearerAuth
 //	@Router			/drone/rtl [post]
 func RTL(c echo.Context) error {
-	mp := c.Get("mp").(*configs.MissionPlanner)
+	commander := c.Get("commands").(*commands.Commander)
 
 	var altitude float64
 	json_map := make(map[string]interface{})
@@ -119,7 +168,13 @@ func RTL(c echo.Context) error {
 		altitude = json_map["altitude"].(float64)
 	}
 
-	if mp.ReturnHome(altitude) {
+	command, err := commander.RTL(requestAuthor(c), altitude)
+	if err != nil {
+		return invalidCommand(c, err)
+	}
+	setCommandID(c, command)
+	auditCommand(c, command)
+	if command.Status != commands.Failed {
 		return c.HTML(http.StatusAccepted, "")
 	} else {
 		return c.HTML(http.StatusInternalServerError, "")
@@ -133,10 +188,14 @@ func RTL(c echo.Context) error {
 //	@Tags			Drone
 //	@Success		200	body	string	"Drone locked successfully"
 //	@Failure		500	body	string	"Drone unable to lock (already locked?)"
+//	@Security		BearerAuth
 //	@Router			/drone/lock [get]
 func Lock(c echo.Context) error {
-	mp := c.Get("mp").(*configs.MissionPlanner)
-	if mp.Lock() {
+	commander := c.Get("commands").(*commands.Commander)
+	command, _ := commander.Lock(requestAuthor(c))
+	setCommandID(c, command)
+	auditCommand(c, command)
+	if command.Status != commands.Failed {
 		return c.HTML(http.StatusAccepted, "a")
 	} else {
 		return c.HTML(http.StatusInternalServerError, "a")
@@ -150,10 +209,14 @@ func Lock(c echo.Context) error {
 //	@Tags			Drone
 //	@Success		200	body	string	"Drone unlocked successfully"
 //	@Failure		500	body	string	"Drone unable to unlock (already unlocked?)"
+//	@Security		BearerAuth
 //	@Router			/drone/unlock [get]
 func Unlock(c echo.Context) error {
-	mp := c.Get("mp").(*configs.MissionPlanner)
-	if mp.Unlock() {
+	commander := c.Get("commands").(*commands.Commander)
+	command, _ := commander.Unlock(requestAuthor(c))
+	setCommandID(c, command)
+	auditCommand(c, command)
+	if command.Status != commands.Failed {
 		return c.HTML(http.StatusAccepted, "")
 	} else {
 		return c.HTML(http.StatusInternalServerError, "")
@@ -167,9 +230,10 @@ func Unlock(c echo.Context) error {
 //	@Tags			Drone
 //	@Produce		json
 //	@Success		200	{object}	[]models.Waypoint
+//	@Security		BearerAuth
 //	@Router			/drone/queue [get]
 func GetQueue(c echo.Context) error {
-	mp := c.Get("mp").(*configs.MissionPlanner)
+	mp := c.Get("mp").(configs.Autopilot)
 	var queue = mp.GetQueue()
 	return c.JSON(http.StatusOK, queue)
 }
@@ -177,14 +241,15 @@ func GetQueue(c echo.Context) error {
 // PostQueue sends a queue to MissionPlanner
 //
 //	@Summary		Sends a queue in Mission Planner
-//	@Description	Sends a queue in Mission Planner
+//	@Description	Sends a queue in Mission Planner and starts tracking progress through it
 //	@Tags			Drone
 //	@Accept			json
 //	@Param			waypoints	body	[]models.Waypoint	true	"Array of Waypoint Data"
 //	@Success		200
+//	@Security		BearerAuth
 //	@Router			/drone/queue [post]
 func PostQueue(c echo.Context) error {
-	mp := c.Get("mp").(*configs.MissionPlanner)
+	commander := c.Get("commands").(*commands.Commander)
 	var queue []models.Waypoint
 	if err := c.Bind(&queue); err != nil {
 		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
@@ -192,15 +257,16 @@ func PostQueue(c echo.Context) error {
 			Data:    err.Error()})
 	}
 
-	for i := 0; i < len(queue); i++ {
-		if validationErr := validate.Struct(&(queue[i])); validationErr != nil {
-			return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
-				Message: "Invalid waypoints data",
-				Data:    validationErr.Error()})
-		}
+	command, err := commander.SetQueue(requestAuthor(c), queue)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid waypoints data",
+			Data:    err.Error()})
 	}
 
-	if mp.SetQueue(queue) {
+	setCommandID(c, command)
+	auditCommand(c, command)
+	if command.Status != commands.Failed {
 		return c.HTML(http.StatusAccepted, "")
 	} else {
 		return c.HTML(http.StatusInternalServerError, "")
@@ -215,9 +281,10 @@ func PostQueue(c echo.Context) error {
 //	@Accept			json
 //	@Param			waypoints	body	models.Waypoint	true	"Home Waypoint"
 //	@Success		200
+//	@Security		BearerAuth
 //	@Router			/drone/home [post]
 func PostHome(c echo.Context) error {
-	mp := c.Get("mp").(*configs.MissionPlanner)
+	commander := c.Get("commands").(*commands.Commander)
 	var wp models.Waypoint
 	if err := c.Bind(&wp); err != nil {
 		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
@@ -225,15 +292,159 @@ func PostHome(c echo.Context) error {
 			Data:    err.Error()})
 	}
 
-	if validationErr := validate.Struct(&wp); validationErr != nil {
+	command, err := commander.SetHome(requestAuthor(c), wp)
+	if err != nil {
 		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
 			Message: "Invalid waypoint data",
-			Data:    validationErr.Error()})
+			Data:    err.Error()})
 	}
 
-	if mp.SetHome(wp) {
+	setCommandID(c, command)
+	auditCommand(c, command)
+	if command.Status != commands.Failed {
 		return c.HTML(http.StatusAccepted, "")
 	} else {
 		return c.HTML(http.StatusInternalServerError, "")
 	}
 }
+
+// GetCommand gets a command sent to the drone
+//
+//	@Summary		Get a drone command
+//	@Description	Get the status of a recent command sent to the drone over REST or socket.io, by the ID in the X-Command-ID header or socket.io acknowledgement
+//	@Tags			Drone
+//	@Produce		json
+//	@Param			commandId	path		int	true	"Command ID"
+//	@Success		200			{object}	events.Command
+//	@Failure		400			{object}	responses.ErrorResponse	"Invalid command ID"
+//	@Failure		404			{object}	responses.ErrorResponse	"Command not found"
+//	@Security		BearerAuth
+//	@Router			/drone/command/{commandId} [get]
+func GetCommand(c echo.Context) error {
+	commander := c.Get("commands").(*commands.Commander)
+
+	id, err := strconv.ParseUint(c.Param("commandId"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid command ID",
+			Data:    err.Error()})
+	}
+
+	command, err := commander.Get(id)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
+			Message: "Command not found",
+			Data:    err.Error()})
+	}
+	return c.JSON(http.StatusOK, command)
+}
+
+// GetPendingCommands gets the commands awaiting confirmation
+//
+//	@Summary		Get commands awaiting confirmation
+//	@Description	Get the safety critical commands waiting for a second user to confirm or reject them, oldest first
+//	@Tags			Drone
+//	@Produce		json
+//	@Success		200	{object}	[]events.Command
+//	@Security		BearerAuth
+//	@Router			/drone/commands/pending [get]
+func GetPendingCommands(c echo.Context) error {
+	commander := c.Get("commands").(*commands.Commander)
+	return c.JSON(http.StatusOK, commander.Pending())
+}
+
+// ConfirmCommand sends a command awaiting confirmation
+//
+//	@Summary		Confirm a drone command
+//	@Description	Confirm a command awaiting confirmation, which sends it to the drone. It must be confirmed by a different user than requested it.
+//	@Tags			Drone
+//	@Produce		json
+//	@Param			commandId	path		int	true	"Command ID"
+//	@Success		200			{object}	events.Command
+//	@Failure		400			{object}	responses.ErrorResponse	"Invalid command ID"
+//	@Failure		403			{object}	responses.ErrorResponse	"Requested by the same user"
+//	@Failure		404			{object}	responses.ErrorResponse	"Command not found"
+//	@Failure		409			{object}	responses.ErrorResponse	"Command is not awaiting confirmation"
+//	@Failure		500			{object}	events.Command			"Command refused by the autopilot"
+//	@Security		BearerAuth
+//	@Router			/drone/command/{commandId}/confirm [post]
+func ConfirmCommand(c echo.Context) error {
+	commander := c.Get("commands").(*commands.Commander)
+
+	id, err := strconv.ParseUint(c.Param("commandId"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid command ID",
+			Data:    err.Error()})
+	}
+
+	command, err := commander.Confirm(id, requestAuthor(c))
+	if err != nil {
+		return unconfirmable(c, err)
+	}
+	auditCommand(c, command)
+	if command.Status == commands.Failed || command.Error != "" {
+		return c.JSON(http.StatusInternalServerError, command)
+	}
+	return c.JSON(http.StatusOK, command)
+}
+
+// RejectCommand drops a command awaiting confirmation
+//
+//	@Summary		Reject a drone command
+//	@Description	Reject a command awaiting confirmation so it is never sent to the drone, which must be done by a different user than requested it
+//	@Tags			Drone
+//	@Produce		json
+//	@Param			commandId	path		int	true	"Command ID"
+//	@Success		200			{object}	events.Command
+//	@Failure		400			{object}	responses.ErrorResponse	"Invalid command ID"
+//	@Failure		403			{object}	responses.ErrorResponse	"Requested by the same user"
+//	@Failure		404			{object}	responses.ErrorResponse	"Command not found"
+//	@Failure		409			{object}	responses.ErrorResponse	"Command is not awaiting confirmation"
+//	@Security		BearerAuth
+//	@Router			/drone/command/{commandId}/reject [post]
+func RejectCommand(c echo.Context) error {
+	commander := c.Get("commands").(*commands.Commander)
+
+	id, err := strconv.ParseUint(c.Param("commandId"), 10, 64)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid command ID",
+			Data:    err.Error()})
+	}
+
+	command, err := commander.Reject(id, requestAuthor(c))
+	if err != nil {
+		return unconfirmable(c, err)
+	}
+	auditCommand(c, command)
+	return c.JSON(http.StatusOK, command)
+}
+
+// CommandIDHeader carries the ID of the command sent by a request, so its
+// status can be followed through command_updated events or GetCommand
+const CommandIDHeader = "X-Command-ID"
+
+func setCommandID(c echo.Context, command events.Command) {
+	c.Response().Header().Set(CommandIDHeader, strconv.FormatUint(command.ID, 10))
+}
+
+func invalidCommand(c echo.Context, err error) error {
+	return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+		Message: "Invalid command",
+		Data:    err.Error()})
+}
+
+// unconfirmable answers a confirmation or rejection which could not be made
+func unconfirmable(c echo.Context, err error) error {
+	status := http.StatusConflict
+	switch {
+	case errors.Is(err, commands.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, commands.ErrSameUser):
+		status = http.StatusForbidden
+	}
+	return c.JSON(status, responses.ErrorResponse{
+		Message: "Unable to confirm or reject command",
+		Data:    err.Error()})
+}
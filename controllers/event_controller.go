@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"gcom-backend/events"
+	"gcom-backend/responses"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ClientIDHeader identifies the client making a request, so that it can
+// recognise the events caused by its own changes. UIs send their socket ID.
+const ClientIDHeader = "X-Client-ID"
+
+// publishEvent tells other clients about a change made by this request.
+// Nothing is published if no bus was injected, as in most tests.
+func publishEvent(c echo.Context, topic events.Topic, action events.Action, model any) {
+	bus, ok := c.Get("events").(*events.Bus)
+	if !ok {
+		return
+	}
+
+	bus.Publish(events.Event{
+		Topic:  topic,
+		Action: action,
+		Model:  model,
+		Client: c.Request().Header.Get(ClientIDHeader),
+	})
+}
+
+// HeartbeatInterval is how often a comment is sent on idle event streams to
+// keep proxies from closing them
+var HeartbeatInterval = 15 * time.Second
+
+// streamBuffer is how many events a slow stream can fall behind by before it
+// is closed, the client then catches up using Last-Event-ID
+const streamBuffer = 64
+
+// writeServerEvent writes an event in the text/event-stream format
+func writeServerEvent(w io.Writer, event events.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Name(), data)
+	return err
+}
+
+// StreamEvents streams events as Server-Sent Events
+//
+//	@Summary		Stream events
+//	@Description	Stream the same events as socket.io using Server-Sent Events, each named like the socket.io event with the JSON event as data. Clients reconnecting with a Last-Event-ID header, or last_event_id query param, first receive the events they missed while the server still has them, otherwise a resync event is sent. A comment is sent every 15 seconds as a heartbeat.
+//	@Tags			Events
+//	@Produce		text/event-stream
+//	@Param			topics			query		string	false	"Comma separated topics, such as telemetry,waypoints,groundobjects,images,progress,commands. Every topic if empty."
+//	@Param			Last-Event-ID	header		int		false	"ID of the last event received"
+//	@Success		200				{object}	events.Event
+//	@Failure		400				{object}	responses.ErrorResponse	"Invalid Last-Event-ID"
+//	@Security		BearerAuth
+//	@Router			/events [get]
+func StreamEvents(c echo.Context) error {
+	bus := c.Get("events").(*events.Bus)
+
+	var topics []events.Topic
+	wantedTopics := map[events.Topic]bool{}
+	for _, topic := range strings.Split(c.QueryParam("topics"), ",") {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			topics = append(topics, events.Topic(topic))
+			wantedTopics[events.Topic(topic)] = true
+		}
+	}
+	wanted := func(event events.Event) bool {
+		return len(wantedTopics) == 0 || wantedTopics[event.Topic]
+	}
+
+	lastEventId := c.Request().Header.Get("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = c.QueryParam("last_event_id")
+	}
+	var lastId uint64
+	if lastEventId != "" {
+		id, err := strconv.ParseUint(lastEventId, 10, 64)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+				Message: "Invalid Last-Event-ID",
+				Data:    err.Error()})
+		}
+		lastId = id
+	}
+
+	//Subscribe before catching up so nothing is missed in between. A stream
+	//which falls behind is closed rather than holding up publishers.
+	subscription := bus.Subscribe("sse "+c.RealIP(), events.Options{
+		Topics: topics,
+		Buffer: streamBuffer,
+		Policy: events.Drop,
+	})
+	defer subscription.Close()
+
+	response := c.Response()
+	response.Header().Set(echo.HeaderContentType, "text/event-stream")
+	response.Header().Set(echo.HeaderCacheControl, "no-cache")
+	response.Header().Set(echo.HeaderConnection, "keep-alive")
+	response.Header().Set("X-Accel-Buffering", "no")
+	response.WriteHeader(http.StatusOK)
+
+	if lastEventId != "" {
+		missed, complete := bus.Since(lastId)
+		if !complete {
+			fmt.Fprintf(response, "event: resync\ndata: {\"last_event_id\": %d}\n\n", lastId)
+			lastId = 0
+		}
+		for _, event := range missed {
+			if !wanted(event) {
+				continue
+			}
+			if err := writeServerEvent(response, event); err != nil {
+				return nil
+			}
+			lastId = event.ID
+		}
+	}
+	response.Flush()
+
+	heartbeat := time.NewTicker(HeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request().Context().Done():
+			return nil
+		case <-heartbeat.C:
+			if _, err := io.WriteString(response, ": heartbeat\n\n"); err != nil {
+				return nil
+			}
+		case event := <-subscription.Events():
+			//Missed events are caught up on by reconnecting
+			if subscription.Dropped() > 0 {
+				return nil
+			}
+			//Already sent while catching up
+			if event.ID <= lastId {
+				continue
+			}
+			if err := writeServerEvent(response, event); err != nil {
+				return nil
+			}
+			lastId = event.ID
+		}
+		response.Flush()
+	}
+}
+
+// GetEventMetrics gets event bus metrics
+//
+//	@Summary		Get event bus metrics
+//	@Description	Get how many events have been published on each topic, and how far behind each subscriber is, including how many events were dropped because it fell too far behind
+//	@Tags			Events
+//	@Produce		json
+//	@Success		200	{object}	events.Metrics
+//	@Security		BearerAuth
+//	@Router			/events/metrics [get]
+func GetEventMetrics(c echo.Context) error {
+	bus := c.Get("events").(*events.Bus)
+	return c.JSON(http.StatusOK, bus.Metrics())
+}
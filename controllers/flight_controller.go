@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"gcom-backend/formats"
+	"gcom-backend/models"
+	"gcom-backend/responses"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+var trackFormats = map[string]exportFormat{
+	"csv": {"csv", "text/csv"},
+	"gpx": {"gpx", "application/gpx+xml"},
+	"kml": exportFormats["kml"],
+}
+
+// findFlight finds a flight session and counts its samples
+func findFlight(db *gorm.DB, id int) (models.FlightSession, error) {
+	var session models.FlightSession
+	if err := db.First(&session, id).Error; err != nil {
+		return session, err
+	}
+
+	err := db.Model(&models.FlightSample{}).Where("session_id = ?", session.ID).Count(&session.Samples).Error
+	return session, err
+}
+
+// GetAllFlights gets all flight sessions
+//
+//	@Summary		Get all flights
+//	@Description	Get every flight session recorded between arming and disarming the drone, newest first
+//	@Tags			Flight
+//	@Produce		json
+//	@Success		200	{object}	responses.MultipleResponse[models.FlightSession]	"Success"
+//	@Failure		500	{object}	responses.ErrorResponse							"Internal Error Querying Flights"
+//	@Security		BearerAuth
+//	@Router			/flights [get]
+func GetAllFlights(c echo.Context) error {
+	var sessions []models.FlightSession
+	db, _ := c.Get("db").(*gorm.DB)
+
+	if err := db.Order("id desc").Find(&sessions).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying flights!",
+			Data:    err.Error()})
+	}
+
+	for i := range sessions {
+		db.Model(&models.FlightSample{}).Where("session_id = ?", sessions[i].ID).Count(&sessions[i].Samples)
+	}
+
+	return c.JSON(http.StatusOK, responses.MultipleResponse[models.FlightSession]{
+		Message: "Flights found!",
+		Models:  sessions,
+	})
+}
+
+// GetFlight gets a single flight session
+//
+//	@Summary		Get a flight
+//	@Description	Get a single flight session by ID
+//	@Tags			Flight
+//	@Produce		json
+//	@Param			flightId	path		int												true	"Flight ID"
+//	@Success		200			{object}	responses.SingleResponse[models.FlightSession]	"Success"
+//	@Failure		400			{object}	responses.ErrorResponse							"Invalid Flight ID"
+//	@Failure		404			{object}	responses.ErrorResponse							"Flight Not Found"
+//	@Security		BearerAuth
+//	@Router			/flight/{flightId} [get]
+func GetFlight(c echo.Context) error {
+	db, _ := c.Get("db").(*gorm.DB)
+
+	flightId, err := strconv.Atoi(c.Param("flightId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid flight ID",
+			Data:    err.Error()})
+	}
+
+	session, err := findFlight(db, flightId)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
+			Message: "Requested flight does not exist!",
+			Data:    err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, responses.SingleResponse[models.FlightSession]{
+		Message: "Flight found!",
+		Model:   session,
+	})
+}
+
+// ExportFlight exports the telemetry of a flight session as a track
+//
+//	@Summary		Export a flight
+//	@Description	Download the archived telemetry of a flight session as a CSV, GPX or KML track with timestamps, altitude and battery voltage
+//	@Tags			Flight
+//	@Produce		plain
+//	@Produce		xml
+//	@Param			flightId	path		int						true	"Flight ID"
+//	@Param			format		query		string					true	"csv, gpx or kml"
+//	@Success		200			{file}		file					"Export file attachment"
+//	@Failure		400			{object}	responses.ErrorResponse	"Invalid Flight ID or Unknown Format"
+//	@Failure		404			{object}	responses.ErrorResponse	"Flight Not Found"
+//	@Security		BearerAuth
+//	@Router			/flight/{flightId}/export [get]
+func ExportFlight(c echo.Context) error {
+	db, _ := c.Get("db").(*gorm.DB)
+
+	flightId, err := strconv.Atoi(c.Param("flightId"))
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid flight ID",
+			Data:    err.Error()})
+	}
+
+	formatName := c.QueryParam("format")
+	format, ok := trackFormats[formatName]
+	if !ok {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Unknown format, use csv, gpx or kml"})
+	}
+
+	session, err := findFlight(db, flightId)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
+			Message: "Requested flight does not exist!",
+			Data:    err.Error()})
+	}
+
+	var samples []models.FlightSample
+	if err := db.Where("session_id = ?", session.ID).Order("timestamp").Find(&samples).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying flight telemetry!",
+			Data:    err.Error()})
+	}
+
+	name := formats.TrackName(session)
+	return sendExport(c, "flight-"+strconv.Itoa(session.ID), format, func(w io.Writer) error {
+		switch formatName {
+		case "csv":
+			return formats.WriteTrackCSV(w, samples)
+		case "gpx":
+			return formats.WriteGPX(w, name, samples)
+		default:
+			return formats.WriteTrackKML(w, name, samples)
+		}
+	})
+}
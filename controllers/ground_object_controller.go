@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"gcom-backend/events"
+	"gcom-backend/formats"
 	"gcom-backend/models"
 	"gcom-backend/responses"
 	"io"
@@ -25,6 +27,7 @@ import (
 //	@Success		200		{object}	responses.SingleResponse[models.GroundObject]	"Success"
 //	@Failure		400		{object}	responses.ErrorResponse							"Invalid JSON or Object Data"
 //	@Failure		500		{object}	responses.ErrorResponse							"Internal Error Creating GroundObject"
+//	@Security		BearerAuth
 //	@Router			/groundobject [post]
 func CreateGroundObject(c echo.Context) error {
 	var object models.GroundObject
@@ -42,6 +45,22 @@ func CreateGroundObject(c echo.Context) error {
 			Data:    validationErr.Error()})
 	}
 
+	if objectErr := object.Validate(); objectErr != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid object data",
+			Data:    objectErr.Error()})
+	}
+
+	if object.MergedInto != 0 {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "merged_into is set by merging objects"})
+	}
+	if reviewChanged(object, models.GroundObject{}) {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Review fields are set by approving or rejecting objects"})
+	}
+	startReview(c, &object)
+
 	if object.ID != -1 {
 		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
 			Message: "Non-sentinel ID passed"})
@@ -54,6 +73,9 @@ func CreateGroundObject(c echo.Context) error {
 			Message: "An error occurred creating the object"})
 	}
 
+	publishCreated(c, object)
+	auditChange(c, "groundobject", object.ID, nil, object)
+
 	return c.JSON(http.StatusOK, responses.SingleResponse[models.GroundObject]{
 		Message: "GroundObject created!",
 		Model:   object})
@@ -70,6 +92,7 @@ func CreateGroundObject(c echo.Context) error {
 //	@Success		200		{object}	responses.MultipleResponse[models.GroundObject]	"Success"
 //	@Failure		400		{object}	responses.ErrorResponse							"Invalid JSON or object Data"
 //	@Failure		500		{object}	responses.ErrorResponse							"Internal Error Creating GroundObject"
+//	@Security		BearerAuth
 //	@Router			/groundobjects [post]
 func CreateGroundObjectBatch(c echo.Context) error {
 	var objects []models.GroundObject
@@ -87,6 +110,20 @@ func CreateGroundObjectBatch(c echo.Context) error {
 				Message: "Invalid objects data",
 				Data:    validationErr.Error()})
 		}
+		if objectErr := objects[i].Validate(); objectErr != nil {
+			return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+				Message: fmt.Sprintf("Invalid data for object %d", i),
+				Data:    objectErr.Error()})
+		}
+		if objects[i].MergedInto != 0 {
+			return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+				Message: fmt.Sprintf("merged_into is set by merging objects, for object %d", i)})
+		}
+		if reviewChanged(objects[i], models.GroundObject{}) {
+			return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+				Message: fmt.Sprintf("Review fields are set by approving or rejecting objects, for object %d", i)})
+		}
+		startReview(c, &objects[i])
 		if objects[i].ID != -1 {
 			return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
 				Message: fmt.Sprintf("Non-sentinel ID passed for object %d", i)})
@@ -101,6 +138,11 @@ func CreateGroundObjectBatch(c echo.Context) error {
 			Message: "An error occurred creating the ground object"})
 	}
 
+	for _, object := range objects {
+		publishCreated(c, object)
+		auditChange(c, "groundobject", object.ID, nil, object)
+	}
+
 	return c.JSON(http.StatusOK, responses.MultipleResponse[models.GroundObject]{
 		Message: "GroundObjects created!",
 		Models:  objects})
@@ -109,7 +151,7 @@ func CreateGroundObjectBatch(c echo.Context) error {
 // EditGroundObject edits a ground object
 //
 //	@Summary		Edit a ground object
-//	@Description	Edit a singular object based on path param and JSON
+//	@Description	Edit a singular object based on path param and JSON. Fields left out keep their values and fields sent empty are cleared, then the object must still be valid for its type.
 //	@Tags			GroundObject
 //	@Accept			json
 //	@Produce		json
@@ -119,51 +161,70 @@ func CreateGroundObjectBatch(c echo.Context) error {
 //	@Failure		400		{object}	responses.ErrorResponse							"Invalid JSON or GroundObject ID"
 //	@Failure		404		{object}	responses.ErrorResponse							"GroundObject Not Found"
 //	@Failure		500		{object}	responses.ErrorResponse							"Internal Error Editing GroundObject"
+//	@Security		BearerAuth
 //	@Router			/groundobject/{id} [patch]
 func EditGroundObject(c echo.Context) error {
-	objectStringId := c.Param("objectId")
-	var object models.GroundObject
 	db, _ := c.Get("db").(*gorm.DB)
 
-	objectId, castErr := strconv.Atoi(objectStringId)
-	bindErr := c.Bind(&object)
-
+	objectId, castErr := strconv.Atoi(c.Param("objectId"))
 	if castErr != nil {
 		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
 			Message: "Invalid ID",
 			Data:    castErr.Error()})
 	}
 
-	if bindErr != nil {
+	var previousObject models.GroundObject
+	if err := db.First(&previousObject, objectId).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
+			Message: "No such object exists!"})
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying object!"})
+	}
+
+	/*
+		The JSON is bound over the stored object so fields which are left out
+		keep their values, while fields sent empty are cleared. The whole object
+		is then checked, as what a type needs depends on the other fields.
+	*/
+	object := previousObject
+	if bindErr := c.Bind(&object); bindErr != nil {
 		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
 			Message: "Invalid JSON format",
 			Data:    bindErr.Error()})
 	}
 
-	if object.ID != 0 {
+	if object.ID != previousObject.ID {
 		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
 			Message: "ID is not editable"})
 	}
+	if object.MergedInto != previousObject.MergedInto {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "merged_into is set by merging and splitting objects"})
+	}
+	if reviewChanged(object, previousObject) {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Review fields are set by approving or rejecting objects"})
+	}
 
-	updateAction := db.Model(&models.GroundObject{}).
-		Where("id = ?", objectId).
-		Updates(&object)
+	if validationErr := object.Validate(); validationErr != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid object data",
+			Data:    validationErr.Error()})
+	}
 
-	if updateAction.Error != nil {
+	if err := db.Save(&object).Error; err != nil {
 		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
 			Message: "An error occurred updating the object",
-			Data:    updateAction.Error.Error()})
-	} else if updateAction.RowsAffected < 1 {
-		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
-			Message: "No such object exists!"})
+			Data:    err.Error()})
 	}
 
-	var updatedObject models.GroundObject
-	db.First(&updatedObject, objectId)
+	publishEvent(c, events.GroundObjects, events.Updated, object)
+	auditChange(c, "groundobject", objectId, previousObject, object)
 
 	return c.JSON(http.StatusOK, responses.SingleResponse[models.GroundObject]{
 		Message: "GroundObject updated!",
-		Model:   updatedObject,
+		Model:   object,
 	})
 }
 
@@ -178,6 +239,7 @@ func EditGroundObject(c echo.Context) error {
 //	@Success		200	{object}	responses.SingleResponse[models.GroundObject]	"Success"
 //	@Failure		404	{object}	responses.ErrorResponse							"Object Not Found"
 //	@Failure		500	{object}	responses.ErrorResponse							"Internal Error Querying GroundObject"
+//	@Security		BearerAuth
 //	@Router			/groundobject/{id} [get]
 func GetGroundObject(c echo.Context) error {
 	objectId := c.Param("objectId")
@@ -201,27 +263,54 @@ func GetGroundObject(c echo.Context) error {
 // DeleteGroundObject deletes a ground object
 //
 //	@Summary		Delete a ground object
-//	@Description	Delete a singular ground object based on path param
+//	@Description	Delete a singular ground object based on path param, along with its detections. Objects in a merge which hasn't been split can't be deleted.
 //	@Tags			GroundObject
 //	@Accept			json
 //	@Produce		json
 //	@Param			id	path		int												true	"GroundObject ID"
 //	@Success		200	{object}	responses.SingleResponse[models.GroundObject]	"Success (returns a blank GroundObject)"
 //	@Failure		404	{object}	responses.ErrorResponse							"GroundObject Not Found"
+//	@Failure		409	{object}	responses.ErrorResponse							"GroundObject In Unsplit Merge"
 //	@Failure		500	{object}	responses.ErrorResponse							"Internal Error Deleting GroundObject"
+//	@Security		BearerAuth
 //	@Router			/groundobject/{id} [delete]
 func DeleteGroundObject(c echo.Context) error {
 	db, _ := c.Get("db").(*gorm.DB)
 	objectId := c.Param("objectId")
-	dbAction := db.Delete(&models.GroundObject{}, objectId)
-	if err := dbAction.Error; err != nil {
-		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
-			Message: "Error whilst deleting object!"})
-	} else if dbAction.RowsAffected < 1 {
+	var deletedObject models.GroundObject
+	if err := db.First(&deletedObject, objectId).Error; errors.Is(err, gorm.ErrRecordNotFound) {
 		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
 			Message: "No requested object exists!"})
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst deleting object!",
+			Data:    err.Error()})
+	}
+	var mergeErr errMerge
+	if err := checkNotMerged(db, deletedObject); errors.As(err, &mergeErr) {
+		return c.JSON(mergeErr.status, responses.ErrorResponse{
+			Message: mergeErr.message})
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst deleting object!",
+			Data:    err.Error()})
 	}
 
+	//Its detections mean nothing without it, so both go or neither does
+	if txErr := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&deletedObject).Error; err != nil {
+			return err
+		}
+		return tx.Where("ground_object_id = ?", deletedObject.ID).Delete(&models.Detection{}).Error
+	}); txErr != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst deleting object!",
+			Data:    txErr.Error()})
+	}
+
+	publishEvent(c, events.GroundObjects, events.Deleted, deletedObject)
+	auditChange(c, "groundobject", deletedObject.ID, deletedObject, nil)
+
 	return c.JSON(http.StatusOK, responses.SingleResponse[models.GroundObject]{
 		Message: "GroundObject deleted!",
 		Model:   models.GroundObject{},
@@ -231,7 +320,7 @@ func DeleteGroundObject(c echo.Context) error {
 // DeleteGroundObjectBatch deletes multiple ground objects
 //
 //	@Summary		Delete multiple ground objects
-//	@Description	Delete multiple ground objects based on json body
+//	@Description	Delete multiple ground objects based on json body, along with their detections. Objects in a merge which hasn't been split can't be deleted.
 //	@Tags			GroundObject
 //	@Accept			json
 //	@Produce		json
@@ -239,7 +328,9 @@ func DeleteGroundObject(c echo.Context) error {
 //	@Success		200	{object}	responses.SingleResponse[models.GroundObject]	"Success (returns a blank GroundObject)"
 //	@Failure		400	{object}	responses.ErrorResponse							"Invalid JSON or object IDs"
 //	@Failure		404	{object}	responses.ErrorResponse							"Objects Not Found"
+//	@Failure		409	{object}	responses.ErrorResponse							"Objects In Unsplit Merge"
 //	@Failure		500	{object}	responses.ErrorResponse							"Internal Error Deleting Objects"
+//	@Security		BearerAuth
 //	@Router			/groundobject [delete]
 func DeleteGroundObjectBatch(c echo.Context) error {
 	db, _ := c.Get("db").(*gorm.DB)
@@ -252,6 +343,7 @@ func DeleteGroundObjectBatch(c echo.Context) error {
 			Data:    marshalErr.Error()})
 	}
 
+	var deletedObjects []models.GroundObject
 	for _, id := range objectIDs {
 		if id < 0 {
 			return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
@@ -262,15 +354,43 @@ func DeleteGroundObjectBatch(c echo.Context) error {
 			return c.JSON(http.StatusNotFound, responses.ErrorResponse{
 				Message: fmt.Sprintf("Requested object %d does not exist!", id),
 				Data:    err.Error()})
+		} else if err != nil {
+			return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+				Message: fmt.Sprintf("Error whilst deleting object with id %d", id),
+				Data:    err.Error()})
+		}
+		var mergeErr errMerge
+		if err := checkNotMerged(db, objectTBValidated); errors.As(err, &mergeErr) {
+			return c.JSON(mergeErr.status, responses.ErrorResponse{
+				Message: mergeErr.message})
+		} else if err != nil {
+			return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+				Message: fmt.Sprintf("Error whilst deleting object with id %d", id),
+				Data:    err.Error()})
 		}
+		deletedObjects = append(deletedObjects, objectTBValidated)
 	}
 
-	for _, id := range objectIDs {
-		dbAction := db.Delete(&models.GroundObject{}, id)
-		if err := dbAction.Error; err != nil {
-			return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
-				Message: fmt.Sprintf("Error whilst deleting object with id %d", id)})
+	//Every object and its detections are deleted, or none are
+	if txErr := db.Transaction(func(tx *gorm.DB) error {
+		for _, id := range objectIDs {
+			if err := tx.Delete(&models.GroundObject{}, id).Error; err != nil {
+				return fmt.Errorf("deleting object with id %d: %w", id, err)
+			}
+			if err := tx.Where("ground_object_id = ?", id).Delete(&models.Detection{}).Error; err != nil {
+				return fmt.Errorf("deleting detections of object with id %d: %w", id, err)
+			}
 		}
+		return nil
+	}); txErr != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst deleting objects",
+			Data:    txErr.Error()})
+	}
+
+	for _, object := range deletedObjects {
+		publishEvent(c, events.GroundObjects, events.Deleted, object)
+		auditChange(c, "groundobject", object.ID, object, nil)
 	}
 
 	return c.JSON(http.StatusOK, responses.SingleResponse[models.GroundObject]{
@@ -282,18 +402,60 @@ func DeleteGroundObjectBatch(c echo.Context) error {
 // GetAllGroundObjects gets all ground objects in the database
 //
 //	@Summary		Get all ground objects
-//	@Description	Get all ground objects in the database
+//	@Description	Get all ground objects in the database, optionally filtered by type, whether they were detected autonomously and confidence. Objects merged into others are left out unless include_merged is true.
 //	@Tags			GroundObject
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{object}	responses.MultipleResponse[models.GroundObject]	"Success"
-//	@Failure		500	{object}	responses.ErrorResponse							"Internal Error Querying GroundObjects"
+//	@Param			object_type		query		string											false	"standard or emergent"
+//	@Param			autonomous		query		bool											false	"Only objects detected with or without a person's help"
+//	@Param			min_confidence	query		number											false	"Lowest confidence, from 0 to 1"
+//	@Param			include_merged	query		bool											false	"Include objects merged into others"
+//	@Success		200				{object}	responses.MultipleResponse[models.GroundObject]	"Success"
+//	@Failure		400				{object}	responses.ErrorResponse							"Invalid Filter"
+//	@Failure		500				{object}	responses.ErrorResponse							"Internal Error Querying GroundObjects"
+//	@Security		BearerAuth
 //	@Router			/groundobjects [get]
 func GetAllGroundObjects(c echo.Context) error {
 	var objects []models.GroundObject
 	db, _ := c.Get("db").(*gorm.DB)
 
-	if err := db.Find(&objects).Error; err != nil {
+	query := db.Order("id")
+	if includeParam := c.QueryParam("include_merged"); includeParam != "" {
+		include, err := strconv.ParseBool(includeParam)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+				Message: "Invalid include_merged filter",
+				Data:    err.Error()})
+		}
+		if !include {
+			query = query.Where("merged_into = 0")
+		}
+	} else {
+		query = query.Where("merged_into = 0")
+	}
+	if objectType := c.QueryParam("object_type"); objectType != "" {
+		query = query.Where("type = ?", objectType)
+	}
+	if autonomousParam := c.QueryParam("autonomous"); autonomousParam != "" {
+		autonomous, err := strconv.ParseBool(autonomousParam)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+				Message: "Invalid autonomous filter",
+				Data:    err.Error()})
+		}
+		query = query.Where("autonomous = ?", autonomous)
+	}
+	if confidenceParam := c.QueryParam("min_confidence"); confidenceParam != "" {
+		confidence, err := strconv.ParseFloat(confidenceParam, 64)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+				Message: "Invalid min_confidence filter",
+				Data:    err.Error()})
+		}
+		query = query.Where("confidence >= ?", confidence)
+	}
+
+	if err := query.Find(&objects).Error; err != nil {
 		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
 			Message: "Error whilst querying objects!",
 			Data:    err.Error()})
@@ -304,3 +466,72 @@ func GetAllGroundObjects(c echo.Context) error {
 		Models:  objects,
 	})
 }
+
+// GroundObjectSchema lists the values allowed for each enum field of a
+// GroundObject
+//
+// @Description the values allowed for each enum field of a ground object
+type GroundObjectSchema struct {
+	ObjectTypes []models.ObjectType `json:"object_types" example:"standard,emergent"`
+	Shapes      []models.Shape      `json:"shapes" example:"circle,triangle"`
+	//Allowed for both color and text_color
+	Colors       []models.Color       `json:"colors" example:"white,black"`
+	Orientations []models.Orientation `json:"orientations" example:"N,NE"`
+}
+
+// GetGroundObjectSchema gets the values allowed for ground object fields
+//
+//	@Summary		Get ground object schema
+//	@Description	List the values allowed for object_type, shape, color, text_color and orientation
+//	@Tags			GroundObject
+//	@Produce		json
+//	@Success		200	{object}	GroundObjectSchema	"Success"
+//	@Security		BearerAuth
+//	@Router			/groundobjects/schema [get]
+func GetGroundObjectSchema(c echo.Context) error {
+	return c.JSON(http.StatusOK, GroundObjectSchema{
+		ObjectTypes:  models.ObjectTypes,
+		Shapes:       models.Shapes,
+		Colors:       models.Colors,
+		Orientations: models.Orientations,
+	})
+}
+
+// ExportGroundObjects exports ground objects as placemarks
+//
+//	@Summary		Export ground objects
+//	@Description	Download every approved ground object as KML or GeoJSON placemarks
+//	@Tags			GroundObject
+//	@Produce		json
+//	@Produce		xml
+//	@Param			format	query		string					true	"kml or geojson"
+//	@Success		200		{file}		file					"Export file attachment"
+//	@Failure		400		{object}	responses.ErrorResponse	"Unknown Format"
+//	@Failure		500		{object}	responses.ErrorResponse	"Internal Error Querying GroundObjects"
+//	@Security		BearerAuth
+//	@Router			/groundobjects/export [get]
+func ExportGroundObjects(c echo.Context) error {
+	var objects []models.GroundObject
+	db, _ := c.Get("db").(*gorm.DB)
+
+	formatName := c.QueryParam("format")
+	if formatName != "kml" && formatName != "geojson" {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Unknown format, use kml or geojson"})
+	}
+
+	//Only approved objects are submitted, and merged objects are duplicates of the object they were merged into
+	if err := db.Where("review_status = ? AND merged_into = 0", models.Approved).Order("id").Find(&objects).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying objects!",
+			Data:    err.Error()})
+	}
+
+	placemarks := formats.GroundObjectPlacemarks(objects)
+	return sendExport(c, "groundobjects", exportFormats[formatName], func(w io.Writer) error {
+		if formatName == "kml" {
+			return formats.WriteKML(w, "Ground Objects", placemarks)
+		}
+		return formats.WriteGeoJSON(w, placemarks)
+	})
+}
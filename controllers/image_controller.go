@@ -1,14 +1,22 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
+	"gcom-backend/events"
+	"gcom-backend/imagery"
 	"gcom-backend/models"
+	"gcom-backend/processing"
+	"gcom-backend/responses"
 	"github.com/labstack/echo/v4"
 	"gorm.io/gorm"
+	"io"
+	"math"
 	"net/http"
 	"os"
 	"regexp"
 	"strconv"
+	"time"
 )
 
 var imgDirectory = "./imgs/"
@@ -29,25 +37,63 @@ func UploadImage(c echo.Context) error {
 	if err != nil || !match {
 		return c.JSON(http.StatusBadRequest, "Invalid image name, use UNIX timestamp")
 	}
+	timestamp, _ := strconv.Atoi(file.Filename[:10])
+
+	//Checked before writing so the image already stored isn't overwritten
+	db, _ := c.Get("db").(*gorm.DB)
+	var existing int64
+	if err := db.Model(&models.Image{}).Where("timestamp = ? OR filename = ?", timestamp, file.Filename).Count(&existing).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, err.Error())
+	} else if existing > 0 {
+		return c.JSON(http.StatusConflict, "An image taken at this timestamp already exists")
+	}
+
 	dst, err := os.Create(imgDirectory + file.Filename)
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, "Error saving image")
 	}
 	defer dst.Close()
 
-	timestamp, _ := strconv.Atoi(file.Filename[:10])
-	fmt.Println(file.Filename[:13])
-	fmt.Println(timestamp)
+	if _, err := io.Copy(dst, src); err != nil {
+		return c.JSON(http.StatusInternalServerError, "Error saving image")
+	}
+	if err := dst.Close(); err != nil {
+		return c.JSON(http.StatusInternalServerError, "Error saving image")
+	}
+
 	image := &models.Image{
 		Timestamp: int64(timestamp),
 		Filename:  file.Filename,
 	}
 
-	db, _ := c.Get("db").(*gorm.DB)
+	//Images are kept when they can't be georeferenced, they just can't be located
+	camera, _ := c.Get("camera").(imagery.Camera)
+	terrain, ok := c.Get("terrain").(imagery.Terrain)
+	if !ok {
+		terrain = imagery.FlatGround{}
+	}
+	if err := imagery.Georeference(db, camera, terrain, imgDirectory+file.Filename, image); err != nil {
+		fmt.Printf("[IMAGE] Unable to georeference %s: %v\n", file.Filename, err)
+	}
+
 	if createErr := db.Create(&image).Error; createErr != nil {
+		//A file without a row would never be listed or processed
+		if err := os.Remove(imgDirectory + file.Filename); err != nil {
+			fmt.Println("[IMAGE] Unable to remove", file.Filename, "after failing to save it:", err)
+		}
 		return c.JSON(http.StatusInternalServerError, createErr.Error())
 	}
 
+	publishEvent(c, events.Images, events.Created, image)
+	auditChange(c, "image", image.Filename, nil, image)
+
+	//Processors look for ground objects in the background
+	if runner, ok := c.Get("processing").(*processing.Runner); ok {
+		if _, err := runner.Submit(*image); err != nil {
+			fmt.Printf("[IMAGE] Unable to queue %s for processing: %v\n", file.Filename, err)
+		}
+	}
+
 	return c.JSON(http.StatusAccepted, "Upload sucessful")
 }
 
@@ -62,11 +108,197 @@ func ListImages(c echo.Context) error {
 }
 
 func GetImage(c echo.Context) error {
-	imgPath, err := os.Stat(imgDirectory + c.Param("filename"))
-	fmt.Println(imgPath.Name())
+	_, err := os.Stat(imgDirectory + c.Param("filename"))
 	if err != nil {
 		return c.JSON(http.StatusBadRequest, err.Error())
 	} else {
 		return c.Attachment(imgDirectory+c.Param("filename"), c.Param("filename"))
 	}
 }
+
+// GetImageJobs gets the status of processing an image
+//
+//	@Summary		Get an image's processing jobs
+//	@Description	List the jobs of each processor looking for ground objects in an image, with their status, attempts and how many detections they created
+//	@Tags			Image
+//	@Produce		json
+//	@Param			filename	path		string										true	"Image Filename"
+//	@Success		200			{object}	responses.MultipleResponse[models.ImageJob]	"Success"
+//	@Failure		404			{object}	responses.ErrorResponse						"Image Not Found"
+//	@Failure		500			{object}	responses.ErrorResponse						"Internal Error Querying Jobs"
+//	@Security		BearerAuth
+//	@Router			/image/{filename}/jobs [get]
+func GetImageJobs(c echo.Context) error {
+	db, _ := c.Get("db").(*gorm.DB)
+	filename := c.Param("filename")
+
+	var img models.Image
+	if err := db.Where("filename = ?", filename).First(&img).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
+			Message: "No such image exists!"})
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying image!"})
+	}
+
+	jobs := []models.ImageJob{}
+	if err := db.Where("image = ?", filename).Order("id").Find(&jobs).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying jobs!",
+			Data:    err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, responses.MultipleResponse[models.ImageJob]{
+		Message: "Jobs found!",
+		Models:  jobs,
+	})
+}
+
+// LocateRequest is a pixel in an image to find on the ground
+//
+// @Description a pixel to locate, measured from the top left corner of the image
+type LocateRequest struct {
+	X float64 `json:"x" example:"300" extensions:"x-order=1"`
+	Y float64 `json:"y" example:"200" extensions:"x-order=2"`
+	//Ground object to create at the pixel with a detection around it, its ID, lat and long are filled in
+	Object *models.GroundObject `json:"object,omitempty" extensions:"x-order=3"`
+	//Size in pixels of the detection's bounding box, centred on the pixel, default 64
+	BoxSize int `json:"box_size,omitempty" example:"64" extensions:"x-order=4"`
+}
+
+// LocateResult is where a pixel lies on the ground
+//
+// @Description where a pixel lies on the ground, and what was created there
+type LocateResult struct {
+	Location  models.GeoPoint      `json:"location" extensions:"x-order=1"`
+	Object    *models.GroundObject `json:"object,omitempty" extensions:"x-order=2"`
+	Detection *models.Detection    `json:"detection,omitempty" extensions:"x-order=3"`
+}
+
+// defaultBoxSize is the size of detections created around a located pixel
+const defaultBoxSize = 64
+
+// detectionAround returns a square bounding box of size pixels centred on x,
+// y, moved inside a width by height image
+func detectionAround(x float64, y float64, size int, width int, height int) models.Detection {
+	size = min(size, width, height)
+	clamp := func(centre float64, limit int) int {
+		return max(0, min(int(math.Round(centre))-size/2, limit-size))
+	}
+	return models.Detection{X: clamp(x, width), Y: clamp(y, height), Width: size, Height: size}
+}
+
+// LocatePixel finds a pixel of an image on the ground
+//
+//	@Summary		Locate a pixel on the ground
+//	@Description	Find the lat and long of a pixel in a georeferenced image from the drone's pose, the camera and the height of the ground. Optionally creates a ground object there with a detection around the pixel.
+//	@Tags			Image
+//	@Accept			json
+//	@Produce		json
+//	@Param			filename	path		string					true	"Image Filename"
+//	@Param			pixel		body		LocateRequest			true	"Pixel and optional ground object"
+//	@Success		200			{object}	LocateResult			"Success"
+//	@Failure		400			{object}	responses.ErrorResponse	"Invalid JSON, Pixel or Object Data"
+//	@Failure		404			{object}	responses.ErrorResponse	"Image Not Found"
+//	@Failure		409			{object}	responses.ErrorResponse	"Image Not Georeferenced"
+//	@Failure		500			{object}	responses.ErrorResponse	"Internal Error Creating Object"
+//	@Security		BearerAuth
+//	@Router			/image/{filename}/locate [post]
+func LocatePixel(c echo.Context) error {
+	db, _ := c.Get("db").(*gorm.DB)
+	camera, _ := c.Get("camera").(imagery.Camera)
+	terrain, ok := c.Get("terrain").(imagery.Terrain)
+	if !ok {
+		terrain = imagery.FlatGround{}
+	}
+
+	var request LocateRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid JSON format",
+			Data:    err.Error()})
+	}
+
+	var img models.Image
+	if err := db.Where("filename = ?", c.Param("filename")).First(&img).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
+			Message: "No such image exists!"})
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying image!"})
+	}
+
+	if !img.Georeferenced {
+		return c.JSON(http.StatusConflict, responses.ErrorResponse{
+			Message: "Image is not georeferenced, there was no telemetry when it was taken"})
+	}
+	if request.X < 0 || request.Y < 0 || request.X > float64(img.Width) || request.Y > float64(img.Height) {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Pixel is outside the image",
+			Data:    fmt.Sprintf("%dx%d", img.Width, img.Height)})
+	}
+
+	pose := models.Drone{Timestamp: img.Timestamp, Latitude: img.Latitude, Longitude: img.Longitude,
+		Altitude: img.Altitude, Heading: img.Heading}
+	location, err := camera.Project(pose, img.Width, img.Height, request.X, request.Y, terrain)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Unable to locate the pixel",
+			Data:    err.Error()})
+	}
+
+	result := LocateResult{Location: location}
+	if request.Object == nil {
+		return c.JSON(http.StatusOK, result)
+	}
+
+	object := *request.Object
+	object.ID = -1
+	object.Latitude = location.Latitude
+	object.Longitude = location.Longitude
+	if validationErr := validate.Struct(&object); validationErr != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid object data",
+			Data:    validationErr.Error()})
+	}
+	if objectErr := object.Validate(); objectErr != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid object data",
+			Data:    objectErr.Error()})
+	}
+	if reviewChanged(object, models.GroundObject{}) {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Review fields are set by approving or rejecting objects"})
+	}
+	object.ID = 0
+	startReview(c, &object)
+
+	if request.BoxSize <= 0 {
+		request.BoxSize = defaultBoxSize
+	}
+	detection := detectionAround(request.X, request.Y, request.BoxSize, img.Width, img.Height)
+	detection.Image = img.Filename
+	detection.Confidence = object.Confidence
+	detection.Timestamp = time.Now().Unix()
+
+	//The object and its detection are created together or not at all
+	if txErr := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&object).Error; err != nil {
+			return err
+		}
+		detection.GroundObjectID = object.ID
+		return tx.Create(&detection).Error
+	}); txErr != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "An error occurred creating the object",
+			Data:    txErr.Error()})
+	}
+
+	publishCreated(c, object)
+	auditChange(c, "groundobject", object.ID, nil, object)
+	auditChange(c, "detection", detection.ID, nil, detection)
+
+	result.Object = &object
+	result.Detection = &detection
+	return c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,343 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"gcom-backend/events"
+	"gcom-backend/interop"
+	"gcom-backend/models"
+	"gcom-backend/responses"
+	"image/png"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// InteropStatus describes the connection to the competition's interop server
+//
+// @Description describes the connection to the competition's interop server
+type InteropStatus struct {
+	URL      string `json:"url" example:"http://10.10.130.10:80" extensions:"x-order=1"`
+	LoggedIn bool   `json:"logged_in" example:"true" extensions:"x-order=2"`
+	//ID of the interop mission objects are submitted to
+	Mission   int                  `json:"mission" example:"1" extensions:"x-order=3"`
+	Telemetry interop.UploadStatus `json:"telemetry" extensions:"x-order=4"`
+}
+
+// InteropMissionRequest chooses the interop mission to import
+//
+// @Description the interop mission to import
+type InteropMissionRequest struct {
+	//ID of the mission on the interop server, the configured mission if 0
+	ID int `json:"id,omitempty" example:"1" extensions:"x-order=1"`
+}
+
+// interopConnection returns the interop connection, or responds that interop
+// is not configured
+func interopConnection(c echo.Context) (*interop.Interop, error) {
+	connection, ok := c.Get("interop").(*interop.Interop)
+	if !ok {
+		return nil, c.JSON(http.StatusServiceUnavailable, responses.ErrorResponse{
+			Message: "Interop is not configured, set INTEROP_URL"})
+	}
+	return connection, nil
+}
+
+// GetInteropStatus gets the status of the interop connection
+//
+//	@Summary		Get the interop status
+//	@Description	Whether the backend is logged in to the competition's interop server, the mission being flown and how telemetry uploads are going
+//	@Tags			Interop
+//	@Produce		json
+//	@Success		200	{object}	responses.SingleResponse[InteropStatus]	"Success"
+//	@Failure		503	{object}	responses.ErrorResponse					"Interop Not Configured"
+//	@Security		BearerAuth
+//	@Router			/interop [get]
+func GetInteropStatus(c echo.Context) error {
+	connection, err := interopConnection(c)
+	if connection == nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, responses.SingleResponse[InteropStatus]{
+		Message: "Interop status found!",
+		Model: InteropStatus{
+			URL:       connection.Config.URL,
+			LoggedIn:  connection.Client.LoggedIn(),
+			Mission:   connection.Config.Mission,
+			Telemetry: connection.Uploader.Status(),
+		}})
+}
+
+// StartInteropTelemetry starts uploading telemetry
+//
+//	@Summary		Start uploading telemetry
+//	@Description	Upload the latest live drone status to interop at the configured rate. Nothing is sent while a flight is replayed or telemetry has stopped.
+//	@Tags			Interop
+//	@Produce		json
+//	@Success		200	{object}	responses.SingleResponse[interop.UploadStatus]	"Success"
+//	@Failure		503	{object}	responses.ErrorResponse							"Interop Not Configured"
+//	@Security		BearerAuth
+//	@Router			/interop/telemetry/start [post]
+func StartInteropTelemetry(c echo.Context) error {
+	connection, err := interopConnection(c)
+	if connection == nil {
+		return err
+	}
+
+	connection.Uploader.Start()
+	return c.JSON(http.StatusOK, responses.SingleResponse[interop.UploadStatus]{
+		Message: "Uploading telemetry!",
+		Model:   connection.Uploader.Status()})
+}
+
+// StopInteropTelemetry stops uploading telemetry
+//
+//	@Summary		Stop uploading telemetry
+//	@Description	Stop uploading drone status to interop
+//	@Tags			Interop
+//	@Produce		json
+//	@Success		200	{object}	responses.SingleResponse[interop.UploadStatus]	"Success"
+//	@Failure		503	{object}	responses.ErrorResponse							"Interop Not Configured"
+//	@Security		BearerAuth
+//	@Router			/interop/telemetry/stop [post]
+func StopInteropTelemetry(c echo.Context) error {
+	connection, err := interopConnection(c)
+	if connection == nil {
+		return err
+	}
+
+	connection.Uploader.Stop()
+	return c.JSON(http.StatusOK, responses.SingleResponse[interop.UploadStatus]{
+		Message: "Stopped uploading telemetry!",
+		Model:   connection.Uploader.Status()})
+}
+
+// ImportInteropMission fetches a mission from interop
+//
+//	@Summary		Import the interop mission
+//	@Description	Fetch a mission from interop, creating a draft mission of its waypoints and obstacle waypoints for its stationary obstacles, and storing its fly zones, search grid and other positions. Importing again creates a new mission.
+//	@Tags			Interop
+//	@Accept			json
+//	@Produce		json
+//	@Param			mission	body		InteropMissionRequest								false	"Mission to import"
+//	@Success		200		{object}	responses.SingleResponse[models.InteropMission]	"Success"
+//	@Failure		400		{object}	responses.ErrorResponse							"Invalid JSON"
+//	@Failure		500		{object}	responses.ErrorResponse							"Internal Error Creating Mission"
+//	@Failure		502		{object}	responses.ErrorResponse							"Interop Error"
+//	@Failure		503		{object}	responses.ErrorResponse							"Interop Not Configured"
+//	@Security		BearerAuth
+//	@Router			/interop/mission [post]
+func ImportInteropMission(c echo.Context) error {
+	connection, err := interopConnection(c)
+	if connection == nil {
+		return err
+	}
+	db, _ := c.Get("db").(*gorm.DB)
+
+	var request InteropMissionRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid JSON format",
+			Data:    err.Error()})
+	}
+	if request.ID == 0 {
+		request.ID = connection.Config.Mission
+	}
+
+	fetched, err := connection.Client.Mission(c.Request().Context(), request.ID)
+	if err != nil {
+		return c.JSON(http.StatusBadGateway, responses.ErrorResponse{
+			Message: "Unable to fetch the mission from interop",
+			Data:    err.Error()})
+	}
+	imported := interop.ImportMission(fetched, connection.Config.HomeAltitude)
+	details := imported.Details
+
+	mission := models.Mission{
+		Name:        fmt.Sprintf("Interop mission %d", fetched.ID),
+		Description: "Waypoints fetched from interop",
+		Status:      models.MissionDraft,
+		Waypoints:   []models.MissionWaypoint{},
+	}
+	if txErr := db.Transaction(func(tx *gorm.DB) error {
+		if len(imported.Waypoints) > 0 {
+			if err := tx.Create(&imported.Waypoints).Error; err != nil {
+				return err
+			}
+		}
+		if len(imported.Obstacles) > 0 {
+			if err := tx.Create(&imported.Obstacles).Error; err != nil {
+				return err
+			}
+		}
+		for i, waypoint := range imported.Waypoints {
+			mission.Waypoints = append(mission.Waypoints, models.MissionWaypoint{Sequence: i, WaypointID: waypoint.ID})
+		}
+		if err := tx.Create(&mission).Error; err != nil {
+			return err
+		}
+		if err := snapshotMission(tx, mission.ID, requestAuthor(c), "Imported from interop"); err != nil {
+			return err
+		}
+
+		details.MissionID = mission.ID
+		for _, obstacle := range imported.Obstacles {
+			details.ObstacleIDs = append(details.ObstacleIDs, obstacle.ID)
+		}
+		return tx.Save(&details).Error
+	}); txErr != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "An error occurred creating the mission",
+			Data:    txErr.Error()})
+	}
+
+	for _, waypoint := range append(imported.Waypoints, imported.Obstacles...) {
+		publishEvent(c, events.Waypoints, events.Created, waypoint)
+		auditChange(c, "waypoint", waypoint.ID, nil, waypoint)
+	}
+	createdMission, _ := findMission(db, mission.ID)
+	auditChange(c, "mission", mission.ID, nil, createdMission)
+
+	return c.JSON(http.StatusOK, responses.SingleResponse[models.InteropMission]{
+		Message: "Mission imported!",
+		Model:   details})
+}
+
+// GetInteropMission gets the interop mission last imported
+//
+//	@Summary		Get the interop mission
+//	@Description	Get the fly zones, search grid and other positions of the interop mission last imported, or the one with the given ID
+//	@Tags			Interop
+//	@Produce		json
+//	@Param			id	query		int												false	"Interop Mission ID"
+//	@Success		200	{object}	responses.SingleResponse[models.InteropMission]	"Success"
+//	@Failure		404	{object}	responses.ErrorResponse							"No Mission Imported"
+//	@Failure		500	{object}	responses.ErrorResponse							"Internal Error Querying Mission"
+//	@Security		BearerAuth
+//	@Router			/interop/mission [get]
+func GetInteropMission(c echo.Context) error {
+	db, _ := c.Get("db").(*gorm.DB)
+
+	query := db.Order("fetched_at desc")
+	if id := c.QueryParam("id"); id != "" {
+		query = query.Where("id = ?", id)
+	}
+
+	var mission models.InteropMission
+	if err := query.First(&mission).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
+			Message: "No interop mission has been imported!"})
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying mission!",
+			Data:    err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, responses.SingleResponse[models.InteropMission]{
+		Message: "Interop mission found!",
+		Model:   mission})
+}
+
+// SubmitGroundObjects submits approved ground objects to interop
+//
+//	@Summary		Submit ground objects to interop
+//	@Description	Submit every approved ground object not merged into another, with the thumbnail of its best detection. Objects are only sent again when they change, and objects submitted before which are no longer approved or were merged are withdrawn. Failures are recorded on each submission.
+//	@Tags			Interop
+//	@Produce		json
+//	@Success		200	{object}	responses.MultipleResponse[models.InteropSubmission]	"Submissions made or withdrawn"
+//	@Failure		500	{object}	responses.ErrorResponse									"Internal Error Querying Objects"
+//	@Failure		503	{object}	responses.ErrorResponse									"Interop Not Configured"
+//	@Security		BearerAuth
+//	@Router			/interop/submit [post]
+func SubmitGroundObjects(c echo.Context) error {
+	connection, err := interopConnection(c)
+	if connection == nil {
+		return err
+	}
+	db, _ := c.Get("db").(*gorm.DB)
+	ctx := c.Request().Context()
+
+	var approved []models.GroundObject
+	if err := db.Where("review_status = ? AND merged_into = 0", models.Approved).Order("id").Find(&approved).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying objects!",
+			Data:    err.Error()})
+	}
+	var withdrawn []int
+	if err := db.Model(&models.InteropSubmission{}).
+		Where("status != ? AND interop_id != 0", models.SubmissionWithdrawn).
+		Where("ground_object_id NOT IN (?)", db.Model(&models.GroundObject{}).Select("id").
+			Where("review_status = ? AND merged_into = 0", models.Approved)).
+		Order("ground_object_id").Pluck("ground_object_id", &withdrawn).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying submissions!",
+			Data:    err.Error()})
+	}
+
+	submissions := []models.InteropSubmission{}
+	failed := 0
+	record := func(before models.InteropSubmission, after models.InteropSubmission, err error) {
+		if err != nil {
+			failed++
+		}
+		if before != after {
+			auditChange(c, "interop_submission", after.GroundObjectID, before, after)
+		}
+		submissions = append(submissions, after)
+	}
+
+	for _, object := range approved {
+		var thumbnail []byte
+		if cropped, err := groundObjectThumbnail(db, object.ID); err == nil {
+			var encoded bytes.Buffer
+			if err := png.Encode(&encoded, cropped); err == nil {
+				thumbnail = encoded.Bytes()
+			}
+		}
+
+		before, _, _ := connection.Submitter.Submission(object.ID)
+		after, err := connection.Submitter.Submit(ctx, object, thumbnail)
+		record(before, after, err)
+	}
+	for _, objectId := range withdrawn {
+		before, _, _ := connection.Submitter.Submission(objectId)
+		after, err := connection.Submitter.Withdraw(ctx, objectId)
+		record(before, after, err)
+	}
+
+	message := "Ground objects submitted!"
+	if failed > 0 {
+		message = fmt.Sprintf("%d of %d submissions failed", failed, len(submissions))
+	}
+	return c.JSON(http.StatusOK, responses.MultipleResponse[models.InteropSubmission]{
+		Message: message,
+		Models:  submissions})
+}
+
+// GetInteropSubmissions gets the ground objects submitted to interop
+//
+//	@Summary		Get interop submissions
+//	@Description	List every ground object submitted to interop, with its interop ID and whether its thumbnail was sent
+//	@Tags			Interop
+//	@Produce		json
+//	@Success		200	{object}	responses.MultipleResponse[models.InteropSubmission]	"Success"
+//	@Failure		500	{object}	responses.ErrorResponse									"Internal Error Querying Submissions"
+//	@Security		BearerAuth
+//	@Router			/interop/submissions [get]
+func GetInteropSubmissions(c echo.Context) error {
+	db, _ := c.Get("db").(*gorm.DB)
+
+	submissions := []models.InteropSubmission{}
+	if err := db.Order("ground_object_id").Find(&submissions).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying submissions!",
+			Data:    err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, responses.MultipleResponse[models.InteropSubmission]{
+		Message: "Submissions found!",
+		Models:  submissions})
+}
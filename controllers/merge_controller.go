@@ -0,0 +1,372 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"gcom-backend/clustering"
+	"gcom-backend/events"
+	"gcom-backend/models"
+	"gcom-backend/responses"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// errMerge is returned from merge and split transactions with the status to
+// respond with
+type errMerge struct {
+	status  int
+	message string
+}
+
+func (e errMerge) Error() string {
+	return e.message
+}
+
+// checkNotMerged refuses to delete an object taking part in a merge which
+// hasn't been split, as splitting it afterwards couldn't restore the
+// detections deleted with it
+func checkNotMerged(db *gorm.DB, object models.GroundObject) error {
+	if object.MergedInto != 0 {
+		return errMerge{http.StatusConflict, fmt.Sprintf("Object %d is merged into %d, split the merge before deleting it", object.ID, object.MergedInto)}
+	}
+
+	var merges int64
+	if err := db.Model(&models.GroundObjectMerge{}).Where("kept_id = ? AND split_at = 0", object.ID).Count(&merges).Error; err != nil {
+		return err
+	}
+	if merges > 0 {
+		return errMerge{http.StatusConflict, fmt.Sprintf("Objects are merged into object %d, split the merges before deleting it", object.ID)}
+	}
+	return nil
+}
+
+// GetMergeProposals proposes ground objects to merge
+//
+//	@Summary		Propose ground objects to merge
+//	@Description	Group ground objects which look like the same target, alike and within radius metres of each other, with the position merging them would give
+//	@Tags			GroundObject
+//	@Produce		json
+//	@Param			radius	query		number											false	"Metres apart objects may be, default 15"
+//	@Success		200		{object}	responses.MultipleResponse[models.MergeProposal]	"Success"
+//	@Failure		400		{object}	responses.ErrorResponse							"Invalid Radius"
+//	@Failure		500		{object}	responses.ErrorResponse							"Internal Error Querying GroundObjects"
+//	@Security		BearerAuth
+//	@Router			/groundobjects/clusters [get]
+func GetMergeProposals(c echo.Context) error {
+	db, _ := c.Get("db").(*gorm.DB)
+
+	radius := clustering.DefaultRadius
+	if radiusParam := c.QueryParam("radius"); radiusParam != "" {
+		var err error
+		if radius, err = strconv.ParseFloat(radiusParam, 64); err != nil || radius <= 0 {
+			return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+				Message: "Invalid radius, use a positive number of metres"})
+		}
+	}
+
+	var objects []models.GroundObject
+	if err := db.Where("merged_into = 0").Order("id").Find(&objects).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying objects!",
+			Data:    err.Error()})
+	}
+
+	var counts []struct {
+		GroundObjectID int
+		Count          int
+	}
+	if err := db.Model(&models.Detection{}).Select("ground_object_id, count(*) as count").
+		Group("ground_object_id").Scan(&counts).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying detections!",
+			Data:    err.Error()})
+	}
+	detections := make(map[int]int, len(counts))
+	for _, count := range counts {
+		detections[count.GroundObjectID] = count.Count
+	}
+
+	return c.JSON(http.StatusOK, responses.MultipleResponse[models.MergeProposal]{
+		Message: "Merges proposed!",
+		Models:  clustering.Propose(objects, detections, radius),
+	})
+}
+
+// MergeRequest names the ground objects to merge
+//
+// @Description ground objects to merge into one
+type MergeRequest struct {
+	IDs []int `json:"ids" example:"1,2,3" extensions:"x-order=1"`
+	//Object to merge the others into, the most confident if left out
+	Keep int `json:"keep,omitempty" example:"1" extensions:"x-order=2"`
+}
+
+// MergeGroundObjects merges ground objects into one
+//
+//	@Summary		Merge ground objects
+//	@Description	Merge ground objects of the same type into one, the most confident unless keep is given. The kept object moves to the position of all of them weighted by confidence and takes their detections, and the others are hidden from lists until the merge is split.
+//	@Tags			GroundObject
+//	@Accept			json
+//	@Produce		json
+//	@Param			merge	body		MergeRequest										true	"Objects to merge"
+//	@Success		200		{object}	responses.SingleResponse[models.GroundObjectMerge]	"Success"
+//	@Failure		400		{object}	responses.ErrorResponse								"Invalid JSON or IDs"
+//	@Failure		404		{object}	responses.ErrorResponse								"GroundObject Not Found"
+//	@Failure		409		{object}	responses.ErrorResponse								"GroundObject Already Merged"
+//	@Failure		500		{object}	responses.ErrorResponse								"Internal Error Merging GroundObjects"
+//	@Security		BearerAuth
+//	@Router			/groundobjects/merge [post]
+func MergeGroundObjects(c echo.Context) error {
+	db, _ := c.Get("db").(*gorm.DB)
+
+	var request MergeRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid JSON format",
+			Data:    err.Error()})
+	}
+
+	seen := make(map[int]bool)
+	for _, id := range request.IDs {
+		if seen[id] {
+			return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+				Message: fmt.Sprintf("Object %d is listed more than once", id)})
+		}
+		seen[id] = true
+	}
+	if len(request.IDs) < 2 {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "At least two objects are needed to merge"})
+	}
+	if request.Keep != 0 && !seen[request.Keep] {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "The kept object must be one of the merged objects"})
+	}
+
+	merge := models.GroundObjectMerge{Author: requestAuthor(c), CreatedAt: time.Now().Unix()}
+	var kept models.GroundObject
+	var merged []models.GroundObject
+	txErr := db.Transaction(func(tx *gorm.DB) error {
+		var objects []models.GroundObject
+		for _, id := range request.IDs {
+			var object models.GroundObject
+			if err := tx.First(&object, id).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+				return errMerge{http.StatusNotFound, fmt.Sprintf("Requested object %d does not exist!", id)}
+			} else if err != nil {
+				return err
+			}
+			if object.MergedInto != 0 {
+				return errMerge{http.StatusConflict, fmt.Sprintf("Object %d is already merged into %d", id, object.MergedInto)}
+			}
+			if len(objects) > 0 && object.Type != objects[0].Type {
+				return errMerge{http.StatusBadRequest, "Only objects of the same type can be merged"}
+			}
+			objects = append(objects, object)
+		}
+
+		clustering.Best(objects)
+		for i, object := range objects {
+			if object.ID == request.Keep {
+				//The kept object goes first, the rest stay most confident first
+				objects = append(append([]models.GroundObject{object}, objects[:i]...), objects[i+1:]...)
+				break
+			}
+		}
+		merge.Before = objects
+		merge.KeptID = objects[0].ID
+
+		kept = objects[0]
+		kept.Latitude, kept.Longitude = clustering.Centre(objects)
+		for _, object := range objects[1:] {
+			kept.Confidence = max(kept.Confidence, object.Confidence)
+			merge.MergedIDs = append(merge.MergedIDs, object.ID)
+
+			var detections []models.Detection
+			if err := tx.Where("ground_object_id = ?", object.ID).Order("id").Find(&detections).Error; err != nil {
+				return err
+			}
+			for _, detection := range detections {
+				merge.MovedDetections = append(merge.MovedDetections, models.MovedDetection{
+					DetectionID: detection.ID, FromID: object.ID})
+			}
+			if err := tx.Model(&models.Detection{}).Where("ground_object_id = ?", object.ID).
+				Update("ground_object_id", kept.ID).Error; err != nil {
+				return err
+			}
+
+			object.MergedInto = kept.ID
+			if err := tx.Save(&object).Error; err != nil {
+				return err
+			}
+			merged = append(merged, object)
+		}
+
+		if err := tx.Save(&kept).Error; err != nil {
+			return err
+		}
+		return tx.Create(&merge).Error
+	})
+
+	var mergeErr errMerge
+	if errors.As(txErr, &mergeErr) {
+		return c.JSON(mergeErr.status, responses.ErrorResponse{
+			Message: mergeErr.message})
+	} else if txErr != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "An error occurred merging the objects",
+			Data:    txErr.Error()})
+	}
+
+	publishEvent(c, events.GroundObjects, events.Updated, kept)
+	auditChange(c, "groundobject", kept.ID, merge.Before[0], kept)
+	for i, object := range merged {
+		publishEvent(c, events.GroundObjects, events.Updated, object)
+		auditChange(c, "groundobject", object.ID, merge.Before[i+1], object)
+	}
+	auditChange(c, "groundobject_merge", merge.ID, nil, merge)
+
+	return c.JSON(http.StatusOK, responses.SingleResponse[models.GroundObjectMerge]{
+		Message: "GroundObjects merged!",
+		Model:   merge})
+}
+
+// GetGroundObjectMerges gets the history of merges
+//
+//	@Summary		Get ground object merges
+//	@Description	List every merge of ground objects, including those which were split, the latest first
+//	@Tags			GroundObject
+//	@Produce		json
+//	@Param			object_id	query		int													false	"Only merges into this object"
+//	@Success		200			{object}	responses.MultipleResponse[models.GroundObjectMerge]	"Success"
+//	@Failure		500			{object}	responses.ErrorResponse								"Internal Error Querying Merges"
+//	@Security		BearerAuth
+//	@Router			/groundobjects/merges [get]
+func GetGroundObjectMerges(c echo.Context) error {
+	db, _ := c.Get("db").(*gorm.DB)
+
+	query := db.Order("id desc")
+	if objectId := c.QueryParam("object_id"); objectId != "" {
+		query = query.Where("kept_id = ?", objectId)
+	}
+
+	merges := []models.GroundObjectMerge{}
+	if err := query.Find(&merges).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying merges!",
+			Data:    err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, responses.MultipleResponse[models.GroundObjectMerge]{
+		Message: "Merges found!",
+		Models:  merges,
+	})
+}
+
+// SplitGroundObjectMerge splits a merge apart again
+//
+//	@Summary		Split a ground object merge
+//	@Description	Undo a merge, showing the merged objects again with their detections and moving the kept object back to where it was. Only the latest merge into an object which hasn't been split can be split.
+//	@Tags			GroundObject
+//	@Produce		json
+//	@Param			id	path		int													true	"Merge ID"
+//	@Success		200	{object}	responses.SingleResponse[models.GroundObjectMerge]	"Success"
+//	@Failure		404	{object}	responses.ErrorResponse								"Merge Not Found"
+//	@Failure		409	{object}	responses.ErrorResponse								"Already Split or Not the Latest Merge"
+//	@Failure		500	{object}	responses.ErrorResponse								"Internal Error Splitting Merge"
+//	@Security		BearerAuth
+//	@Router			/groundobjects/merge/{id}/split [post]
+func SplitGroundObjectMerge(c echo.Context) error {
+	db, _ := c.Get("db").(*gorm.DB)
+
+	var merge, previousMerge models.GroundObjectMerge
+	var kept, previousKept models.GroundObject
+	var restored, previousRestored []models.GroundObject
+	txErr := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&merge, c.Param("mergeId")).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+			return errMerge{http.StatusNotFound, "No such merge exists!"}
+		} else if err != nil {
+			return err
+		}
+		previousMerge = merge
+		if merge.SplitAt != 0 {
+			return errMerge{http.StatusConflict, "The merge was already split"}
+		}
+
+		//Later merges built on this one, so they are split first
+		var later int64
+		if err := tx.Model(&models.GroundObjectMerge{}).
+			Where("kept_id = ? AND id > ? AND split_at = 0", merge.KeptID, merge.ID).Count(&later).Error; err != nil {
+			return err
+		}
+		if later > 0 {
+			return errMerge{http.StatusConflict, "A later merge into the object must be split first"}
+		}
+
+		//The merged objects are still shown again if the kept one was deleted
+		if err := tx.First(&kept, merge.KeptID).Error; err == nil {
+			previousKept = kept
+			kept.Latitude = merge.Before[0].Latitude
+			kept.Longitude = merge.Before[0].Longitude
+			kept.Confidence = merge.Before[0].Confidence
+			if err := tx.Save(&kept).Error; err != nil {
+				return err
+			}
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		for _, id := range merge.MergedIDs {
+			var object models.GroundObject
+			if err := tx.First(&object, id).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			previousRestored = append(previousRestored, object)
+			object.MergedInto = 0
+			if err := tx.Save(&object).Error; err != nil {
+				return err
+			}
+			restored = append(restored, object)
+		}
+
+		for _, moved := range merge.MovedDetections {
+			if err := tx.Model(&models.Detection{}).Where("id = ? AND ground_object_id = ?", moved.DetectionID, merge.KeptID).
+				Update("ground_object_id", moved.FromID).Error; err != nil {
+				return err
+			}
+		}
+
+		merge.SplitBy = requestAuthor(c)
+		merge.SplitAt = time.Now().Unix()
+		return tx.Save(&merge).Error
+	})
+
+	var mergeErr errMerge
+	if errors.As(txErr, &mergeErr) {
+		return c.JSON(mergeErr.status, responses.ErrorResponse{
+			Message: mergeErr.message})
+	} else if txErr != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "An error occurred splitting the merge",
+			Data:    txErr.Error()})
+	}
+
+	if kept.ID != 0 {
+		publishEvent(c, events.GroundObjects, events.Updated, kept)
+		auditChange(c, "groundobject", kept.ID, previousKept, kept)
+	}
+	for i, object := range restored {
+		publishEvent(c, events.GroundObjects, events.Updated, object)
+		auditChange(c, "groundobject", object.ID, previousRestored[i], object)
+	}
+	auditChange(c, "groundobject_merge", merge.ID, previousMerge, merge)
+
+	return c.JSON(http.StatusOK, responses.SingleResponse[models.GroundObjectMerge]{
+		Message: "Merge split!",
+		Model:   merge})
+}
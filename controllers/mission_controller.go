@@ -0,0 +1,530 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"gcom-backend/configs"
+	"gcom-backend/models"
+	"gcom-backend/progress"
+	"gcom-backend/responses"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// errQueueRejected is returned when activating a mission if Mission Planner
+// doesn't accept its waypoints
+var errQueueRejected = errors.New("mission planner rejected the mission queue")
+
+// findMission queries a mission along with its waypoints in sequence order
+func findMission(db *gorm.DB, missionId int) (models.Mission, error) {
+	var mission models.Mission
+	err := db.Preload("Waypoints", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sequence")
+	}).Preload("Waypoints.Waypoint").First(&mission, missionId).Error
+
+	return mission, err
+}
+
+// checkMissionWaypoints ensures sequence numbers are unique and every
+// referenced waypoint exists, then sorts the references by sequence
+func checkMissionWaypoints(db *gorm.DB, missionWaypoints []models.MissionWaypoint) error {
+	sequences := make(map[int]bool)
+	for i := range missionWaypoints {
+		if sequences[missionWaypoints[i].Sequence] {
+			return fmt.Errorf("sequence %d is used more than once", missionWaypoints[i].Sequence)
+		}
+		sequences[missionWaypoints[i].Sequence] = true
+
+		var waypoint models.Waypoint
+		if err := db.First(&waypoint, missionWaypoints[i].WaypointID).Error; err != nil {
+			return fmt.Errorf("waypoint %d does not exist", missionWaypoints[i].WaypointID)
+		}
+
+		//Only the reference is stored, the waypoint itself is left untouched
+		missionWaypoints[i].ID = 0
+		missionWaypoints[i].Waypoint = nil
+	}
+
+	sort.Slice(missionWaypoints, func(i, j int) bool {
+		return missionWaypoints[i].Sequence < missionWaypoints[j].Sequence
+	})
+
+	return nil
+}
+
+// CreateMission creates a mission
+//
+//	@Summary		Create a mission
+//	@Description	Create a mission with an ordered list of waypoint references, must have sentinel ID of "-1"
+//	@Tags			Mission
+//	@Accept			json
+//	@Produce		json
+//	@Param			mission	body		models.Mission								true	"Mission Data"
+//	@Success		200		{object}	responses.SingleResponse[models.Mission]	"Success"
+//	@Failure		400		{object}	responses.ErrorResponse						"Invalid JSON or Mission Data"
+//	@Failure		500		{object}	responses.ErrorResponse						"Internal Error Creating Mission"
+//	@Security		BearerAuth
+//	@Router			/mission [post]
+func CreateMission(c echo.Context) error {
+	var mission models.Mission
+	db, _ := c.Get("db").(*gorm.DB)
+
+	if err := c.Bind(&mission); err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid JSON format",
+			Data:    err.Error()})
+	}
+
+	if validationErr := validate.Struct(&mission); validationErr != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid mission data",
+			Data:    validationErr.Error()})
+	}
+
+	if mission.ID != -1 {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Non-sentinel ID passed"})
+	} else {
+		mission.ID = 0
+	}
+
+	if mission.Status != "" {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Status is not editable, activate the mission instead"})
+	}
+	mission.Status = models.MissionDraft
+
+	if mission.Version != 0 || mission.UploadedVersion != 0 {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Versions are not editable"})
+	}
+
+	if err := checkMissionWaypoints(db, mission.Waypoints); err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid mission waypoints",
+			Data:    err.Error()})
+	}
+
+	createErr := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&mission).Error; err != nil {
+			return err
+		}
+		return snapshotMission(tx, mission.ID, requestAuthor(c), "Mission created")
+	})
+
+	if createErr != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "An error occurred creating the mission"})
+	}
+
+	createdMission, _ := findMission(db, mission.ID)
+	auditChange(c, "mission", mission.ID, nil, createdMission)
+
+	return c.JSON(http.StatusOK, responses.SingleResponse[models.Mission]{
+		Message: "Mission created!",
+		Model:   createdMission})
+}
+
+// EditMission edits a mission
+//
+//	@Summary		Edit a mission
+//	@Description	Edit a mission based on path param and JSON, passing waypoints replaces the whole list
+//	@Tags			Mission
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int											true	"Mission ID"
+//	@Param			fields	body		string										true	"JSON fields"	example({"name": "Search route"})
+//	@Success		200		{object}	responses.SingleResponse[models.Mission]	"Success"
+//	@Failure		400		{object}	responses.ErrorResponse						"Invalid JSON or Mission ID"
+//	@Failure		404		{object}	responses.ErrorResponse						"Mission Not Found"
+//	@Failure		500		{object}	responses.ErrorResponse						"Internal Error Editing Mission"
+//	@Security		BearerAuth
+//	@Router			/mission/{id} [patch]
+func EditMission(c echo.Context) error {
+	missionStringId := c.Param("missionId")
+	var mission models.Mission
+	db, _ := c.Get("db").(*gorm.DB)
+
+	missionId, castErr := strconv.Atoi(missionStringId)
+	bindErr := c.Bind(&mission)
+
+	if castErr != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid ID",
+			Data:    castErr.Error()})
+	}
+
+	if bindErr != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid JSON format",
+			Data:    bindErr.Error()})
+	}
+
+	if mission.ID != 0 {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "ID is not editable"})
+	}
+
+	if mission.Status != "" {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Status is not editable, activate the mission instead"})
+	}
+
+	if mission.Version != 0 || mission.UploadedVersion != 0 {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Versions are not editable"})
+	}
+
+	if err := checkMissionWaypoints(db, mission.Waypoints); err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid mission waypoints",
+			Data:    err.Error()})
+	}
+
+	previousMission, err := findMission(db, missionId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
+			Message: "No such mission exists!"})
+	}
+
+	txErr := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Mission{}).
+			Where("id = ?", missionId).
+			Omit("Waypoints").
+			Updates(&mission).Error; err != nil {
+			return err
+		}
+
+		//A nil list means the waypoints were not passed and are left as is
+		if mission.Waypoints == nil {
+			return nil
+		}
+
+		if err := tx.Where("mission_id = ?", missionId).Delete(&models.MissionWaypoint{}).Error; err != nil {
+			return err
+		}
+
+		for i := range mission.Waypoints {
+			mission.Waypoints[i].MissionID = missionId
+		}
+
+		if len(mission.Waypoints) > 0 {
+			if err := tx.Create(&mission.Waypoints).Error; err != nil {
+				return err
+			}
+		}
+
+		return snapshotMission(tx, missionId, requestAuthor(c), "Mission edited")
+	})
+
+	if txErr != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "An error occurred updating the mission",
+			Data:    txErr.Error()})
+	}
+
+	updatedMission, _ := findMission(db, missionId)
+	auditChange(c, "mission", missionId, previousMission, updatedMission)
+
+	return c.JSON(http.StatusOK, responses.SingleResponse[models.Mission]{
+		Message: "Mission updated!",
+		Model:   updatedMission,
+	})
+}
+
+// GetMission gets a mission
+//
+//	@Summary		Get a mission
+//	@Description	Get a mission and its waypoints in sequence order based on path param
+//	@Tags			Mission
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int											true	"Mission ID"
+//	@Success		200	{object}	responses.SingleResponse[models.Mission]	"Success"
+//	@Failure		404	{object}	responses.ErrorResponse						"Mission Not Found"
+//	@Failure		500	{object}	responses.ErrorResponse						"Internal Error Querying Mission"
+//	@Security		BearerAuth
+//	@Router			/mission/{id} [get]
+func GetMission(c echo.Context) error {
+	db, _ := c.Get("db").(*gorm.DB)
+
+	missionId, castErr := strconv.Atoi(c.Param("missionId"))
+	if castErr != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid ID",
+			Data:    castErr.Error()})
+	}
+
+	mission, err := findMission(db, missionId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
+			Message: "No such mission exists!"})
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying mission!"})
+	}
+
+	return c.JSON(http.StatusOK, responses.SingleResponse[models.Mission]{
+		Message: "Mission found!",
+		Model:   mission,
+	})
+}
+
+// DeleteMission deletes a mission
+//
+//	@Summary		Delete a mission
+//	@Description	Delete a mission based on path param, the referenced waypoints are kept
+//	@Tags			Mission
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int											true	"Mission ID"
+//	@Success		200	{object}	responses.SingleResponse[models.Mission]	"Success (returns a blank Mission)"
+//	@Failure		404	{object}	responses.ErrorResponse						"Mission Not Found"
+//	@Failure		500	{object}	responses.ErrorResponse						"Internal Error Deleting Mission"
+//	@Security		BearerAuth
+//	@Router			/mission/{id} [delete]
+func DeleteMission(c echo.Context) error {
+	db, _ := c.Get("db").(*gorm.DB)
+	missionId := c.Param("missionId")
+
+	var deletedMission models.Mission
+	db.Preload("Waypoints").First(&deletedMission, missionId)
+
+	var rowsAffected int64
+	txErr := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("mission_id = ?", missionId).Delete(&models.MissionWaypoint{}).Error; err != nil {
+			return err
+		}
+
+		dbAction := tx.Delete(&models.Mission{}, missionId)
+		rowsAffected = dbAction.RowsAffected
+		return dbAction.Error
+	})
+
+	if txErr != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst deleting mission!"})
+	} else if rowsAffected < 1 {
+		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
+			Message: "No requested mission exists!"})
+	}
+
+	auditChange(c, "mission", missionId, deletedMission, nil)
+
+	return c.JSON(http.StatusOK, responses.SingleResponse[models.Mission]{
+		Message: "Mission deleted!",
+		Model:   models.Mission{},
+	})
+}
+
+// GetAllMissions gets all missions in the database
+//
+//	@Summary		Get all missions
+//	@Description	Get all missions and their waypoints in the database
+//	@Tags			Mission
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	responses.MultipleResponse[models.Mission]	"Success"
+//	@Failure		500	{object}	responses.ErrorResponse						"Internal Error Querying Missions"
+//	@Security		BearerAuth
+//	@Router			/missions [get]
+func GetAllMissions(c echo.Context) error {
+	var missions []models.Mission
+	db, _ := c.Get("db").(*gorm.DB)
+
+	err := db.Preload("Waypoints", func(db *gorm.DB) *gorm.DB {
+		return db.Order("sequence")
+	}).Preload("Waypoints.Waypoint").Find(&missions).Error
+
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying missions!",
+			Data:    err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, responses.MultipleResponse[models.Mission]{
+		Message: "Missions found!",
+		Models:  missions,
+	})
+}
+
+// DuplicateMission duplicates a mission
+//
+//	@Summary		Duplicate a mission
+//	@Description	Create a draft copy of a mission referencing the same waypoints
+//	@Tags			Mission
+//	@Produce		json
+//	@Param			id	path		int											true	"Mission ID"
+//	@Success		200	{object}	responses.SingleResponse[models.Mission]	"Success"
+//	@Failure		404	{object}	responses.ErrorResponse						"Mission Not Found"
+//	@Failure		500	{object}	responses.ErrorResponse						"Internal Error Duplicating Mission"
+//	@Security		BearerAuth
+//	@Router			/mission/{id}/duplicate [post]
+func DuplicateMission(c echo.Context) error {
+	db, _ := c.Get("db").(*gorm.DB)
+
+	missionId, castErr := strconv.Atoi(c.Param("missionId"))
+	if castErr != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid ID",
+			Data:    castErr.Error()})
+	}
+
+	original, err := findMission(db, missionId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
+			Message: "No such mission exists!"})
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying mission!"})
+	}
+
+	duplicate := models.Mission{
+		Name:        original.Name + " (copy)",
+		Description: original.Description,
+		Status:      models.MissionDraft,
+	}
+	for _, missionWaypoint := range original.Waypoints {
+		duplicate.Waypoints = append(duplicate.Waypoints, models.MissionWaypoint{
+			Sequence:   missionWaypoint.Sequence,
+			WaypointID: missionWaypoint.WaypointID,
+		})
+	}
+
+	createErr := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&duplicate).Error; err != nil {
+			return err
+		}
+		return snapshotMission(tx, duplicate.ID, requestAuthor(c), fmt.Sprintf("Duplicated from mission %d", missionId))
+	})
+
+	if createErr != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "An error occurred duplicating the mission"})
+	}
+
+	createdMission, _ := findMission(db, duplicate.ID)
+	auditChange(c, "mission", duplicate.ID, nil, createdMission)
+
+	return c.JSON(http.StatusOK, responses.SingleResponse[models.Mission]{
+		Message: "Mission duplicated!",
+		Model:   createdMission})
+}
+
+// ActivateMission uploads a mission to MissionPlanner
+//
+//	@Summary		Activate a mission
+//	@Description	Sends the mission's waypoints as the queue in Mission Planner and marks it as the active mission
+//	@Tags			Mission
+//	@Produce		json
+//	@Param			id	path		int											true	"Mission ID"
+//	@Success		200	{object}	responses.SingleResponse[models.Mission]	"Success"
+//	@Failure		400	{object}	responses.ErrorResponse						"Mission References Missing Waypoints"
+//	@Failure		404	{object}	responses.ErrorResponse						"Mission Not Found"
+//	@Failure		500	{object}	responses.ErrorResponse						"Mission Planner Rejected Queue"
+//	@Security		BearerAuth
+//	@Router			/mission/{id}/activate [post]
+func ActivateMission(c echo.Context) error {
+	db, _ := c.Get("db").(*gorm.DB)
+	mp := c.Get("mp").(configs.Autopilot)
+
+	missionId, castErr := strconv.Atoi(c.Param("missionId"))
+	if castErr != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid ID",
+			Data:    castErr.Error()})
+	}
+
+	mission, err := findMission(db, missionId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
+			Message: "No such mission exists!"})
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying mission!"})
+	}
+
+	//Waypoints can be deleted after being added to a mission
+	for _, missionWaypoint := range mission.Waypoints {
+		if missionWaypoint.Waypoint == nil {
+			return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+				Message: fmt.Sprintf("Waypoint %d in the mission no longer exists", missionWaypoint.WaypointID)})
+		}
+	}
+
+	//The version is only recorded if the mission is uploaded and marked active
+	var queue []models.Waypoint
+	uploaded := false
+	txErr := db.Transaction(func(tx *gorm.DB) error {
+		//Makes sure the exact waypoints being uploaded are recorded as a version
+		if err := snapshotMission(tx, missionId, requestAuthor(c), "Mission activated"); err != nil {
+			return err
+		}
+
+		var err error
+		if mission, err = findMission(tx, missionId); err != nil {
+			return err
+		}
+		queue = nil
+		for _, missionWaypoint := range mission.Waypoints {
+			if missionWaypoint.Waypoint == nil {
+				return fmt.Errorf("waypoint %d in the mission no longer exists", missionWaypoint.WaypointID)
+			}
+			queue = append(queue, *missionWaypoint.Waypoint)
+		}
+
+		if !mp.SetQueue(queue) {
+			return errQueueRejected
+		}
+		uploaded = true
+
+		if err := tx.Model(&models.Mission{}).
+			Where("status = ?", models.MissionActive).
+			Update("status", models.MissionDraft).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.MissionVersion{}).
+			Where("mission_id = ? AND version = ?", missionId, mission.Version).
+			Update("uploaded_at", time.Now().Unix()).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Mission{}).
+			Where("id = ?", missionId).
+			Updates(map[string]interface{}{
+				"status":           models.MissionActive,
+				"uploaded_version": mission.Version,
+			}).Error
+	})
+
+	if errors.Is(txErr, errQueueRejected) {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Mission Planner rejected the mission queue"})
+	} else if txErr != nil && uploaded {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Mission uploaded but an error occurred marking it active",
+			Data:    txErr.Error()})
+	} else if txErr != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "An error occurred recording the mission version",
+			Data:    txErr.Error()})
+	}
+
+	tracker := c.Get("progress").(*progress.Tracker)
+	tracker.SetQueue(queue)
+
+	previousMission := mission
+	mission.Status = models.MissionActive
+	mission.UploadedVersion = mission.Version
+	auditChange(c, "mission", missionId, previousMission, mission)
+
+	return c.JSON(http.StatusOK, responses.SingleResponse[models.Mission]{
+		Message: "Mission activated!",
+		Model:   mission})
+}
@@ -0,0 +1,461 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gcom-backend/events"
+	"gcom-backend/models"
+	"gcom-backend/responses"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// requestAuthor names whoever made a request, for recording in history
+func requestAuthor(c echo.Context) string {
+	if user, ok := currentUser(c); ok {
+		return user.Username
+	}
+	return "anonymous"
+}
+
+// snapshotMission creates a new MissionVersion if the mission's waypoints
+// differ from its latest version
+func snapshotMission(tx *gorm.DB, missionId int, author string, reason string) error {
+	mission, err := findMission(tx, missionId)
+	if err != nil {
+		return err
+	}
+
+	snapshot := make([]models.MissionVersionWaypoint, 0, len(mission.Waypoints))
+	for _, missionWaypoint := range mission.Waypoints {
+		if missionWaypoint.Waypoint != nil {
+			snapshot = append(snapshot, models.MissionVersionWaypoint{
+				Sequence: missionWaypoint.Sequence,
+				Waypoint: *missionWaypoint.Waypoint,
+			})
+		}
+	}
+
+	var latest models.MissionVersion
+	query := tx.Where("mission_id = ?", missionId).Order("version desc").Limit(1).Find(&latest)
+	if query.Error != nil {
+		return query.Error
+	}
+
+	if query.RowsAffected > 0 {
+		latestBytes, _ := json.Marshal(latest.Waypoints)
+		snapshotBytes, _ := json.Marshal(snapshot)
+		if bytes.Equal(latestBytes, snapshotBytes) {
+			return nil
+		}
+	}
+
+	version := models.MissionVersion{
+		MissionID: missionId,
+		Version:   latest.Version + 1,
+		Author:    author,
+		CreatedAt: time.Now().Unix(),
+		Reason:    reason,
+		Waypoints: snapshot,
+	}
+	if err := tx.Create(&version).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&models.Mission{}).Where("id = ?", missionId).Update("version", version.Version).Error
+}
+
+// snapshotMissionsWith snapshots every mission which references a waypoint
+func snapshotMissionsWith(tx *gorm.DB, waypointId int, author string, reason string) error {
+	var missionIds []int
+	if err := tx.Model(&models.MissionWaypoint{}).
+		Where("waypoint_id = ?", waypointId).
+		Distinct().Pluck("mission_id", &missionIds).Error; err != nil {
+		return err
+	}
+
+	for _, missionId := range missionIds {
+		if err := snapshotMission(tx, missionId, author, reason); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeFromMissions drops a deleted waypoint from every mission which
+// referenced it, snapshotting each of those missions
+func removeFromMissions(tx *gorm.DB, waypointId int, author string) error {
+	var missionIds []int
+	if err := tx.Model(&models.MissionWaypoint{}).
+		Where("waypoint_id = ?", waypointId).
+		Distinct().Pluck("mission_id", &missionIds).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("waypoint_id = ?", waypointId).Delete(&models.MissionWaypoint{}).Error; err != nil {
+		return err
+	}
+
+	for _, missionId := range missionIds {
+		if err := snapshotMission(tx, missionId, author, fmt.Sprintf("Waypoint %d deleted", waypointId)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// longestCommonOrder returns the waypoints in the longest subsequence shared
+// by both orderings, these are the waypoints that did not move
+func longestCommonOrder(from []versionEntry, to []versionEntry) map[versionEntry]bool {
+	lengths := make([][]int, len(from)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(to)+1)
+	}
+
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else {
+				lengths[i][j] = max(lengths[i+1][j], lengths[i][j+1])
+			}
+		}
+	}
+
+	common := make(map[versionEntry]bool)
+	for i, j := 0, 0; i < len(from) && j < len(to); {
+		if from[i] == to[j] {
+			common[from[i]] = true
+			i++
+			j++
+		} else if lengths[i+1][j] >= lengths[i][j+1] {
+			i++
+		} else {
+			j++
+		}
+	}
+
+	return common
+}
+
+// changedFields compares two waypoints field by field using their JSON names
+func changedFields(from models.Waypoint, to models.Waypoint) []models.FieldChange {
+	var fromFields, toFields map[string]any
+	fromBytes, _ := json.Marshal(from)
+	toBytes, _ := json.Marshal(to)
+	_ = json.Unmarshal(fromBytes, &fromFields)
+	_ = json.Unmarshal(toBytes, &toFields)
+
+	keys := make(map[string]bool)
+	for key := range fromFields {
+		keys[key] = true
+	}
+	for key := range toFields {
+		keys[key] = true
+	}
+
+	var changes []models.FieldChange
+	for key := range keys {
+		if !reflect.DeepEqual(fromFields[key], toFields[key]) {
+			changes = append(changes, models.FieldChange{Field: key, From: fromFields[key], To: toFields[key]})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Field < changes[j].Field
+	})
+
+	return changes
+}
+
+// versionEntry identifies a waypoint in a version by its ID and which
+// occurrence of it this is, as missions flying laps or revisiting a waypoint
+// list it more than once
+type versionEntry struct {
+	id         int
+	occurrence int
+}
+
+// versionEntries lists the entry for each waypoint of a version, in order
+func versionEntries(version models.MissionVersion) []versionEntry {
+	seen := make(map[int]int)
+	entries := make([]versionEntry, len(version.Waypoints))
+	for i, entry := range version.Waypoints {
+		entries[i] = versionEntry{id: entry.Waypoint.ID, occurrence: seen[entry.Waypoint.ID]}
+		seen[entry.Waypoint.ID]++
+	}
+	return entries
+}
+
+// diffVersions works out which waypoints were added, removed, moved or edited
+// between two versions, matching waypoints by ID
+func diffVersions(from models.MissionVersion, to models.MissionVersion) models.MissionDiff {
+	diff := models.MissionDiff{
+		From:    from.Version,
+		To:      to.Version,
+		Added:   []models.MissionVersionWaypoint{},
+		Removed: []models.MissionVersionWaypoint{},
+		Moved:   []models.WaypointMove{},
+		Changed: []models.WaypointChange{},
+	}
+
+	fromOrder := versionEntries(from)
+	toOrder := versionEntries(to)
+	fromPositions := make(map[versionEntry]int)
+	toPositions := make(map[versionEntry]int)
+	for i, entry := range fromOrder {
+		fromPositions[entry] = i
+	}
+	for i, entry := range toOrder {
+		toPositions[entry] = i
+	}
+
+	for i, entry := range from.Waypoints {
+		if _, kept := toPositions[fromOrder[i]]; !kept {
+			diff.Removed = append(diff.Removed, entry)
+		}
+	}
+
+	var keptFrom, keptTo []versionEntry
+	for _, entry := range fromOrder {
+		if _, kept := toPositions[entry]; kept {
+			keptFrom = append(keptFrom, entry)
+		}
+	}
+	for _, entry := range toOrder {
+		if _, kept := fromPositions[entry]; kept {
+			keptTo = append(keptTo, entry)
+		}
+	}
+	unmoved := longestCommonOrder(keptFrom, keptTo)
+
+	for i, entry := range to.Waypoints {
+		fromPosition, kept := fromPositions[toOrder[i]]
+		if !kept {
+			diff.Added = append(diff.Added, entry)
+			continue
+		}
+
+		if !unmoved[toOrder[i]] {
+			diff.Moved = append(diff.Moved, models.WaypointMove{
+				WaypointID: entry.Waypoint.ID,
+				Name:       entry.Waypoint.Name,
+				From:       fromPosition,
+				To:         i,
+			})
+		}
+
+		if fields := changedFields(from.Waypoints[fromPosition].Waypoint, entry.Waypoint); len(fields) > 0 {
+			diff.Changed = append(diff.Changed, models.WaypointChange{
+				WaypointID: entry.Waypoint.ID,
+				Name:       entry.Waypoint.Name,
+				Fields:     fields,
+			})
+		}
+	}
+
+	return diff
+}
+
+// findMissionVersion queries a single version of a mission
+func findMissionVersion(db *gorm.DB, missionId int, version string) (models.MissionVersion, error) {
+	var missionVersion models.MissionVersion
+	err := db.Where("mission_id = ? AND version = ?", missionId, version).First(&missionVersion).Error
+
+	return missionVersion, err
+}
+
+// GetMissionVersions gets the version history of a mission
+//
+//	@Summary		Get mission versions
+//	@Description	Get every version of a mission's waypoints, oldest first
+//	@Tags			Mission
+//	@Produce		json
+//	@Param			id	path		int													true	"Mission ID"
+//	@Success		200	{object}	responses.MultipleResponse[models.MissionVersion]	"Success"
+//	@Failure		400	{object}	responses.ErrorResponse								"Invalid Mission ID"
+//	@Failure		500	{object}	responses.ErrorResponse								"Internal Error Querying Versions"
+//	@Security		BearerAuth
+//	@Router			/mission/{id}/versions [get]
+func GetMissionVersions(c echo.Context) error {
+	db, _ := c.Get("db").(*gorm.DB)
+
+	missionId, castErr := strconv.Atoi(c.Param("missionId"))
+	if castErr != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid ID",
+			Data:    castErr.Error()})
+	}
+
+	var versions []models.MissionVersion
+	if err := db.Where("mission_id = ?", missionId).Order("version").Find(&versions).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying mission versions!",
+			Data:    err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, responses.MultipleResponse[models.MissionVersion]{
+		Message: "Mission versions found!",
+		Models:  versions,
+	})
+}
+
+// DiffMissionVersions compares two versions of a mission
+//
+//	@Summary		Diff mission versions
+//	@Description	Get the waypoints added, removed, moved or edited between two versions of a mission
+//	@Tags			Mission
+//	@Produce		json
+//	@Param			id		path		int												true	"Mission ID"
+//	@Param			from	query		int												true	"Earlier version"
+//	@Param			to		query		int												true	"Later version"
+//	@Success		200		{object}	responses.SingleResponse[models.MissionDiff]	"Success"
+//	@Failure		400		{object}	responses.ErrorResponse							"Invalid Mission ID"
+//	@Failure		404		{object}	responses.ErrorResponse							"Version Not Found"
+//	@Security		BearerAuth
+//	@Router			/mission/{id}/diff [get]
+func DiffMissionVersions(c echo.Context) error {
+	db, _ := c.Get("db").(*gorm.DB)
+
+	missionId, castErr := strconv.Atoi(c.Param("missionId"))
+	if castErr != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid ID",
+			Data:    castErr.Error()})
+	}
+
+	var versions [2]models.MissionVersion
+	for i, param := range []string{"from", "to"} {
+		version, err := findMissionVersion(db, missionId, c.QueryParam(param))
+		if err != nil {
+			return c.JSON(http.StatusNotFound, responses.ErrorResponse{
+				Message: fmt.Sprintf("Requested %s version does not exist!", param)})
+		}
+		versions[i] = version
+	}
+
+	return c.JSON(http.StatusOK, responses.SingleResponse[models.MissionDiff]{
+		Message: "Mission versions compared!",
+		Model:   diffVersions(versions[0], versions[1]),
+	})
+}
+
+// RestoreMissionVersion restores a mission's waypoints to an earlier version
+//
+//	@Summary		Restore a mission version
+//	@Description	Restore the waypoints of a mission, and their fields, to an earlier version. Deleted waypoints are recreated. Creates a new version.
+//	@Tags			Mission
+//	@Produce		json
+//	@Param			id		path		int											true	"Mission ID"
+//	@Param			version	path		int											true	"Version to restore"
+//	@Success		200		{object}	responses.SingleResponse[models.Mission]	"Success"
+//	@Failure		400		{object}	responses.ErrorResponse						"Invalid Mission ID"
+//	@Failure		404		{object}	responses.ErrorResponse						"Version Not Found"
+//	@Failure		500		{object}	responses.ErrorResponse						"Internal Error Restoring Version"
+//	@Security		BearerAuth
+//	@Router			/mission/{id}/versions/{version}/restore [post]
+func RestoreMissionVersion(c echo.Context) error {
+	db, _ := c.Get("db").(*gorm.DB)
+	author := requestAuthor(c)
+
+	missionId, castErr := strconv.Atoi(c.Param("missionId"))
+	if castErr != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid ID",
+			Data:    castErr.Error()})
+	}
+
+	version, err := findMissionVersion(db, missionId, c.Param("version"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
+			Message: "No such mission version exists!"})
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying mission version!"})
+	}
+
+	reason := fmt.Sprintf("Restored version %d", version.Version)
+	var changed []events.Event
+	var previous []any
+	txErr := db.Transaction(func(tx *gorm.DB) error {
+		changed, previous = nil, nil
+		var missionWaypoints []models.MissionWaypoint
+		for _, entry := range version.Waypoints {
+			waypoint := entry.Waypoint
+
+			var existing models.Waypoint
+			if err := tx.First(&existing, waypoint.ID).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+				waypoint.ID = 0
+				if err := tx.Create(&waypoint).Error; err != nil {
+					return err
+				}
+				changed = append(changed, events.Event{Action: events.Created, Model: waypoint})
+				previous = append(previous, nil)
+			} else if err != nil {
+				return err
+			} else if err := tx.Save(&waypoint).Error; err != nil {
+				return err
+			} else {
+				changed = append(changed, events.Event{Action: events.Updated, Model: waypoint})
+				previous = append(previous, existing)
+			}
+
+			missionWaypoints = append(missionWaypoints, models.MissionWaypoint{
+				MissionID:  missionId,
+				Sequence:   entry.Sequence,
+				WaypointID: waypoint.ID,
+			})
+		}
+
+		if err := tx.Where("mission_id = ?", missionId).Delete(&models.MissionWaypoint{}).Error; err != nil {
+			return err
+		}
+		if len(missionWaypoints) > 0 {
+			if err := tx.Create(&missionWaypoints).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := snapshotMission(tx, missionId, author, reason); err != nil {
+			return err
+		}
+
+		//Other missions using these waypoints see the restored fields too
+		for _, missionWaypoint := range missionWaypoints {
+			if err := snapshotMissionsWith(tx, missionWaypoint.WaypointID, author, reason+" of another mission"); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if txErr != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "An error occurred restoring the mission version",
+			Data:    txErr.Error()})
+	}
+
+	for i, event := range changed {
+		publishEvent(c, events.Waypoints, event.Action, event.Model)
+		auditChange(c, "waypoint", event.Model.(models.Waypoint).ID, previous[i], event.Model)
+	}
+
+	restoredMission, _ := findMission(db, missionId)
+	auditChange(c, "mission", missionId, nil, restoredMission)
+
+	return c.JSON(http.StatusOK, responses.SingleResponse[models.Mission]{
+		Message: "Mission version restored!",
+		Model:   restoredMission,
+	})
+}
@@ -0,0 +1,27 @@
+package controllers
+
+import (
+	"gcom-backend/progress"
+	"gcom-backend/responses"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// GetMissionProgress gets the progress through the active queue
+//
+//	@Summary		Get mission progress
+//	@Description	Get when each waypoint in the active queue was reached, with the closest approach distance and altitude error
+//	@Tags			Mission
+//	@Produce		json
+//	@Success		200	{object}	responses.SingleResponse[progress.Snapshot]	"Success"
+//	@Security		BearerAuth
+//	@Router			/mission/progress [get]
+func GetMissionProgress(c echo.Context) error {
+	tracker := c.Get("progress").(*progress.Tracker)
+
+	return c.JSON(http.StatusOK, responses.SingleResponse[progress.Snapshot]{
+		Message: "Mission progress found!",
+		Model:   tracker.Snapshot(),
+	})
+}
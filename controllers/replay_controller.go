@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"errors"
+	"gcom-backend/replay"
+	"gcom-backend/responses"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ReplayRequest describes a change to the replay
+//
+// @Description describes a change to the replay, fields are used by the endpoints that need them
+type ReplayRequest struct {
+	FlightID  int     `json:"flight_id" example:"1"`
+	Speed     float64 `json:"speed" example:"2"`
+	Timestamp int64   `json:"timestamp" example:"1698544981"`
+}
+
+// replayResponse responds with the replay state, or the error changing it
+func replayResponse(c echo.Context, state replay.State, err error) error {
+	if errors.Is(err, replay.ErrNotLoaded) {
+		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
+			Message: "No replay is loaded",
+			Data:    err.Error()})
+	} else if err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Unable to change the replay",
+			Data:    err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, responses.SingleResponse[replay.State]{
+		Message: "Replay updated!",
+		Model:   state,
+	})
+}
+
+// bindReplay reads a ReplayRequest, defaulting the speed to 1x
+func bindReplay(c echo.Context) (ReplayRequest, error) {
+	request := ReplayRequest{Speed: 1}
+	err := c.Bind(&request)
+	return request, err
+}
+
+// StartReplay replays a recorded flight as if it were live
+//
+//	@Summary		Start a replay
+//	@Description	Replay the telemetry of a recorded flight through the drone status, progress tracking and socket.io as if it were live. Live progress is put back when the replay ends, and live telemetry is still stored but not published until the replay is stopped or finishes.
+//	@Tags			Replay
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		ReplayRequest							true	"Flight ID and speed, such as 1, 2 or 10"
+//	@Success		200		{object}	responses.SingleResponse[replay.State]	"Success"
+//	@Failure		400		{object}	responses.ErrorResponse					"Invalid Speed or Flight Without Telemetry"
+//	@Security		BearerAuth
+//	@Router			/replay [post]
+func StartReplay(c echo.Context) error {
+	engine := c.Get("replay").(*replay.Engine)
+
+	request, err := bindReplay(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid JSON format",
+			Data:    err.Error()})
+	}
+
+	state, err := engine.Start(request.FlightID, request.Speed)
+	return replayResponse(c, state, err)
+}
+
+// GetReplay gets the state of the replay
+//
+//	@Summary		Get the replay
+//	@Description	Get the flight being replayed, its speed and position
+//	@Tags			Replay
+//	@Produce		json
+//	@Success		200	{object}	responses.SingleResponse[replay.State]	"Success"
+//	@Failure		404	{object}	responses.ErrorResponse					"No Replay Loaded"
+//	@Security		BearerAuth
+//	@Router			/replay [get]
+func GetReplay(c echo.Context) error {
+	engine := c.Get("replay").(*replay.Engine)
+
+	state, err := engine.State()
+	return replayResponse(c, state, err)
+}
+
+// PauseReplay pauses the replay
+//
+//	@Summary		Pause the replay
+//	@Description	Hold the replay at the current sample
+//	@Tags			Replay
+//	@Produce		json
+//	@Success		200	{object}	responses.SingleResponse[replay.State]	"Success"
+//	@Failure		404	{object}	responses.ErrorResponse					"No Replay Loaded"
+//	@Security		BearerAuth
+//	@Router			/replay/pause [post]
+func PauseReplay(c echo.Context) error {
+	engine := c.Get("replay").(*replay.Engine)
+
+	state, err := engine.Pause()
+	return replayResponse(c, state, err)
+}
+
+// ResumeReplay resumes the replay
+//
+//	@Summary		Resume the replay
+//	@Description	Continue a paused replay
+//	@Tags			Replay
+//	@Produce		json
+//	@Success		200	{object}	responses.SingleResponse[replay.State]	"Success"
+//	@Failure		404	{object}	responses.ErrorResponse					"No Replay Loaded"
+//	@Security		BearerAuth
+//	@Router			/replay/resume [post]
+func ResumeReplay(c echo.Context) error {
+	engine := c.Get("replay").(*replay.Engine)
+
+	state, err := engine.Resume()
+	return replayResponse(c, state, err)
+}
+
+// SeekReplay moves the replay to a point in the flight
+//
+//	@Summary		Seek the replay
+//	@Description	Move the replay to the first sample at or after a timestamp, which also restarts a replay that has finished
+//	@Tags			Replay
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		ReplayRequest							true	"Timestamp to seek to"
+//	@Success		200		{object}	responses.SingleResponse[replay.State]	"Success"
+//	@Failure		404		{object}	responses.ErrorResponse					"No Replay Loaded"
+//	@Security		BearerAuth
+//	@Router			/replay/seek [post]
+func SeekReplay(c echo.Context) error {
+	engine := c.Get("replay").(*replay.Engine)
+
+	request, err := bindReplay(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid JSON format",
+			Data:    err.Error()})
+	}
+
+	state, err := engine.SeekTo(request.Timestamp)
+	return replayResponse(c, state, err)
+}
+
+// SetReplaySpeed changes the speed of the replay
+//
+//	@Summary		Set the replay speed
+//	@Description	Change how many times faster than real time the flight is replayed
+//	@Tags			Replay
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		ReplayRequest							true	"Speed, such as 1, 2 or 10"
+//	@Success		200		{object}	responses.SingleResponse[replay.State]	"Success"
+//	@Failure		400		{object}	responses.ErrorResponse					"Invalid Speed"
+//	@Failure		404		{object}	responses.ErrorResponse					"No Replay Loaded"
+//	@Security		BearerAuth
+//	@Router			/replay/speed [post]
+func SetReplaySpeed(c echo.Context) error {
+	engine := c.Get("replay").(*replay.Engine)
+
+	request, err := bindReplay(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid JSON format",
+			Data:    err.Error()})
+	}
+
+	state, err := engine.SetSpeed(request.Speed)
+	return replayResponse(c, state, err)
+}
+
+// StopReplay stops the replay
+//
+//	@Summary		Stop the replay
+//	@Description	Stop the replay and go back to publishing live telemetry
+//	@Tags			Replay
+//	@Success		200
+//	@Security		BearerAuth
+//	@Router			/replay [delete]
+func StopReplay(c echo.Context) error {
+	engine := c.Get("replay").(*replay.Engine)
+
+	engine.Stop()
+	return c.NoContent(http.StatusOK)
+}
@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"gcom-backend/events"
+	"gcom-backend/models"
+	"gcom-backend/responses"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// startReview sets the review status of a new object. Objects detected
+// autonomously wait for review, while operators creating objects confirm them.
+func startReview(c echo.Context, object *models.GroundObject) {
+	if object.Autonomous {
+		object.ReviewStatus = models.Pending
+		return
+	}
+	object.ReviewStatus = models.Approved
+	object.Reviewer = requestAuthor(c)
+	object.ReviewedAt = time.Now().Unix()
+}
+
+// reviewChanged reports whether the fields set by reviewing differ between
+// two objects, as they can only be changed by reviewing
+func reviewChanged(a models.GroundObject, b models.GroundObject) bool {
+	return a.ReviewStatus != b.ReviewStatus || a.Reviewer != b.Reviewer || a.ReviewNotes != b.ReviewNotes ||
+		a.ReviewedAt != b.ReviewedAt
+}
+
+// publishCreated publishes a new object, and that it is waiting for review
+// if it is
+func publishCreated(c echo.Context, object models.GroundObject) {
+	publishEvent(c, events.GroundObjects, events.Created, object)
+	if object.ReviewStatus == models.Pending {
+		publishEvent(c, events.GroundObjects, events.Queued, object)
+	}
+}
+
+// GetReviewQueue gets ground objects to review
+//
+//	@Summary		Get the review queue
+//	@Description	List ground objects with a review status, pending by default, the most confident first. Objects merged into others are left out.
+//	@Tags			Review
+//	@Produce		json
+//	@Param			status	query		string											false	"pending, approved or rejected"
+//	@Success		200		{object}	responses.MultipleResponse[models.GroundObject]	"Success"
+//	@Failure		400		{object}	responses.ErrorResponse							"Invalid Status"
+//	@Failure		500		{object}	responses.ErrorResponse							"Internal Error Querying GroundObjects"
+//	@Security		BearerAuth
+//	@Router			/groundobjects/review [get]
+func GetReviewQueue(c echo.Context) error {
+	db, _ := c.Get("db").(*gorm.DB)
+
+	status := models.Pending
+	if statusParam := c.QueryParam("status"); statusParam != "" {
+		status = models.ReviewStatus(statusParam)
+		if err := (models.GroundObject{ReviewStatus: status}).ValidateEnums(); err != nil {
+			return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+				Message: "Invalid status filter",
+				Data:    err.Error()})
+		}
+	}
+
+	objects := []models.GroundObject{}
+	if err := db.Where("review_status = ? AND merged_into = 0", status).
+		Order("confidence desc").Order("id").Find(&objects).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying objects!",
+			Data:    err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, responses.MultipleResponse[models.GroundObject]{
+		Message: "GroundObjects found!",
+		Models:  objects,
+	})
+}
+
+// ReviewRequest is a reviewer's decision on a ground object
+//
+// @Description notes on a review and changes to make to the object
+type ReviewRequest struct {
+	Notes string `json:"notes,omitempty" example:"Letter could be an O" extensions:"x-order=1"`
+	//Fields of the object to change as it is reviewed, like editing it
+	Changes json.RawMessage `json:"changes,omitempty" swaggertype:"object" extensions:"x-order=2"`
+}
+
+// reviewGroundObject approves or rejects an object, applying any changes the
+// reviewer made first
+func reviewGroundObject(c echo.Context, status models.ReviewStatus, action events.Action) error {
+	db, _ := c.Get("db").(*gorm.DB)
+
+	var request ReviewRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid JSON format",
+			Data:    err.Error()})
+	}
+
+	var previousObject models.GroundObject
+	if err := db.First(&previousObject, c.Param("objectId")).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
+			Message: "No such object exists!"})
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst querying object!"})
+	}
+	if previousObject.MergedInto != 0 {
+		return c.JSON(http.StatusConflict, responses.ErrorResponse{
+			Message: "The object was merged into another, review that one instead"})
+	}
+
+	object := previousObject
+	if len(request.Changes) > 0 {
+		if err := json.Unmarshal(request.Changes, &object); err != nil {
+			return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+				Message: "Invalid changes",
+				Data:    err.Error()})
+		}
+		if object.ID != previousObject.ID || object.MergedInto != previousObject.MergedInto || reviewChanged(object, previousObject) {
+			return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+				Message: "Only the object's details can be changed"})
+		}
+		if validationErr := object.Validate(); validationErr != nil {
+			return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+				Message: "Invalid object data",
+				Data:    validationErr.Error()})
+		}
+	}
+
+	object.ReviewStatus = status
+	object.Reviewer = requestAuthor(c)
+	object.ReviewNotes = request.Notes
+	object.ReviewedAt = time.Now().Unix()
+	if err := db.Save(&object).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "An error occurred reviewing the object",
+			Data:    err.Error()})
+	}
+
+	publishEvent(c, events.GroundObjects, action, object)
+	auditChange(c, "groundobject", object.ID, previousObject, object)
+
+	return c.JSON(http.StatusOK, responses.SingleResponse[models.GroundObject]{
+		Message: "GroundObject " + string(status) + "!",
+		Model:   object,
+	})
+}
+
+// ApproveGroundObject approves a ground object for submission
+//
+//	@Summary		Approve a ground object
+//	@Description	Approve a ground object for submission, optionally changing its details first
+//	@Tags			Review
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int												true	"Ground Object ID"
+//	@Param			review	body		ReviewRequest									false	"Notes and changes"
+//	@Success		200		{object}	responses.SingleResponse[models.GroundObject]	"Success"
+//	@Failure		400		{object}	responses.ErrorResponse							"Invalid JSON or Changes"
+//	@Failure		404		{object}	responses.ErrorResponse							"Object Not Found"
+//	@Failure		409		{object}	responses.ErrorResponse							"Object Merged Into Another"
+//	@Failure		500		{object}	responses.ErrorResponse							"Internal Error Reviewing Object"
+//	@Security		BearerAuth
+//	@Router			/groundobject/{id}/approve [post]
+func ApproveGroundObject(c echo.Context) error {
+	return reviewGroundObject(c, models.Approved, events.Approved)
+}
+
+// RejectGroundObject rejects a ground object
+//
+//	@Summary		Reject a ground object
+//	@Description	Reject a ground object so it isn't submitted, such as a false detection, optionally changing its details first
+//	@Tags			Review
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int												true	"Ground Object ID"
+//	@Param			review	body		ReviewRequest									false	"Notes and changes"
+//	@Success		200		{object}	responses.SingleResponse[models.GroundObject]	"Success"
+//	@Failure		400		{object}	responses.ErrorResponse							"Invalid JSON or Changes"
+//	@Failure		404		{object}	responses.ErrorResponse							"Object Not Found"
+//	@Failure		409		{object}	responses.ErrorResponse							"Object Merged Into Another"
+//	@Failure		500		{object}	responses.ErrorResponse							"Internal Error Reviewing Object"
+//	@Security		BearerAuth
+//	@Router			/groundobject/{id}/reject [post]
+func RejectGroundObject(c echo.Context) error {
+	return reviewGroundObject(c, models.Rejected, events.Rejected)
+}
@@ -0,0 +1,438 @@
+package controllers
+
+import (
+	"errors"
+	"gcom-backend/auth"
+	"gcom-backend/models"
+	"gcom-backend/responses"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"gorm.io/gorm"
+)
+
+// UserRequest describes a user to create or the fields of one to edit
+//
+// @Description describes a user to create, or the fields of one to edit
+type UserRequest struct {
+	Username string      `json:"username" example:"pilot" extensions:"x-order=1"`
+	Password string      `json:"password" example:"correct horse" extensions:"x-order=2"`
+	Role     models.Role `json:"role" example:"operator" extensions:"x-order=3"`
+	Disabled *bool       `json:"disabled,omitempty" example:"false" extensions:"x-order=4"`
+}
+
+// TokenRequest describes a token to create
+//
+// @Description describes a token to create
+type TokenRequest struct {
+	Name string `json:"name" example:"MPS" extensions:"x-order=1"`
+	//Unix time the token stops working, 0 if never
+	ExpiresAt int64 `json:"expires_at" example:"0" extensions:"x-order=2"`
+}
+
+// IssuedToken is the only time a token is shown, after signing in or
+// creating one
+//
+// @Description a new token, which is not shown again
+type IssuedToken struct {
+	Message string `json:"message" example:"Signed in!" extensions:"x-order=1"`
+	//Send as "Authorization: Bearer <token>"
+	Token     string      `json:"token" example:"gcom_3f9a5c..." extensions:"x-order=2"`
+	User      models.User `json:"user" extensions:"x-order=3"`
+	ExpiresAt int64       `json:"expires_at" example:"1698588000" extensions:"x-order=4"`
+}
+
+// currentUser returns the user authenticated by AuthMiddleware
+func currentUser(c echo.Context) (models.User, bool) {
+	user, ok := c.Get("user").(models.User)
+	return user, ok
+}
+
+// findUser loads the user named by the userId path param
+func findUser(c echo.Context, db *gorm.DB) (models.User, error) {
+	var user models.User
+	userId, err := strconv.Atoi(c.Param("userId"))
+	if err != nil {
+		return user, err
+	}
+	return user, db.First(&user, userId).Error
+}
+
+// leavesNoAdmin reports whether changing or removing a user would leave
+// nobody able to manage users
+func leavesNoAdmin(db *gorm.DB, user models.User) bool {
+	if user.Role != models.Admin || user.Disabled {
+		return false
+	}
+	var others int64
+	db.Model(&models.User{}).Where("role = ? AND disabled = ? AND id <> ?", models.Admin, false, user.ID).Count(&others)
+	return others == 0
+}
+
+// Login signs a user in
+//
+//	@Summary		Sign in
+//	@Description	Sign in with a username and password, returning a token which lasts 12 hours. Send it as "Authorization: Bearer <token>". Clients which cannot set headers can send it as the token query param to /events, or as socket.io auth.
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			credentials	body		UserRequest				true	"Username and password"
+//	@Success		200			{object}	IssuedToken				"Success"
+//	@Failure		400			{object}	responses.ErrorResponse	"Invalid JSON"
+//	@Failure		401			{object}	responses.ErrorResponse	"Invalid username or password"
+//	@Router			/auth/login [post]
+func Login(c echo.Context) error {
+	authenticator := c.Get("auth").(*auth.Authenticator)
+
+	var credentials UserRequest
+	if err := c.Bind(&credentials); err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid JSON format",
+			Data:    err.Error()})
+	}
+
+	user, token, secret, err := authenticator.Login(credentials.Username, credentials.Password)
+	if errors.Is(err, auth.ErrBadCredentials) {
+		return c.JSON(http.StatusUnauthorized, responses.ErrorResponse{
+			Message: "Unable to sign in",
+			Data:    err.Error()})
+	} else if err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "An error occurred signing in",
+			Data:    err.Error()})
+	}
+
+	//Whoever signed in is the actor in the audit log
+	c.Set("user", user)
+	auditChange(c, "token", token.ID, nil, token)
+
+	return c.JSON(http.StatusOK, IssuedToken{
+		Message:   "Signed in!",
+		Token:     secret,
+		User:      user,
+		ExpiresAt: token.ExpiresAt})
+}
+
+// GetCurrentUser gets the signed in user
+//
+//	@Summary		Get the signed in user
+//	@Description	Get the user the request's token belongs to
+//	@Tags			Auth
+//	@Produce		json
+//	@Success		200	{object}	models.User
+//	@Failure		401	{object}	responses.ErrorResponse	"Missing or invalid token"
+//	@Security		BearerAuth
+//	@Router			/auth/me [get]
+func GetCurrentUser(c echo.Context) error {
+	user, _ := currentUser(c)
+	return c.JSON(http.StatusOK, user)
+}
+
+// GetAllUsers gets every user
+//
+//	@Summary		Get all users
+//	@Description	Get every user, for admins only
+//	@Tags			Auth
+//	@Produce		json
+//	@Success		200	{object}	responses.MultipleResponse[models.User]
+//	@Failure		403	{object}	responses.ErrorResponse	"Not an admin"
+//	@Security		BearerAuth
+//	@Router			/users [get]
+func GetAllUsers(c echo.Context) error {
+	db := c.Get("db").(*gorm.DB)
+
+	var users []models.User
+	if err := db.Order("id").Find(&users).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "An error occurred getting users",
+			Data:    err.Error()})
+	}
+
+	return c.JSON(http.StatusOK, responses.MultipleResponse[models.User]{
+		Message: "Users found!",
+		Models:  users})
+}
+
+// CreateUser creates a user
+//
+//	@Summary		Create a user
+//	@Description	Create a user with a username, a password of at least 8 characters and a role of viewer, operator, pilot_in_command or admin, for admins only
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			user	body		UserRequest								true	"User Data"
+//	@Success		200		{object}	responses.SingleResponse[models.User]	"Success"
+//	@Failure		400		{object}	responses.ErrorResponse					"Invalid user data"
+//	@Failure		409		{object}	responses.ErrorResponse					"Username taken"
+//	@Security		BearerAuth
+//	@Router			/user [post]
+func CreateUser(c echo.Context) error {
+	db := c.Get("db").(*gorm.DB)
+	authenticator := c.Get("auth").(*auth.Authenticator)
+
+	var request UserRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid JSON format",
+			Data:    err.Error()})
+	}
+
+	var taken int64
+	db.Model(&models.User{}).Where("username = ?", request.Username).Count(&taken)
+	if taken > 0 {
+		return c.JSON(http.StatusConflict, responses.ErrorResponse{
+			Message: "Username is already taken"})
+	}
+
+	user, err := authenticator.CreateUser(request.Username, request.Password, request.Role)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid user data",
+			Data:    err.Error()})
+	}
+
+	auditChange(c, "user", user.ID, nil, user)
+
+	return c.JSON(http.StatusOK, responses.SingleResponse[models.User]{
+		Message: "User created!",
+		Model:   user})
+}
+
+// EditUser edits a user
+//
+//	@Summary		Edit a user
+//	@Description	Change a user's password, role or whether they are disabled, for admins only. The last enabled admin cannot be demoted or disabled.
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			userId	path		int										true	"User ID"
+//	@Param			user	body		UserRequest								true	"Fields to change"
+//	@Success		200		{object}	responses.SingleResponse[models.User]	"Success"
+//	@Failure		400		{object}	responses.ErrorResponse					"Invalid user data"
+//	@Failure		404		{object}	responses.ErrorResponse					"User not found"
+//	@Security		BearerAuth
+//	@Router			/user/{userId} [patch]
+func EditUser(c echo.Context) error {
+	db := c.Get("db").(*gorm.DB)
+
+	user, err := findUser(c, db)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
+			Message: "User not found"})
+	}
+
+	var request UserRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid JSON format",
+			Data:    err.Error()})
+	}
+
+	edited := user
+	if request.Username != "" && request.Username != user.Username {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Usernames cannot be changed"})
+	}
+	if request.Role != "" {
+		if !request.Role.Valid() {
+			return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+				Message: "Invalid user data",
+				Data:    "role must be viewer, operator, pilot_in_command or admin"})
+		}
+		edited.Role = request.Role
+	}
+	if request.Disabled != nil {
+		edited.Disabled = *request.Disabled
+	}
+	if request.Password != "" {
+		if edited.PasswordHash, err = auth.HashPassword(request.Password); err != nil {
+			return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+				Message: "Invalid user data",
+				Data:    err.Error()})
+		}
+	}
+
+	if (edited.Role != models.Admin || edited.Disabled) && leavesNoAdmin(db, user) {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "The last admin cannot be demoted or disabled"})
+	}
+
+	if err := db.Save(&edited).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "An error occurred editing the user",
+			Data:    err.Error()})
+	}
+
+	auditChange(c, "user", user.ID, user, edited)
+
+	return c.JSON(http.StatusOK, responses.SingleResponse[models.User]{
+		Message: "User edited!",
+		Model:   edited})
+}
+
+// DeleteUser deletes a user
+//
+//	@Summary		Delete a user
+//	@Description	Delete a user and all their tokens, for admins only. The last enabled admin cannot be deleted.
+//	@Tags			Auth
+//	@Produce		json
+//	@Param			userId	path		int										true	"User ID"
+//	@Success		200		{object}	responses.SingleResponse[models.User]	"Success"
+//	@Failure		400		{object}	responses.ErrorResponse					"Last admin"
+//	@Failure		404		{object}	responses.ErrorResponse					"User not found"
+//	@Security		BearerAuth
+//	@Router			/user/{userId} [delete]
+func DeleteUser(c echo.Context) error {
+	db := c.Get("db").(*gorm.DB)
+
+	user, err := findUser(c, db)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
+			Message: "User not found"})
+	}
+	if leavesNoAdmin(db, user) {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "The last admin cannot be deleted"})
+	}
+
+	deleteErr := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.Token{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&user).Error
+	})
+	if deleteErr != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "An error occurred deleting the user",
+			Data:    deleteErr.Error()})
+	}
+
+	auditChange(c, "user", user.ID, user, nil)
+
+	return c.JSON(http.StatusOK, responses.SingleResponse[models.User]{
+		Message: "User deleted!",
+		Model:   user})
+}
+
+// GetUserTokens gets a user's tokens
+//
+//	@Summary		Get a user's tokens
+//	@Description	Get the tokens belonging to a user, without the tokens themselves, for admins only
+//	@Tags			Auth
+//	@Produce		json
+//	@Param			userId	path		int	true	"User ID"
+//	@Success		200		{object}	responses.MultipleResponse[models.Token]
+//	@Failure		404		{object}	responses.ErrorResponse	"User not found"
+//	@Security		BearerAuth
+//	@Router			/user/{userId}/tokens [get]
+func GetUserTokens(c echo.Context) error {
+	db := c.Get("db").(*gorm.DB)
+
+	user, err := findUser(c, db)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
+			Message: "User not found"})
+	}
+
+	var tokens []models.Token
+	db.Where("user_id = ?", user.ID).Order("id").Find(&tokens)
+	return c.JSON(http.StatusOK, responses.MultipleResponse[models.Token]{
+		Message: "Tokens found!",
+		Models:  tokens})
+}
+
+// CreateUserToken creates a token for a user
+//
+//	@Summary		Create an API token
+//	@Description	Create a token for a user, such as a long lived one for MPS, for admins only. The token is only shown in this response.
+//	@Tags			Auth
+//	@Accept			json
+//	@Produce		json
+//	@Param			userId	path		int						true	"User ID"
+//	@Param			token	body		TokenRequest			true	"Token Data"
+//	@Success		200		{object}	IssuedToken				"Success"
+//	@Failure		400		{object}	responses.ErrorResponse	"Invalid token data"
+//	@Failure		404		{object}	responses.ErrorResponse	"User not found"
+//	@Security		BearerAuth
+//	@Router			/user/{userId}/token [post]
+func CreateUserToken(c echo.Context) error {
+	db := c.Get("db").(*gorm.DB)
+	authenticator := c.Get("auth").(*auth.Authenticator)
+
+	user, err := findUser(c, db)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
+			Message: "User not found"})
+	}
+
+	var request TokenRequest
+	if err := c.Bind(&request); err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid JSON format",
+			Data:    err.Error()})
+	}
+	if request.Name == "" {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid token data",
+			Data:    "name is required"})
+	}
+	if request.ExpiresAt != 0 && request.ExpiresAt < time.Now().Unix() {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid token data",
+			Data:    "expires_at is in the past"})
+	}
+
+	token, secret, err := authenticator.IssueToken(user.ID, request.Name, request.ExpiresAt)
+	if err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "An error occurred creating the token",
+			Data:    err.Error()})
+	}
+
+	auditChange(c, "token", token.ID, nil, token)
+
+	return c.JSON(http.StatusOK, IssuedToken{
+		Message:   "Token created!",
+		Token:     secret,
+		User:      user,
+		ExpiresAt: token.ExpiresAt})
+}
+
+// DeleteToken revokes a token
+//
+//	@Summary		Revoke an API token
+//	@Description	Delete a token so it can no longer be used, for admins only
+//	@Tags			Auth
+//	@Produce		json
+//	@Param			tokenId	path		int										true	"Token ID"
+//	@Success		200		{object}	responses.SingleResponse[models.Token]	"Success"
+//	@Failure		404		{object}	responses.ErrorResponse					"Token not found"
+//	@Security		BearerAuth
+//	@Router			/token/{tokenId} [delete]
+func DeleteToken(c echo.Context) error {
+	db := c.Get("db").(*gorm.DB)
+
+	var token models.Token
+	tokenId, err := strconv.Atoi(c.Param("tokenId"))
+	if err == nil {
+		err = db.First(&token, tokenId).Error
+	}
+	if err != nil {
+		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
+			Message: "Token not found"})
+	}
+
+	if err := db.Delete(&token).Error; err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "An error occurred revoking the token",
+			Data:    err.Error()})
+	}
+
+	auditChange(c, "token", token.ID, token, nil)
+
+	return c.JSON(http.StatusOK, responses.SingleResponse[models.Token]{
+		Message: "Token revoked!",
+		Model:   token})
+}
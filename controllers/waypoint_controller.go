@@ -1,14 +1,18 @@
 package controllers
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"gcom-backend/events"
+	"gcom-backend/formats"
 	"gcom-backend/models"
 	"gcom-backend/responses"
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/go-playground/validator"
 	"github.com/labstack/echo/v4"
@@ -28,6 +32,7 @@ var validate = validator.New()
 //	@Success		200			{object}	responses.SingleResponse[models.Waypoint]	"Success"
 //	@Failure		400			{object}	responses.ErrorResponse						"Invalid JSON or Waypoint Data"
 //	@Failure		500			{object}	responses.ErrorResponse						"Internal Error Creating Waypoint"
+//	@Security		BearerAuth
 //	@Router			/waypoint [post]
 func CreateWaypoint(c echo.Context) error {
 	var waypoint models.Waypoint    //Declares an empty Waypoint class
@@ -73,6 +78,9 @@ func CreateWaypoint(c echo.Context) error {
 			Message: "An error occurred creating the waypoint"})
 	}
 
+	publishEvent(c, events.Waypoints, events.Created, waypoint)
+	auditChange(c, "waypoint", waypoint.ID, nil, waypoint)
+
 	return c.JSON(http.StatusOK, responses.SingleResponse[models.Waypoint]{
 		Message: "Waypoint created!",
 		Model:   waypoint})
@@ -89,6 +97,7 @@ func CreateWaypoint(c echo.Context) error {
 //	@Success		200			{object}	responses.MultipleResponse[models.Waypoint]	"Success"
 //	@Failure		400			{object}	responses.ErrorResponse						"Invalid JSON or Waypoint Data"
 //	@Failure		500			{object}	responses.ErrorResponse						"Internal Error Creating Waypoint"
+//	@Security		BearerAuth
 //	@Router			/waypoints [post]
 func CreateWaypointBatch(c echo.Context) error {
 	var waypoints []models.Waypoint
@@ -120,6 +129,11 @@ func CreateWaypointBatch(c echo.Context) error {
 			Message: "An error occurred creating the waypoints"})
 	}
 
+	for _, waypoint := range waypoints {
+		publishEvent(c, events.Waypoints, events.Created, waypoint)
+		auditChange(c, "waypoint", waypoint.ID, nil, waypoint)
+	}
+
 	return c.JSON(http.StatusOK, responses.MultipleResponse[models.Waypoint]{
 		Message: "Waypoints created!",
 		Models:  waypoints})
@@ -138,6 +152,7 @@ func CreateWaypointBatch(c echo.Context) error {
 //	@Failure		400		{object}	responses.ErrorResponse						"Invalid JSON or Waypoint ID"
 //	@Failure		404		{object}	responses.ErrorResponse						"Waypoint Not Found"
 //	@Failure		500		{object}	responses.ErrorResponse						"Internal Error Editing Waypoint"
+//	@Security		BearerAuth
 //	@Router			/waypoint/{id} [patch]
 func EditWaypoint(c echo.Context) error {
 	/*
@@ -176,27 +191,43 @@ func EditWaypoint(c echo.Context) error {
 		the function filling in those ?'s with the arguments that follow in
 		left-to-right order.
 	*/
-	updateAction := db.Model(&models.Waypoint{}).
-		Where("id = ?", waypointId).
-		Updates(&waypoint)
+	var previousWaypoint, updatedWaypoint models.Waypoint
+	txErr := db.Transaction(func(tx *gorm.DB) error {
+		tx.First(&previousWaypoint, waypointId)
+		updateAction := tx.Model(&models.Waypoint{}).
+			Where("id = ?", waypointId).
+			Updates(&waypoint)
+
+		if updateAction.Error != nil {
+			return updateAction.Error
+		} else if updateAction.RowsAffected < 1 {
+			return gorm.ErrRecordNotFound
+		}
 
-	if updateAction.Error != nil {
-		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
-			Message: "An error occurred updating the waypoint",
-			Data:    updateAction.Error.Error()})
-	} else if updateAction.RowsAffected < 1 {
+		/*
+			Here, we are getting the updated waypoint information using a shorthand
+			notation, which overwrites the waypoint variable with the information
+			from the query, which is querying by the primaryKey specified in the
+			second argument.
+		*/
+		tx.First(&updatedWaypoint, waypointId)
+
+		//Any mission using this waypoint now has a different set of waypoints,
+		//so the edit is only kept if their versions are recorded
+		return snapshotMissionsWith(tx, waypointId, requestAuthor(c), fmt.Sprintf("Waypoint %d edited", waypointId))
+	})
+
+	if errors.Is(txErr, gorm.ErrRecordNotFound) {
 		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
 			Message: "No such waypoint exists!"})
+	} else if txErr != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "An error occurred updating the waypoint",
+			Data:    txErr.Error()})
 	}
 
-	/*
-		Here, we are getting the updated waypoint information using a shorthand
-		notation, which overwrites the waypoint variable with the information
-		from the query, which is querying by the primaryKey specified in the
-		second argument.
-	*/
-	var updatedWaypoint models.Waypoint
-	db.First(&updatedWaypoint, waypointId)
+	publishEvent(c, events.Waypoints, events.Updated, updatedWaypoint)
+	auditChange(c, "waypoint", waypointId, previousWaypoint, updatedWaypoint)
 
 	return c.JSON(http.StatusOK, responses.SingleResponse[models.Waypoint]{
 		Message: "Waypoint updated!",
@@ -215,6 +246,7 @@ func EditWaypoint(c echo.Context) error {
 //	@Success		200	{object}	responses.SingleResponse[models.Waypoint]	"Success"
 //	@Failure		404	{object}	responses.ErrorResponse						"Waypoint Not Found"
 //	@Failure		500	{object}	responses.ErrorResponse						"Internal Error Querying Waypoint"
+//	@Security		BearerAuth
 //	@Router			/waypoint/{id} [get]
 func GetWaypoint(c echo.Context) error {
 	waypointId := c.Param("waypointId")
@@ -246,6 +278,7 @@ func GetWaypoint(c echo.Context) error {
 //	@Success		200	{object}	responses.SingleResponse[models.Waypoint]	"Success (returns a blank Waypoint)"
 //	@Failure		404	{object}	responses.ErrorResponse						"Waypoint Not Found"
 //	@Failure		500	{object}	responses.ErrorResponse						"Internal Error Deleting Waypoint"
+//	@Security		BearerAuth
 //	@Router			/waypoint/{id} [delete]
 func DeleteWaypoint(c echo.Context) error {
 	db, _ := c.Get("db").(*gorm.DB)
@@ -256,16 +289,35 @@ func DeleteWaypoint(c echo.Context) error {
 		in an id that does not exist, we need to be a bit more creative to detect
 		this. Here, we are checking if our action resulting in any rows changing
 		and if not, telling the user that the waypoint did not exist anyway.
+		The waypoint is read first so other clients can be told what was deleted.
 	*/
-	dbAction := db.Delete(&models.Waypoint{}, waypointId)
-	if err := dbAction.Error; err != nil {
-		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
-			Message: "Error whilst deleting waypoint!"})
-	} else if dbAction.RowsAffected < 1 {
+	var deletedWaypoint models.Waypoint
+	txErr := db.Transaction(func(tx *gorm.DB) error {
+		tx.First(&deletedWaypoint, waypointId)
+		dbAction := tx.Delete(&models.Waypoint{}, waypointId)
+		if err := dbAction.Error; err != nil {
+			return err
+		} else if dbAction.RowsAffected < 1 {
+			return gorm.ErrRecordNotFound
+		}
+
+		//The waypoint is only deleted if it is removed from missions too
+		id, _ := strconv.Atoi(waypointId)
+		return removeFromMissions(tx, id, requestAuthor(c))
+	})
+
+	if errors.Is(txErr, gorm.ErrRecordNotFound) {
 		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
 			Message: "No requested waypoint exists!"})
+	} else if txErr != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst deleting waypoint!",
+			Data:    txErr.Error()})
 	}
 
+	publishEvent(c, events.Waypoints, events.Deleted, deletedWaypoint)
+	auditChange(c, "waypoint", deletedWaypoint.ID, deletedWaypoint, nil)
+
 	return c.JSON(http.StatusOK, responses.SingleResponse[models.Waypoint]{
 		Message: "Waypoint deleted!",
 		Model:   models.Waypoint{},
@@ -284,6 +336,7 @@ func DeleteWaypoint(c echo.Context) error {
 //	@Failure		400	{object}	responses.ErrorResponse						"Invalid JSON or Waypoint IDs"
 //	@Failure		404	{object}	responses.ErrorResponse						"Waypoints Not Found"
 //	@Failure		500	{object}	responses.ErrorResponse						"Internal Error Deleting Waypoint"
+//	@Security		BearerAuth
 //	@Router			/waypoints [delete]
 func DeleteWaypointBatch(c echo.Context) error {
 	db, _ := c.Get("db").(*gorm.DB)
@@ -303,6 +356,7 @@ func DeleteWaypointBatch(c echo.Context) error {
 	}
 
 	// ID verification
+	var deletedWaypoints []models.Waypoint
 	for _, id := range waypointIDs {
 		// Negative id check
 		if id < 0 {
@@ -316,16 +370,29 @@ func DeleteWaypointBatch(c echo.Context) error {
 				Message: fmt.Sprintf("Requested waypoint %d does not exist!", id),
 				Data:    err.Error()})
 		}
+		deletedWaypoints = append(deletedWaypoints, waypointTBValidated)
 	}
 
-	for _, id := range waypointIDs {
-		dbAction := db.Delete(&models.Waypoint{}, id)
-		// Theoretically shouldn't error out after validation, extra validation
-		// must be implemented if db.Delete errors out.
-		if err := dbAction.Error; err != nil {
-			return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
-				Message: fmt.Sprintf("Error whilst deleting waypoint with id %d", id)})
+	// Every waypoint is deleted and removed from missions, or none are
+	if txErr := db.Transaction(func(tx *gorm.DB) error {
+		for _, id := range waypointIDs {
+			if err := tx.Delete(&models.Waypoint{}, id).Error; err != nil {
+				return fmt.Errorf("deleting waypoint with id %d: %w", id, err)
+			}
+			if err := removeFromMissions(tx, id, requestAuthor(c)); err != nil {
+				return fmt.Errorf("removing waypoint %d from missions: %w", id, err)
+			}
 		}
+		return nil
+	}); txErr != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "Error whilst deleting waypoints",
+			Data:    txErr.Error()})
+	}
+
+	for _, waypoint := range deletedWaypoints {
+		publishEvent(c, events.Waypoints, events.Deleted, waypoint)
+		auditChange(c, "waypoint", waypoint.ID, waypoint, nil)
 	}
 
 	return c.JSON(http.StatusOK, responses.SingleResponse[models.Waypoint]{
@@ -343,6 +410,7 @@ func DeleteWaypointBatch(c echo.Context) error {
 //	@Produce		json
 //	@Success		200	{object}	responses.MultipleResponse[models.Waypoint]	"Success"
 //	@Failure		500	{object}	responses.ErrorResponse						"Internal Error Querying Waypoints"
+//	@Security		BearerAuth
 //	@Router			/waypoints [get]
 func GetAllWaypoints(c echo.Context) error {
 	var waypoints []models.Waypoint
@@ -364,3 +432,220 @@ func GetAllWaypoints(c echo.Context) error {
 		Models:  waypoints,
 	})
 }
+
+// readUpload reads a file sent either as the "file" form field or as the raw request body
+func readUpload(c echo.Context) ([]byte, error) {
+	if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), echo.MIMEMultipartForm) {
+		file, err := c.FormFile("file")
+		if err != nil {
+			return nil, err
+		}
+		src, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer src.Close()
+		return io.ReadAll(src)
+	}
+
+	return io.ReadAll(c.Request().Body)
+}
+
+// ImportWaypoints creates waypoints from a Mission Planner or QGroundControl file
+//
+//	@Summary		Import waypoints
+//	@Description	Create waypoints from QGC WPL 110 text or QGroundControl .plan JSON, sent as the body or a "file" form field. NAV_WAYPOINT, TAKEOFF, LAND and LOITER items are imported, anything else is skipped and reported with its line number, as are items without a position or, unless landing, an altitude.
+//	@Tags			Waypoint
+//	@Accept			plain
+//	@Accept			json
+//	@Accept			mpfd
+//	@Produce		json
+//	@Param			file	body		string									true	"Mission file"
+//	@Param			format	query		string									false	"wpl or plan, detected from the file if omitted"
+//	@Param			name	query		string									false	"Prefix for waypoint names"	default(WP)
+//	@Param			dry_run	query		bool									false	"Parse the file without creating waypoints"
+//	@Success		200		{object}	responses.SingleResponse[formats.Import]	"Success"
+//	@Failure		400		{object}	responses.ErrorResponse					"Unreadable File"
+//	@Failure		500		{object}	responses.ErrorResponse					"Internal Error Creating Waypoints"
+//	@Security		BearerAuth
+//	@Router			/waypoints/import [post]
+func ImportWaypoints(c echo.Context) error {
+	db, _ := c.Get("db").(*gorm.DB)
+
+	data, err := readUpload(c)
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Error reading file",
+			Data:    err.Error()})
+	}
+
+	dryRun, _ := strconv.ParseBool(c.QueryParam("dry_run"))
+	prefix := c.QueryParam("name")
+	if prefix == "" {
+		prefix = "WP"
+	}
+
+	format := c.QueryParam("format")
+	if format == "" {
+		if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+			format = "plan"
+		} else {
+			format = "wpl"
+		}
+	}
+
+	var imported formats.Import
+	switch format {
+	case "wpl":
+		imported, err = formats.ParseWPL(bytes.NewReader(data), prefix)
+	case "plan":
+		imported, err = formats.ParsePlan(data, prefix)
+	default:
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Unknown format, use wpl or plan"})
+	}
+
+	if err != nil {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Invalid " + format + " file",
+			Data:    err.Error()})
+	}
+
+	if dryRun {
+		return c.JSON(http.StatusOK, responses.SingleResponse[formats.Import]{
+			Message: "Dry run, no waypoints created",
+			Model:   imported})
+	}
+
+	if len(imported.Waypoints) > 0 {
+		if createErr := db.Create(&imported.Waypoints).Error; createErr != nil {
+			return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+				Message: "An error occurred creating the waypoints"})
+		}
+	}
+
+	for _, waypoint := range imported.Waypoints {
+		publishEvent(c, events.Waypoints, events.Created, waypoint)
+		auditChange(c, "waypoint", waypoint.ID, nil, waypoint)
+	}
+
+	return c.JSON(http.StatusOK, responses.SingleResponse[formats.Import]{
+		Message: "Waypoints imported!",
+		Model:   imported})
+}
+
+// exportFormat describes a file format waypoints can be exported as
+type exportFormat struct {
+	extension   string
+	contentType string
+}
+
+var exportFormats = map[string]exportFormat{
+	"wpl":     {"waypoints", echo.MIMETextPlainCharsetUTF8},
+	"plan":    {"plan", echo.MIMEApplicationJSONCharsetUTF8},
+	"kml":     {"kml", "application/vnd.google-earth.kml+xml"},
+	"geojson": {"geojson", "application/geo+json"},
+}
+
+// sendExport writes an export as a file attachment named <name>.<extension>
+func sendExport(c echo.Context, name string, format exportFormat, write func(io.Writer) error) error {
+	var buffer bytes.Buffer
+	if err := write(&buffer); err != nil {
+		return c.JSON(http.StatusInternalServerError, responses.ErrorResponse{
+			Message: "An error occurred writing the export",
+			Data:    err.Error()})
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition,
+		fmt.Sprintf("attachment; filename=%q", name+"."+format.extension))
+	return c.Blob(http.StatusOK, format.contentType, buffer.Bytes())
+}
+
+// exportWaypointSelection finds the waypoints to export, either a mission's
+// waypoints in order, a list of IDs in the order given, or every waypoint
+func exportWaypointSelection(c echo.Context, db *gorm.DB) (string, []models.Waypoint, error) {
+	if missionParam := c.QueryParam("mission"); missionParam != "" {
+		var mission models.Mission
+		if missionId, err := strconv.Atoi(missionParam); err == nil {
+			mission, err = findMission(db, missionId)
+			if err != nil {
+				return "", nil, err
+			}
+		} else if err := db.Where("name = ?", missionParam).First(&mission).Error; err != nil {
+			return "", nil, err
+		} else if mission, err = findMission(db, mission.ID); err != nil {
+			return "", nil, err
+		}
+
+		var waypoints []models.Waypoint
+		for _, missionWaypoint := range mission.Waypoints {
+			if missionWaypoint.Waypoint != nil {
+				waypoints = append(waypoints, *missionWaypoint.Waypoint)
+			}
+		}
+		return mission.Name, waypoints, nil
+	}
+
+	if idsParam := c.QueryParam("ids"); idsParam != "" {
+		var waypoints []models.Waypoint
+		for _, idString := range strings.Split(idsParam, ",") {
+			var waypoint models.Waypoint
+			if err := db.First(&waypoint, strings.TrimSpace(idString)).Error; err != nil {
+				return "", nil, fmt.Errorf("waypoint %s: %w", idString, err)
+			}
+			waypoints = append(waypoints, waypoint)
+		}
+		return "waypoints", waypoints, nil
+	}
+
+	var waypoints []models.Waypoint
+	err := db.Order("id").Find(&waypoints).Error
+	return "waypoints", waypoints, err
+}
+
+// ExportWaypoints exports waypoints as a file
+//
+//	@Summary		Export waypoints
+//	@Description	Download waypoints as a QGC WPL 110, QGroundControl .plan, KML or GeoJSON file. Exports every waypoint unless a mission or list of IDs is given. KML and GeoJSON include names, designations and radius rings.
+//	@Tags			Waypoint
+//	@Produce		plain
+//	@Produce		json
+//	@Produce		xml
+//	@Param			format	query		string					true	"wpl, plan, kml or geojson"
+//	@Param			mission	query		string					false	"Mission ID or name"
+//	@Param			ids		query		string					false	"Comma separated waypoint IDs"	example(1,2,3)
+//	@Success		200		{file}		file					"Export file attachment"
+//	@Failure		400		{object}	responses.ErrorResponse	"Unknown Format"
+//	@Failure		404		{object}	responses.ErrorResponse	"Mission or Waypoint Not Found"
+//	@Security		BearerAuth
+//	@Router			/waypoints/export [get]
+func ExportWaypoints(c echo.Context) error {
+	db, _ := c.Get("db").(*gorm.DB)
+
+	formatName := c.QueryParam("format")
+	format, ok := exportFormats[formatName]
+	if !ok {
+		return c.JSON(http.StatusBadRequest, responses.ErrorResponse{
+			Message: "Unknown format, use wpl, plan, kml or geojson"})
+	}
+
+	name, waypoints, err := exportWaypointSelection(c, db)
+	if err != nil {
+		return c.JSON(http.StatusNotFound, responses.ErrorResponse{
+			Message: "Requested waypoints do not exist!",
+			Data:    err.Error()})
+	}
+
+	return sendExport(c, name, format, func(w io.Writer) error {
+		switch formatName {
+		case "wpl":
+			return formats.WriteWPL(w, waypoints)
+		case "plan":
+			return formats.WritePlan(w, waypoints)
+		case "kml":
+			return formats.WriteKML(w, name, formats.WaypointPlacemarks(waypoints))
+		default:
+			return formats.WriteGeoJSON(w, formats.WaypointPlacemarks(waypoints))
+		}
+	})
+}
@@ -3,20 +3,200 @@ package controllers
 import (
 	"encoding/json"
 	"fmt"
+	"gcom-backend/audit"
+	"gcom-backend/auth"
+	"gcom-backend/commands"
+	"gcom-backend/events"
 	"gcom-backend/models"
+	"gcom-backend/responses"
+	"gcom-backend/telemetry"
 	"github.com/labstack/echo/v4"
 	"github.com/zishang520/socket.io/v2/socket"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
+	"net/http"
 	"time"
 )
 
-func WebsocketHandler() func(context echo.Context) error {
-	io := socket.NewServer(nil, nil)
-	db, err := gorm.Open(sqlite.Open("./db/database.db"), &gorm.Config{})
+// TelemetryRoom is joined by clients which want drone telemetry
+const TelemetryRoom = "telemetry"
+
+// TelemetryInterval is the fastest telemetry is sent to the TelemetryRoom
+const TelemetryInterval = 200 * time.Millisecond
+
+// SocketTimeout is the longest a publisher waits for the socket server to
+// make room for an event before it is dropped
+const SocketTimeout = time.Second
+
+// socketRooms are the rooms clients may subscribe to
+var socketRooms = map[string]bool{TelemetryRoom: true}
+
+// ack answers a socket event. Clients which pass an acknowledgement callback
+// get the result through it, otherwise only errors are sent back as an
+// "error" event. Either way only the sender hears about it.
+func ack(client *socket.Socket, args []any, message string, err error) {
+	var response any = echo.Map{"message": message}
+	if err != nil {
+		response = responses.ErrorResponse{Message: "Unable to handle event", Data: err.Error()}
+	}
+
+	if len(args) > 0 {
+		if callback, ok := args[len(args)-1].(func([]any, error)); ok {
+			callback([]any{response}, nil)
+			return
+		}
+	}
+
+	if err != nil {
+		client.Emit("error", response)
+	}
+}
+
+// socketPayload decodes the first argument of a socket event into v
+func socketPayload(args []any, v any) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing payload")
+	}
+	if _, ok := args[0].(func([]any, error)); ok {
+		return fmt.Errorf("missing payload")
+	}
+
+	jsonString, err := json.Marshal(args[0])
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(jsonString, v)
+}
+
+// ackCommand answers a socket command with the command sent, whose status
+// changes are then pushed as command_updated events. Commands awaiting
+// confirmation are not errors.
+func ackCommand(client *socket.Socket, args []any, command events.Command, err error) {
+	if err == nil && command.Status == commands.Failed {
+		err = fmt.Errorf("%s command %d was refused by the autopilot", command.Name, command.ID)
+	}
+
+	if len(args) > 0 {
+		if callback, ok := args[len(args)-1].(func([]any, error)); ok {
+			var response any = echo.Map{"message": "Command sent!", "command": command}
+			if err != nil {
+				response = echo.Map{"message": "Unable to send command", "data": err.Error(), "command": command}
+			}
+			callback([]any{response}, nil)
+			return
+		}
+	}
+
 	if err != nil {
-		panic(err)
+		client.Emit("error", responses.ErrorResponse{Message: "Unable to send command", Data: err.Error()})
 	}
+}
+
+// auditSocket records a command sent over socket.io in the audit log, or
+// why it could not be sent
+func auditSocket(log *audit.Log, client *socket.Socket, event string, command events.Command, err error) {
+	base := models.AuditEntry{
+		Actor:    socketUser(client),
+		Method:   "socket.io",
+		Route:    event,
+		ClientIP: client.Handshake().Address,
+	}
+
+	request := &audit.Request{}
+	if err != nil {
+		base.Result = err.Error()
+	} else {
+		request.Command(command)
+	}
+	if appendErr := log.Append(request.Entries(base)...); appendErr != nil {
+		fmt.Println("[AUDIT] Unable to append to the audit log:", appendErr)
+	}
+}
+
+// socketToken reads the token from the socket.io auth payload, such as
+// io({auth: {token}}), or the Authorization header or token query param
+func socketToken(handshake *socket.Handshake) string {
+	if payload, ok := handshake.Auth.(map[string]any); ok {
+		if token, ok := payload["token"].(string); ok {
+			return token
+		}
+	}
+
+	request, _ := http.NewRequest(http.MethodGet, handshake.Url, nil)
+	if request == nil {
+		return ""
+	}
+	request.Header = handshake.Headers
+	return auth.StreamToken(request)
+}
+
+// socketUser returns the username of whoever opened the socket
+func socketUser(client *socket.Socket) string {
+	user, _ := client.Data().(models.User)
+	return user.Username
+}
+
+// allowed checks the socket's user may send an event needing role, answering
+// the event with an error if not
+func allowed(client *socket.Socket, args []any, role models.Role) bool {
+	user, _ := client.Data().(models.User)
+	if user.Role.Allows(role) {
+		return true
+	}
+	ack(client, args, "", fmt.Errorf("this needs the %s role", role))
+	return false
+}
+
+// socketRoomArgs reads the room names sent with subscribe and unsubscribe
+func socketRoomArgs(args []any) ([]socket.Room, error) {
+	var rooms []socket.Room
+	for _, arg := range args {
+		if _, ok := arg.(func([]any, error)); ok {
+			continue
+		}
+
+		name, ok := arg.(string)
+		if !ok || !socketRooms[name] {
+			return nil, fmt.Errorf("unknown room %v", arg)
+		}
+		rooms = append(rooms, socket.Room(name))
+	}
+	return rooms, nil
+}
+
+func WebsocketHandler(pipeline *telemetry.Pipeline, bus *events.Bus, commander *commands.Commander,
+	authenticator *auth.Authenticator, auditLog *audit.Log) func(context echo.Context) error {
+	io := socket.NewServer(nil, nil)
+
+	//Refuse connections without a valid token, and remember who made the rest
+	io.Use(func(client *socket.Socket, next func(*socket.ExtendedError)) {
+		user, err := authenticator.Authenticate(socketToken(client.Handshake()))
+		if err != nil {
+			next(socket.NewExtendedError("Unauthorized", err.Error()))
+			return
+		}
+		client.SetData(user)
+		next(nil)
+	})
+
+	//Forward telemetry, live or replayed, to subscribed clients no faster than
+	//TelemetryInterval
+	throttle := telemetry.NewThrottle(TelemetryInterval, func(drone any) {
+		io.To(TelemetryRoom).Emit(TelemetryRoom, drone)
+	})
+
+	//Forward changes to every client, which can use the client ID to ignore
+	//changes they made themselves. Emitting only queues the event for each
+	//client, so publishers wait for room rather than clients missing progress
+	//or ground objects, but never longer than SocketTimeout.
+	bus.Handle("socket.io", events.Options{Policy: events.Block, Timeout: SocketTimeout}, func(event events.Event) {
+		switch event.Topic {
+		case events.Telemetry:
+			throttle.Push(event.Model)
+		case events.Progress:
+			io.Sockets().Emit("waypoint_reached", event.Model)
+		default:
+			io.Sockets().Emit(event.Name(), event)
+		}
+	})
 
 	io.On("connection", func(clients ...any) {
 		fmt.Println("[SOCKET] Client Connected")
@@ -30,25 +210,142 @@ func WebsocketHandler() func(context echo.Context) error {
 			fmt.Println("[SOCKET] Client Disconnected")
 		})
 
+		client.On("subscribe", func(a ...any) {
+			rooms, err := socketRoomArgs(a)
+			if err == nil {
+				client.Join(rooms...)
+			}
+			ack(client, a, "Subscribed!", err)
+		})
+
+		client.On("unsubscribe", func(a ...any) {
+			rooms, err := socketRoomArgs(a)
+			for _, room := range rooms {
+				client.Leave(room)
+			}
+			ack(client, a, "Unsubscribed!", err)
+		})
+
 		client.On("drone_update", func(a ...any) {
-			droneMap := a[0].(map[string]interface{})
-			jsonString, err := json.Marshal(droneMap)
-			if err != nil {
-				client.Emit("error", err.Error())
+			if !allowed(client, a, models.Operator) {
+				return
 			}
 
-			var drone models.Drone
 			// Read received drone JSON
-			if err := json.Unmarshal(jsonString, &drone); err != nil {
-				client.Emit("error", err.Error())
-			} else {
-				fmt.Println(drone)
-				//Add drone
-				db.Save(&drone)
-				//Delete drones older than 5 minutes
-				var drones []models.Drone
-				db.Delete(&drones, "timestamp < ?", time.Now().Unix()-300)
+			var drone models.Drone
+			err := socketPayload(a, &drone)
+			if err == nil {
+				//Validate, store, archive and publish the drone
+				err = pipeline.Ingest(drone)
+			}
+			ack(client, a, "Telemetry received!", err)
+		})
+
+		//Commands take the same path as the REST endpoints
+		client.On(commands.Takeoff, func(a ...any) {
+			if !allowed(client, a, models.PilotInCommand) {
+				return
+			}
+			var params struct {
+				Altitude float64 `json:"altitude"`
+			}
+			if err := socketPayload(a, &params); err != nil {
+				ackCommand(client, a, events.Command{}, err)
+				return
+			}
+			command, err := commander.Takeoff(socketUser(client), params.Altitude)
+			auditSocket(auditLog, client, commands.Takeoff, command, err)
+			ackCommand(client, a, command, err)
+		})
+
+		client.On(commands.Land, func(a ...any) {
+			if !allowed(client, a, models.PilotInCommand) {
+				return
+			}
+			command, err := commander.Land(socketUser(client))
+			auditSocket(auditLog, client, commands.Land, command, err)
+			ackCommand(client, a, command, err)
+		})
+
+		client.On(commands.RTL, func(a ...any) {
+			if !allowed(client, a, models.PilotInCommand) {
+				return
+			}
+			var params struct {
+				Altitude float64 `json:"altitude"`
+			}
+			if err := socketPayload(a, &params); err != nil {
+				ackCommand(client, a, events.Command{}, err)
+				return
+			}
+			command, err := commander.RTL(socketUser(client), params.Altitude)
+			auditSocket(auditLog, client, commands.RTL, command, err)
+			ackCommand(client, a, command, err)
+		})
+
+		client.On(commands.Lock, func(a ...any) {
+			if !allowed(client, a, models.PilotInCommand) {
+				return
+			}
+			command, err := commander.Lock(socketUser(client))
+			auditSocket(auditLog, client, commands.Lock, command, err)
+			ackCommand(client, a, command, err)
+		})
+
+		client.On(commands.Unlock, func(a ...any) {
+			if !allowed(client, a, models.PilotInCommand) {
+				return
+			}
+			command, err := commander.Unlock(socketUser(client))
+			auditSocket(auditLog, client, commands.Unlock, command, err)
+			ackCommand(client, a, command, err)
+		})
+
+		client.On(commands.QueueSet, func(a ...any) {
+			if !allowed(client, a, models.PilotInCommand) {
+				return
+			}
+			var queue []models.Waypoint
+			if err := socketPayload(a, &queue); err != nil {
+				ackCommand(client, a, events.Command{}, err)
+				return
+			}
+			command, err := commander.SetQueue(socketUser(client), queue)
+			auditSocket(auditLog, client, commands.QueueSet, command, err)
+			ackCommand(client, a, command, err)
+		})
+
+		//A second user confirms or rejects commands awaiting confirmation
+		client.On("confirm", func(a ...any) {
+			if !allowed(client, a, models.PilotInCommand) {
+				return
+			}
+			var params struct {
+				ID uint64 `json:"id"`
+			}
+			if err := socketPayload(a, &params); err != nil {
+				ackCommand(client, a, events.Command{}, err)
+				return
+			}
+			command, err := commander.Confirm(params.ID, socketUser(client))
+			auditSocket(auditLog, client, "confirm", command, err)
+			ackCommand(client, a, command, err)
+		})
+
+		client.On("reject", func(a ...any) {
+			if !allowed(client, a, models.PilotInCommand) {
+				return
+			}
+			var params struct {
+				ID uint64 `json:"id"`
+			}
+			if err := socketPayload(a, &params); err != nil {
+				ackCommand(client, a, events.Command{}, err)
+				return
 			}
+			command, err := commander.Reject(params.ID, socketUser(client))
+			auditSocket(auditLog, client, "reject", command, err)
+			ackCommand(client, a, command, err)
 		})
 	})
 
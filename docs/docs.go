@@ -25,209 +25,265 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/drone/home": {
-            "post": {
-                "description": "Updates the home waypoint",
-                "consumes": [
+        "/audit": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the record of state-changing API calls and drone commands, oldest first, optionally filtered",
+                "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Drone"
+                    "Audit"
                 ],
-                "summary": "Updates the home waypoint",
+                "summary": "Get the audit log",
                 "parameters": [
                     {
-                        "description": "Home Waypoint",
-                        "name": "waypoints",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.Waypoint"
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK"
+                        "type": "string",
+                        "description": "Username of whoever made the call",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "HTTP method, or socket.io for socket events",
+                        "name": "method",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Route as registered, such as /waypoint/:waypointId",
+                        "name": "route",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Kind of entity changed, such as waypoint or command",
+                        "name": "entity_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the entity changed",
+                        "name": "entity_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Earliest unix time",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Latest unix time",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Most entries to return",
+                        "name": "limit",
+                        "in": "query"
                     }
-                }
-            }
-        },
-        "/drone/land": {
-            "get": {
-                "description": "Tells Drone to land",
-                "tags": [
-                    "Drone"
                 ],
-                "summary": "Take off Drone",
                 "responses": {
                     "200": {
-                        "description": "Command issued successfully",
+                        "description": "OK",
                         "schema": {
-                            "type": "body"
+                            "$ref": "#/definitions/responses.MultipleResponse-models_AuditEntry"
                         }
                     },
-                    "500": {
-                        "description": "Command failed to be issued",
-                        "schema": {
-                            "type": "body"
-                        }
-                    }
-                }
-            }
-        },
-        "/drone/lock": {
-            "get": {
-                "description": "Stops drone movement while preserving existing queue",
-                "tags": [
-                    "Drone"
-                ],
-                "summary": "Halts drone in place while preserving queue",
-                "responses": {
-                    "200": {
-                        "description": "Drone locked successfully",
+                    "400": {
+                        "description": "Invalid Filter",
                         "schema": {
-                            "type": "body"
+                            "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Drone unable to lock (already locked?)",
+                        "description": "Internal Error Querying Audit Log",
                         "schema": {
-                            "type": "body"
+                            "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/drone/queue": {
+        "/audit/export": {
             "get": {
-                "description": "Returns queue in Mission Planner",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Drone"
-                ],
-                "summary": "Returns queue in Mission Planner",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Waypoint"
-                            }
-                        }
+                "security": [
+                    {
+                        "BearerAuth": []
                     }
-                }
-            },
-            "post": {
-                "description": "Sends a queue in Mission Planner",
-                "consumes": [
-                    "application/json"
+                ],
+                "description": "Download the audit log with one JSON entry per line, oldest first, taking the same filters as /audit",
+                "produces": [
+                    "text/plain"
                 ],
                 "tags": [
-                    "Drone"
+                    "Audit"
                 ],
-                "summary": "Sends a queue in Mission Planner",
+                "summary": "Export the audit log",
                 "parameters": [
                     {
-                        "description": "Array of Waypoint Data",
-                        "name": "waypoints",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Waypoint"
-                            }
-                        }
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK"
+                        "type": "string",
+                        "description": "Username of whoever made the call",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "HTTP method, or socket.io for socket events",
+                        "name": "method",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Route as registered, such as /waypoint/:waypointId",
+                        "name": "route",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Kind of entity changed, such as waypoint or command",
+                        "name": "entity_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID of the entity changed",
+                        "name": "entity_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Earliest unix time",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Latest unix time",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Most entries to return",
+                        "name": "limit",
+                        "in": "query"
                     }
-                }
-            }
-        },
-        "/drone/rtl": {
-            "post": {
-                "description": "Tells Drone to return home and land",
-                "tags": [
-                    "Drone"
                 ],
-                "summary": "Returns to Home and Lands",
                 "responses": {
                     "200": {
-                        "description": "RTL command issued successfully",
+                        "description": "JSONL file attachment",
                         "schema": {
-                            "type": "body"
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Filter",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "RTL command encountered an error",
+                        "description": "Internal Error Querying Audit Log",
                         "schema": {
-                            "type": "body"
+                            "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/drone/takeoff": {
+        "/auth/login": {
             "post": {
-                "description": "Tells Drone to takeoff",
+                "description": "Sign in with a username and password, returning a token which lasts 12 hours. Send it as \"Authorization: Bearer \u003ctoken\u003e\". Clients which cannot set headers can send it as the token query param to /events, or as socket.io auth.",
                 "consumes": [
                     "application/json"
                 ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Drone"
+                    "Auth"
                 ],
-                "summary": "Take off Drone",
+                "summary": "Sign in",
                 "parameters": [
                     {
-                        "description": "Takeoff Altitude",
-                        "name": "altitude",
+                        "description": "Username and password",
+                        "name": "credentials",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "number"
+                            "$ref": "#/definitions/controllers.UserRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK"
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.IssuedToken"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid username or password",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
                     }
                 }
             }
         },
-        "/drone/unlock": {
+        "/auth/me": {
             "get": {
-                "description": "Stops drone movement while preserving existing queue",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the user the request's token belongs to",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Drone"
+                    "Auth"
                 ],
-                "summary": "Halts drone in place while preserving queue",
+                "summary": "Get the signed in user",
                 "responses": {
                     "200": {
-                        "description": "Drone unlocked successfully",
+                        "description": "OK",
                         "schema": {
-                            "type": "body"
+                            "$ref": "#/definitions/models.User"
                         }
                     },
-                    "500": {
-                        "description": "Drone unable to unlock (already unlocked?)",
+                    "401": {
+                        "description": "Missing or invalid token",
                         "schema": {
-                            "type": "body"
+                            "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/groundobject": {
+        "/detection": {
             "post": {
-                "description": "Create a singular ground object based on JSON, must have sentinel ID of \"-1\"",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Record where in an image a ground object was seen, must have sentinel ID of \"-1\". The ground object and image must exist, and the bounding box must lie within the image.",
                 "consumes": [
                     "application/json"
                 ],
@@ -235,17 +291,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "GroundObject"
+                    "Detection"
                 ],
-                "summary": "Create a ground object",
+                "summary": "Create a detection",
                 "parameters": [
                     {
-                        "description": "Ground Object Data",
-                        "name": "object",
+                        "description": "Detection Data",
+                        "name": "detection",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.GroundObject"
+                            "$ref": "#/definitions/models.Detection"
                         }
                     }
                 ],
@@ -253,70 +309,63 @@ const docTemplate = `{
                     "200": {
                         "description": "Success",
                         "schema": {
-                            "$ref": "#/definitions/responses.SingleResponse-models_GroundObject"
+                            "$ref": "#/definitions/responses.SingleResponse-models_Detection"
                         }
                     },
                     "400": {
-                        "description": "Invalid JSON or Object Data",
+                        "description": "Invalid JSON or Detection Data",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal Error Creating GroundObject",
+                        "description": "Internal Error Creating Detection",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/detection/{id}": {
             "delete": {
-                "description": "Delete multiple ground objects based on json body",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Delete a singular detection based on path param",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "GroundObject"
+                    "Detection"
                 ],
-                "summary": "Delete multiple ground objects",
+                "summary": "Delete a detection",
                 "parameters": [
                     {
-                        "description": "Ground Object IDs",
-                        "name": "ids",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "integer"
-                            }
-                        }
+                        "type": "integer",
+                        "description": "Detection ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Success (returns a blank GroundObject)",
+                        "description": "Success (returns a blank Detection)",
                         "schema": {
-                            "$ref": "#/definitions/responses.SingleResponse-models_GroundObject"
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid JSON or object IDs",
-                        "schema": {
-                            "$ref": "#/definitions/responses.ErrorResponse"
+                            "$ref": "#/definitions/responses.SingleResponse-models_Detection"
                         }
                     },
                     "404": {
-                        "description": "Objects Not Found",
+                        "description": "Detection Not Found",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal Error Deleting Objects",
+                        "description": "Internal Error Deleting Detection",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
@@ -324,143 +373,206 @@ const docTemplate = `{
                 }
             }
         },
-        "/groundobject/{id}": {
-            "get": {
-                "description": "Get a singular ground object based on path param",
-                "consumes": [
-                    "application/json"
+        "/drone/arm": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
-                "produces": [
+                "description": "Arms the drone after takeoff request, or disarms it. Arming starts a flight session which archives telemetry until the drone is disarmed. Arming and disarming may need confirming by a second user before they are sent.",
+                "consumes": [
                     "application/json"
                 ],
                 "tags": [
-                    "GroundObject"
+                    "Drone"
                 ],
-                "summary": "Get a ground object",
+                "summary": "Arm drone",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Ground Object ID",
-                        "name": "id",
+                        "description": "1 to arm, 0 to disarm",
+                        "name": "arm",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "number"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "500": {
+                        "description": "Command failed to be issued",
+                        "schema": {
+                            "type": "body"
+                        }
+                    }
+                }
+            }
+        },
+        "/drone/command/{commandId}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the status of a recent command sent to the drone over REST or socket.io, by the ID in the X-Command-ID header or socket.io acknowledgement",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Drone"
+                ],
+                "summary": "Get a drone command",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Command ID",
+                        "name": "commandId",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Success",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.SingleResponse-models_GroundObject"
+                            "$ref": "#/definitions/events.Command"
                         }
                     },
-                    "404": {
-                        "description": "Object Not Found",
+                    "400": {
+                        "description": "Invalid command ID",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Error Querying GroundObject",
+                    "404": {
+                        "description": "Command not found",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete a singular ground object based on path param",
-                "consumes": [
-                    "application/json"
+            }
+        },
+        "/drone/command/{commandId}/confirm": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Confirm a command awaiting confirmation, which sends it to the drone. It must be confirmed by a different user than requested it.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "GroundObject"
+                    "Drone"
                 ],
-                "summary": "Delete a ground object",
+                "summary": "Confirm a drone command",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "GroundObject ID",
-                        "name": "id",
+                        "description": "Command ID",
+                        "name": "commandId",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Success (returns a blank GroundObject)",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.SingleResponse-models_GroundObject"
+                            "$ref": "#/definitions/events.Command"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid command ID",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Requested by the same user",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "GroundObject Not Found",
+                        "description": "Command not found",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Error Deleting GroundObject",
+                    "409": {
+                        "description": "Command is not awaiting confirmation",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
+                    },
+                    "500": {
+                        "description": "Command refused by the autopilot",
+                        "schema": {
+                            "$ref": "#/definitions/events.Command"
+                        }
                     }
                 }
-            },
-            "patch": {
-                "description": "Edit a singular object based on path param and JSON",
-                "consumes": [
-                    "application/json"
+            }
+        },
+        "/drone/command/{commandId}/reject": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Reject a command awaiting confirmation so it is never sent to the drone, which must be done by a different user than requested it",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "GroundObject"
+                    "Drone"
                 ],
-                "summary": "Edit a ground object",
+                "summary": "Reject a drone command",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "GroundObject ID",
-                        "name": "id",
+                        "description": "Command ID",
+                        "name": "commandId",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "example": "{\"color\": \"black\"}",
-                        "description": "JSON fields",
-                        "name": "fields",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "string"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Success",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.SingleResponse-models_GroundObject"
+                            "$ref": "#/definitions/events.Command"
                         }
                     },
                     "400": {
-                        "description": "Invalid JSON or GroundObject ID",
+                        "description": "Invalid command ID",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Requested by the same user",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "GroundObject Not Found",
+                        "description": "Command not found",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Error Editing GroundObject",
+                    "409": {
+                        "description": "Command is not awaiting confirmation",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
@@ -468,308 +580,443 @@ const docTemplate = `{
                 }
             }
         },
-        "/groundobjects": {
+        "/drone/commands/pending": {
             "get": {
-                "description": "Get all ground objects in the database",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Get the safety critical commands waiting for a second user to confirm or reject them, oldest first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "GroundObject"
+                    "Drone"
                 ],
-                "summary": "Get all ground objects",
+                "summary": "Get commands awaiting confirmation",
                 "responses": {
                     "200": {
-                        "description": "Success",
-                        "schema": {
-                            "$ref": "#/definitions/responses.MultipleResponse-models_GroundObject"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Error Querying GroundObjects",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.ErrorResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/events.Command"
+                            }
                         }
                     }
                 }
-            },
+            }
+        },
+        "/drone/home": {
             "post": {
-                "description": "Create multiple ground objects based on JSON, all must have sentinel ID of \"-1\"",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
-                "produces": [
+                "description": "Updates the home waypoint",
+                "consumes": [
                     "application/json"
                 ],
                 "tags": [
-                    "GroundObject"
+                    "Drone"
                 ],
-                "summary": "Create multiple ground objects",
+                "summary": "Updates the home waypoint",
                 "parameters": [
                     {
-                        "description": "Array of object Data",
-                        "name": "objects",
+                        "description": "Home Waypoint",
+                        "name": "waypoints",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.GroundObject"
-                            }
+                            "$ref": "#/definitions/models.Waypoint"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Success",
-                        "schema": {
-                            "$ref": "#/definitions/responses.MultipleResponse-models_GroundObject"
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid JSON or object Data",
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/drone/land": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Tells Drone to land",
+                "tags": [
+                    "Drone"
+                ],
+                "summary": "Take off Drone",
+                "responses": {
+                    "200": {
+                        "description": "Command issued successfully",
                         "schema": {
-                            "$ref": "#/definitions/responses.ErrorResponse"
+                            "type": "body"
                         }
                     },
                     "500": {
-                        "description": "Internal Error Creating GroundObject",
+                        "description": "Command failed to be issued",
                         "schema": {
-                            "$ref": "#/definitions/responses.ErrorResponse"
+                            "type": "body"
                         }
                     }
                 }
             }
         },
-        "/status": {
+        "/drone/lock": {
             "get": {
-                "description": "Get the current status of the drone",
-                "produces": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Stops drone movement while preserving existing queue",
                 "tags": [
                     "Drone"
                 ],
-                "summary": "Get drone status",
+                "summary": "Halts drone in place while preserving queue",
                 "responses": {
                     "200": {
-                        "description": "Success",
+                        "description": "Drone locked successfully",
                         "schema": {
-                            "$ref": "#/definitions/models.Drone"
+                            "type": "body"
+                        }
+                    },
+                    "500": {
+                        "description": "Drone unable to lock (already locked?)",
+                        "schema": {
+                            "type": "body"
                         }
                     }
                 }
             }
         },
-        "/status/history": {
+        "/drone/queue": {
             "get": {
-                "description": "Get drone status for the last 5 minutes",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Returns queue in Mission Planner",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Drone"
                 ],
-                "summary": "Get drone status history",
+                "summary": "Returns queue in Mission Planner",
                 "responses": {
                     "200": {
-                        "description": "Success",
+                        "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/models.Drone"
+                                "$ref": "#/definitions/models.Waypoint"
                             }
                         }
                     }
                 }
-            }
-        },
-        "/waypoint": {
+            },
             "post": {
-                "description": "Create a singular waypoint based on JSON, must have sentinel ID of \"-1\"",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
-                "produces": [
+                "description": "Sends a queue in Mission Planner and starts tracking progress through it",
+                "consumes": [
                     "application/json"
                 ],
                 "tags": [
-                    "Waypoint"
+                    "Drone"
                 ],
-                "summary": "Create a waypoint",
+                "summary": "Sends a queue in Mission Planner",
                 "parameters": [
                     {
-                        "description": "Waypoint Data",
-                        "name": "waypoint",
+                        "description": "Array of Waypoint Data",
+                        "name": "waypoints",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Waypoint"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Waypoint"
+                            }
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Success",
-                        "schema": {
-                            "$ref": "#/definitions/responses.SingleResponse-models_Waypoint"
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid JSON or Waypoint Data",
-                        "schema": {
-                            "$ref": "#/definitions/responses.ErrorResponse"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Error Creating Waypoint",
-                        "schema": {
-                            "$ref": "#/definitions/responses.ErrorResponse"
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/drone/rtl": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Tells Drone to return home and land",
+                "tags": [
+                    "Drone"
+                ],
+                "summary": "Returns to Home and Lands",
+                "responses": {
+                    "200": {
+                        "description": "RTL command issued successfully",
+                        "schema": {
+                            "type": "body"
+                        }
+                    },
+                    "500": {
+                        "description": "RTL command encountered an error",
+                        "schema": {
+                            "type": "body"
                         }
                     }
                 }
             }
         },
-        "/waypoint/{id}": {
-            "get": {
-                "description": "Get a singular waypoint based on path param",
+        "/drone/takeoff": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Tells Drone to takeoff. Takeoff may need confirming by a second user before it is sent.",
                 "consumes": [
                     "application/json"
                 ],
+                "tags": [
+                    "Drone"
+                ],
+                "summary": "Take off Drone",
+                "parameters": [
+                    {
+                        "description": "Takeoff Altitude",
+                        "name": "altitude",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "number"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "500": {
+                        "description": "Command failed to be issued",
+                        "schema": {
+                            "type": "body"
+                        }
+                    }
+                }
+            }
+        },
+        "/drone/unlock": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Stops drone movement while preserving existing queue",
+                "tags": [
+                    "Drone"
+                ],
+                "summary": "Halts drone in place while preserving queue",
+                "responses": {
+                    "200": {
+                        "description": "Drone unlocked successfully",
+                        "schema": {
+                            "type": "body"
+                        }
+                    },
+                    "500": {
+                        "description": "Drone unable to unlock (already unlocked?)",
+                        "schema": {
+                            "type": "body"
+                        }
+                    }
+                }
+            }
+        },
+        "/events": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Stream the same events as socket.io using Server-Sent Events, each named like the socket.io event with the JSON event as data. Clients reconnecting with a Last-Event-ID header, or last_event_id query param, first receive the events they missed while the server still has them, otherwise a resync event is sent. A comment is sent every 15 seconds as a heartbeat.",
                 "produces": [
-                    "application/json"
+                    "text/event-stream"
                 ],
                 "tags": [
-                    "Waypoint"
+                    "Events"
                 ],
-                "summary": "Get a waypoint",
+                "summary": "Stream events",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Comma separated topics, such as telemetry,waypoints,groundobjects,images,progress,commands. Every topic if empty.",
+                        "name": "topics",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "Waypoint ID",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "ID of the last event received",
+                        "name": "Last-Event-ID",
+                        "in": "header"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Success",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.SingleResponse-models_Waypoint"
+                            "$ref": "#/definitions/events.Event"
                         }
                     },
-                    "404": {
-                        "description": "Waypoint Not Found",
+                    "400": {
+                        "description": "Invalid Last-Event-ID",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
-                    },
-                    "500": {
-                        "description": "Internal Error Querying Waypoint",
+                    }
+                }
+            }
+        },
+        "/events/metrics": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get how many events have been published on each topic, and how far behind each subscriber is, including how many events were dropped because it fell too far behind",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Events"
+                ],
+                "summary": "Get event bus metrics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/responses.ErrorResponse"
+                            "$ref": "#/definitions/events.Metrics"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Delete a singular waypoint based on path param",
-                "consumes": [
-                    "application/json"
+            }
+        },
+        "/flight/{flightId}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Get a single flight session by ID",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Waypoint"
+                    "Flight"
                 ],
-                "summary": "Delete a waypoint",
+                "summary": "Get a flight",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Waypoint ID",
-                        "name": "id",
+                        "description": "Flight ID",
+                        "name": "flightId",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Success (returns a blank Waypoint)",
+                        "description": "Success",
                         "schema": {
-                            "$ref": "#/definitions/responses.SingleResponse-models_Waypoint"
+                            "$ref": "#/definitions/responses.SingleResponse-models_FlightSession"
                         }
                     },
-                    "404": {
-                        "description": "Waypoint Not Found",
+                    "400": {
+                        "description": "Invalid Flight ID",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Error Deleting Waypoint",
+                    "404": {
+                        "description": "Flight Not Found",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     }
                 }
-            },
-            "patch": {
-                "description": "Edit a singular waypoint based on path param and JSON",
-                "consumes": [
-                    "application/json"
+            }
+        },
+        "/flight/{flightId}/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Download the archived telemetry of a flight session as a CSV, GPX or KML track with timestamps, altitude and battery voltage",
                 "produces": [
-                    "application/json"
+                    "text/plain",
+                    "text/xml"
                 ],
                 "tags": [
-                    "Waypoint"
+                    "Flight"
                 ],
-                "summary": "Edit a waypoint",
+                "summary": "Export a flight",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Waypoint ID",
-                        "name": "id",
+                        "description": "Flight ID",
+                        "name": "flightId",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "example": "{\"name\": \"Whiskey}",
-                        "description": "JSON fields",
-                        "name": "fields",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "string"
-                        }
+                        "type": "string",
+                        "description": "csv, gpx or kml",
+                        "name": "format",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Success",
+                        "description": "Export file attachment",
                         "schema": {
-                            "$ref": "#/definitions/responses.SingleResponse-models_Waypoint"
+                            "type": "file"
                         }
                     },
                     "400": {
-                        "description": "Invalid JSON or Waypoint ID",
+                        "description": "Invalid Flight ID or Unknown Format",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Waypoint Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/responses.ErrorResponse"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Error Editing Waypoint",
+                        "description": "Flight Not Found",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
@@ -777,36 +1024,45 @@ const docTemplate = `{
                 }
             }
         },
-        "/waypoints": {
+        "/flights": {
             "get": {
-                "description": "Get all waypoints in the database",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Get every flight session recorded between arming and disarming the drone, newest first",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Waypoint"
+                    "Flight"
                 ],
-                "summary": "Get all waypoints",
+                "summary": "Get all flights",
                 "responses": {
                     "200": {
                         "description": "Success",
                         "schema": {
-                            "$ref": "#/definitions/responses.MultipleResponse-models_Waypoint"
+                            "$ref": "#/definitions/responses.MultipleResponse-models_FlightSession"
                         }
                     },
                     "500": {
-                        "description": "Internal Error Querying Waypoints",
+                        "description": "Internal Error Querying Flights",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/groundobject": {
             "post": {
-                "description": "Create multiple waypoints based on JSON, all must have sentinel ID of \"-1\"",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a singular ground object based on JSON, must have sentinel ID of \"-1\"",
                 "consumes": [
                     "application/json"
                 ],
@@ -814,20 +1070,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Waypoint"
+                    "GroundObject"
                 ],
-                "summary": "Create multiple waypoints",
+                "summary": "Create a ground object",
                 "parameters": [
                     {
-                        "description": "Array of Waypoint Data",
-                        "name": "waypoints",
+                        "description": "Ground Object Data",
+                        "name": "object",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Waypoint"
-                            }
+                            "$ref": "#/definitions/models.GroundObject"
                         }
                     }
                 ],
@@ -835,17 +1088,17 @@ const docTemplate = `{
                     "200": {
                         "description": "Success",
                         "schema": {
-                            "$ref": "#/definitions/responses.MultipleResponse-models_Waypoint"
+                            "$ref": "#/definitions/responses.SingleResponse-models_GroundObject"
                         }
                     },
                     "400": {
-                        "description": "Invalid JSON or Waypoint Data",
+                        "description": "Invalid JSON or Object Data",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal Error Creating Waypoint",
+                        "description": "Internal Error Creating GroundObject",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
@@ -853,7 +1106,12 @@ const docTemplate = `{
                 }
             },
             "delete": {
-                "description": "Delete multiple waypoints based on json body",
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete multiple ground objects based on json body, along with their detections. Objects in a merge which hasn't been split can't be deleted.",
                 "consumes": [
                     "application/json"
                 ],
@@ -861,12 +1119,12 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Waypoint"
+                    "GroundObject"
                 ],
-                "summary": "Delete multiple waypoints",
+                "summary": "Delete multiple ground objects",
                 "parameters": [
                     {
-                        "description": "Waypoint IDs",
+                        "description": "Ground Object IDs",
                         "name": "ids",
                         "in": "body",
                         "required": true,
@@ -880,270 +1138,5342 @@ const docTemplate = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "Success (returns a blank Waypoint)",
+                        "description": "Success (returns a blank GroundObject)",
                         "schema": {
-                            "$ref": "#/definitions/responses.SingleResponse-models_Waypoint"
+                            "$ref": "#/definitions/responses.SingleResponse-models_GroundObject"
                         }
                     },
                     "400": {
-                        "description": "Invalid JSON or Waypoint IDs",
+                        "description": "Invalid JSON or object IDs",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "Waypoints Not Found",
+                        "description": "Objects Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Objects In Unsplit Merge",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal Error Deleting Waypoint",
+                        "description": "Internal Error Deleting Objects",
                         "schema": {
                             "$ref": "#/definitions/responses.ErrorResponse"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "models.Designation": {
-            "description": "Describes a special purpose for a Waypoint",
-            "type": "string",
-            "enum": [
-                "launch",
-                "land",
+        },
+        "/groundobject/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a singular ground object based on path param",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "GroundObject"
+                ],
+                "summary": "Get a ground object",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Ground Object ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-models_GroundObject"
+                        }
+                    },
+                    "404": {
+                        "description": "Object Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Querying GroundObject",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a singular ground object based on path param, along with its detections. Objects in a merge which hasn't been split can't be deleted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "GroundObject"
+                ],
+                "summary": "Delete a ground object",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "GroundObject ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success (returns a blank GroundObject)",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-models_GroundObject"
+                        }
+                    },
+                    "404": {
+                        "description": "GroundObject Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "GroundObject In Unsplit Merge",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Deleting GroundObject",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Edit a singular object based on path param and JSON. Fields left out keep their values and fields sent empty are cleared, then the object must still be valid for its type.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "GroundObject"
+                ],
+                "summary": "Edit a ground object",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "GroundObject ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "example": "{\"color\": \"black\"}",
+                        "description": "JSON fields",
+                        "name": "fields",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-models_GroundObject"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON or GroundObject ID",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "GroundObject Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Editing GroundObject",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/groundobject/{id}/approve": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Approve a ground object for submission, optionally changing its details first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Review"
+                ],
+                "summary": "Approve a ground object",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Ground Object ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Notes and changes",
+                        "name": "review",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ReviewRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-models_GroundObject"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON or Changes",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Object Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Object Merged Into Another",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Reviewing Object",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/groundobject/{id}/detections": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List every detection of a ground object, the most confident first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Detection"
+                ],
+                "summary": "Get a ground object's detections",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Ground Object ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.MultipleResponse-models_Detection"
+                        }
+                    },
+                    "404": {
+                        "description": "Object Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Querying Detections",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/groundobject/{id}/reject": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Reject a ground object so it isn't submitted, such as a false detection, optionally changing its details first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Review"
+                ],
+                "summary": "Reject a ground object",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Ground Object ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Notes and changes",
+                        "name": "review",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ReviewRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-models_GroundObject"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON or Changes",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Object Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Object Merged Into Another",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Reviewing Object",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/groundobject/{id}/thumbnail": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Crop the best detection of a ground object from its image, the most confident then the largest, and serve it as a PNG",
+                "produces": [
+                    "image/png"
+                ],
+                "tags": [
+                    "Detection"
+                ],
+                "summary": "Get a ground object's thumbnail",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Ground Object ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "PNG thumbnail",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "404": {
+                        "description": "Object Not Found or No Image Of It",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Querying Detections",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/groundobjects": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get all ground objects in the database, optionally filtered by type, whether they were detected autonomously and confidence. Objects merged into others are left out unless include_merged is true.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "GroundObject"
+                ],
+                "summary": "Get all ground objects",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "standard or emergent",
+                        "name": "object_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only objects detected with or without a person's help",
+                        "name": "autonomous",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Lowest confidence, from 0 to 1",
+                        "name": "min_confidence",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include objects merged into others",
+                        "name": "include_merged",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.MultipleResponse-models_GroundObject"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Filter",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Querying GroundObjects",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create multiple ground objects based on JSON, all must have sentinel ID of \"-1\"",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "GroundObject"
+                ],
+                "summary": "Create multiple ground objects",
+                "parameters": [
+                    {
+                        "description": "Array of object Data",
+                        "name": "objects",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.GroundObject"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.MultipleResponse-models_GroundObject"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON or object Data",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Creating GroundObject",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/groundobjects/clusters": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Group ground objects which look like the same target, alike and within radius metres of each other, with the position merging them would give",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "GroundObject"
+                ],
+                "summary": "Propose ground objects to merge",
+                "parameters": [
+                    {
+                        "type": "number",
+                        "description": "Metres apart objects may be, default 15",
+                        "name": "radius",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.MultipleResponse-models_MergeProposal"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Radius",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Querying GroundObjects",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/groundobjects/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Download every approved ground object as KML or GeoJSON placemarks",
+                "produces": [
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "GroundObject"
+                ],
+                "summary": "Export ground objects",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "kml or geojson",
+                        "name": "format",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Export file attachment",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Unknown Format",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Querying GroundObjects",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/groundobjects/merge": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Merge ground objects of the same type into one, the most confident unless keep is given. The kept object moves to the position of all of them weighted by confidence and takes their detections, and the others are hidden from lists until the merge is split.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "GroundObject"
+                ],
+                "summary": "Merge ground objects",
+                "parameters": [
+                    {
+                        "description": "Objects to merge",
+                        "name": "merge",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.MergeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-models_GroundObjectMerge"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON or IDs",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "GroundObject Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "GroundObject Already Merged",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Merging GroundObjects",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/groundobjects/merge/{id}/split": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Undo a merge, showing the merged objects again with their detections and moving the kept object back to where it was. Only the latest merge into an object which hasn't been split can be split.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "GroundObject"
+                ],
+                "summary": "Split a ground object merge",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Merge ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-models_GroundObjectMerge"
+                        }
+                    },
+                    "404": {
+                        "description": "Merge Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Already Split or Not the Latest Merge",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Splitting Merge",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/groundobjects/merges": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List every merge of ground objects, including those which were split, the latest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "GroundObject"
+                ],
+                "summary": "Get ground object merges",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Only merges into this object",
+                        "name": "object_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.MultipleResponse-models_GroundObjectMerge"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Querying Merges",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/groundobjects/review": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List ground objects with a review status, pending by default, the most confident first. Objects merged into others are left out.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Review"
+                ],
+                "summary": "Get the review queue",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "pending, approved or rejected",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.MultipleResponse-models_GroundObject"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Status",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Querying GroundObjects",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/groundobjects/schema": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the values allowed for object_type, shape, color, text_color and orientation",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "GroundObject"
+                ],
+                "summary": "Get ground object schema",
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.GroundObjectSchema"
+                        }
+                    }
+                }
+            }
+        },
+        "/image/{filename}/detections": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List every detection in an image, in the order they were created",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Detection"
+                ],
+                "summary": "Get an image's detections",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Image Filename",
+                        "name": "filename",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.MultipleResponse-models_Detection"
+                        }
+                    },
+                    "404": {
+                        "description": "Image Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Querying Detections",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/image/{filename}/jobs": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List the jobs of each processor looking for ground objects in an image, with their status, attempts and how many detections they created",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Image"
+                ],
+                "summary": "Get an image's processing jobs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Image Filename",
+                        "name": "filename",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.MultipleResponse-models_ImageJob"
+                        }
+                    },
+                    "404": {
+                        "description": "Image Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Querying Jobs",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/image/{filename}/locate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Find the lat and long of a pixel in a georeferenced image from the drone's pose, the camera and the height of the ground. Optionally creates a ground object there with a detection around the pixel.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Image"
+                ],
+                "summary": "Locate a pixel on the ground",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Image Filename",
+                        "name": "filename",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Pixel and optional ground object",
+                        "name": "pixel",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.LocateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.LocateResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON, Pixel or Object Data",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Image Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Image Not Georeferenced",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Creating Object",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/interop": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Whether the backend is logged in to the competition's interop server, the mission being flown and how telemetry uploads are going",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Interop"
+                ],
+                "summary": "Get the interop status",
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-controllers_InteropStatus"
+                        }
+                    },
+                    "503": {
+                        "description": "Interop Not Configured",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/interop/mission": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the fly zones, search grid and other positions of the interop mission last imported, or the one with the given ID",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Interop"
+                ],
+                "summary": "Get the interop mission",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Interop Mission ID",
+                        "name": "id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-models_InteropMission"
+                        }
+                    },
+                    "404": {
+                        "description": "No Mission Imported",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Querying Mission",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Fetch a mission from interop, creating a draft mission of its waypoints and obstacle waypoints for its stationary obstacles, and storing its fly zones, search grid and other positions. Importing again creates a new mission.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Interop"
+                ],
+                "summary": "Import the interop mission",
+                "parameters": [
+                    {
+                        "description": "Mission to import",
+                        "name": "mission",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.InteropMissionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-models_InteropMission"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Creating Mission",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Interop Error",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Interop Not Configured",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/interop/submissions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "List every ground object submitted to interop, with its interop ID and whether its thumbnail was sent",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Interop"
+                ],
+                "summary": "Get interop submissions",
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.MultipleResponse-models_InteropSubmission"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Querying Submissions",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/interop/submit": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Submit every approved ground object not merged into another, with the thumbnail of its best detection. Objects are only sent again when they change, and objects submitted before which are no longer approved or were merged are withdrawn. Failures are recorded on each submission.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Interop"
+                ],
+                "summary": "Submit ground objects to interop",
+                "responses": {
+                    "200": {
+                        "description": "Submissions made or withdrawn",
+                        "schema": {
+                            "$ref": "#/definitions/responses.MultipleResponse-models_InteropSubmission"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Querying Objects",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Interop Not Configured",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/interop/telemetry/start": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Upload the latest live drone status to interop at the configured rate. Nothing is sent while a flight is replayed or telemetry has stopped.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Interop"
+                ],
+                "summary": "Start uploading telemetry",
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-interop_UploadStatus"
+                        }
+                    },
+                    "503": {
+                        "description": "Interop Not Configured",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/interop/telemetry/stop": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Stop uploading drone status to interop",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Interop"
+                ],
+                "summary": "Stop uploading telemetry",
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-interop_UploadStatus"
+                        }
+                    },
+                    "503": {
+                        "description": "Interop Not Configured",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/mission": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a mission with an ordered list of waypoint references, must have sentinel ID of \"-1\"",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Mission"
+                ],
+                "summary": "Create a mission",
+                "parameters": [
+                    {
+                        "description": "Mission Data",
+                        "name": "mission",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Mission"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-models_Mission"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON or Mission Data",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Creating Mission",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/mission/progress": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get when each waypoint in the active queue was reached, with the closest approach distance and altitude error",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Mission"
+                ],
+                "summary": "Get mission progress",
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-progress_Snapshot"
+                        }
+                    }
+                }
+            }
+        },
+        "/mission/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a mission and its waypoints in sequence order based on path param",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Mission"
+                ],
+                "summary": "Get a mission",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Mission ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-models_Mission"
+                        }
+                    },
+                    "404": {
+                        "description": "Mission Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Querying Mission",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a mission based on path param, the referenced waypoints are kept",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Mission"
+                ],
+                "summary": "Delete a mission",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Mission ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success (returns a blank Mission)",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-models_Mission"
+                        }
+                    },
+                    "404": {
+                        "description": "Mission Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Deleting Mission",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Edit a mission based on path param and JSON, passing waypoints replaces the whole list",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Mission"
+                ],
+                "summary": "Edit a mission",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Mission ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "example": "{\"name\": \"Search route\"}",
+                        "description": "JSON fields",
+                        "name": "fields",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-models_Mission"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON or Mission ID",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Mission Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Editing Mission",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/mission/{id}/activate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Sends the mission's waypoints as the queue in Mission Planner and marks it as the active mission",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Mission"
+                ],
+                "summary": "Activate a mission",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Mission ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-models_Mission"
+                        }
+                    },
+                    "400": {
+                        "description": "Mission References Missing Waypoints",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Mission Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Mission Planner Rejected Queue",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/mission/{id}/diff": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the waypoints added, removed, moved or edited between two versions of a mission",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Mission"
+                ],
+                "summary": "Diff mission versions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Mission ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Earlier version",
+                        "name": "from",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Later version",
+                        "name": "to",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-models_MissionDiff"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Mission ID",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Version Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/mission/{id}/duplicate": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a draft copy of a mission referencing the same waypoints",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Mission"
+                ],
+                "summary": "Duplicate a mission",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Mission ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-models_Mission"
+                        }
+                    },
+                    "404": {
+                        "description": "Mission Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Duplicating Mission",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/mission/{id}/versions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get every version of a mission's waypoints, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Mission"
+                ],
+                "summary": "Get mission versions",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Mission ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.MultipleResponse-models_MissionVersion"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Mission ID",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Querying Versions",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/mission/{id}/versions/{version}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Restore the waypoints of a mission, and their fields, to an earlier version. Deleted waypoints are recreated. Creates a new version.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Mission"
+                ],
+                "summary": "Restore a mission version",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Mission ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Version to restore",
+                        "name": "version",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-models_Mission"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Mission ID",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Version Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Restoring Version",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/missions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get all missions and their waypoints in the database",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Mission"
+                ],
+                "summary": "Get all missions",
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.MultipleResponse-models_Mission"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Querying Missions",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/replay": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the flight being replayed, its speed and position",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Replay"
+                ],
+                "summary": "Get the replay",
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-replay_State"
+                        }
+                    },
+                    "404": {
+                        "description": "No Replay Loaded",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Replay the telemetry of a recorded flight through the drone status, progress tracking and socket.io as if it were live. Live progress is put back when the replay ends, and live telemetry is still stored but not published until the replay is stopped or finishes.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Replay"
+                ],
+                "summary": "Start a replay",
+                "parameters": [
+                    {
+                        "description": "Flight ID and speed, such as 1, 2 or 10",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ReplayRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-replay_State"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Speed or Flight Without Telemetry",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Stop the replay and go back to publishing live telemetry",
+                "tags": [
+                    "Replay"
+                ],
+                "summary": "Stop the replay",
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/replay/pause": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Hold the replay at the current sample",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Replay"
+                ],
+                "summary": "Pause the replay",
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-replay_State"
+                        }
+                    },
+                    "404": {
+                        "description": "No Replay Loaded",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/replay/resume": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Continue a paused replay",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Replay"
+                ],
+                "summary": "Resume the replay",
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-replay_State"
+                        }
+                    },
+                    "404": {
+                        "description": "No Replay Loaded",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/replay/seek": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Move the replay to the first sample at or after a timestamp, which also restarts a replay that has finished",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Replay"
+                ],
+                "summary": "Seek the replay",
+                "parameters": [
+                    {
+                        "description": "Timestamp to seek to",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ReplayRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-replay_State"
+                        }
+                    },
+                    "404": {
+                        "description": "No Replay Loaded",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/replay/speed": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change how many times faster than real time the flight is replayed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Replay"
+                ],
+                "summary": "Set the replay speed",
+                "parameters": [
+                    {
+                        "description": "Speed, such as 1, 2 or 10",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.ReplayRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-replay_State"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid Speed",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No Replay Loaded",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/status": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the current status of the drone, which is replayed telemetry while a replay is running",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Drone"
+                ],
+                "summary": "Get drone status",
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/models.Drone"
+                        }
+                    },
+                    "404": {
+                        "description": "No Telemetry Received",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/status/history": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get drone status for the last 5 minutes, oldest first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Drone"
+                ],
+                "summary": "Get drone status history",
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Drone"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/token/{tokenId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a token so it can no longer be used, for admins only",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Revoke an API token",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Token ID",
+                        "name": "tokenId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-models_Token"
+                        }
+                    },
+                    "404": {
+                        "description": "Token not found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a user with a username, a password of at least 8 characters and a role of viewer, operator, pilot_in_command or admin, for admins only",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Create a user",
+                "parameters": [
+                    {
+                        "description": "User Data",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.UserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-models_User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user data",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Username taken",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{userId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a user and all their tokens, for admins only. The last enabled admin cannot be deleted.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Delete a user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-models_User"
+                        }
+                    },
+                    "400": {
+                        "description": "Last admin",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Change a user's password, role or whether they are disabled, for admins only. The last enabled admin cannot be demoted or disabled.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Edit a user",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to change",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.UserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-models_User"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid user data",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{userId}/token": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a token for a user, such as a long lived one for MPS, for admins only. The token is only shown in this response.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Create an API token",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Token Data",
+                        "name": "token",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/controllers.TokenRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/controllers.IssuedToken"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid token data",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/user/{userId}/tokens": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get the tokens belonging to a user, without the tokens themselves, for admins only",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Get a user's tokens",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.MultipleResponse-models_Token"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get every user, for admins only",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Get all users",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/responses.MultipleResponse-models_User"
+                        }
+                    },
+                    "403": {
+                        "description": "Not an admin",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/waypoint": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create a singular waypoint based on JSON, must have sentinel ID of \"-1\"",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Waypoint"
+                ],
+                "summary": "Create a waypoint",
+                "parameters": [
+                    {
+                        "description": "Waypoint Data",
+                        "name": "waypoint",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Waypoint"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-models_Waypoint"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON or Waypoint Data",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Creating Waypoint",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/waypoint/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get a singular waypoint based on path param",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Waypoint"
+                ],
+                "summary": "Get a waypoint",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Waypoint ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-models_Waypoint"
+                        }
+                    },
+                    "404": {
+                        "description": "Waypoint Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Querying Waypoint",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete a singular waypoint based on path param",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Waypoint"
+                ],
+                "summary": "Delete a waypoint",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Waypoint ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success (returns a blank Waypoint)",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-models_Waypoint"
+                        }
+                    },
+                    "404": {
+                        "description": "Waypoint Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Deleting Waypoint",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Edit a singular waypoint based on path param and JSON",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Waypoint"
+                ],
+                "summary": "Edit a waypoint",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Waypoint ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "example": "{\"name\": \"Whiskey}",
+                        "description": "JSON fields",
+                        "name": "fields",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-models_Waypoint"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON or Waypoint ID",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Waypoint Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Editing Waypoint",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/waypoints": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Get all waypoints in the database",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Waypoint"
+                ],
+                "summary": "Get all waypoints",
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.MultipleResponse-models_Waypoint"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Querying Waypoints",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create multiple waypoints based on JSON, all must have sentinel ID of \"-1\"",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Waypoint"
+                ],
+                "summary": "Create multiple waypoints",
+                "parameters": [
+                    {
+                        "description": "Array of Waypoint Data",
+                        "name": "waypoints",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Waypoint"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.MultipleResponse-models_Waypoint"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON or Waypoint Data",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Creating Waypoint",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Delete multiple waypoints based on json body",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Waypoint"
+                ],
+                "summary": "Delete multiple waypoints",
+                "parameters": [
+                    {
+                        "description": "Waypoint IDs",
+                        "name": "ids",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "integer"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success (returns a blank Waypoint)",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-models_Waypoint"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid JSON or Waypoint IDs",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Waypoints Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Deleting Waypoint",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/waypoints/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Download waypoints as a QGC WPL 110, QGroundControl .plan, KML or GeoJSON file. Exports every waypoint unless a mission or list of IDs is given. KML and GeoJSON include names, designations and radius rings.",
+                "produces": [
+                    "text/plain",
+                    "application/json",
+                    "text/xml"
+                ],
+                "tags": [
+                    "Waypoint"
+                ],
+                "summary": "Export waypoints",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "wpl, plan, kml or geojson",
+                        "name": "format",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Mission ID or name",
+                        "name": "mission",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "1,2,3",
+                        "description": "Comma separated waypoint IDs",
+                        "name": "ids",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Export file attachment",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Unknown Format",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Mission or Waypoint Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/waypoints/import": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Create waypoints from QGC WPL 110 text or QGroundControl .plan JSON, sent as the body or a \"file\" form field. NAV_WAYPOINT, TAKEOFF, LAND and LOITER items are imported, anything else is skipped and reported with its line number, as are items without a position or, unless landing, an altitude.",
+                "consumes": [
+                    "text/plain",
+                    "application/json",
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Waypoint"
+                ],
+                "summary": "Import waypoints",
+                "parameters": [
+                    {
+                        "description": "Mission file",
+                        "name": "file",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "wpl or plan, detected from the file if omitted",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "WP",
+                        "description": "Prefix for waypoint names",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Parse the file without creating waypoints",
+                        "name": "dry_run",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Success",
+                        "schema": {
+                            "$ref": "#/definitions/responses.SingleResponse-formats_Import"
+                        }
+                    },
+                    "400": {
+                        "description": "Unreadable File",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Error Creating Waypoints",
+                        "schema": {
+                            "$ref": "#/definitions/responses.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "controllers.GroundObjectSchema": {
+            "description": "the values allowed for each enum field of a ground object",
+            "type": "object",
+            "properties": {
+                "colors": {
+                    "description": "Allowed for both color and text_color",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Color"
+                    },
+                    "example": [
+                        "white",
+                        "black"
+                    ]
+                },
+                "object_types": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ObjectType"
+                    },
+                    "example": [
+                        "standard",
+                        "emergent"
+                    ]
+                },
+                "orientations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Orientation"
+                    },
+                    "example": [
+                        "N",
+                        "NE"
+                    ]
+                },
+                "shapes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Shape"
+                    },
+                    "example": [
+                        "circle",
+                        "triangle"
+                    ]
+                }
+            }
+        },
+        "controllers.InteropMissionRequest": {
+            "description": "the interop mission to import",
+            "type": "object",
+            "properties": {
+                "id": {
+                    "description": "ID of the mission on the interop server, the configured mission if 0",
+                    "type": "integer",
+                    "x-order": "1",
+                    "example": 1
+                }
+            }
+        },
+        "controllers.InteropStatus": {
+            "description": "describes the connection to the competition's interop server",
+            "type": "object",
+            "properties": {
+                "url": {
+                    "type": "string",
+                    "x-order": "1",
+                    "example": "http://10.10.130.10:80"
+                },
+                "logged_in": {
+                    "type": "boolean",
+                    "x-order": "2",
+                    "example": true
+                },
+                "mission": {
+                    "description": "ID of the interop mission objects are submitted to",
+                    "type": "integer",
+                    "x-order": "3",
+                    "example": 1
+                },
+                "telemetry": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/interop.UploadStatus"
+                        }
+                    ],
+                    "x-order": "4"
+                }
+            }
+        },
+        "controllers.IssuedToken": {
+            "description": "a new token, which is not shown again",
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "x-order": "1",
+                    "example": "Signed in!"
+                },
+                "token": {
+                    "description": "Send as \"Authorization: Bearer \u003ctoken\u003e\"",
+                    "type": "string",
+                    "x-order": "2",
+                    "example": "gcom_3f9a5c..."
+                },
+                "user": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.User"
+                        }
+                    ],
+                    "x-order": "3"
+                },
+                "expires_at": {
+                    "type": "integer",
+                    "x-order": "4",
+                    "example": 1698588000
+                }
+            }
+        },
+        "controllers.LocateRequest": {
+            "description": "a pixel to locate, measured from the top left corner of the image",
+            "type": "object",
+            "properties": {
+                "x": {
+                    "type": "number",
+                    "x-order": "1",
+                    "example": 300
+                },
+                "y": {
+                    "type": "number",
+                    "x-order": "2",
+                    "example": 200
+                },
+                "object": {
+                    "description": "Ground object to create at the pixel with a detection around it, its ID, lat and long are filled in",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.GroundObject"
+                        }
+                    ],
+                    "x-order": "3"
+                },
+                "box_size": {
+                    "description": "Size in pixels of the detection's bounding box, centred on the pixel, default 64",
+                    "type": "integer",
+                    "x-order": "4",
+                    "example": 64
+                }
+            }
+        },
+        "controllers.LocateResult": {
+            "description": "where a pixel lies on the ground, and what was created there",
+            "type": "object",
+            "properties": {
+                "location": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.GeoPoint"
+                        }
+                    ],
+                    "x-order": "1"
+                },
+                "object": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.GroundObject"
+                        }
+                    ],
+                    "x-order": "2"
+                },
+                "detection": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Detection"
+                        }
+                    ],
+                    "x-order": "3"
+                }
+            }
+        },
+        "controllers.MergeRequest": {
+            "description": "ground objects to merge into one",
+            "type": "object",
+            "properties": {
+                "ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "x-order": "1",
+                    "example": [
+                        1,
+                        2,
+                        3
+                    ]
+                },
+                "keep": {
+                    "description": "Object to merge the others into, the most confident if left out",
+                    "type": "integer",
+                    "x-order": "2",
+                    "example": 1
+                }
+            }
+        },
+        "controllers.ReplayRequest": {
+            "description": "describes a change to the replay, fields are used by the endpoints that need them",
+            "type": "object",
+            "properties": {
+                "flight_id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "speed": {
+                    "type": "number",
+                    "example": 2
+                },
+                "timestamp": {
+                    "type": "integer",
+                    "example": 1698544981
+                }
+            }
+        },
+        "controllers.ReviewRequest": {
+            "description": "notes on a review and changes to make to the object",
+            "type": "object",
+            "properties": {
+                "notes": {
+                    "type": "string",
+                    "x-order": "1",
+                    "example": "Letter could be an O"
+                },
+                "changes": {
+                    "description": "Fields of the object to change as it is reviewed, like editing it",
+                    "type": "object",
+                    "x-order": "2"
+                }
+            }
+        },
+        "controllers.TokenRequest": {
+            "description": "describes a token to create",
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "x-order": "1",
+                    "example": "MPS"
+                },
+                "expires_at": {
+                    "description": "Unix time the token stops working, 0 if never",
+                    "type": "integer",
+                    "x-order": "2",
+                    "example": 0
+                }
+            }
+        },
+        "controllers.UserRequest": {
+            "description": "describes a user to create, or the fields of one to edit",
+            "type": "object",
+            "properties": {
+                "username": {
+                    "type": "string",
+                    "x-order": "1",
+                    "example": "pilot"
+                },
+                "password": {
+                    "type": "string",
+                    "x-order": "2",
+                    "example": "correct horse"
+                },
+                "role": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Role"
+                        }
+                    ],
+                    "x-order": "3",
+                    "example": "operator"
+                },
+                "disabled": {
+                    "type": "boolean",
+                    "x-order": "4",
+                    "example": false
+                }
+            }
+        },
+        "events.Action": {
+            "type": "string",
+            "enum": [
+                "created",
+                "updated",
+                "deleted",
+                "received",
+                "reached",
+                "sent",
+                "requested",
+                "confirmed",
+                "rejected",
+                "expired",
+                "queued",
+                "approved"
+            ],
+            "x-enum-varnames": [
+                "Created",
+                "Updated",
+                "Deleted",
+                "Received",
+                "Reached",
+                "Sent",
+                "Requested",
+                "Confirmed",
+                "Rejected",
+                "Expired",
+                "Queued",
+                "Approved"
+            ]
+        },
+        "events.Command": {
+            "description": "a command sent to the drone",
+            "type": "object",
+            "properties": {
+                "id": {
+                    "description": "Increases by one for every command sent",
+                    "type": "integer",
+                    "x-order": "1",
+                    "example": 7
+                },
+                "updated": {
+                    "description": "Unix time the status last changed",
+                    "type": "integer",
+                    "x-order": "10",
+                    "example": 1698544781
+                },
+                "expires_at": {
+                    "description": "Unix time a command awaiting confirmation expires",
+                    "type": "integer",
+                    "x-order": "11",
+                    "example": 1698544841
+                },
+                "name": {
+                    "type": "string",
+                    "x-order": "2",
+                    "example": "takeoff"
+                },
+                "params": {
+                    "description": "Arguments of the command, such as the altitude",
+                    "x-order": "3"
+                },
+                "success": {
+                    "description": "Whether the autopilot accepted the command",
+                    "type": "boolean",
+                    "x-order": "4",
+                    "example": true
+                },
+                "status": {
+                    "description": "One of awaiting_confirmation, sent, completed, failed, superseded,\ntimed_out, rejected or expired",
+                    "type": "string",
+                    "x-order": "5",
+                    "example": "sent"
+                },
+                "error": {
+                    "description": "Why a command which was accepted could not be completely handled",
+                    "type": "string",
+                    "x-order": "6",
+                    "example": "the flight session could not be saved"
+                },
+                "requested_by": {
+                    "description": "Username of whoever sent the command",
+                    "type": "string",
+                    "x-order": "7",
+                    "example": "pilot"
+                },
+                "reviewed_by": {
+                    "description": "Username of whoever confirmed or rejected the command",
+                    "type": "string",
+                    "x-order": "8",
+                    "example": "safety"
+                },
+                "issued": {
+                    "description": "Unix time the command was requested",
+                    "type": "integer",
+                    "x-order": "9",
+                    "example": 1698544781
+                }
+            }
+        },
+        "events.Event": {
+            "description": "describes a change to a model, sent to every client",
+            "type": "object",
+            "properties": {
+                "id": {
+                    "description": "Increases by one for every event published",
+                    "type": "integer",
+                    "x-order": "1",
+                    "example": 42
+                },
+                "topic": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/events.Topic"
+                        }
+                    ],
+                    "x-order": "2",
+                    "example": "waypoints"
+                },
+                "action": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/events.Action"
+                        }
+                    ],
+                    "x-order": "3",
+                    "example": "updated"
+                },
+                "model": {
+                    "description": "The model after the change, or before it for deletes",
+                    "x-order": "4"
+                },
+                "client": {
+                    "description": "ID of the client which made the change, from the X-Client-ID header",
+                    "type": "string",
+                    "x-order": "5",
+                    "example": "Zx8d0cGk1uFq2bQbAAAB"
+                },
+                "time": {
+                    "description": "Unix time the event was published",
+                    "type": "integer",
+                    "x-order": "6",
+                    "example": 1698544781
+                }
+            }
+        },
+        "events.Metrics": {
+            "description": "describes the events published and how each subscriber is keeping up",
+            "type": "object",
+            "properties": {
+                "published": {
+                    "description": "Events published per topic since the server started",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    },
+                    "x-order": "1"
+                },
+                "subscribers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/events.SubscriberMetrics"
+                    },
+                    "x-order": "2"
+                }
+            }
+        },
+        "events.Policy": {
+            "type": "string",
+            "enum": [
+                "drop",
+                "block"
+            ],
+            "x-enum-varnames": [
+                "Drop",
+                "Block"
+            ]
+        },
+        "events.SubscriberMetrics": {
+            "description": "describes how a subscriber is keeping up",
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "x-order": "1",
+                    "example": "socket.io"
+                },
+                "topics": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/events.Topic"
+                    },
+                    "x-order": "2",
+                    "example": [
+                        "telemetry"
+                    ]
+                },
+                "policy": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/events.Policy"
+                        }
+                    ],
+                    "x-order": "3",
+                    "example": "drop"
+                },
+                "buffer": {
+                    "type": "integer",
+                    "x-order": "4",
+                    "example": 256
+                },
+                "queued": {
+                    "description": "Events waiting to be handled",
+                    "type": "integer",
+                    "x-order": "5",
+                    "example": 3
+                },
+                "delivered": {
+                    "type": "integer",
+                    "x-order": "6",
+                    "example": 1200
+                },
+                "dropped": {
+                    "type": "integer",
+                    "x-order": "7",
+                    "example": 0
+                }
+            }
+        },
+        "events.Topic": {
+            "type": "string",
+            "enum": [
+                "waypoints",
+                "groundobjects",
+                "images",
+                "telemetry",
+                "progress",
+                "commands",
+                "jobs"
+            ],
+            "x-enum-varnames": [
+                "Waypoints",
+                "GroundObjects",
+                "Images",
+                "Telemetry",
+                "Progress",
+                "Commands",
+                "Jobs"
+            ]
+        },
+        "formats.Import": {
+            "description": "describes the waypoints parsed from a mission file",
+            "type": "object",
+            "properties": {
+                "format": {
+                    "type": "string",
+                    "x-order": "1",
+                    "example": "wpl"
+                },
+                "waypoints": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Waypoint"
+                    },
+                    "x-order": "2"
+                },
+                "issues": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/formats.Issue"
+                    },
+                    "x-order": "3"
+                }
+            }
+        },
+        "formats.Issue": {
+            "description": "describes a line of an imported file which was skipped",
+            "type": "object",
+            "properties": {
+                "line": {
+                    "description": "Line of the file the item starts on, starting at 1",
+                    "type": "integer",
+                    "x-order": "1",
+                    "example": 4
+                },
+                "command": {
+                    "type": "integer",
+                    "x-order": "2",
+                    "example": 183
+                },
+                "message": {
+                    "type": "string",
+                    "x-order": "3",
+                    "example": "Unsupported command 183"
+                }
+            }
+        },
+        "interop.UploadStatus": {
+            "description": "describes how telemetry uploads to interop are going",
+            "type": "object",
+            "properties": {
+                "running": {
+                    "type": "boolean",
+                    "x-order": "1",
+                    "example": true
+                },
+                "rate": {
+                    "description": "Uploads per second, averaged over the last 10 seconds",
+                    "type": "number",
+                    "x-order": "2",
+                    "example": 2
+                },
+                "uploaded": {
+                    "description": "Uploads which succeeded and failed since starting",
+                    "type": "integer",
+                    "x-order": "3",
+                    "example": 1200
+                },
+                "failed": {
+                    "type": "integer",
+                    "x-order": "4",
+                    "example": 3
+                },
+                "last_error": {
+                    "description": "Why the last upload failed",
+                    "type": "string",
+                    "x-order": "5",
+                    "example": "interop responded 500 Internal Server Error"
+                },
+                "last_uploaded": {
+                    "description": "Timestamp of the last sample uploaded",
+                    "type": "integer",
+                    "x-order": "6",
+                    "example": 1698544781
+                }
+            }
+        },
+        "models.AuditEntry": {
+            "description": "records a state-changing API call or drone command",
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "x-order": "1",
+                    "example": 1
+                },
+                "status": {
+                    "description": "HTTP status of the response, 0 for socket.io events",
+                    "type": "integer",
+                    "x-order": "10",
+                    "example": 200
+                },
+                "result": {
+                    "description": "What MPS made of a drone command, its status and any error",
+                    "type": "string",
+                    "x-order": "11",
+                    "example": "completed"
+                },
+                "time": {
+                    "description": "Unix time the call was made",
+                    "type": "integer",
+                    "x-order": "2",
+                    "example": 1698544781
+                },
+                "actor": {
+                    "description": "Username of whoever made the call, empty if they were not signed in",
+                    "type": "string",
+                    "x-order": "3",
+                    "example": "pilot"
+                },
+                "method": {
+                    "description": "HTTP method, or socket.io for socket.io events",
+                    "type": "string",
+                    "x-order": "4",
+                    "example": "PATCH"
+                },
+                "route": {
+                    "description": "Route as registered, such as /waypoint/:waypointId, or the socket.io event",
+                    "type": "string",
+                    "x-order": "5",
+                    "example": "/waypoint/:waypointId"
+                },
+                "entity_type": {
+                    "description": "Kind of entity changed, such as waypoint or command, empty if none was",
+                    "type": "string",
+                    "x-order": "6",
+                    "example": "waypoint"
+                },
+                "entity_id": {
+                    "type": "string",
+                    "x-order": "7",
+                    "example": "1"
+                },
+                "diff": {
+                    "description": "Fields which changed, each with its value before and after",
+                    "type": "object",
+                    "x-order": "8"
+                },
+                "client_ip": {
+                    "type": "string",
+                    "x-order": "9",
+                    "example": "192.168.1.20"
+                }
+            }
+        },
+        "models.Color": {
+            "type": "string",
+            "enum": [
+                "white",
+                "black",
+                "red",
+                "blue",
+                "green",
+                "purple",
+                "brown",
+                "orange"
+            ],
+            "x-enum-varnames": [
+                "White",
+                "Black",
+                "Red",
+                "Blue",
+                "Green",
+                "Purple",
+                "Brown",
+                "Orange"
+            ]
+        },
+        "models.Designation": {
+            "description": "Describes a special purpose for a Waypoint",
+            "type": "string",
+            "enum": [
+                "launch",
+                "land",
                 "obstacle",
-                "payload"
+                "payload",
+                "loiter"
+            ],
+            "x-enum-varnames": [
+                "Launch",
+                "Land",
+                "Obstacle",
+                "Payload",
+                "Loiter"
+            ]
+        },
+        "models.Detection": {
+            "description": "describes where in an image a ground object was seen",
+            "type": "object",
+            "required": [
+                "ground_object_id",
+                "id",
+                "image"
+            ],
+            "properties": {
+                "id": {
+                    "description": "To create a detection, ID of \"-1\" must be passed",
+                    "type": "string",
+                    "x-order": "1",
+                    "example": "1"
+                },
+                "ground_object_id": {
+                    "type": "integer",
+                    "x-order": "2",
+                    "example": 1
+                },
+                "image": {
+                    "description": "Filename of the Image",
+                    "type": "string",
+                    "x-order": "3",
+                    "example": "1714898050.png"
+                },
+                "x": {
+                    "description": "Bounding box in pixels, from the top left corner of the image",
+                    "type": "integer",
+                    "x-order": "4",
+                    "example": 120
+                },
+                "y": {
+                    "type": "integer",
+                    "x-order": "5",
+                    "example": 80
+                },
+                "width": {
+                    "type": "integer",
+                    "x-order": "6",
+                    "example": 64
+                },
+                "height": {
+                    "type": "integer",
+                    "x-order": "7",
+                    "example": 64
+                },
+                "confidence": {
+                    "description": "How sure the detector is of the detection, from 0 to 1",
+                    "type": "number",
+                    "x-order": "8",
+                    "example": 0.87
+                },
+                "timestamp": {
+                    "description": "Unix time of the detection, the time it was created if not given",
+                    "type": "integer",
+                    "x-order": "9",
+                    "example": 1714898052
+                }
+            }
+        },
+        "models.Drone": {
+            "description": "describes the drone being flown",
+            "type": "object",
+            "required": [
+                "altitude",
+                "battery_voltage",
+                "heading",
+                "latitude",
+                "longitude",
+                "timestamp",
+                "velocity",
+                "vertical_velocity"
+            ],
+            "properties": {
+                "timestamp": {
+                    "type": "integer",
+                    "x-order": "1",
+                    "example": 1698544781
+                },
+                "latitude": {
+                    "type": "number",
+                    "x-order": "2",
+                    "example": 49.267941
+                },
+                "longitude": {
+                    "type": "number",
+                    "x-order": "3",
+                    "example": -123.24736
+                },
+                "altitude": {
+                    "type": "number",
+                    "x-order": "4",
+                    "example": 100
+                },
+                "vertical_velocity": {
+                    "type": "number",
+                    "x-order": "5",
+                    "example": -1.63
+                },
+                "velocity": {
+                    "type": "number",
+                    "x-order": "6",
+                    "example": 0.98
+                },
+                "heading": {
+                    "type": "number",
+                    "x-order": "7",
+                    "example": 298.12
+                },
+                "battery_voltage": {
+                    "description": "Payloads TBD",
+                    "type": "number",
+                    "x-order": "9",
+                    "example": 2.6
+                }
+            }
+        },
+        "models.FieldChange": {
+            "description": "describes a single edited field by its JSON name",
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string",
+                    "x-order": "1",
+                    "example": "alt"
+                },
+                "from": {
+                    "x-order": "2"
+                },
+                "to": {
+                    "x-order": "3"
+                }
+            }
+        },
+        "models.FlightSession": {
+            "description": "describes a flight from arming to disarming the drone",
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "x-order": "1",
+                    "example": 1
+                },
+                "started_at": {
+                    "description": "Unix time the drone was armed",
+                    "type": "integer",
+                    "x-order": "2",
+                    "example": 1698544700
+                },
+                "ended_at": {
+                    "description": "Unix time the drone was disarmed, 0 while the flight is in progress",
+                    "type": "integer",
+                    "x-order": "3",
+                    "example": 1698545300
+                },
+                "samples": {
+                    "description": "Number of archived telemetry samples",
+                    "type": "integer",
+                    "x-order": "4",
+                    "example": 600
+                }
+            }
+        },
+        "models.FlyZone": {
+            "description": "an area the drone must stay inside",
+            "type": "object",
+            "properties": {
+                "altitude_min": {
+                    "description": "Altitudes in metres, measured like waypoint altitudes",
+                    "type": "number",
+                    "x-order": "1",
+                    "example": 30.48
+                },
+                "altitude_max": {
+                    "type": "number",
+                    "x-order": "2",
+                    "example": 228.6
+                },
+                "boundary": {
+                    "description": "Corners of the zone in order",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.GeoPoint"
+                    },
+                    "x-order": "3"
+                }
+            }
+        },
+        "models.GeoPoint": {
+            "description": "a point on the ground",
+            "type": "object",
+            "properties": {
+                "lat": {
+                    "type": "number",
+                    "x-order": "1",
+                    "example": 49.267941
+                },
+                "long": {
+                    "type": "number",
+                    "x-order": "2",
+                    "example": -123.24736
+                }
+            }
+        },
+        "models.GroundObject": {
+            "description": "describes targets in GCOM",
+            "type": "object",
+            "required": [
+                "id",
+                "lat",
+                "long",
+                "object_type"
+            ],
+            "properties": {
+                "id": {
+                    "type": "string",
+                    "x-order": "1",
+                    "example": "1"
+                },
+                "description": {
+                    "description": "What an emergent object is, such as a person lying down",
+                    "type": "string",
+                    "x-order": "10",
+                    "example": "Mannequin lying on a blanket"
+                },
+                "autonomous": {
+                    "description": "Whether the object was detected without a person's help",
+                    "type": "boolean",
+                    "x-order": "11",
+                    "example": false
+                },
+                "confidence": {
+                    "description": "How sure whoever detected the object is of it, from 0 to 1",
+                    "type": "number",
+                    "x-order": "12",
+                    "example": 0.9
+                },
+                "merged_into": {
+                    "description": "ID of the object this was merged into, set by merging and hidden from lists while set",
+                    "type": "integer",
+                    "x-order": "13",
+                    "example": 0
+                },
+                "review_status": {
+                    "description": "Set by approving or rejecting the object. Objects detected autonomously start pending, others approved.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ReviewStatus"
+                        }
+                    ],
+                    "x-order": "14",
+                    "example": "pending"
+                },
+                "reviewer": {
+                    "type": "string",
+                    "x-order": "15",
+                    "example": "operator"
+                },
+                "review_notes": {
+                    "type": "string",
+                    "x-order": "16",
+                    "example": "Letter could be an O"
+                },
+                "reviewed_at": {
+                    "description": "Unix time of the review",
+                    "type": "integer",
+                    "x-order": "17",
+                    "example": 1698544781
+                },
+                "object_type": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ObjectType"
+                        }
+                    ],
+                    "x-order": "2",
+                    "example": "emergent"
+                },
+                "lat": {
+                    "type": "number",
+                    "x-order": "3",
+                    "example": 49.267941
+                },
+                "long": {
+                    "type": "number",
+                    "x-order": "4",
+                    "example": -123.24736
+                },
+                "shape": {
+                    "description": "Shape and colours of a standard object",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Shape"
+                        }
+                    ],
+                    "x-order": "5",
+                    "example": "circle"
+                },
+                "color": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Color"
+                        }
+                    ],
+                    "x-order": "6",
+                    "example": "black"
+                },
+                "text": {
+                    "description": "Alphanumeric of a standard object, a single upper case letter or digit",
+                    "type": "string",
+                    "x-order": "7",
+                    "example": "A"
+                },
+                "text_color": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Color"
+                        }
+                    ],
+                    "x-order": "8",
+                    "example": "white"
+                },
+                "orientation": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Orientation"
+                        }
+                    ],
+                    "x-order": "9",
+                    "example": "NE"
+                }
+            }
+        },
+        "models.GroundObjectMerge": {
+            "description": "records ground objects merged into one, and whether it was split",
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "x-order": "1",
+                    "example": 1
+                },
+                "kept_id": {
+                    "description": "Object the others were merged into",
+                    "type": "integer",
+                    "x-order": "2",
+                    "example": 1
+                },
+                "merged_ids": {
+                    "description": "Objects merged into the kept one, hidden from lists until the merge is split",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "x-order": "3",
+                    "example": [
+                        2,
+                        3
+                    ]
+                },
+                "before": {
+                    "description": "Every object as it was before the merge, the kept one first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.GroundObject"
+                    },
+                    "x-order": "4"
+                },
+                "moved_detections": {
+                    "description": "Detections moved to the kept object and where they came from",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.MovedDetection"
+                    },
+                    "x-order": "5"
+                },
+                "author": {
+                    "type": "string",
+                    "x-order": "6",
+                    "example": "operator"
+                },
+                "created_at": {
+                    "type": "integer",
+                    "x-order": "7",
+                    "example": 1698544781
+                },
+                "split_by": {
+                    "description": "Who split the merge and when, empty until it is split",
+                    "type": "string",
+                    "x-order": "8",
+                    "example": "operator"
+                },
+                "split_at": {
+                    "type": "integer",
+                    "x-order": "9",
+                    "example": 1698544900
+                }
+            }
+        },
+        "models.ImageJob": {
+            "description": "the status of a processor looking for ground objects in an image",
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "x-order": "1",
+                    "example": 1
+                },
+                "image": {
+                    "description": "Filename of the Image",
+                    "type": "string",
+                    "x-order": "2",
+                    "example": "1714898050.png"
+                },
+                "processor": {
+                    "description": "Name of the processor",
+                    "type": "string",
+                    "x-order": "3",
+                    "example": "yolo"
+                },
+                "status": {
+                    "description": "One of queued, running, retrying, succeeded or failed",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.JobStatus"
+                        }
+                    ],
+                    "x-order": "4",
+                    "example": "succeeded"
+                },
+                "attempts": {
+                    "description": "How many times the processor has been run",
+                    "type": "integer",
+                    "x-order": "5",
+                    "example": 1
+                },
+                "error": {
+                    "description": "Why the last attempt failed, or which results were skipped",
+                    "type": "string",
+                    "x-order": "6",
+                    "example": "exit status 1"
+                },
+                "detections": {
+                    "description": "How many detections the processor's results created",
+                    "type": "integer",
+                    "x-order": "7",
+                    "example": 2
+                },
+                "created_at": {
+                    "description": "Unix times the job was created and its status last changed",
+                    "type": "integer",
+                    "x-order": "8",
+                    "example": 1714898051
+                },
+                "updated_at": {
+                    "type": "integer",
+                    "x-order": "9",
+                    "example": 1714898053
+                }
+            }
+        },
+        "models.InteropMission": {
+            "description": "a mission fetched from the competition's interop server",
+            "type": "object",
+            "properties": {
+                "id": {
+                    "description": "ID of the mission on the interop server",
+                    "type": "integer",
+                    "x-order": "1",
+                    "example": 1
+                },
+                "mission_id": {
+                    "description": "Mission created from the interop waypoints, in order",
+                    "type": "integer",
+                    "x-order": "2",
+                    "example": 3
+                },
+                "obstacle_ids": {
+                    "description": "Waypoints created for the stationary obstacles, with the obstacle designation",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "x-order": "3"
+                },
+                "fly_zones": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FlyZone"
+                    },
+                    "x-order": "4"
+                },
+                "search_grid": {
+                    "description": "Corners of the area to search for ground objects",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.GeoPoint"
+                    },
+                    "x-order": "5"
+                },
+                "off_axis_object": {
+                    "description": "Where the off-axis object is, and where the emergent object was last seen",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.GeoPoint"
+                        }
+                    ],
+                    "x-order": "6"
+                },
+                "emergent_last_known": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.GeoPoint"
+                        }
+                    ],
+                    "x-order": "7"
+                },
+                "lost_comms": {
+                    "description": "Where to fly if communication is lost",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.GeoPoint"
+                        }
+                    ],
+                    "x-order": "8"
+                },
+                "fetched_at": {
+                    "description": "Unix time the mission was fetched",
+                    "type": "integer",
+                    "x-order": "9",
+                    "example": 1698544781
+                }
+            }
+        },
+        "models.InteropSubmission": {
+            "description": "a ground object submitted to the competition's interop server",
+            "type": "object",
+            "properties": {
+                "ground_object_id": {
+                    "type": "integer",
+                    "x-order": "1",
+                    "example": 1
+                },
+                "interop_id": {
+                    "description": "ID of the object on the interop server, 0 until it is created",
+                    "type": "integer",
+                    "x-order": "2",
+                    "example": 12
+                },
+                "status": {
+                    "description": "One of failed, submitted, complete or withdrawn",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.SubmissionStatus"
+                        }
+                    ],
+                    "x-order": "3",
+                    "example": "complete"
+                },
+                "error": {
+                    "description": "Why the last attempt failed",
+                    "type": "string",
+                    "x-order": "4",
+                    "example": "interop responded 400 Bad Request"
+                },
+                "submitted": {
+                    "description": "The object as it was last sent, to resend it when it changes",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.GroundObject"
+                        }
+                    ],
+                    "x-order": "5"
+                },
+                "submitted_at": {
+                    "description": "Unix times it was first sent and last attempted",
+                    "type": "integer",
+                    "x-order": "6",
+                    "example": 1698544781
+                },
+                "updated_at": {
+                    "type": "integer",
+                    "x-order": "7",
+                    "example": 1698544781
+                }
+            }
+        },
+        "models.JobStatus": {
+            "type": "string",
+            "enum": [
+                "queued",
+                "running",
+                "retrying",
+                "succeeded",
+                "failed"
+            ],
+            "x-enum-varnames": [
+                "JobQueued",
+                "JobRunning",
+                "JobRetrying",
+                "JobSucceeded",
+                "JobFailed"
+            ]
+        },
+        "models.MergeProposal": {
+            "description": "ground objects which look like the same target, and the object merging them would give",
+            "type": "object",
+            "properties": {
+                "keep_id": {
+                    "description": "Object the others would be merged into, the most confident",
+                    "type": "integer",
+                    "x-order": "1",
+                    "example": 1
+                },
+                "object_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "x-order": "2",
+                    "example": [
+                        1,
+                        2,
+                        3
+                    ]
+                },
+                "lat": {
+                    "description": "Position averaged over the objects, weighted by their confidence",
+                    "type": "number",
+                    "x-order": "3",
+                    "example": 49.267941
+                },
+                "long": {
+                    "type": "number",
+                    "x-order": "4",
+                    "example": -123.24736
+                },
+                "confidence": {
+                    "type": "number",
+                    "x-order": "5",
+                    "example": 0.9
+                },
+                "spread": {
+                    "description": "Furthest any of the objects is from the averaged position, in metres",
+                    "type": "number",
+                    "x-order": "6",
+                    "example": 4.2
+                },
+                "detections": {
+                    "description": "Detections of all the objects",
+                    "type": "integer",
+                    "x-order": "7",
+                    "example": 5
+                }
+            }
+        },
+        "models.Mission": {
+            "description": "describes a named route of waypoints in GCOM",
+            "type": "object",
+            "required": [
+                "id",
+                "name"
+            ],
+            "properties": {
+                "id": {
+                    "description": "To create a mission, ID of \"-1\" must be passed",
+                    "type": "string",
+                    "x-order": "1",
+                    "example": "1"
+                },
+                "name": {
+                    "type": "string",
+                    "x-order": "2",
+                    "example": "Task 1 route"
+                },
+                "description": {
+                    "type": "string",
+                    "x-order": "3",
+                    "example": "Waypoint lap for task 1"
+                },
+                "status": {
+                    "description": "Status of the mission, set by activating it",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.MissionStatus"
+                        }
+                    ],
+                    "x-order": "4",
+                    "example": "draft"
+                },
+                "version": {
+                    "description": "Latest MissionVersion, a new version is created whenever the waypoints change",
+                    "type": "integer",
+                    "x-order": "5",
+                    "example": 3
+                },
+                "uploaded_version": {
+                    "description": "MissionVersion last uploaded to the drone",
+                    "type": "integer",
+                    "x-order": "6",
+                    "example": 2
+                },
+                "waypoints": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.MissionWaypoint"
+                    },
+                    "x-order": "7"
+                }
+            }
+        },
+        "models.MissionDiff": {
+            "description": "describes how a mission's waypoints changed between two versions",
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "integer",
+                    "x-order": "1",
+                    "example": 1
+                },
+                "to": {
+                    "type": "integer",
+                    "x-order": "2",
+                    "example": 3
+                },
+                "added": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.MissionVersionWaypoint"
+                    },
+                    "x-order": "3"
+                },
+                "removed": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.MissionVersionWaypoint"
+                    },
+                    "x-order": "4"
+                },
+                "moved": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WaypointMove"
+                    },
+                    "x-order": "5"
+                },
+                "changed": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WaypointChange"
+                    },
+                    "x-order": "6"
+                }
+            }
+        },
+        "models.MissionStatus": {
+            "description": "Describes whether a Mission is active",
+            "type": "string",
+            "enum": [
+                "draft",
+                "active"
             ],
             "x-enum-varnames": [
-                "Launch",
-                "Land",
-                "Obstacle",
-                "Payload"
+                "MissionDraft",
+                "MissionActive"
+            ]
+        },
+        "models.MissionVersion": {
+            "description": "describes a snapshot of a mission's waypoints at one point in time",
+            "type": "object",
+            "properties": {
+                "mission_id": {
+                    "type": "integer",
+                    "x-order": "1",
+                    "example": 1
+                },
+                "version": {
+                    "description": "Versions are numbered from 1 for each mission",
+                    "type": "integer",
+                    "x-order": "2",
+                    "example": 3
+                },
+                "author": {
+                    "type": "string",
+                    "x-order": "3",
+                    "example": "operator"
+                },
+                "created_at": {
+                    "type": "integer",
+                    "x-order": "4",
+                    "example": 1698544781
+                },
+                "reason": {
+                    "type": "string",
+                    "x-order": "5",
+                    "example": "Mission edited"
+                },
+                "uploaded_at": {
+                    "description": "Unix time this version was last uploaded to the drone, 0 if never",
+                    "type": "integer",
+                    "x-order": "6",
+                    "example": 1698544790
+                },
+                "waypoints": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.MissionVersionWaypoint"
+                    },
+                    "x-order": "7"
+                }
+            }
+        },
+        "models.MissionVersionWaypoint": {
+            "description": "describes a waypoint in a mission snapshot",
+            "type": "object",
+            "properties": {
+                "sequence": {
+                    "type": "integer",
+                    "x-order": "1",
+                    "example": 0
+                },
+                "waypoint": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Waypoint"
+                        }
+                    ],
+                    "x-order": "2"
+                }
+            }
+        },
+        "models.MissionWaypoint": {
+            "description": "describes the position of a waypoint in a mission",
+            "type": "object",
+            "required": [
+                "waypoint_id"
+            ],
+            "properties": {
+                "sequence": {
+                    "type": "integer",
+                    "x-order": "1",
+                    "example": 0
+                },
+                "waypoint_id": {
+                    "type": "integer",
+                    "x-order": "2",
+                    "example": 1
+                },
+                "waypoint": {
+                    "description": "Referenced waypoint, filled in when a mission is returned",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Waypoint"
+                        }
+                    ],
+                    "x-order": "3"
+                }
+            }
+        },
+        "models.MovedDetection": {
+            "description": "a detection moved by a merge",
+            "type": "object",
+            "properties": {
+                "detection_id": {
+                    "type": "integer",
+                    "x-order": "1",
+                    "example": 4
+                },
+                "from_id": {
+                    "type": "integer",
+                    "x-order": "2",
+                    "example": 2
+                }
+            }
+        },
+        "models.ObjectType": {
+            "type": "string",
+            "enum": [
+                "standard",
+                "emergent"
+            ],
+            "x-enum-varnames": [
+                "Standard",
+                "Emergent"
+            ]
+        },
+        "models.Orientation": {
+            "type": "string",
+            "enum": [
+                "N",
+                "NE",
+                "E",
+                "SE",
+                "S",
+                "SW",
+                "W",
+                "NW"
+            ],
+            "x-enum-varnames": [
+                "North",
+                "NorthEast",
+                "East",
+                "SouthEast",
+                "South",
+                "SouthWest",
+                "West",
+                "NorthWest"
+            ]
+        },
+        "models.ReviewStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "approved",
+                "rejected"
+            ],
+            "x-enum-varnames": [
+                "Pending",
+                "Approved",
+                "Rejected"
+            ]
+        },
+        "models.Role": {
+            "type": "string",
+            "enum": [
+                "viewer",
+                "operator",
+                "pilot_in_command",
+                "admin"
+            ],
+            "x-enum-varnames": [
+                "Viewer",
+                "Operator",
+                "PilotInCommand",
+                "Admin"
+            ]
+        },
+        "models.Shape": {
+            "type": "string",
+            "enum": [
+                "circle",
+                "semicircle",
+                "quartercircle",
+                "triangle",
+                "rectangle",
+                "pentagon",
+                "star",
+                "cross"
+            ],
+            "x-enum-varnames": [
+                "Circle",
+                "SemiCircle",
+                "QuarterCircle",
+                "Triangle",
+                "Rectangle",
+                "Pentagon",
+                "Star",
+                "Cross"
+            ]
+        },
+        "models.SubmissionStatus": {
+            "type": "string",
+            "enum": [
+                "failed",
+                "submitted",
+                "complete",
+                "withdrawn"
+            ],
+            "x-enum-varnames": [
+                "SubmissionFailed",
+                "SubmissionSubmitted",
+                "SubmissionComplete",
+                "SubmissionWithdrawn"
             ]
         },
-        "models.Drone": {
-            "description": "describes the drone being flown",
+        "models.Token": {
+            "description": "describes an API token, without the token itself",
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "x-order": "1",
+                    "example": 1
+                },
+                "user_id": {
+                    "type": "integer",
+                    "x-order": "2",
+                    "example": 1
+                },
+                "name": {
+                    "description": "What the token is for, such as \"MPS\" or \"login\"",
+                    "type": "string",
+                    "x-order": "3",
+                    "example": "MPS"
+                },
+                "prefix": {
+                    "description": "The start of the token, to tell tokens apart",
+                    "type": "string",
+                    "x-order": "4",
+                    "example": "gcom_3f9a"
+                },
+                "created_at": {
+                    "type": "integer",
+                    "x-order": "5",
+                    "example": 1698544700
+                },
+                "expires_at": {
+                    "description": "Unix time the token stops working, 0 if never",
+                    "type": "integer",
+                    "x-order": "6",
+                    "example": 0
+                }
+            }
+        },
+        "models.User": {
+            "description": "describes someone who can sign in to GCOM",
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer",
+                    "x-order": "1",
+                    "example": 1
+                },
+                "username": {
+                    "type": "string",
+                    "x-order": "2",
+                    "example": "pilot"
+                },
+                "role": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Role"
+                        }
+                    ],
+                    "x-order": "3",
+                    "example": "operator"
+                },
+                "disabled": {
+                    "description": "Disabled users cannot sign in and their tokens are refused",
+                    "type": "boolean",
+                    "x-order": "4",
+                    "example": false
+                },
+                "created_at": {
+                    "type": "integer",
+                    "x-order": "5",
+                    "example": 1698544700
+                }
+            }
+        },
+        "models.Waypoint": {
+            "description": "describes a location in GCOM",
+            "type": "object",
+            "required": [
+                "alt",
+                "id",
+                "lat",
+                "long",
+                "name"
+            ],
+            "properties": {
+                "id": {
+                    "description": "To create a waypoint, ID of \"-1\" must be passed",
+                    "type": "string",
+                    "x-order": "1",
+                    "example": "1"
+                },
+                "name": {
+                    "type": "string",
+                    "x-order": "2",
+                    "example": "Alpha"
+                },
+                "lat": {
+                    "type": "number",
+                    "x-order": "3",
+                    "example": 49.267941
+                },
+                "long": {
+                    "type": "number",
+                    "x-order": "4",
+                    "example": -123.24736
+                },
+                "alt": {
+                    "type": "number",
+                    "x-order": "5",
+                    "example": 100
+                },
+                "radius": {
+                    "description": "Radius around waypoint where it is considered flown over",
+                    "type": "number",
+                    "x-order": "6",
+                    "example": 10
+                },
+                "designation": {
+                    "description": "Designation of waypoint, none by default",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Designation"
+                        }
+                    ],
+                    "x-order": "7",
+                    "example": "land"
+                },
+                "remarks": {
+                    "type": "string",
+                    "x-order": "8",
+                    "example": "Task 1 Landing Zone"
+                }
+            }
+        },
+        "models.WaypointChange": {
+            "description": "describes a waypoint whose fields were edited",
+            "type": "object",
+            "properties": {
+                "waypoint_id": {
+                    "type": "integer",
+                    "x-order": "1",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "x-order": "2",
+                    "example": "Alpha"
+                },
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FieldChange"
+                    },
+                    "x-order": "3"
+                }
+            }
+        },
+        "models.WaypointMove": {
+            "description": "describes a waypoint which was reordered",
             "type": "object",
-            "required": [
-                "alt",
-                "battery_voltage",
-                "heading",
-                "lat",
-                "long",
-                "speed",
-                "timestamp",
-                "v_speed"
-            ],
             "properties": {
-                "timestamp": {
+                "waypoint_id": {
+                    "type": "integer",
+                    "x-order": "1",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "x-order": "2",
+                    "example": "Alpha"
+                },
+                "from": {
+                    "description": "Positions in the ordered list of waypoints, starting at 0",
+                    "type": "integer",
+                    "x-order": "3",
+                    "example": 0
+                },
+                "to": {
+                    "type": "integer",
+                    "x-order": "4",
+                    "example": 2
+                }
+            }
+        },
+        "models.WaypointProgress": {
+            "description": "records when and how accurately a queued waypoint was flown over",
+            "type": "object",
+            "properties": {
+                "id": {
                     "type": "integer",
                     "x-order": "1",
+                    "example": 1
+                },
+                "run": {
+                    "description": "Unix time the queue was uploaded, groups records from the same run of a queue",
+                    "type": "integer",
+                    "x-order": "2",
+                    "example": 1698544700
+                },
+                "sequence": {
+                    "type": "integer",
+                    "x-order": "3",
+                    "example": 0
+                },
+                "waypoint_id": {
+                    "type": "integer",
+                    "x-order": "4",
+                    "example": 1
+                },
+                "name": {
+                    "type": "string",
+                    "x-order": "5",
+                    "example": "Alpha"
+                },
+                "reached_at": {
+                    "description": "Unix time of the closest approach to the waypoint",
+                    "type": "integer",
+                    "x-order": "6",
                     "example": 1698544781
                 },
-                "lat": {
+                "distance": {
+                    "description": "Closest horizontal distance to the waypoint in metres",
                     "type": "number",
-                    "x-order": "2",
-                    "example": 49.267941
+                    "x-order": "7",
+                    "example": 2.41
                 },
-                "long": {
+                "altitude_error": {
+                    "description": "Drone altitude minus waypoint altitude at the closest approach",
                     "type": "number",
+                    "x-order": "8",
+                    "example": -1.2
+                }
+            }
+        },
+        "progress.Snapshot": {
+            "description": "progress through the active queue",
+            "type": "object",
+            "properties": {
+                "run": {
+                    "type": "integer",
+                    "x-order": "1",
+                    "example": 1698544700
+                },
+                "reached": {
+                    "type": "integer",
+                    "x-order": "2",
+                    "example": 1
+                },
+                "total": {
+                    "type": "integer",
                     "x-order": "3",
-                    "example": -123.24736
+                    "example": 4
                 },
-                "alt": {
+                "waypoints": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/progress.WaypointState"
+                    },
+                    "x-order": "4"
+                }
+            }
+        },
+        "progress.WaypointState": {
+            "description": "progress of a single waypoint in the active queue",
+            "type": "object",
+            "properties": {
+                "sequence": {
+                    "type": "integer",
+                    "x-order": "1",
+                    "example": 0
+                },
+                "waypoint": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Waypoint"
+                        }
+                    ],
+                    "x-order": "2"
+                },
+                "state": {
+                    "type": "string",
+                    "x-order": "3",
+                    "example": "reached"
+                },
+                "progress": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.WaypointProgress"
+                        }
+                    ],
+                    "x-order": "4"
+                }
+            }
+        },
+        "replay.State": {
+            "description": "describes the flight being replayed",
+            "type": "object",
+            "properties": {
+                "flight_id": {
+                    "type": "integer",
+                    "x-order": "1",
+                    "example": 1
+                },
+                "speed": {
                     "type": "number",
+                    "x-order": "2",
+                    "example": 2
+                },
+                "playing": {
+                    "type": "boolean",
+                    "x-order": "3",
+                    "example": true
+                },
+                "paused": {
+                    "type": "boolean",
                     "x-order": "4",
-                    "example": 100
+                    "example": false
                 },
-                "v_speed": {
-                    "type": "number",
+                "start": {
+                    "description": "Timestamps of the first and last samples of the flight",
+                    "type": "integer",
                     "x-order": "5",
-                    "example": -1.63
+                    "example": 1698544700
                 },
-                "speed": {
-                    "type": "number",
+                "end": {
+                    "type": "integer",
                     "x-order": "6",
-                    "example": 0.98
+                    "example": 1698545300
                 },
-                "heading": {
-                    "type": "number",
+                "position": {
+                    "description": "Timestamp of the last sample replayed",
+                    "type": "integer",
                     "x-order": "7",
-                    "example": 298.12
+                    "example": 1698544981
+                },
+                "sample": {
+                    "type": "integer",
+                    "x-order": "8",
+                    "example": 281
+                },
+                "samples": {
+                    "type": "integer",
+                    "x-order": "9",
+                    "example": 600
+                }
+            }
+        },
+        "responses.ErrorResponse": {
+            "description": "JSON response for any error",
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "Sample error message"
+                }
+            }
+        },
+        "responses.MultipleResponse-models_AuditEntry": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Sample success message"
+                },
+                "models": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AuditEntry"
+                    }
+                }
+            }
+        },
+        "responses.MultipleResponse-models_Detection": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Sample success message"
+                },
+                "models": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Detection"
+                    }
+                }
+            }
+        },
+        "responses.MultipleResponse-models_FlightSession": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Sample success message"
+                },
+                "models": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FlightSession"
+                    }
+                }
+            }
+        },
+        "responses.MultipleResponse-models_GroundObject": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Sample success message"
+                },
+                "models": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.GroundObject"
+                    }
+                }
+            }
+        },
+        "responses.MultipleResponse-models_GroundObjectMerge": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Sample success message"
+                },
+                "models": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.GroundObjectMerge"
+                    }
+                }
+            }
+        },
+        "responses.MultipleResponse-models_ImageJob": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Sample success message"
+                },
+                "models": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ImageJob"
+                    }
+                }
+            }
+        },
+        "responses.MultipleResponse-models_InteropSubmission": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Sample success message"
+                },
+                "models": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.InteropSubmission"
+                    }
+                }
+            }
+        },
+        "responses.MultipleResponse-models_MergeProposal": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Sample success message"
+                },
+                "models": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.MergeProposal"
+                    }
+                }
+            }
+        },
+        "responses.MultipleResponse-models_Mission": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Sample success message"
                 },
-                "battery_voltage": {
-                    "description": "Payloads TBD",
-                    "type": "number",
-                    "x-order": "9",
-                    "example": 2.6
+                "models": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Mission"
+                    }
                 }
             }
         },
-        "models.GroundObject": {
-            "description": "describes targets in GCOM",
+        "responses.MultipleResponse-models_MissionVersion": {
             "type": "object",
-            "required": [
-                "id",
-                "lat",
-                "long",
-                "object_type"
-            ],
             "properties": {
-                "id": {
+                "message": {
                     "type": "string",
-                    "x-order": "1",
-                    "example": "1"
-                },
-                "object_type": {
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/models.ObjectType"
-                        }
-                    ],
-                    "x-order": "2",
-                    "example": "emergent"
-                },
-                "lat": {
-                    "type": "number",
-                    "x-order": "3",
-                    "example": 49.267941
-                },
-                "long": {
-                    "type": "number",
-                    "x-order": "4",
-                    "example": -123.24736
-                },
-                "color": {
-                    "type": "string"
-                },
-                "shape": {
-                    "type": "string"
+                    "example": "Sample success message"
                 },
-                "text": {
-                    "type": "string"
+                "models": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.MissionVersion"
+                    }
+                }
+            }
+        },
+        "responses.MultipleResponse-models_Token": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Sample success message"
                 },
-                "textColor": {
-                    "type": "string"
+                "models": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Token"
+                    }
                 }
             }
         },
-        "models.ObjectType": {
-            "type": "string",
-            "enum": [
-                "standard",
-                "emergent"
-            ],
-            "x-enum-varnames": [
-                "Standard",
-                "Emergent"
-            ]
+        "responses.MultipleResponse-models_User": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Sample success message"
+                },
+                "models": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.User"
+                    }
+                }
+            }
         },
-        "models.Waypoint": {
-            "description": "describes a location in GCOM",
+        "responses.MultipleResponse-models_Waypoint": {
             "type": "object",
-            "required": [
-                "alt",
-                "id",
-                "lat",
-                "long",
-                "name"
-            ],
             "properties": {
-                "id": {
-                    "description": "To create a waypoint, ID of \"-1\" must be passed",
+                "message": {
                     "type": "string",
-                    "x-order": "1",
-                    "example": "1"
+                    "example": "Sample success message"
                 },
-                "name": {
+                "models": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Waypoint"
+                    }
+                }
+            }
+        },
+        "responses.SingleResponse-controllers_InteropStatus": {
+            "type": "object",
+            "properties": {
+                "message": {
                     "type": "string",
-                    "x-order": "2",
-                    "example": "Alpha"
+                    "example": "Sample success message"
                 },
-                "lat": {
-                    "type": "number",
-                    "x-order": "3",
-                    "example": 49.267941
+                "waypoint": {
+                    "$ref": "#/definitions/controllers.InteropStatus"
+                }
+            }
+        },
+        "responses.SingleResponse-formats_Import": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Sample success message"
                 },
-                "long": {
-                    "type": "number",
-                    "x-order": "4",
-                    "example": -123.24736
+                "waypoint": {
+                    "$ref": "#/definitions/formats.Import"
+                }
+            }
+        },
+        "responses.SingleResponse-interop_UploadStatus": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Sample success message"
                 },
-                "alt": {
-                    "type": "number",
-                    "x-order": "5",
-                    "example": 100
+                "waypoint": {
+                    "$ref": "#/definitions/interop.UploadStatus"
+                }
+            }
+        },
+        "responses.SingleResponse-models_Detection": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Sample success message"
                 },
-                "radius": {
-                    "description": "Radius around waypoint where it is considered flown over",
-                    "type": "number",
-                    "x-order": "6",
-                    "example": 10
+                "waypoint": {
+                    "$ref": "#/definitions/models.Detection"
+                }
+            }
+        },
+        "responses.SingleResponse-models_FlightSession": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Sample success message"
                 },
-                "designation": {
-                    "description": "Designation of waypoint, none by default",
-                    "allOf": [
-                        {
-                            "$ref": "#/definitions/models.Designation"
-                        }
-                    ],
-                    "x-order": "7",
-                    "example": "land"
+                "waypoint": {
+                    "$ref": "#/definitions/models.FlightSession"
+                }
+            }
+        },
+        "responses.SingleResponse-models_GroundObject": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Sample success message"
                 },
-                "remarks": {
+                "waypoint": {
+                    "$ref": "#/definitions/models.GroundObject"
+                }
+            }
+        },
+        "responses.SingleResponse-models_GroundObjectMerge": {
+            "type": "object",
+            "properties": {
+                "message": {
                     "type": "string",
-                    "x-order": "8",
-                    "example": "Task 1 Landing Zone"
+                    "example": "Sample success message"
+                },
+                "waypoint": {
+                    "$ref": "#/definitions/models.GroundObjectMerge"
                 }
             }
         },
-        "responses.ErrorResponse": {
-            "description": "JSON response for any error",
+        "responses.SingleResponse-models_InteropMission": {
             "type": "object",
             "properties": {
-                "data": {
-                    "type": "string"
+                "message": {
+                    "type": "string",
+                    "example": "Sample success message"
                 },
+                "waypoint": {
+                    "$ref": "#/definitions/models.InteropMission"
+                }
+            }
+        },
+        "responses.SingleResponse-models_Mission": {
+            "type": "object",
+            "properties": {
                 "message": {
                     "type": "string",
-                    "example": "Sample error message"
+                    "example": "Sample success message"
+                },
+                "waypoint": {
+                    "$ref": "#/definitions/models.Mission"
                 }
             }
         },
-        "responses.MultipleResponse-models_GroundObject": {
+        "responses.SingleResponse-models_MissionDiff": {
             "type": "object",
             "properties": {
                 "message": {
                     "type": "string",
                     "example": "Sample success message"
                 },
-                "waypoints": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.GroundObject"
-                    }
+                "waypoint": {
+                    "$ref": "#/definitions/models.MissionDiff"
                 }
             }
         },
-        "responses.MultipleResponse-models_Waypoint": {
+        "responses.SingleResponse-models_Token": {
             "type": "object",
             "properties": {
                 "message": {
                     "type": "string",
                     "example": "Sample success message"
                 },
-                "waypoints": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.Waypoint"
-                    }
+                "waypoint": {
+                    "$ref": "#/definitions/models.Token"
                 }
             }
         },
-        "responses.SingleResponse-models_GroundObject": {
+        "responses.SingleResponse-models_User": {
             "type": "object",
             "properties": {
                 "message": {
@@ -1151,7 +6481,7 @@ const docTemplate = `{
                     "example": "Sample success message"
                 },
                 "waypoint": {
-                    "$ref": "#/definitions/models.GroundObject"
+                    "$ref": "#/definitions/models.User"
                 }
             }
         },
@@ -1166,6 +6496,38 @@ const docTemplate = `{
                     "$ref": "#/definitions/models.Waypoint"
                 }
             }
+        },
+        "responses.SingleResponse-progress_Snapshot": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Sample success message"
+                },
+                "waypoint": {
+                    "$ref": "#/definitions/progress.Snapshot"
+                }
+            }
+        },
+        "responses.SingleResponse-replay_State": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string",
+                    "example": "Sample success message"
+                },
+                "waypoint": {
+                    "$ref": "#/definitions/replay.State"
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "BearerAuth": {
+            "description": "\"Bearer \" followed by a token from /auth/login",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
         }
     }
 }`
@@ -0,0 +1,239 @@
+package events
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Topic groups related events, such as every change to waypoints
+type Topic string
+
+// Topics published by the controllers and the telemetry pipeline
+const (
+	Waypoints     Topic = "waypoints"
+	GroundObjects Topic = "groundobjects"
+	Images        Topic = "images"
+	Telemetry     Topic = "telemetry"
+	Progress      Topic = "progress"
+	Commands      Topic = "commands"
+	Jobs          Topic = "jobs"
+)
+
+// Action is what happened to the model of an Event
+type Action string
+
+// Actions published by the controllers and the telemetry pipeline
+const (
+	Created  Action = "created"
+	Updated  Action = "updated"
+	Deleted  Action = "deleted"
+	Received Action = "received"
+	Reached  Action = "reached"
+	Sent     Action = "sent"
+	//Commands waiting for a second user to confirm them
+	Requested Action = "requested"
+	Confirmed Action = "confirmed"
+	Rejected  Action = "rejected"
+	Expired   Action = "expired"
+	//Ground objects entering review and being approved, or Rejected
+	Queued   Action = "queued"
+	Approved Action = "approved"
+)
+
+// ReplaySize is how many recent events are kept for clients to catch up on
+// after reconnecting
+const ReplaySize = 500
+
+// Event describes a change to a model
+//
+// @Description describes a change to a model, sent to every client
+type Event struct {
+	//Increases by one for every event published
+	ID     uint64 `json:"id" example:"42" extensions:"x-order=1"`
+	Topic  Topic  `json:"topic" example:"waypoints" extensions:"x-order=2"`
+	Action Action `json:"action" example:"updated" extensions:"x-order=3"`
+	//The model after the change, or before it for deletes
+	Model any `json:"model" extensions:"x-order=4"`
+	//ID of the client which made the change, from the X-Client-ID header
+	Client string `json:"client,omitempty" example:"Zx8d0cGk1uFq2bQbAAAB" extensions:"x-order=5"`
+	//Unix time the event was published
+	Time int64 `json:"time" example:"1698544781" extensions:"x-order=6"`
+}
+
+// Command is the model of events on the Commands topic, published with Sent
+// when the drone is sent a command and Updated when its status changes.
+// Commands needing a second user are published with Requested first, then
+// Confirmed, Rejected or Expired.
+//
+// @Description a command sent to the drone
+type Command struct {
+	//Increases by one for every command sent
+	ID   uint64 `json:"id" example:"7" extensions:"x-order=1"`
+	Name string `json:"name" example:"takeoff" extensions:"x-order=2"`
+	//Arguments of the command, such as the altitude
+	Params any `json:"params,omitempty" extensions:"x-order=3"`
+	//Whether the autopilot accepted the command
+	Success bool `json:"success" example:"true" extensions:"x-order=4"`
+	//One of awaiting_confirmation, sent, completed, failed, superseded,
+	//timed_out, rejected or expired
+	Status string `json:"status" example:"sent" extensions:"x-order=5"`
+	//Why a command which was accepted could not be completely handled
+	Error string `json:"error,omitempty" example:"the flight session could not be saved" extensions:"x-order=6"`
+	//Username of whoever sent the command
+	RequestedBy string `json:"requested_by,omitempty" example:"pilot" extensions:"x-order=7"`
+	//Username of whoever confirmed or rejected the command
+	ReviewedBy string `json:"reviewed_by,omitempty" example:"safety" extensions:"x-order=8"`
+	//Unix time the command was requested
+	Issued int64 `json:"issued" example:"1698544781" extensions:"x-order=9"`
+	//Unix time the status last changed
+	Updated int64 `json:"updated" example:"1698544781" extensions:"x-order=10"`
+	//Unix time a command awaiting confirmation expires
+	ExpiresAt int64 `json:"expires_at,omitempty" example:"1698544841" extensions:"x-order=11"`
+}
+
+// Name is the event name used by socket.io, such as "waypoint_updated"
+func (e Event) Name() string {
+	return strings.TrimSuffix(string(e.Topic), "s") + "_" + string(e.Action)
+}
+
+// Bus is an in-process publish/subscribe hub. Every subscription has its own
+// bounded buffer, so a slow subscriber only affects publishers if it asks for
+// back-pressure. The last ReplaySize events are kept for catching up.
+type Bus struct {
+	mu          sync.Mutex
+	lastID      uint64
+	subscribers []*Subscription
+	recent      []Event
+	published   map[Topic]uint64
+}
+
+// NewBus creates a Bus with no subscribers
+func NewBus() *Bus {
+	return &Bus{published: map[Topic]uint64{}}
+}
+
+// Publish numbers and timestamps an event, then queues it for every
+// subscriber of its topic. Every subscriber is handed events in the order
+// they were published.
+func (b *Bus) Publish(event Event) Event {
+	b.mu.Lock()
+	b.lastID++
+	event.ID = b.lastID
+	event.Time = time.Now().Unix()
+	b.published[event.Topic]++
+
+	b.recent = append(b.recent, event)
+	if len(b.recent) > ReplaySize {
+		b.recent = b.recent[len(b.recent)-ReplaySize:]
+	}
+
+	//Queueing for Drop subscribers never waits, so it is done in order under
+	//the lock. Block subscribers are handed events without the lock, so one
+	//making its publisher wait does not hold up anyone else, with a turn
+	//taken now to keep them in order.
+	var turns []turn
+	for _, subscription := range b.subscribers {
+		if !subscription.wants(event) {
+			continue
+		}
+		if subscription.policy == Block {
+			turns = append(turns, turn{subscription, subscription.takeTurn()})
+		} else {
+			subscription.deliver(event)
+		}
+	}
+	b.mu.Unlock()
+
+	for _, waiting := range turns {
+		waiting.subscription.deliverInTurn(waiting.number, event)
+	}
+	return event
+}
+
+// turn is a publisher's place in line to hand an event to a Block subscriber
+type turn struct {
+	subscription *Subscription
+	number       uint64
+}
+
+// Subscribe queues events from now on for a new subscription, which must be
+// closed when no longer needed
+func (b *Bus) Subscribe(name string, options Options) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subscription := newSubscription(b, name, options)
+	b.subscribers = append(b.subscribers, subscription)
+	return subscription
+}
+
+// Handle subscribes and calls handler with each event on its own goroutine,
+// until the returned subscription is closed
+func (b *Bus) Handle(name string, options Options, handler func(Event)) *Subscription {
+	subscription := b.Subscribe(name, options)
+	go func() {
+		for event := range subscription.Events() {
+			handler(event)
+		}
+	}()
+	return subscription
+}
+
+func (b *Bus) remove(subscription *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, existing := range b.subscribers {
+		if existing == subscription {
+			b.subscribers = append(b.subscribers[:i], b.subscribers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Metrics describes the events published and how each subscriber is keeping up
+//
+// @Description describes the events published and how each subscriber is keeping up
+type Metrics struct {
+	//Events published per topic since the server started
+	Published   map[Topic]uint64    `json:"published" extensions:"x-order=1"`
+	Subscribers []SubscriberMetrics `json:"subscribers" extensions:"x-order=2"`
+}
+
+// Metrics returns the number of events published and the state of every
+// subscription
+func (b *Bus) Metrics() Metrics {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	metrics := Metrics{Published: map[Topic]uint64{}, Subscribers: []SubscriberMetrics{}}
+	for topic, count := range b.published {
+		metrics.Published[topic] = count
+	}
+	for _, subscription := range b.subscribers {
+		metrics.Subscribers = append(metrics.Subscribers, subscription.metrics())
+	}
+	return metrics
+}
+
+// Since returns the recent events published after the event with the given
+// ID. It returns false if some of those events are no longer kept, or if the
+// ID is from before the server restarted.
+func (b *Bus) Since(id uint64) ([]Event, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if id > b.lastID {
+		return append([]Event{}, b.recent...), false
+	} else if id == b.lastID {
+		return nil, true
+	}
+
+	complete := len(b.recent) > 0 && b.recent[0].ID <= id+1
+	start := len(b.recent)
+	for start > 0 && b.recent[start-1].ID > id {
+		start--
+	}
+	return append([]Event{}, b.recent[start:]...), complete
+}
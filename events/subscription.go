@@ -0,0 +1,217 @@
+package events
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Policy decides what happens when a subscriber's buffer is full
+type Policy string
+
+const (
+	//Drop discards the event for that subscriber only and counts it
+	Drop Policy = "drop"
+	//Block makes the publisher wait for room, for subscribers which must see
+	//every event. Their handlers must not publish.
+	Block Policy = "block"
+)
+
+// DefaultBuffer is the buffer size of subscriptions which do not set one
+const DefaultBuffer = 256
+
+// Options configures a subscription
+type Options struct {
+	//Topics to receive, every topic if empty
+	Topics []Topic
+	//Events which can be queued before the Policy applies
+	Buffer int
+	Policy Policy
+	//How long a Block subscription makes a publisher wait for room before
+	//dropping the event, forever if 0
+	Timeout time.Duration
+}
+
+// Subscription receives the events published on a Bus for its topics
+type Subscription struct {
+	bus       *Bus
+	name      string
+	topics    map[Topic]bool
+	policy    Policy
+	timeout   time.Duration
+	events    chan Event
+	done      chan struct{}
+	closeOnce sync.Once
+	//Held by publishers while delivering, so events is not closed under them
+	mu sync.RWMutex
+	//Publishers of Block subscriptions wait for their turn, taken in the
+	//order events were published
+	order     sync.Mutex
+	served    *sync.Cond
+	turns     uint64
+	serving   uint64
+	delivered atomic.Uint64
+	dropped   atomic.Uint64
+}
+
+// SubscriberMetrics describes how a subscriber is keeping up
+//
+// @Description describes how a subscriber is keeping up
+type SubscriberMetrics struct {
+	Name   string  `json:"name" example:"socket.io" extensions:"x-order=1"`
+	Topics []Topic `json:"topics" example:"telemetry" extensions:"x-order=2"`
+	Policy Policy  `json:"policy" example:"drop" extensions:"x-order=3"`
+	Buffer int     `json:"buffer" example:"256" extensions:"x-order=4"`
+	//Events waiting to be handled
+	Queued    int    `json:"queued" example:"3" extensions:"x-order=5"`
+	Delivered uint64 `json:"delivered" example:"1200" extensions:"x-order=6"`
+	Dropped   uint64 `json:"dropped" example:"0" extensions:"x-order=7"`
+}
+
+func newSubscription(bus *Bus, name string, options Options) *Subscription {
+	if options.Buffer <= 0 {
+		options.Buffer = DefaultBuffer
+	}
+	if options.Policy == "" {
+		options.Policy = Drop
+	}
+
+	subscription := &Subscription{
+		bus:     bus,
+		name:    name,
+		topics:  map[Topic]bool{},
+		policy:  options.Policy,
+		timeout: options.Timeout,
+		events:  make(chan Event, options.Buffer),
+		done:    make(chan struct{}),
+	}
+	subscription.served = sync.NewCond(&subscription.order)
+	for _, topic := range options.Topics {
+		subscription.topics[topic] = true
+	}
+	return subscription
+}
+
+func (s *Subscription) wants(event Event) bool {
+	return len(s.topics) == 0 || s.topics[event.Topic]
+}
+
+// takeTurn returns the place in line of the next event to deliver, called by
+// the Bus under its lock
+func (s *Subscription) takeTurn() uint64 {
+	s.order.Lock()
+	defer s.order.Unlock()
+
+	number := s.turns
+	s.turns++
+	return number
+}
+
+// deliverInTurn waits for the events published before this one to be
+// delivered, then delivers it. Called by the Bus without its lock held so a
+// full Block subscription only holds up its own publishers.
+func (s *Subscription) deliverInTurn(number uint64, event Event) {
+	s.order.Lock()
+	for s.serving != number {
+		s.served.Wait()
+	}
+	s.order.Unlock()
+
+	s.deliver(event)
+
+	s.order.Lock()
+	s.serving++
+	s.served.Broadcast()
+	s.order.Unlock()
+}
+
+// deliver queues an event
+func (s *Subscription) deliver(event Event) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+
+	if s.policy == Block {
+		var timeout <-chan time.Time
+		if s.timeout > 0 {
+			timer := time.NewTimer(s.timeout)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+
+		select {
+		case s.events <- event:
+			s.delivered.Add(1)
+		case <-s.done:
+		case <-timeout:
+			s.dropped.Add(1)
+		}
+		return
+	}
+
+	select {
+	case s.events <- event:
+		s.delivered.Add(1)
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// Events returns the channel events are queued on, which is closed when the
+// subscription is
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Dropped returns how many events were discarded because the buffer was full,
+// or stayed full for longer than the Timeout
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close stops the subscription. A publisher waiting on a full buffer is
+// released first.
+func (s *Subscription) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.bus.remove(s)
+
+		//Wait for publishers still delivering before closing the channel
+		s.mu.Lock()
+		close(s.events)
+		s.mu.Unlock()
+	})
+}
+
+func (s *Subscription) metrics() SubscriberMetrics {
+	topics := []Topic{}
+	for topic := range s.topics {
+		topics = append(topics, topic)
+	}
+	sort.Slice(topics, func(i, j int) bool { return topics[i] < topics[j] })
+
+	return SubscriberMetrics{
+		Name:      s.name,
+		Topics:    topics,
+		Policy:    s.policy,
+		Buffer:    cap(s.events),
+		Queued:    len(s.events),
+		Delivered: s.delivered.Load(),
+		Dropped:   s.dropped.Load(),
+	}
+}
+
+// Typed adapts a handler for one model type, ignoring events with other models
+func Typed[T any](handler func(Event, T)) func(Event) {
+	return func(event Event) {
+		if model, ok := event.Model.(T); ok {
+			handler(event, model)
+		}
+	}
+}
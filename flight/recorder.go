@@ -0,0 +1,98 @@
+package flight
+
+import (
+	"errors"
+	"gcom-backend/models"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrNoSession is returned when stopping a recorder with no flight in progress
+var ErrNoSession = errors.New("no flight session in progress")
+
+// Recorder archives telemetry for the flight in progress, separately from
+// the rolling window of Drone rows which is purged after 5 minutes
+type Recorder struct {
+	mu      sync.Mutex
+	db      *gorm.DB
+	session *models.FlightSession
+}
+
+// NewRecorder creates a Recorder archiving to db. A session left open by a
+// restart of the backend is resumed.
+func NewRecorder(db *gorm.DB) *Recorder {
+	recorder := &Recorder{db: db}
+
+	var session models.FlightSession
+	if err := db.Where("ended_at = 0").Order("id desc").First(&session).Error; err == nil {
+		recorder.session = &session
+	}
+
+	return recorder
+}
+
+// Start opens a new session when the drone is armed. If one is already in
+// progress it is kept and returned.
+func (r *Recorder) Start() (models.FlightSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.session != nil {
+		return *r.session, nil
+	}
+
+	session := models.FlightSession{StartedAt: time.Now().Unix()}
+	if err := r.db.Create(&session).Error; err != nil {
+		return session, err
+	}
+
+	r.session = &session
+	return session, nil
+}
+
+// Stop closes the session in progress when the drone is disarmed
+func (r *Recorder) Stop() (models.FlightSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.session == nil {
+		return models.FlightSession{}, ErrNoSession
+	}
+
+	session := *r.session
+	session.EndedAt = time.Now().Unix()
+	if err := r.db.Model(&session).Update("ended_at", session.EndedAt).Error; err != nil {
+		return session, err
+	}
+
+	r.session = nil
+	return session, nil
+}
+
+// Active returns the session in progress, if there is one
+func (r *Recorder) Active() (models.FlightSession, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.session == nil {
+		return models.FlightSession{}, false
+	}
+	return *r.session, true
+}
+
+// Record archives a telemetry sample if a session is in progress. A sample
+// repeating a timestamp already archived for the session is ignored.
+func (r *Recorder) Record(drone models.Drone) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.session == nil {
+		return nil
+	}
+
+	sample := models.FlightSample{Drone: drone, SessionID: r.session.ID}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&sample).Error
+}
@@ -0,0 +1,81 @@
+package formats
+
+import (
+	"encoding/json"
+	"gcom-backend/geo"
+	"io"
+)
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string          `json:"type"`
+	Geometry   geoJSONGeometry `json:"geometry"`
+	Properties map[string]any  `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"`
+}
+
+// geoJSONPosition formats a point as GeoJSON expects, longitude first
+func geoJSONPosition(lat float64, long float64, alt float64) []float64 {
+	if alt == 0 {
+		return []float64{long, lat}
+	}
+	return []float64{long, lat, alt}
+}
+
+func writeGeoJSON(w io.Writer, features []geoJSONFeature) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(geoJSONFeatureCollection{Type: "FeatureCollection", Features: features})
+}
+
+// WriteGeoJSON writes placemarks as a GeoJSON FeatureCollection of points.
+// Radius rings are written as separate polygon features with a "ring" property.
+func WriteGeoJSON(w io.Writer, placemarks []Placemark) error {
+	features := make([]geoJSONFeature, 0, len(placemarks))
+
+	for _, placemark := range placemarks {
+		properties := map[string]any{"name": placemark.Name}
+		for key, value := range placemark.Properties {
+			properties[key] = value
+		}
+
+		features = append(features, geoJSONFeature{
+			Type: "Feature",
+			Geometry: geoJSONGeometry{
+				Type:        "Point",
+				Coordinates: geoJSONPosition(placemark.Latitude, placemark.Longitude, placemark.Altitude),
+			},
+			Properties: properties,
+		})
+
+		if placemark.Radius > 0 {
+			var ring [][]float64
+			for _, point := range geo.Circle(placemark.Latitude, placemark.Longitude, placemark.Radius) {
+				ring = append(ring, geoJSONPosition(point[0], point[1], 0))
+			}
+
+			features = append(features, geoJSONFeature{
+				Type: "Feature",
+				Geometry: geoJSONGeometry{
+					Type:        "Polygon",
+					Coordinates: [][][]float64{ring},
+				},
+				Properties: map[string]any{
+					"name":   placemark.Name,
+					"radius": placemark.Radius,
+					"ring":   true,
+				},
+			})
+		}
+	}
+
+	return writeGeoJSON(w, features)
+}
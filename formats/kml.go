@@ -0,0 +1,144 @@
+package formats
+
+import (
+	"encoding/xml"
+	"fmt"
+	"gcom-backend/geo"
+	"io"
+	"sort"
+	"strings"
+)
+
+const kmlNamespace = "http://www.opengis.net/kml/2.2"
+
+type kmlFile struct {
+	XMLName   xml.Name    `xml:"kml"`
+	Namespace string      `xml:"xmlns,attr"`
+	Document  kmlDocument `xml:"Document"`
+}
+
+type kmlDocument struct {
+	Name       string         `xml:"name"`
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlPlacemark struct {
+	Name          string           `xml:"name"`
+	TimeStamp     *kmlTimeStamp    `xml:"TimeStamp,omitempty"`
+	TimeSpan      *kmlTimeSpan     `xml:"TimeSpan,omitempty"`
+	ExtendedData  *kmlExtendedData `xml:"ExtendedData,omitempty"`
+	MultiGeometry kmlMultiGeometry `xml:"MultiGeometry"`
+}
+
+type kmlTimeStamp struct {
+	When string `xml:"when"`
+}
+
+type kmlTimeSpan struct {
+	Begin string `xml:"begin"`
+	End   string `xml:"end"`
+}
+
+type kmlExtendedData struct {
+	Data []kmlData `xml:"Data"`
+}
+
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+type kmlMultiGeometry struct {
+	Point      *kmlPoint      `xml:"Point,omitempty"`
+	Polygon    *kmlPolygon    `xml:"Polygon,omitempty"`
+	LineString *kmlLineString `xml:"LineString,omitempty"`
+}
+
+type kmlPoint struct {
+	AltitudeMode string `xml:"altitudeMode"`
+	Coordinates  string `xml:"coordinates"`
+}
+
+type kmlPolygon struct {
+	AltitudeMode string `xml:"altitudeMode"`
+	Coordinates  string `xml:"outerBoundaryIs>LinearRing>coordinates"`
+}
+
+type kmlLineString struct {
+	AltitudeMode string `xml:"altitudeMode"`
+	Coordinates  string `xml:"coordinates"`
+}
+
+// kmlCoordinate formats a point as KML expects, longitude first
+func kmlCoordinate(lat float64, long float64, alt float64) string {
+	return fmt.Sprintf("%f,%f,%f", long, lat, alt)
+}
+
+// altitudeMode places points without an altitude on the ground
+func altitudeMode(alt float64) string {
+	if alt == 0 {
+		return "clampToGround"
+	}
+	return "relativeToGround"
+}
+
+// extendedData lists properties in a stable order
+func extendedData(properties map[string]any) *kmlExtendedData {
+	if len(properties) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	data := &kmlExtendedData{}
+	for _, key := range keys {
+		data.Data = append(data.Data, kmlData{Name: key, Value: fmt.Sprint(properties[key])})
+	}
+	return data
+}
+
+func writeKML(w io.Writer, document kmlDocument) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(kmlFile{Namespace: kmlNamespace, Document: document})
+}
+
+// WriteKML writes placemarks as a KML document, with radius rings drawn as
+// polygons on the ground
+func WriteKML(w io.Writer, name string, placemarks []Placemark) error {
+	document := kmlDocument{Name: name}
+
+	for _, placemark := range placemarks {
+		kml := kmlPlacemark{
+			Name:         placemark.Name,
+			ExtendedData: extendedData(placemark.Properties),
+		}
+		kml.MultiGeometry.Point = &kmlPoint{
+			AltitudeMode: altitudeMode(placemark.Altitude),
+			Coordinates:  kmlCoordinate(placemark.Latitude, placemark.Longitude, placemark.Altitude),
+		}
+
+		if placemark.Radius > 0 {
+			var ring []string
+			for _, point := range geo.Circle(placemark.Latitude, placemark.Longitude, placemark.Radius) {
+				ring = append(ring, kmlCoordinate(point[0], point[1], 0))
+			}
+			kml.MultiGeometry.Polygon = &kmlPolygon{
+				AltitudeMode: "clampToGround",
+				Coordinates:  strings.Join(ring, " "),
+			}
+		}
+
+		document.Placemarks = append(document.Placemarks, kml)
+	}
+
+	return writeKML(w, document)
+}
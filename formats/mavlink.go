@@ -0,0 +1,183 @@
+package formats
+
+import (
+	"fmt"
+	"gcom-backend/models"
+	"math"
+)
+
+// MAVLink MAV_CMD values understood by the importers and exporters
+const (
+	CmdNavWaypoint    = 16
+	CmdNavLoiterUnlim = 17
+	CmdNavLoiterTurns = 18
+	CmdNavLoiterTime  = 19
+	CmdNavLand        = 21
+	CmdNavTakeoff     = 22
+	CmdNavLoiterToAlt = 31
+)
+
+// MAVLink MAV_FRAME values understood by the importers and exporters
+const (
+	FrameGlobal            = 0
+	FrameGlobalRelativeAlt = 3
+)
+
+// Issue describes a mission item which could not be imported
+//
+// @Description describes a line of an imported file which was skipped
+type Issue struct {
+	//Line of the file the item starts on, starting at 1
+	Line    int    `json:"line" example:"4" extensions:"x-order=1"`
+	Command int    `json:"command,omitempty" example:"183" extensions:"x-order=2"`
+	Message string `json:"message" example:"Unsupported command 183" extensions:"x-order=3"`
+}
+
+// Import describes the result of parsing a mission file
+//
+// @Description describes the waypoints parsed from a mission file
+type Import struct {
+	Format    string            `json:"format" example:"wpl" extensions:"x-order=1"`
+	Waypoints []models.Waypoint `json:"waypoints" extensions:"x-order=2"`
+	Issues    []Issue           `json:"issues" extensions:"x-order=3"`
+}
+
+// missionItem is a single MAVLink mission item in either file format
+type missionItem struct {
+	line    int
+	command int
+	frame   int
+	params  [4]float64
+	lat     float64
+	long    float64
+	alt     float64
+}
+
+// commandName is used in the remarks of imported waypoints
+func commandName(command int) string {
+	switch command {
+	case CmdNavWaypoint:
+		return "NAV_WAYPOINT"
+	case CmdNavLoiterUnlim:
+		return "NAV_LOITER_UNLIM"
+	case CmdNavLoiterTurns:
+		return "NAV_LOITER_TURNS"
+	case CmdNavLoiterTime:
+		return "NAV_LOITER_TIME"
+	case CmdNavLand:
+		return "NAV_LAND"
+	case CmdNavTakeoff:
+		return "NAV_TAKEOFF"
+	case CmdNavLoiterToAlt:
+		return "NAV_LOITER_TO_ALT"
+	}
+	return fmt.Sprintf("command %d", command)
+}
+
+// toWaypoints maps mission items to waypoints named "<prefix> <n>". Items
+// without a position, such as a takeoff from the current location, inherit
+// the position of the item before them, or of home for the first item.
+func toWaypoints(items []missionItem, home *missionItem, prefix string, imported *Import) {
+	previous := home
+	for i := range items {
+		item := items[i]
+
+		if item.frame != FrameGlobal && item.frame != FrameGlobalRelativeAlt {
+			imported.Issues = append(imported.Issues, Issue{
+				Line:    item.line,
+				Command: item.command,
+				Message: fmt.Sprintf("Unsupported frame %d", item.frame)})
+			continue
+		}
+
+		waypoint := models.Waypoint{
+			Name:      fmt.Sprintf("%s %d", prefix, len(imported.Waypoints)+1),
+			Latitude:  item.lat,
+			Longitude: item.long,
+			Altitude:  item.alt,
+			Remarks:   fmt.Sprintf("Imported %s from line %d", commandName(item.command), item.line),
+		}
+
+		switch item.command {
+		case CmdNavWaypoint:
+			//Param 2 is the acceptance radius
+			waypoint.Radius = item.params[1]
+		case CmdNavTakeoff:
+			waypoint.Designation = models.Launch
+		case CmdNavLand:
+			waypoint.Designation = models.Land
+		case CmdNavLoiterUnlim, CmdNavLoiterTurns, CmdNavLoiterTime:
+			//Param 3 is the loiter radius, negative for counter-clockwise
+			waypoint.Designation = models.Loiter
+			waypoint.Radius = math.Abs(item.params[2])
+		case CmdNavLoiterToAlt:
+			waypoint.Designation = models.Loiter
+			waypoint.Radius = math.Abs(item.params[1])
+		default:
+			imported.Issues = append(imported.Issues, Issue{
+				Line:    item.line,
+				Command: item.command,
+				Message: fmt.Sprintf("Unsupported command %d", item.command)})
+			continue
+		}
+
+		if waypoint.Latitude == 0 && waypoint.Longitude == 0 {
+			if previous == nil {
+				imported.Issues = append(imported.Issues, Issue{
+					Line:    item.line,
+					Command: item.command,
+					Message: "Item has no position and there is no earlier position to use"})
+				continue
+			}
+			waypoint.Latitude = previous.lat
+			waypoint.Longitude = previous.long
+		}
+
+		//Waypoints need the same fields as when created directly, except
+		//that landing is at altitude 0
+		if waypoint.Latitude == 0 || waypoint.Longitude == 0 {
+			imported.Issues = append(imported.Issues, Issue{
+				Line:    item.line,
+				Command: item.command,
+				Message: "Item has a latitude or longitude of 0"})
+			continue
+		}
+		if waypoint.Altitude == 0 && waypoint.Designation != models.Land {
+			imported.Issues = append(imported.Issues, Issue{
+				Line:    item.line,
+				Command: item.command,
+				Message: "Only landing items may have an altitude of 0"})
+			continue
+		}
+
+		item.lat = waypoint.Latitude
+		item.long = waypoint.Longitude
+		previous = &item
+		imported.Waypoints = append(imported.Waypoints, waypoint)
+	}
+}
+
+// fromWaypoint maps a waypoint back to a mission item based on its designation
+func fromWaypoint(waypoint models.Waypoint) missionItem {
+	item := missionItem{
+		command: CmdNavWaypoint,
+		frame:   FrameGlobalRelativeAlt,
+		lat:     waypoint.Latitude,
+		long:    waypoint.Longitude,
+		alt:     waypoint.Altitude,
+	}
+
+	switch waypoint.Designation {
+	case models.Launch:
+		item.command = CmdNavTakeoff
+	case models.Land:
+		item.command = CmdNavLand
+	case models.Loiter:
+		item.command = CmdNavLoiterUnlim
+		item.params[2] = waypoint.Radius
+	default:
+		item.params[1] = waypoint.Radius
+	}
+
+	return item
+}
@@ -0,0 +1,65 @@
+package formats
+
+import (
+	"encoding/json"
+	"gcom-backend/models"
+)
+
+// Placemark is a named point shared by the KML and GeoJSON writers
+type Placemark struct {
+	Name      string
+	Latitude  float64
+	Longitude float64
+	Altitude  float64
+	//A ring is drawn around the point when Radius is above 0
+	Radius float64
+	//Remaining fields of the model, keyed by their JSON names
+	Properties map[string]any
+}
+
+// properties flattens a model to its JSON fields, leaving out the position
+// which is already part of the placemark
+func properties(model any) map[string]any {
+	var fields map[string]any
+	modelBytes, _ := json.Marshal(model)
+	_ = json.Unmarshal(modelBytes, &fields)
+
+	delete(fields, "lat")
+	delete(fields, "long")
+	return fields
+}
+
+// WaypointPlacemarks converts waypoints to placemarks with radius rings
+func WaypointPlacemarks(waypoints []models.Waypoint) []Placemark {
+	placemarks := make([]Placemark, 0, len(waypoints))
+	for _, waypoint := range waypoints {
+		placemarks = append(placemarks, Placemark{
+			Name:       waypoint.Name,
+			Latitude:   waypoint.Latitude,
+			Longitude:  waypoint.Longitude,
+			Altitude:   waypoint.Altitude,
+			Radius:     waypoint.Radius,
+			Properties: properties(waypoint),
+		})
+	}
+	return placemarks
+}
+
+// GroundObjectPlacemarks converts ground objects to placemarks on the ground
+func GroundObjectPlacemarks(objects []models.GroundObject) []Placemark {
+	placemarks := make([]Placemark, 0, len(objects))
+	for _, object := range objects {
+		name := string(object.Type)
+		if object.Text != "" {
+			name += " " + object.Text
+		}
+
+		placemarks = append(placemarks, Placemark{
+			Name:       name,
+			Latitude:   object.Latitude,
+			Longitude:  object.Longitude,
+			Properties: properties(object),
+		})
+	}
+	return placemarks
+}
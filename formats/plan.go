@@ -0,0 +1,187 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gcom-backend/models"
+	"io"
+)
+
+// planFile is the subset of a QGroundControl .plan file used by GCOM
+type planFile struct {
+	FileType string `json:"fileType"`
+	Version  int    `json:"version"`
+	Mission  struct {
+		Items               []json.RawMessage `json:"items"`
+		PlannedHomePosition []float64         `json:"plannedHomePosition"`
+	} `json:"mission"`
+}
+
+// planItem is a single entry of mission.items, QGroundControl writes NaN
+// params as null
+type planItem struct {
+	Type            string     `json:"type"`
+	ComplexItemType string     `json:"complexItemType"`
+	Command         int        `json:"command"`
+	Frame           int        `json:"frame"`
+	Params          []*float64 `json:"params"`
+}
+
+// lineOf returns the line a byte offset falls on, starting at 1
+func lineOf(data []byte, offset int) int {
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// ParsePlan reads the mission items of a QGroundControl .plan JSON file.
+// Complex items such as surveys are reported as issues.
+func ParsePlan(data []byte, prefix string) (Import, error) {
+	imported := Import{Format: "plan", Waypoints: []models.Waypoint{}, Issues: []Issue{}}
+
+	var plan planFile
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return imported, err
+	}
+	if plan.FileType != "Plan" {
+		return imported, errors.New("fileType is not \"Plan\"")
+	}
+
+	var home *missionItem
+	if len(plan.Mission.PlannedHomePosition) == 3 {
+		home = &missionItem{
+			lat:  plan.Mission.PlannedHomePosition[0],
+			long: plan.Mission.PlannedHomePosition[1],
+			alt:  plan.Mission.PlannedHomePosition[2],
+		}
+	}
+
+	var items []missionItem
+	searchFrom := 0
+	for _, raw := range plan.Mission.Items {
+		//RawMessage keeps the original bytes, so the item can be found in the file
+		offset := bytes.Index(data[searchFrom:], raw) + searchFrom
+		searchFrom = offset + len(raw)
+		line := lineOf(data, offset)
+
+		var item planItem
+		if err := json.Unmarshal(raw, &item); err != nil {
+			imported.Issues = append(imported.Issues, Issue{
+				Line:    line,
+				Message: "Malformed item: " + err.Error()})
+			continue
+		}
+
+		if item.Type != "SimpleItem" {
+			imported.Issues = append(imported.Issues, Issue{
+				Line:    line,
+				Message: fmt.Sprintf("Unsupported %s %s", item.Type, item.ComplexItemType)})
+			continue
+		}
+
+		if len(item.Params) != 7 {
+			imported.Issues = append(imported.Issues, Issue{
+				Line:    line,
+				Command: item.Command,
+				Message: fmt.Sprintf("Expected 7 params but found %d", len(item.Params))})
+			continue
+		}
+
+		var params [7]float64
+		for i, param := range item.Params {
+			if param != nil {
+				params[i] = *param
+			}
+		}
+
+		items = append(items, missionItem{
+			line:    line,
+			command: item.Command,
+			frame:   item.Frame,
+			params:  [4]float64{params[0], params[1], params[2], params[3]},
+			lat:     params[4],
+			long:    params[5],
+			alt:     params[6],
+		})
+	}
+
+	toWaypoints(items, home, prefix, &imported)
+	return imported, nil
+}
+
+type planSimpleItem struct {
+	Type                string    `json:"type"`
+	AutoContinue        bool      `json:"autoContinue"`
+	Command             int       `json:"command"`
+	DoJumpId            int       `json:"doJumpId"`
+	Frame               int       `json:"frame"`
+	Params              []float64 `json:"params"`
+	AMSLAltAboveTerrain *float64  `json:"AMSLAltAboveTerrain"`
+	Altitude            float64   `json:"Altitude"`
+	AltitudeMode        int       `json:"AltitudeMode"`
+}
+
+type planGeoFence struct {
+	Circles  []any `json:"circles"`
+	Polygons []any `json:"polygons"`
+	Version  int   `json:"version"`
+}
+
+type planRallyPoints struct {
+	Points  []any `json:"points"`
+	Version int   `json:"version"`
+}
+
+type planOutput struct {
+	FileType      string `json:"fileType"`
+	GroundStation string `json:"groundStation"`
+	Version       int    `json:"version"`
+	Mission       struct {
+		CruiseSpeed         float64          `json:"cruiseSpeed"`
+		FirmwareType        int              `json:"firmwareType"`
+		HoverSpeed          float64          `json:"hoverSpeed"`
+		Items               []planSimpleItem `json:"items"`
+		PlannedHomePosition []float64        `json:"plannedHomePosition"`
+		VehicleType         int              `json:"vehicleType"`
+		Version             int              `json:"version"`
+	} `json:"mission"`
+	GeoFence    planGeoFence    `json:"geoFence"`
+	RallyPoints planRallyPoints `json:"rallyPoints"`
+}
+
+// WritePlan writes waypoints in order as a QGroundControl .plan file for an
+// ArduPilot vehicle. The first waypoint doubles as the planned home position.
+func WritePlan(w io.Writer, waypoints []models.Waypoint) error {
+	plan := planOutput{FileType: "Plan", GroundStation: "GCOM", Version: 1}
+	plan.Mission.CruiseSpeed = 15
+	plan.Mission.FirmwareType = 3
+	plan.Mission.HoverSpeed = 5
+	plan.Mission.VehicleType = 2
+	plan.Mission.Version = 2
+	plan.Mission.Items = []planSimpleItem{}
+	plan.Mission.PlannedHomePosition = []float64{0, 0, 0}
+	plan.GeoFence = planGeoFence{Circles: []any{}, Polygons: []any{}, Version: 2}
+	plan.RallyPoints = planRallyPoints{Points: []any{}, Version: 2}
+
+	if len(waypoints) > 0 {
+		plan.Mission.PlannedHomePosition = []float64{waypoints[0].Latitude, waypoints[0].Longitude, waypoints[0].Altitude}
+	}
+
+	for i, waypoint := range waypoints {
+		item := fromWaypoint(waypoint)
+		plan.Mission.Items = append(plan.Mission.Items, planSimpleItem{
+			Type:         "SimpleItem",
+			AutoContinue: true,
+			Command:      item.command,
+			DoJumpId:     i + 1,
+			Frame:        item.frame,
+			Params:       []float64{item.params[0], item.params[1], item.params[2], item.params[3], item.lat, item.long, item.alt},
+			Altitude:     item.alt,
+			AltitudeMode: 1,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(plan)
+}
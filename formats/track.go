@@ -0,0 +1,159 @@
+package formats
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"gcom-backend/models"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const gpxNamespace = "http://www.topografix.com/GPX/1/1"
+
+// trackTime formats a unix timestamp as the UTC time GPX and KML expect
+func trackTime(timestamp int64) string {
+	return time.Unix(timestamp, 0).UTC().Format(time.RFC3339)
+}
+
+// WriteTrackCSV writes telemetry samples as CSV with a header row
+func WriteTrackCSV(w io.Writer, samples []models.FlightSample) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"timestamp", "time", "latitude", "longitude", "altitude",
+		"vertical_velocity", "velocity", "heading", "battery_voltage"}); err != nil {
+		return err
+	}
+
+	format := func(value float64) string {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	for _, sample := range samples {
+		if err := writer.Write([]string{
+			strconv.FormatInt(sample.Timestamp, 10),
+			trackTime(sample.Timestamp),
+			format(sample.Latitude),
+			format(sample.Longitude),
+			format(sample.Altitude),
+			format(sample.VerticalSpeed),
+			format(sample.Speed),
+			format(sample.Heading),
+			format(sample.BatteryVoltage),
+		}); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+type gpxFile struct {
+	XMLName   xml.Name `xml:"gpx"`
+	Namespace string   `xml:"xmlns,attr"`
+	Version   string   `xml:"version,attr"`
+	Creator   string   `xml:"creator,attr"`
+	Track     gpxTrack `xml:"trk"`
+}
+
+type gpxTrack struct {
+	Name    string     `xml:"name"`
+	Segment []gpxPoint `xml:"trkseg>trkpt"`
+}
+
+type gpxPoint struct {
+	Latitude   float64       `xml:"lat,attr"`
+	Longitude  float64       `xml:"lon,attr"`
+	Elevation  float64       `xml:"ele"`
+	Time       string        `xml:"time"`
+	Extensions gpxExtensions `xml:"extensions"`
+}
+
+// gpxExtensions holds the telemetry GPX has no elements for
+type gpxExtensions struct {
+	Speed          float64 `xml:"https://ubcuas.com/gcom velocity"`
+	VerticalSpeed  float64 `xml:"https://ubcuas.com/gcom vertical_velocity"`
+	Heading        float64 `xml:"https://ubcuas.com/gcom heading"`
+	BatteryVoltage float64 `xml:"https://ubcuas.com/gcom battery_voltage"`
+}
+
+// WriteGPX writes telemetry samples as a single GPX track. Speed, heading and
+// battery voltage are written as extensions of each track point.
+func WriteGPX(w io.Writer, name string, samples []models.FlightSample) error {
+	gpx := gpxFile{Namespace: gpxNamespace, Version: "1.1", Creator: "GCOM", Track: gpxTrack{Name: name}}
+	for _, sample := range samples {
+		gpx.Track.Segment = append(gpx.Track.Segment, gpxPoint{
+			Latitude:  sample.Latitude,
+			Longitude: sample.Longitude,
+			Elevation: sample.Altitude,
+			Time:      trackTime(sample.Timestamp),
+			Extensions: gpxExtensions{
+				Speed:          sample.Speed,
+				VerticalSpeed:  sample.VerticalSpeed,
+				Heading:        sample.Heading,
+				BatteryVoltage: sample.BatteryVoltage,
+			},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	return encoder.Encode(gpx)
+}
+
+// WriteTrackKML writes telemetry samples as a KML document with the flight
+// path as a line, followed by a time stamped point for every sample
+func WriteTrackKML(w io.Writer, name string, samples []models.FlightSample) error {
+	document := kmlDocument{Name: name}
+	if len(samples) == 0 {
+		return writeKML(w, document)
+	}
+
+	path := make([]string, 0, len(samples))
+	for _, sample := range samples {
+		path = append(path, kmlCoordinate(sample.Latitude, sample.Longitude, sample.Altitude))
+	}
+
+	track := kmlPlacemark{
+		Name: name,
+		TimeSpan: &kmlTimeSpan{
+			Begin: trackTime(samples[0].Timestamp),
+			End:   trackTime(samples[len(samples)-1].Timestamp),
+		},
+	}
+	track.MultiGeometry.LineString = &kmlLineString{
+		AltitudeMode: "relativeToGround",
+		Coordinates:  strings.Join(path, " "),
+	}
+	document.Placemarks = append(document.Placemarks, track)
+
+	for _, sample := range samples {
+		point := kmlPlacemark{
+			Name:      trackTime(sample.Timestamp),
+			TimeStamp: &kmlTimeStamp{When: trackTime(sample.Timestamp)},
+			ExtendedData: extendedData(map[string]any{
+				"altitude":        sample.Altitude,
+				"velocity":        sample.Speed,
+				"heading":         sample.Heading,
+				"battery_voltage": sample.BatteryVoltage,
+			}),
+		}
+		point.MultiGeometry.Point = &kmlPoint{
+			AltitudeMode: "relativeToGround",
+			Coordinates:  kmlCoordinate(sample.Latitude, sample.Longitude, sample.Altitude),
+		}
+		document.Placemarks = append(document.Placemarks, point)
+	}
+
+	return writeKML(w, document)
+}
+
+// TrackName names an exported flight session
+func TrackName(session models.FlightSession) string {
+	return fmt.Sprintf("Flight %d %s", session.ID, trackTime(session.StartedAt))
+}
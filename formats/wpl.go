@@ -0,0 +1,125 @@
+package formats
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"gcom-backend/models"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WPLHeader is the first line of a QGC WPL 110 file
+const WPLHeader = "QGC WPL 110"
+
+// ParseWPL reads a QGC WPL 110 file as written by Mission Planner and
+// QGroundControl. Each line after the header is tab separated:
+//
+//	seq current frame command p1 p2 p3 p4 lat long alt autocontinue
+//
+// The item with seq 0 is the home position and is not imported.
+func ParseWPL(r io.Reader, prefix string) (Import, error) {
+	imported := Import{Format: "wpl", Waypoints: []models.Waypoint{}, Issues: []Issue{}}
+	scanner := bufio.NewScanner(r)
+
+	line := 0
+	header := false
+	for scanner.Scan() {
+		line++
+		if text := strings.TrimSpace(scanner.Text()); text != "" {
+			header = strings.HasPrefix(text, WPLHeader)
+			break
+		}
+	}
+	if line == 0 {
+		return imported, errors.New("file is empty")
+	}
+	if !header {
+		return imported, errors.New("missing \"" + WPLHeader + "\" header")
+	}
+
+	var home *missionItem
+	var items []missionItem
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		if len(fields) != 12 {
+			imported.Issues = append(imported.Issues, Issue{
+				Line:    line,
+				Message: fmt.Sprintf("Expected 12 fields but found %d", len(fields))})
+			continue
+		}
+
+		var numbers [12]float64
+		var parseErr error
+		for i, field := range fields {
+			if numbers[i], parseErr = strconv.ParseFloat(field, 64); parseErr != nil {
+				break
+			}
+		}
+		if parseErr != nil {
+			imported.Issues = append(imported.Issues, Issue{
+				Line:    line,
+				Message: "Malformed number: " + parseErr.Error()})
+			continue
+		}
+
+		item := missionItem{
+			line:    line,
+			command: int(numbers[3]),
+			frame:   int(numbers[2]),
+			params:  [4]float64{numbers[4], numbers[5], numbers[6], numbers[7]},
+			lat:     numbers[8],
+			long:    numbers[9],
+			alt:     numbers[10],
+		}
+
+		if numbers[0] == 0 {
+			home = &item
+			continue
+		}
+		items = append(items, item)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return imported, err
+	}
+
+	toWaypoints(items, home, prefix, &imported)
+	return imported, nil
+}
+
+// WriteWPL writes waypoints in order as a QGC WPL 110 file. The first
+// waypoint doubles as the home position.
+func WriteWPL(w io.Writer, waypoints []models.Waypoint) error {
+	if _, err := fmt.Fprintln(w, WPLHeader); err != nil {
+		return err
+	}
+
+	if len(waypoints) == 0 {
+		return nil
+	}
+
+	home := waypoints[0]
+	if _, err := fmt.Fprintf(w, "0\t1\t%d\t%d\t0\t0\t0\t0\t%.7f\t%.7f\t%.6f\t1\n",
+		FrameGlobal, CmdNavWaypoint, home.Latitude, home.Longitude, home.Altitude); err != nil {
+		return err
+	}
+
+	for i, waypoint := range waypoints {
+		item := fromWaypoint(waypoint)
+		if _, err := fmt.Fprintf(w, "%d\t0\t%d\t%d\t%g\t%g\t%g\t%g\t%.7f\t%.7f\t%.6f\t1\n",
+			i+1, item.frame, item.command,
+			item.params[0], item.params[1], item.params[2], item.params[3],
+			item.lat, item.long, item.alt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
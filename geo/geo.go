@@ -0,0 +1,65 @@
+package geo
+
+import "math"
+
+// EarthRadius is the mean radius of the Earth in metres
+const EarthRadius = 6371008.8
+
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func toDegrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
+
+// Distance returns the great-circle distance in metres between two points
+// given in decimal degrees, using the haversine formula
+func Distance(lat1 float64, long1 float64, lat2 float64, long2 float64) float64 {
+	dLat := toRadians(lat2 - lat1)
+	dLong := toRadians(long2 - long1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLong/2)*math.Sin(dLong/2)
+
+	return 2 * EarthRadius * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// Destination returns the point reached by travelling distance metres from a
+// starting point along an initial bearing in degrees clockwise from north
+func Destination(lat float64, long float64, bearing float64, distance float64) (float64, float64) {
+	angular := distance / EarthRadius
+	theta := toRadians(bearing)
+	phi1 := toRadians(lat)
+	lambda1 := toRadians(long)
+
+	phi2 := math.Asin(math.Sin(phi1)*math.Cos(angular) + math.Cos(phi1)*math.Sin(angular)*math.Cos(theta))
+	lambda2 := lambda1 + math.Atan2(math.Sin(theta)*math.Sin(angular)*math.Cos(phi1),
+		math.Cos(angular)-math.Sin(phi1)*math.Sin(phi2))
+
+	return toDegrees(phi2), math.Mod(toDegrees(lambda2)+540, 360) - 180
+}
+
+// Circle approximates a circle on the ground with points every 10 degrees,
+// the first point is repeated at the end to close the ring
+func Circle(lat float64, long float64, radius float64) [][2]float64 {
+	var ring [][2]float64
+	for bearing := 0.0; bearing < 360; bearing += 10 {
+		pointLat, pointLong := Destination(lat, long, bearing, radius)
+		ring = append(ring, [2]float64{pointLat, pointLong})
+	}
+	return append(ring, ring[0])
+}
+
+// Bearing returns the initial bearing in degrees clockwise from north to
+// travel from the first point to the second along a great circle
+func Bearing(lat1 float64, long1 float64, lat2 float64, long2 float64) float64 {
+	phi1 := toRadians(lat1)
+	phi2 := toRadians(lat2)
+	dLong := toRadians(long2 - long1)
+
+	y := math.Sin(dLong) * math.Cos(phi2)
+	x := math.Cos(phi1)*math.Sin(phi2) - math.Sin(phi1)*math.Cos(phi2)*math.Cos(dLong)
+
+	return math.Mod(toDegrees(math.Atan2(y, x))+360, 360)
+}
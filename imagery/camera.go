@@ -0,0 +1,101 @@
+package imagery
+
+import (
+	"errors"
+	"gcom-backend/geo"
+	"gcom-backend/models"
+	"math"
+)
+
+// ErrNoAltitude is returned when projecting from a drone which isn't above
+// the ground, as nothing in the image can be located
+var ErrNoAltitude = errors.New("the drone must be above the ground to locate pixels")
+
+// ErrNoCamera is returned when the camera's lens or sensor size is unknown
+var ErrNoCamera = errors.New("the camera's focal length and sensor size must be set")
+
+// Camera describes the lens and sensor of the camera on the drone. The camera
+// is assumed to point straight down with the top of the image towards the
+// drone's heading.
+type Camera struct {
+	//Focal length of the lens in millimetres
+	FocalLength float64
+	//Size of the sensor in millimetres
+	SensorWidth  float64
+	SensorHeight float64
+}
+
+// Locate returns where on flat ground the pixel x, y of a width by height
+// image taken from pose lies, measuring from the top left corner. The
+// altitude of pose is taken as its height above the ground.
+func (c Camera) Locate(pose models.Drone, width int, height int, x float64, y float64) (models.GeoPoint, error) {
+	if c.FocalLength <= 0 || c.SensorWidth <= 0 || c.SensorHeight <= 0 {
+		return models.GeoPoint{}, ErrNoCamera
+	}
+	if pose.Altitude <= 0 {
+		return models.GeoPoint{}, ErrNoAltitude
+	}
+
+	//Focal lengths in pixels, so pixels can be scaled to metres on the ground
+	fx := c.FocalLength * float64(width) / c.SensorWidth
+	fy := c.FocalLength * float64(height) / c.SensorHeight
+	right := (x - float64(width)/2) * pose.Altitude / fx
+	forward := (float64(height)/2 - y) * pose.Altitude / fy
+
+	heading := pose.Heading * math.Pi / 180
+	north := forward*math.Cos(heading) - right*math.Sin(heading)
+	east := forward*math.Sin(heading) + right*math.Cos(heading)
+
+	bearing := math.Atan2(east, north) * 180 / math.Pi
+	lat, long := geo.Destination(pose.Latitude, pose.Longitude, bearing, math.Hypot(north, east))
+	return models.GeoPoint{Latitude: lat, Longitude: long}, nil
+}
+
+// Footprint returns where the corners of a width by height image taken from
+// pose meet the ground described by terrain, from the top left clockwise
+func (c Camera) Footprint(pose models.Drone, width int, height int, terrain Terrain) ([]models.GeoPoint, error) {
+	corners := [][2]float64{{0, 0}, {float64(width), 0}, {float64(width), float64(height)}, {0, float64(height)}}
+	footprint := make([]models.GeoPoint, 0, len(corners))
+	for _, corner := range corners {
+		point, err := c.Project(pose, width, height, corner[0], corner[1], terrain)
+		if err != nil {
+			return nil, err
+		}
+		footprint = append(footprint, point)
+	}
+	return footprint, nil
+}
+
+// projectIterations bounds how many times Project refines the ground height
+// under a pixel, which only matters over steep terrain
+const projectIterations = 20
+
+// Project returns where the pixel x, y of a width by height image taken from
+// pose meets the ground described by terrain. The ground height under the
+// pixel is found by repeatedly locating the pixel at the height of the ground
+// last found, until it moves less than 10cm.
+func (c Camera) Project(pose models.Drone, width int, height int, x float64, y float64, terrain Terrain) (models.GeoPoint, error) {
+	ground, err := terrain.Elevation(pose.Latitude, pose.Longitude)
+	if err != nil {
+		return models.GeoPoint{}, err
+	}
+
+	var point models.GeoPoint
+	for i := 0; i < projectIterations; i++ {
+		above := pose
+		above.Altitude = pose.Altitude - ground
+		if point, err = c.Locate(above, width, height, x, y); err != nil {
+			return models.GeoPoint{}, err
+		}
+
+		next, err := terrain.Elevation(point.Latitude, point.Longitude)
+		if err != nil {
+			return models.GeoPoint{}, err
+		}
+		if math.Abs(next-ground) < 0.1 {
+			break
+		}
+		ground = next
+	}
+	return point, nil
+}
@@ -0,0 +1,55 @@
+package imagery
+
+import (
+	"fmt"
+	"image"
+	"os"
+
+	//Decoders for the formats the camera saves
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// Size returns the width and height in pixels of the image at path, reading
+// only its header
+func Size(path string) (image.Rectangle, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+	defer file.Close()
+
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+	return image.Rect(0, 0, config.Width, config.Height), nil
+}
+
+// Crop returns the part of the image at path within bounds. Bounds reaching
+// past the edges of the image are cut back to them.
+func Crop(path string, bounds image.Rectangle) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds = bounds.Add(img.Bounds().Min).Intersect(img.Bounds())
+	if bounds.Empty() {
+		return nil, fmt.Errorf("bounds are outside the %dx%d image", img.Bounds().Dx(), img.Bounds().Dy())
+	}
+
+	cropper, ok := img.(interface {
+		SubImage(r image.Rectangle) image.Image
+	})
+	if !ok {
+		return nil, fmt.Errorf("images of type %T can't be cropped", img)
+	}
+	return cropper.SubImage(bounds), nil
+}
@@ -0,0 +1,118 @@
+package imagery
+
+import (
+	"errors"
+	"fmt"
+	"gcom-backend/models"
+	"math"
+
+	"gorm.io/gorm"
+)
+
+// MaxSampleGap is how many seconds a telemetry sample may be from the time an
+// image was taken and still be used to find where the drone was
+var MaxSampleGap int64 = 5
+
+// ErrNoTelemetry is returned when no telemetry was received close enough to
+// the time an image was taken
+var ErrNoTelemetry = errors.New("no telemetry close to the time the image was taken")
+
+// samplesAround finds the samples of model just before and after timestamp,
+// within MaxSampleGap of it
+func samplesAround(db *gorm.DB, model any, timestamp int64) (before *models.Drone, after *models.Drone, err error) {
+	var found []models.Drone
+	query := db.Model(model).Where("timestamp BETWEEN ? AND ?", timestamp-MaxSampleGap, timestamp)
+	if err := query.Order("timestamp desc").Limit(1).Find(&found).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(found) > 0 {
+		before = &found[0]
+	}
+
+	found = nil
+	query = db.Model(model).Where("timestamp BETWEEN ? AND ?", timestamp, timestamp+MaxSampleGap)
+	if err := query.Order("timestamp").Limit(1).Find(&found).Error; err != nil {
+		return nil, nil, err
+	}
+	if len(found) > 0 {
+		after = &found[0]
+	}
+	return before, after, nil
+}
+
+// PoseAt finds where the drone was at timestamp by interpolating between the
+// telemetry samples either side of it. Recent telemetry is searched first,
+// then telemetry archived with flights.
+func PoseAt(db *gorm.DB, timestamp int64) (models.Drone, error) {
+	for _, model := range []any{&models.Drone{}, &models.FlightSample{}} {
+		before, after, err := samplesAround(db, model, timestamp)
+		if err != nil {
+			return models.Drone{}, err
+		}
+
+		switch {
+		case before != nil && after != nil:
+			return interpolate(*before, *after, timestamp), nil
+		case before != nil:
+			return *before, nil
+		case after != nil:
+			return *after, nil
+		}
+	}
+	return models.Drone{}, ErrNoTelemetry
+}
+
+// interpolate estimates the drone's pose at timestamp between two samples,
+// turning the shortest way between their headings
+func interpolate(before models.Drone, after models.Drone, timestamp int64) models.Drone {
+	if after.Timestamp == before.Timestamp {
+		return before
+	}
+	t := float64(timestamp-before.Timestamp) / float64(after.Timestamp-before.Timestamp)
+	between := func(a float64, b float64) float64 {
+		return a + (b-a)*t
+	}
+
+	turn := math.Mod(after.Heading-before.Heading+540, 360) - 180
+	pose := before
+	pose.Timestamp = timestamp
+	pose.Latitude = between(before.Latitude, after.Latitude)
+	pose.Longitude = between(before.Longitude, after.Longitude)
+	pose.Altitude = between(before.Altitude, after.Altitude)
+	pose.Heading = math.Mod(before.Heading+turn*t+360, 360)
+	pose.Speed = between(before.Speed, after.Speed)
+	pose.VerticalSpeed = between(before.VerticalSpeed, after.VerticalSpeed)
+	pose.BatteryVoltage = between(before.BatteryVoltage, after.BatteryVoltage)
+	return pose
+}
+
+// Georeference fills in the size of an image from its file at path, then
+// where the drone was when it was taken and the footprint of the image on the
+// ground described by terrain. Images are left unreferenced when there is no
+// telemetry from the time they were taken, which is returned as an error.
+func Georeference(db *gorm.DB, camera Camera, terrain Terrain, path string, image *models.Image) error {
+	size, err := Size(path)
+	if err != nil {
+		return fmt.Errorf("unable to read the image: %w", err)
+	}
+	image.Width = size.Dx()
+	image.Height = size.Dy()
+
+	pose, err := PoseAt(db, image.Timestamp)
+	if err != nil {
+		return err
+	}
+
+	footprint, err := camera.Footprint(pose, image.Width, image.Height, terrain)
+	if err != nil {
+		return err
+	}
+
+	image.Georeferenced = true
+	image.Latitude = pose.Latitude
+	image.Longitude = pose.Longitude
+	image.Altitude = pose.Altitude
+	image.Heading = pose.Heading
+	image.Footprint = footprint
+	return nil
+}
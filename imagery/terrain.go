@@ -0,0 +1,182 @@
+package imagery
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrOutsideTerrain is returned for points a Terrain has no height for
+var ErrOutsideTerrain = errors.New("the point is outside the elevation model")
+
+// Terrain gives the height of the ground, measured from the same datum as the
+// drone's altitude
+type Terrain interface {
+	Elevation(lat float64, long float64) (float64, error)
+}
+
+// FlatGround is level ground at a fixed height
+type FlatGround struct {
+	Height float64
+}
+
+// Elevation returns the height of the ground, the same everywhere
+func (f FlatGround) Elevation(lat float64, long float64) (float64, error) {
+	return f.Height, nil
+}
+
+// DEM is a digital elevation model, a grid of ground heights with rows from
+// north to south and columns from west to east
+type DEM struct {
+	Rows    int
+	Columns int
+	//Position of the centre of the south west cell in decimal degrees
+	South float64
+	West  float64
+	//Size of each cell in decimal degrees
+	CellSize float64
+	NoData   float64
+	Heights  [][]float64
+	//Subtracted from every height, such as the height of home when the drone
+	//reports its altitude above home
+	Offset float64
+}
+
+// LoadDEM reads a DEM from an ESRI ASCII grid file in decimal degrees
+func LoadDEM(path string) (*DEM, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadDEM(file)
+}
+
+// ReadDEM reads a DEM in the ESRI ASCII grid format, with a header of
+// ncols, nrows, xllcorner or xllcenter, yllcorner or yllcenter, cellsize and
+// optionally NODATA_value, followed by the heights
+func ReadDEM(r io.Reader) (*DEM, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(bufio.ScanWords)
+	next := func() (string, bool) {
+		if !scanner.Scan() {
+			return "", false
+		}
+		return scanner.Text(), true
+	}
+
+	dem := &DEM{NoData: -9999}
+	header := map[string]float64{}
+	var word string
+	for {
+		key, ok := next()
+		if !ok {
+			return nil, errors.New("the DEM has no heights")
+		}
+		if _, err := strconv.ParseFloat(key, 64); err == nil {
+			//The first height ends the header
+			word = key
+			break
+		}
+		value, ok := next()
+		if !ok {
+			return nil, fmt.Errorf("the DEM header %s has no value", key)
+		}
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("the DEM header %s has an invalid value %q", key, value)
+		}
+		header[strings.ToLower(key)] = number
+	}
+
+	for _, key := range []string{"ncols", "nrows", "cellsize"} {
+		if header[key] <= 0 {
+			return nil, fmt.Errorf("the DEM header needs a positive %s", key)
+		}
+	}
+	dem.Columns = int(header["ncols"])
+	dem.Rows = int(header["nrows"])
+	dem.CellSize = header["cellsize"]
+	if value, ok := header["nodata_value"]; ok {
+		dem.NoData = value
+	}
+
+	//Corners are moved to the centre of their cell
+	if value, ok := header["xllcenter"]; ok {
+		dem.West = value
+	} else if value, ok := header["xllcorner"]; ok {
+		dem.West = value + dem.CellSize/2
+	} else {
+		return nil, errors.New("the DEM header needs xllcorner or xllcenter")
+	}
+	if value, ok := header["yllcenter"]; ok {
+		dem.South = value
+	} else if value, ok := header["yllcorner"]; ok {
+		dem.South = value + dem.CellSize/2
+	} else {
+		return nil, errors.New("the DEM header needs yllcorner or yllcenter")
+	}
+
+	dem.Heights = make([][]float64, dem.Rows)
+	for row := range dem.Heights {
+		dem.Heights[row] = make([]float64, dem.Columns)
+		for column := range dem.Heights[row] {
+			if word == "" {
+				var ok bool
+				if word, ok = next(); !ok {
+					return nil, fmt.Errorf("the DEM has fewer than %d heights", dem.Rows*dem.Columns)
+				}
+			}
+			height, err := strconv.ParseFloat(word, 64)
+			if err != nil {
+				return nil, fmt.Errorf("the DEM has an invalid height %q", word)
+			}
+			dem.Heights[row][column] = height
+			word = ""
+		}
+	}
+	return dem, nil
+}
+
+// Elevation interpolates between the centres of the four cells around a
+// point. Points beyond the centres of the edge cells use the edge cells.
+func (d *DEM) Elevation(lat float64, long float64) (float64, error) {
+	//Fractional column from the west and row from the south
+	column := (long - d.West) / d.CellSize
+	row := (lat - d.South) / d.CellSize
+	if column < -0.5 || row < -0.5 || column > float64(d.Columns)-0.5 || row > float64(d.Rows)-0.5 {
+		return 0, ErrOutsideTerrain
+	}
+	column = math.Max(0, math.Min(column, float64(d.Columns-1)))
+	row = math.Max(0, math.Min(row, float64(d.Rows-1)))
+
+	west, south := int(math.Floor(column)), int(math.Floor(row))
+	east, north := min(west+1, d.Columns-1), min(south+1, d.Rows-1)
+	tx, ty := column-float64(west), row-float64(south)
+
+	//Heights are stored from north to south
+	height := func(column int, row int) float64 {
+		return d.Heights[d.Rows-1-row][column]
+	}
+	cells := [4][2]int{{west, south}, {east, south}, {west, north}, {east, north}}
+	weights := [4]float64{(1 - tx) * (1 - ty), tx * (1 - ty), (1 - tx) * ty, tx * ty}
+
+	//Cells which barely count are left out, so points on the edge of missing data still have a height
+	var sum, total float64
+	for i, cell := range cells {
+		if weights[i] < 1e-9 {
+			continue
+		}
+		if height(cell[0], cell[1]) == d.NoData {
+			return 0, ErrOutsideTerrain
+		}
+		sum += height(cell[0], cell[1]) * weights[i]
+		total += weights[i]
+	}
+	return sum/total - d.Offset, nil
+}
@@ -0,0 +1,187 @@
+package interop
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Timeout is how long a request to the interop server may take
+var Timeout = 10 * time.Second
+
+// StatusError is returned when the interop server responds with an error
+type StatusError struct {
+	Status  int
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("interop responded %d %s", e.Status, http.StatusText(e.Status))
+	}
+	return fmt.Sprintf("interop responded %d %s: %s", e.Status, http.StatusText(e.Status), e.Message)
+}
+
+// Client talks to the competition's interop server. It logs in on the first
+// request and again whenever its session cookie is refused.
+type Client struct {
+	mu       sync.Mutex
+	url      string
+	username string
+	password string
+	http     *http.Client
+	loggedIn bool
+}
+
+// NewClient creates a Client for the server at url, such as
+// "http://10.10.130.10:80"
+func NewClient(url string, username string, password string) *Client {
+	jar, _ := cookiejar.New(nil)
+	return &Client{
+		url:      strings.TrimSuffix(url, "/"),
+		username: username,
+		password: password,
+		http:     &http.Client{Jar: jar, Timeout: Timeout},
+	}
+}
+
+// Login starts a session, which is kept in a cookie
+func (c *Client) Login(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.login(ctx)
+}
+
+func (c *Client) login(ctx context.Context) error {
+	body, _ := json.Marshal(map[string]string{"username": c.username, "password": c.password})
+	if err := c.send(ctx, http.MethodPost, "/api/login", "application/json", body, nil); err != nil {
+		c.loggedIn = false
+		return fmt.Errorf("logging in: %w", err)
+	}
+	c.loggedIn = true
+	return nil
+}
+
+// LoggedIn reports whether the client has a session
+func (c *Client) LoggedIn() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.loggedIn
+}
+
+// do sends a request, logging in first if needed and once more if the
+// session was refused, and decodes the JSON response into out if not nil
+func (c *Client) do(ctx context.Context, method string, path string, contentType string, body []byte, out any) error {
+	c.mu.Lock()
+	if !c.loggedIn {
+		if err := c.login(ctx); err != nil {
+			c.mu.Unlock()
+			return err
+		}
+	}
+	c.mu.Unlock()
+
+	err := c.send(ctx, method, path, contentType, body, out)
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || (statusErr.Status != http.StatusUnauthorized && statusErr.Status != http.StatusForbidden) {
+		return err
+	}
+
+	c.mu.Lock()
+	if loginErr := c.login(ctx); loginErr != nil {
+		c.mu.Unlock()
+		return loginErr
+	}
+	c.mu.Unlock()
+	return c.send(ctx, method, path, contentType, body, out)
+}
+
+func (c *Client) send(ctx context.Context, method string, path string, contentType string, body []byte, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.url+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &StatusError{Status: resp.StatusCode, Message: strings.TrimSpace(string(message))}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid response from interop: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) json(ctx context.Context, method string, path string, in any, out any) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+	return c.do(ctx, method, path, "application/json", body, out)
+}
+
+// Mission fetches a mission
+func (c *Client) Mission(ctx context.Context, id int) (Mission, error) {
+	var mission Mission
+	err := c.json(ctx, http.MethodGet, fmt.Sprintf("/api/missions/%d", id), nil, &mission)
+	return mission, err
+}
+
+// UploadTelemetry sends the drone's position
+func (c *Client) UploadTelemetry(ctx context.Context, telemetry Telemetry) error {
+	return c.json(ctx, http.MethodPost, "/api/telemetry", telemetry, nil)
+}
+
+// CreateODLC submits a new object, returning it with its ID
+func (c *Client) CreateODLC(ctx context.Context, odlc ODLC) (ODLC, error) {
+	var created ODLC
+	err := c.json(ctx, http.MethodPost, "/api/odlcs", odlc, &created)
+	return created, err
+}
+
+// UpdateODLC replaces an object which was submitted
+func (c *Client) UpdateODLC(ctx context.Context, odlc ODLC) (ODLC, error) {
+	var updated ODLC
+	err := c.json(ctx, http.MethodPut, fmt.Sprintf("/api/odlcs/%d", odlc.ID), odlc, &updated)
+	return updated, err
+}
+
+// ODLC fetches an object which was submitted
+func (c *Client) ODLC(ctx context.Context, id int) (ODLC, error) {
+	var odlc ODLC
+	err := c.json(ctx, http.MethodGet, fmt.Sprintf("/api/odlcs/%d", id), nil, &odlc)
+	return odlc, err
+}
+
+// DeleteODLC withdraws an object which was submitted
+func (c *Client) DeleteODLC(ctx context.Context, id int) error {
+	return c.json(ctx, http.MethodDelete, fmt.Sprintf("/api/odlcs/%d", id), nil, nil)
+}
+
+// UploadODLCImage sets the PNG thumbnail of an object which was submitted
+func (c *Client) UploadODLCImage(ctx context.Context, id int, png []byte) error {
+	return c.do(ctx, http.MethodPut, fmt.Sprintf("/api/odlcs/%d/image", id), "image/png", png, nil)
+}
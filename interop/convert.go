@@ -0,0 +1,130 @@
+package interop
+
+import (
+	"fmt"
+	"gcom-backend/models"
+	"strings"
+	"time"
+)
+
+// MetresPerFoot converts the feet interop uses to metres
+const MetresPerFoot = 0.3048
+
+// Import is an interop mission converted to our models, ready to be stored
+type Import struct {
+	//Waypoints to fly in order
+	Waypoints []models.Waypoint
+	//Stationary obstacles as waypoints with the obstacle designation
+	Obstacles []models.Waypoint
+	//Everything else, with the IDs of the mission and obstacles to fill in
+	Details models.InteropMission
+}
+
+// ImportMission converts a mission. Interop altitudes are above mean sea
+// level, so homeAltitude, the height of home above sea level in metres, is
+// subtracted to match the altitudes the drone flies at.
+func ImportMission(mission Mission, homeAltitude float64) Import {
+	altitude := func(feet float64) float64 {
+		return feet*MetresPerFoot - homeAltitude
+	}
+	point := func(position Position) models.GeoPoint {
+		return models.GeoPoint{Latitude: position.Latitude, Longitude: position.Longitude}
+	}
+
+	imported := Import{
+		Waypoints: []models.Waypoint{},
+		Obstacles: []models.Waypoint{},
+		Details: models.InteropMission{
+			ID:                mission.ID,
+			ObstacleIDs:       []int{},
+			FlyZones:          []models.FlyZone{},
+			SearchGrid:        []models.GeoPoint{},
+			OffAxisObject:     point(mission.OffAxisOdlcPos),
+			EmergentLastKnown: point(mission.EmergentLastKnownPos),
+			LostComms:         point(mission.LostCommsPos),
+			FetchedAt:         time.Now().Unix(),
+		},
+	}
+
+	for i, waypoint := range mission.Waypoints {
+		imported.Waypoints = append(imported.Waypoints, models.Waypoint{
+			Name:      fmt.Sprintf("Interop %d-%d", mission.ID, i+1),
+			Latitude:  waypoint.Latitude,
+			Longitude: waypoint.Longitude,
+			Altitude:  altitude(waypoint.Altitude),
+			Remarks:   fmt.Sprintf("Waypoint %d of interop mission %d", i+1, mission.ID),
+		})
+	}
+
+	//Obstacles stand on the ground, so their height is not above sea level
+	for i, obstacle := range mission.StationaryObstacles {
+		imported.Obstacles = append(imported.Obstacles, models.Waypoint{
+			Name:        fmt.Sprintf("Interop %d obstacle %d", mission.ID, i+1),
+			Latitude:    obstacle.Latitude,
+			Longitude:   obstacle.Longitude,
+			Altitude:    obstacle.Height * MetresPerFoot,
+			Radius:      obstacle.Radius * MetresPerFoot,
+			Designation: models.Obstacle,
+			Remarks:     fmt.Sprintf("Stationary obstacle %d of interop mission %d", i+1, mission.ID),
+		})
+	}
+
+	for _, zone := range mission.FlyZones {
+		converted := models.FlyZone{
+			AltitudeMin: altitude(zone.AltitudeMin),
+			AltitudeMax: altitude(zone.AltitudeMax),
+			Boundary:    []models.GeoPoint{},
+		}
+		for _, position := range zone.BoundaryPoints {
+			converted.Boundary = append(converted.Boundary, point(position))
+		}
+		imported.Details.FlyZones = append(imported.Details.FlyZones, converted)
+	}
+
+	for _, position := range mission.SearchGridPoints {
+		imported.Details.SearchGrid = append(imported.Details.SearchGrid, point(position))
+	}
+
+	return imported
+}
+
+// FromDrone converts a sample of drone status, adding homeAltitude to its
+// altitude to make it above mean sea level
+func FromDrone(drone models.Drone, homeAltitude float64) Telemetry {
+	return Telemetry{
+		Latitude:  drone.Latitude,
+		Longitude: drone.Longitude,
+		Altitude:  (drone.Altitude + homeAltitude) / MetresPerFoot,
+		Heading:   drone.Heading,
+	}
+}
+
+// enum converts one of our enums to interop's, such as white to WHITE
+func enum[T ~string](value T) string {
+	return strings.ToUpper(string(value))
+}
+
+// FromGroundObject converts a ground object for submission to a mission
+func FromGroundObject(mission int, object models.GroundObject) ODLC {
+	odlc := ODLC{
+		Mission:    mission,
+		Type:       enum(object.Type),
+		Latitude:   object.Latitude,
+		Longitude:  object.Longitude,
+		Autonomous: object.Autonomous,
+	}
+	if object.Type == models.Emergent {
+		odlc.Description = object.Description
+		return odlc
+	}
+
+	odlc.Orientation = enum(object.Orientation)
+	odlc.Shape = enum(object.Shape)
+	if object.Shape == models.QuarterCircle {
+		odlc.Shape = "QUARTER_CIRCLE"
+	}
+	odlc.ShapeColor = enum(object.Color)
+	odlc.Alphanumeric = object.Text
+	odlc.AlphanumericColor = enum(object.TextColor)
+	return odlc
+}
@@ -0,0 +1,37 @@
+package interop
+
+import "gorm.io/gorm"
+
+// Config describes the interop server and the mission being flown
+type Config struct {
+	URL      string
+	Username string
+	Password string
+	//ID of the mission on the interop server
+	Mission int
+	//Telemetry uploads a second, DefaultRate if 0
+	TelemetryRate float64
+	//Height of home above mean sea level in metres, see ImportMission
+	HomeAltitude float64
+}
+
+// Interop is everything needed to take part in the competition over interop
+type Interop struct {
+	Config    Config
+	Client    *Client
+	Uploader  *Uploader
+	Submitter *Submitter
+}
+
+// New creates a client, uploading telemetry from source and submitting
+// objects to the configured mission. Nothing is sent until the uploader is
+// started or objects are submitted.
+func New(db *gorm.DB, source Source, config Config) *Interop {
+	client := NewClient(config.URL, config.Username, config.Password)
+	return &Interop{
+		Config:    config,
+		Client:    client,
+		Uploader:  NewUploader(client, source, config.TelemetryRate, config.HomeAltitude),
+		Submitter: NewSubmitter(db, client, config.Mission),
+	}
+}
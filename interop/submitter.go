@@ -0,0 +1,128 @@
+package interop
+
+import (
+	"context"
+	"errors"
+	"gcom-backend/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Submitter submits ground objects to a mission on interop, recording each
+// submission so objects are only sent again when they change
+type Submitter struct {
+	db      *gorm.DB
+	client  *Client
+	mission int
+}
+
+// NewSubmitter creates a Submitter for the interop mission with the given ID
+func NewSubmitter(db *gorm.DB, client *Client, mission int) *Submitter {
+	return &Submitter{db: db, client: client, mission: mission}
+}
+
+// Mission returns the ID of the interop mission objects are submitted to
+func (s *Submitter) Mission() int {
+	return s.mission
+}
+
+// Submission returns the record of an object's submission, and whether there
+// is one
+func (s *Submitter) Submission(objectId int) (models.InteropSubmission, bool, error) {
+	var submission models.InteropSubmission
+	err := s.db.First(&submission, objectId).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.InteropSubmission{GroundObjectID: objectId}, false, nil
+	}
+	return submission, err == nil, err
+}
+
+// Submit sends an object, or its changes if it was sent before, then its PNG
+// thumbnail if interop doesn't have one yet. Objects which haven't changed
+// since they were sent with a thumbnail are left alone.
+func (s *Submitter) Submit(ctx context.Context, object models.GroundObject, thumbnail []byte) (models.InteropSubmission, error) {
+	submission, _, err := s.Submission(object.ID)
+	if err != nil {
+		return submission, err
+	}
+
+	odlc := FromGroundObject(s.mission, object)
+	onInterop := submission.InteropID != 0 && submission.Status != models.SubmissionWithdrawn
+	unchanged := onInterop && FromGroundObject(s.mission, submission.Submitted) == odlc
+	//Interop keeps an object's image when the object is updated
+	hasImage := onInterop && submission.Status == models.SubmissionComplete
+	if unchanged && (hasImage || thumbnail == nil) {
+		return submission, nil
+	}
+
+	if !unchanged {
+		var sent ODLC
+		if onInterop {
+			odlc.ID = submission.InteropID
+			sent, err = s.client.UpdateODLC(ctx, odlc)
+		} else {
+			sent, err = s.client.CreateODLC(ctx, odlc)
+		}
+		if err != nil {
+			return s.fail(submission, err)
+		}
+
+		submission.InteropID = sent.ID
+		submission.Submitted = object
+		if !hasImage {
+			submission.Status = models.SubmissionSubmitted
+		}
+		if submission.SubmittedAt == 0 {
+			submission.SubmittedAt = time.Now().Unix()
+		}
+	}
+
+	if thumbnail != nil && !hasImage {
+		if err := s.client.UploadODLCImage(ctx, submission.InteropID, thumbnail); err != nil {
+			return s.fail(submission, err)
+		}
+		submission.Status = models.SubmissionComplete
+	}
+
+	submission.Error = ""
+	return submission, s.save(&submission)
+}
+
+// Withdraw deletes an object which was submitted, such as one rejected on a
+// second look or merged into another
+func (s *Submitter) Withdraw(ctx context.Context, objectId int) (models.InteropSubmission, error) {
+	submission, found, err := s.Submission(objectId)
+	if err != nil || !found || submission.InteropID == 0 || submission.Status == models.SubmissionWithdrawn {
+		return submission, err
+	}
+
+	var statusErr *StatusError
+	if err := s.client.DeleteODLC(ctx, submission.InteropID); err != nil &&
+		!(errors.As(err, &statusErr) && statusErr.Status == 404) {
+		submission.Error = err.Error()
+		return submission, errors.Join(err, s.save(&submission))
+	}
+
+	submission.Status = models.SubmissionWithdrawn
+	submission.Error = ""
+	return submission, s.save(&submission)
+}
+
+// fail records why a submission failed. Objects already on interop keep
+// their status, as the last version sent is still there.
+func (s *Submitter) fail(submission models.InteropSubmission, err error) (models.InteropSubmission, error) {
+	if submission.Status == models.SubmissionWithdrawn {
+		submission.InteropID = 0
+	}
+	if submission.InteropID == 0 {
+		submission.Status = models.SubmissionFailed
+	}
+	submission.Error = err.Error()
+	return submission, errors.Join(err, s.save(&submission))
+}
+
+func (s *Submitter) save(submission *models.InteropSubmission) error {
+	submission.UpdatedAt = time.Now().Unix()
+	return s.db.Save(submission).Error
+}
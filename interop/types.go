@@ -0,0 +1,69 @@
+package interop
+
+// Types sent to and from the interop server. Altitudes, heights and radii
+// are in feet, altitudes above mean sea level.
+
+// Position is a point on the ground
+type Position struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// Waypoint is a point of the route to fly
+type Waypoint struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Altitude  float64 `json:"altitude"`
+}
+
+// FlyZone is an area the drone must stay inside
+type FlyZone struct {
+	AltitudeMin    float64    `json:"altitudeMin"`
+	AltitudeMax    float64    `json:"altitudeMax"`
+	BoundaryPoints []Position `json:"boundaryPoints"`
+}
+
+// StationaryObstacle is a cylinder standing on the ground
+type StationaryObstacle struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Radius    float64 `json:"radius"`
+	Height    float64 `json:"height"`
+}
+
+// Mission is what the drone has to do
+type Mission struct {
+	ID                   int                  `json:"id"`
+	LostCommsPos         Position             `json:"lostCommsPos"`
+	FlyZones             []FlyZone            `json:"flyZones"`
+	Waypoints            []Waypoint           `json:"waypoints"`
+	SearchGridPoints     []Position           `json:"searchGridPoints"`
+	OffAxisOdlcPos       Position             `json:"offAxisOdlcPos"`
+	EmergentLastKnownPos Position             `json:"emergentLastKnownPos"`
+	StationaryObstacles  []StationaryObstacle `json:"stationaryObstacles"`
+}
+
+// Telemetry is the position of the drone
+type Telemetry struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Altitude  float64 `json:"altitude"`
+	Heading   float64 `json:"heading"`
+}
+
+// ODLC is an object detected, localised and classified, with upper case
+// enums such as STANDARD, QUARTER_CIRCLE and WHITE
+type ODLC struct {
+	ID                int     `json:"id,omitempty"`
+	Mission           int     `json:"mission"`
+	Type              string  `json:"type"`
+	Latitude          float64 `json:"latitude"`
+	Longitude         float64 `json:"longitude"`
+	Orientation       string  `json:"orientation,omitempty"`
+	Shape             string  `json:"shape,omitempty"`
+	ShapeColor        string  `json:"shapeColor,omitempty"`
+	Alphanumeric      string  `json:"alphanumeric,omitempty"`
+	AlphanumericColor string  `json:"alphanumericColor,omitempty"`
+	Description       string  `json:"description,omitempty"`
+	Autonomous        bool    `json:"autonomous"`
+}
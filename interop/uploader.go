@@ -0,0 +1,156 @@
+package interop
+
+import (
+	"context"
+	"gcom-backend/models"
+	"sync"
+	"time"
+)
+
+var (
+	// DefaultRate is how many times a second telemetry is uploaded, above
+	// the 1Hz average interop requires
+	DefaultRate = 2.0
+
+	// StaleAfter is how many seconds old the latest sample can be and still
+	// be uploaded, so a lost link isn't hidden by resending the last position
+	StaleAfter int64 = 3
+
+	// RateWindow is how long the average upload rate is measured over
+	RateWindow = 10 * time.Second
+)
+
+// Source gives the latest drone status, such as the telemetry pipeline
+type Source interface {
+	Latest() (models.Drone, bool)
+	Replaying() bool
+}
+
+// UploadStatus describes how telemetry uploads are going
+//
+// @Description describes how telemetry uploads to interop are going
+type UploadStatus struct {
+	Running bool `json:"running" example:"true" extensions:"x-order=1"`
+	//Uploads per second, averaged over the last 10 seconds
+	Rate float64 `json:"rate" example:"2" extensions:"x-order=2"`
+	//Uploads which succeeded and failed since starting
+	Uploaded uint64 `json:"uploaded" example:"1200" extensions:"x-order=3"`
+	Failed   uint64 `json:"failed" example:"3" extensions:"x-order=4"`
+	//Why the last upload failed
+	LastError string `json:"last_error,omitempty" example:"interop responded 500 Internal Server Error" extensions:"x-order=5"`
+	//Timestamp of the last sample uploaded
+	LastUploaded int64 `json:"last_uploaded,omitempty" example:"1698544781" extensions:"x-order=6"`
+}
+
+// Uploader sends the latest live drone status to interop at a steady rate.
+// Nothing is sent while a flight is replayed or telemetry has stopped.
+type Uploader struct {
+	mu           sync.Mutex
+	client       *Client
+	source       Source
+	rate         float64
+	homeAltitude float64
+	stop         chan struct{}
+	done         chan struct{}
+	status       UploadStatus
+	uploads      []time.Time
+}
+
+// NewUploader creates an Uploader sending rate times a second, adding
+// homeAltitude to altitudes as FromDrone does
+func NewUploader(client *Client, source Source, rate float64, homeAltitude float64) *Uploader {
+	if rate <= 0 {
+		rate = DefaultRate
+	}
+	return &Uploader{client: client, source: source, rate: rate, homeAltitude: homeAltitude}
+}
+
+// Start starts uploading, if it isn't already
+func (u *Uploader) Start() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.stop != nil {
+		return
+	}
+	u.stop = make(chan struct{})
+	u.done = make(chan struct{})
+	u.status.Running = true
+	go u.run(u.stop, u.done)
+}
+
+// Stop stops uploading and waits for the upload in progress
+func (u *Uploader) Stop() {
+	u.mu.Lock()
+	if u.stop == nil {
+		u.mu.Unlock()
+		return
+	}
+	close(u.stop)
+	done := u.done
+	u.stop = nil
+	u.status.Running = false
+	u.mu.Unlock()
+
+	<-done
+}
+
+// Status returns how uploads are going
+func (u *Uploader) Status() UploadStatus {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	status := u.status
+	u.trim(time.Now())
+	status.Rate = float64(len(u.uploads)) / RateWindow.Seconds()
+	return status
+}
+
+// trim forgets uploads from before the rate window
+func (u *Uploader) trim(now time.Time) {
+	start := 0
+	for start < len(u.uploads) && now.Sub(u.uploads[start]) > RateWindow {
+		start++
+	}
+	u.uploads = u.uploads[start:]
+}
+
+func (u *Uploader) run(stop chan struct{}, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / u.rate))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			u.upload()
+		}
+	}
+}
+
+// upload sends the latest sample if it is live and recent
+func (u *Uploader) upload() {
+	drone, ok := u.source.Latest()
+	if !ok || u.source.Replaying() || time.Now().Unix()-drone.Timestamp > StaleAfter {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(float64(time.Second)/u.rate))
+	defer cancel()
+	err := u.client.UploadTelemetry(ctx, FromDrone(drone, u.homeAltitude))
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if err != nil {
+		u.status.Failed++
+		u.status.LastError = err.Error()
+		return
+	}
+	now := time.Now()
+	u.status.Uploaded++
+	u.status.LastUploaded = drone.Timestamp
+	u.uploads = append(u.uploads, now)
+	u.trim(now)
+}
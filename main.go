@@ -1,10 +1,22 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"gcom-backend/audit"
+	"gcom-backend/auth"
+	"gcom-backend/commands"
 	"gcom-backend/configs"
 	"gcom-backend/controllers"
 	_ "gcom-backend/docs"
+	"gcom-backend/events"
+	"gcom-backend/flight"
+	"gcom-backend/interop"
+	"gcom-backend/models"
+	"gcom-backend/processing"
+	"gcom-backend/progress"
+	"gcom-backend/replay"
+	"gcom-backend/telemetry"
 	"gcom-backend/util"
 	"log"
 	"os"
@@ -24,6 +36,11 @@ import (
 
 //	@host	localhost:1323
 
+//	@securityDefinitions.apikey	BearerAuth
+//	@in							header
+//	@name						Authorization
+//	@description				"Bearer " followed by a token from /auth/login
+
 //	@Accept		json
 //	@Produce	json
 //	@Tags		Waypoints
@@ -37,19 +54,79 @@ func main() {
 		return
 	}
 
-	mp, err := configs.ConnectMissionPlanner("http://host.docker.internal:9000")
+	mp, err := configs.ConnectAutopilot()
 	if err != nil {
 		log.Fatal("Error connecting to MPS")
 	}
 
+	bus := events.NewBus()
+	tracker := progress.NewTracker(db)
+	recorder := flight.NewRecorder(db)
+	pipeline := telemetry.NewPipeline(db, bus, tracker, recorder)
+	engine := replay.NewEngine(db, pipeline)
+	commander := commands.NewCommander(mp, bus, tracker, recorder)
+	commander.RequireConfirmation(commands.Arm, commands.Takeoff)
+
+	terrain, err := configs.TerrainFromEnv()
+	if err != nil {
+		log.Fatal("Error loading the DEM: ", err)
+	}
+	camera := configs.CameraFromEnv()
+
+	runner := processing.NewRunner(db, bus, camera, terrain, "imgs")
+	processors, workers := configs.ProcessorsFromEnv()
+	for _, processor := range processors {
+		runner.Register(processor)
+	}
+	if err := runner.Start(workers); err != nil {
+		log.Fatal("Error starting image processing: ", err)
+	}
+
+	//Telemetry is uploaded to the competition's interop server from the start
+	var connection *interop.Interop
+	if config, ok := configs.InteropFromEnv(); ok {
+		connection = interop.New(db, pipeline, config)
+		if err := connection.Client.Login(context.Background()); err != nil {
+			fmt.Printf("[INTEROP] Unable to log in, retrying with each request: %v\n", err)
+		}
+		connection.Uploader.Start()
+	}
+
+	authenticator := auth.NewAuthenticator(db)
+	auditLog := audit.NewLog(db)
+	password, err := authenticator.Bootstrap()
+	if err != nil {
+		log.Fatal("Error creating the first admin: ", err)
+	} else if password != "" {
+		fmt.Println("[AUTH] Created user \"admin\" with password " + password + ", change it or set ADMIN_PASSWORD")
+	}
+
+	//The simulator produces its own telemetry instead of MPS sending it over the socket
+	if simulator, ok := mp.(*configs.Simulator); ok {
+		simulator.Start(pipeline.Ingest, nil)
+	}
+
 	e := echo.New()
 	e.Use(middleware.CORS())
 
 	e.Use(util.DBMiddleware(db))
+	e.Use(util.AuthenticatorMiddleware(authenticator))
+	e.Use(util.AuditMiddleware(auditLog))
+	e.Use(util.EventsMiddleware(bus))
 	e.Use(util.MPMiddleware(mp))
+	e.Use(util.CommandsMiddleware(commander))
+	e.Use(util.ProgressMiddleware(tracker))
+	e.Use(util.FlightMiddleware(recorder))
+	e.Use(util.TelemetryMiddleware(pipeline))
+	e.Use(util.ReplayMiddleware(engine))
+	e.Use(util.CameraMiddleware(camera))
+	e.Use(util.TerrainMiddleware(terrain))
+	e.Use(util.ProcessingMiddleware(runner))
+	e.Use(util.InteropMiddleware(connection))
 	e.Use(middleware.CORS())
 	e.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
-		Format: "${time_rfc3339} method=${method} uri=${uri} status=${status} ping=${latency_human}\n",
+		Format:        util.RequestLogFormat,
+		CustomTagFunc: util.RedactURI,
 	}))
 
 	e.GET("/", func(c echo.Context) error {
@@ -59,44 +136,131 @@ func main() {
 	//Swagger Docs
 	e.GET("/swagger/*", echoSwagger.WrapHandler)
 
+	//Every other route needs a token for a user with at least the group's role
+	viewer := e.Group("", util.AuthMiddleware(authenticator, models.Viewer))
+	operator := e.Group("", util.AuthMiddleware(authenticator, models.Operator))
+	pilot := e.Group("", util.AuthMiddleware(authenticator, models.PilotInCommand))
+	admin := e.Group("", util.AuthMiddleware(authenticator, models.Admin))
+	//EventSource can't set headers, so event streams also take the token query param
+	streams := e.Group("", util.StreamAuthMiddleware(authenticator, models.Viewer))
+
+	//Auth
+	e.POST("/auth/login", controllers.Login)
+	viewer.GET("/auth/me", controllers.GetCurrentUser)
+	admin.GET("/users", controllers.GetAllUsers)
+	admin.POST("/user", controllers.CreateUser)
+	admin.PATCH("/user/:userId", controllers.EditUser)
+	admin.DELETE("/user/:userId", controllers.DeleteUser)
+	admin.GET("/user/:userId/tokens", controllers.GetUserTokens)
+	admin.POST("/user/:userId/token", controllers.CreateUserToken)
+	admin.DELETE("/token/:tokenId", controllers.DeleteToken)
+
+	//Audit
+	admin.GET("/audit", controllers.GetAuditLog)
+	admin.GET("/audit/export", controllers.ExportAuditLog)
+
 	//Waypoints
-	e.POST("/waypoint", controllers.CreateWaypoint)
-	e.POST("/waypoints", controllers.CreateWaypointBatch)
-	e.PATCH("/waypoint/:waypointId", controllers.EditWaypoint)
-	e.GET("/waypoint/:waypointId", controllers.GetWaypoint)
-	e.DELETE("/waypoint/:waypointId", controllers.DeleteWaypoint)
-	e.DELETE("/waypoints", controllers.DeleteWaypointBatch)
-	e.GET("/waypoints", controllers.GetAllWaypoints)
+	operator.POST("/waypoint", controllers.CreateWaypoint)
+	operator.POST("/waypoints", controllers.CreateWaypointBatch)
+	operator.PATCH("/waypoint/:waypointId", controllers.EditWaypoint)
+	viewer.GET("/waypoint/:waypointId", controllers.GetWaypoint)
+	operator.DELETE("/waypoint/:waypointId", controllers.DeleteWaypoint)
+	operator.DELETE("/waypoints", controllers.DeleteWaypointBatch)
+	viewer.GET("/waypoints", controllers.GetAllWaypoints)
+	operator.POST("/waypoints/import", controllers.ImportWaypoints)
+	viewer.GET("/waypoints/export", controllers.ExportWaypoints)
 
 	//Drone
-	e.GET("/status", controllers.GetCurrentStatus)
-	e.GET("/status/history", controllers.GetStatusHistory)
-	e.POST("/drone/takeoff", controllers.Takeoff)
-	e.GET("/drone/land", controllers.Land)
-	e.POST("/drone/rtl", controllers.RTL)
-	e.GET("/drone/lock", controllers.Lock)
-	e.GET("/drone/unlock", controllers.Unlock)
-	e.GET("/drone/queue", controllers.GetQueue)
-	e.POST("/drone/queue", controllers.PostQueue)
-	e.POST("/drone/home", controllers.PostHome)
-	e.POST("/drone/arm", controllers.Arm)
+	viewer.GET("/status", controllers.GetCurrentStatus)
+	viewer.GET("/status/history", controllers.GetStatusHistory)
+	pilot.POST("/drone/takeoff", controllers.Takeoff)
+	pilot.GET("/drone/land", controllers.Land)
+	pilot.POST("/drone/rtl", controllers.RTL)
+	pilot.GET("/drone/lock", controllers.Lock)
+	pilot.GET("/drone/unlock", controllers.Unlock)
+	viewer.GET("/drone/queue", controllers.GetQueue)
+	pilot.POST("/drone/queue", controllers.PostQueue)
+	pilot.POST("/drone/home", controllers.PostHome)
+	pilot.POST("/drone/arm", controllers.Arm)
+	viewer.GET("/drone/command/:commandId", controllers.GetCommand)
+	viewer.GET("/drone/commands/pending", controllers.GetPendingCommands)
+	pilot.POST("/drone/command/:commandId/confirm", controllers.ConfirmCommand)
+	pilot.POST("/drone/command/:commandId/reject", controllers.RejectCommand)
+
+	//Missions
+	operator.POST("/mission", controllers.CreateMission)
+	operator.PATCH("/mission/:missionId", controllers.EditMission)
+	viewer.GET("/mission/:missionId", controllers.GetMission)
+	operator.DELETE("/mission/:missionId", controllers.DeleteMission)
+	viewer.GET("/missions", controllers.GetAllMissions)
+	operator.POST("/mission/:missionId/duplicate", controllers.DuplicateMission)
+	pilot.POST("/mission/:missionId/activate", controllers.ActivateMission)
+	viewer.GET("/mission/:missionId/versions", controllers.GetMissionVersions)
+	viewer.GET("/mission/:missionId/diff", controllers.DiffMissionVersions)
+	operator.POST("/mission/:missionId/versions/:version/restore", controllers.RestoreMissionVersion)
+	viewer.GET("/mission/progress", controllers.GetMissionProgress)
+
+	//Flights
+	viewer.GET("/flights", controllers.GetAllFlights)
+	viewer.GET("/flight/:flightId", controllers.GetFlight)
+	viewer.GET("/flight/:flightId/export", controllers.ExportFlight)
+
+	//Replay
+	operator.POST("/replay", controllers.StartReplay)
+	viewer.GET("/replay", controllers.GetReplay)
+	operator.DELETE("/replay", controllers.StopReplay)
+	operator.POST("/replay/pause", controllers.PauseReplay)
+	operator.POST("/replay/resume", controllers.ResumeReplay)
+	operator.POST("/replay/seek", controllers.SeekReplay)
+	operator.POST("/replay/speed", controllers.SetReplaySpeed)
+
+	//Events
+	streams.GET("/events", controllers.StreamEvents)
+	viewer.GET("/events/metrics", controllers.GetEventMetrics)
 
 	//Ground Objects
-	e.POST("/groundobject", controllers.CreateGroundObject)
-	e.POST("/groundobjects", controllers.CreateGroundObjectBatch)
-	e.PATCH("/groundobject/:objectId", controllers.EditGroundObject)
-	e.GET("/groundobject/:objectId", controllers.GetGroundObject)
-	e.DELETE("/groundobject/:objectId", controllers.DeleteGroundObject)
-	e.DELETE("/groundobjects", controllers.DeleteGroundObjectBatch)
-	e.GET("/groundobjects", controllers.GetAllGroundObjects)
+	operator.POST("/groundobject", controllers.CreateGroundObject)
+	operator.POST("/groundobjects", controllers.CreateGroundObjectBatch)
+	operator.PATCH("/groundobject/:objectId", controllers.EditGroundObject)
+	viewer.GET("/groundobject/:objectId", controllers.GetGroundObject)
+	operator.DELETE("/groundobject/:objectId", controllers.DeleteGroundObject)
+	operator.DELETE("/groundobjects", controllers.DeleteGroundObjectBatch)
+	viewer.GET("/groundobjects", controllers.GetAllGroundObjects)
+	viewer.GET("/groundobjects/export", controllers.ExportGroundObjects)
+	viewer.GET("/groundobjects/schema", controllers.GetGroundObjectSchema)
+	viewer.GET("/groundobjects/review", controllers.GetReviewQueue)
+	operator.POST("/groundobject/:objectId/approve", controllers.ApproveGroundObject)
+	operator.POST("/groundobject/:objectId/reject", controllers.RejectGroundObject)
+	viewer.GET("/groundobjects/clusters", controllers.GetMergeProposals)
+	viewer.GET("/groundobjects/merges", controllers.GetGroundObjectMerges)
+	operator.POST("/groundobjects/merge", controllers.MergeGroundObjects)
+	operator.POST("/groundobjects/merge/:mergeId/split", controllers.SplitGroundObjectMerge)
+	viewer.GET("/groundobject/:objectId/detections", controllers.GetGroundObjectDetections)
+	viewer.GET("/groundobject/:objectId/thumbnail", controllers.GetGroundObjectThumbnail)
+
+	//Detections of ground objects in images
+	operator.POST("/detection", controllers.CreateDetection)
+	operator.DELETE("/detection/:detectionId", controllers.DeleteDetection)
 
 	//Image Handling
-	e.POST("/image", controllers.UploadImage)
-	e.GET("/image/list", controllers.ListImages)
-	e.GET("/image/:filename", controllers.GetImage)
+	operator.POST("/image", controllers.UploadImage)
+	viewer.GET("/image/list", controllers.ListImages)
+	viewer.GET("/image/:filename", controllers.GetImage)
+	viewer.GET("/image/:filename/detections", controllers.GetImageDetections)
+	viewer.GET("/image/:filename/jobs", controllers.GetImageJobs)
+	operator.POST("/image/:filename/locate", controllers.LocatePixel)
+
+	//Competition interop server
+	viewer.GET("/interop", controllers.GetInteropStatus)
+	operator.POST("/interop/telemetry/start", controllers.StartInteropTelemetry)
+	operator.POST("/interop/telemetry/stop", controllers.StopInteropTelemetry)
+	operator.POST("/interop/mission", controllers.ImportInteropMission)
+	viewer.GET("/interop/mission", controllers.GetInteropMission)
+	operator.POST("/interop/submit", controllers.SubmitGroundObjects)
+	viewer.GET("/interop/submissions", controllers.GetInteropSubmissions)
 
-	//Websockets
-	e.Any("/socket.io/", controllers.WebsocketHandler())
+	//Websockets, which check tokens in their handshake
+	e.Any("/socket.io/", controllers.WebsocketHandler(pipeline, bus, commander, authenticator, auditLog))
 
 	e.Logger.Fatal(e.Start("0.0.0.0:1323"))
 }
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrAppendOnly is returned when something tries to change the audit log
+var ErrAppendOnly = errors.New("audit entries cannot be changed or deleted")
+
+// AuditEntry records a state-changing API call or drone command. Entries are
+// only ever added, never changed or deleted.
+//
+// @Description records a state-changing API call or drone command
+type AuditEntry struct {
+	ID int `json:"id" gorm:"primaryKey" example:"1" extensions:"x-order=1"`
+	//Unix time the call was made
+	Time int64 `json:"time" gorm:"index" example:"1698544781" extensions:"x-order=2"`
+	//Username of whoever made the call, empty if they were not signed in
+	Actor string `json:"actor" gorm:"index" example:"pilot" extensions:"x-order=3"`
+	//HTTP method, or socket.io for socket.io events
+	Method string `json:"method" example:"PATCH" extensions:"x-order=4"`
+	//Route as registered, such as /waypoint/:waypointId, or the socket.io event
+	Route string `json:"route" gorm:"index" example:"/waypoint/:waypointId" extensions:"x-order=5"`
+	//Kind of entity changed, such as waypoint or command, empty if none was
+	EntityType string `json:"entity_type,omitempty" gorm:"index" example:"waypoint" extensions:"x-order=6"`
+	EntityID   string `json:"entity_id,omitempty" gorm:"index" example:"1" extensions:"x-order=7"`
+	//Fields which changed, each with its value before and after
+	Diff     json.RawMessage `json:"diff,omitempty" swaggertype:"object" extensions:"x-order=8"`
+	ClientIP string          `json:"client_ip" example:"192.168.1.20" extensions:"x-order=9"`
+	//HTTP status of the response, 0 for socket.io events
+	Status int `json:"status" example:"200" extensions:"x-order=10"`
+	//What MPS made of a drone command, its status and any error
+	Result string `json:"result,omitempty" example:"completed" extensions:"x-order=11"`
+}
+
+// BeforeUpdate keeps entries from being changed
+func (AuditEntry) BeforeUpdate(*gorm.DB) error {
+	return ErrAppendOnly
+}
+
+// BeforeDelete keeps entries from being deleted
+func (AuditEntry) BeforeDelete(*gorm.DB) error {
+	return ErrAppendOnly
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"fmt"
+	"image"
+)
+
+// Detection links a GroundObject to the Image it was seen in
+//
+// @Description describes where in an image a ground object was seen
+type Detection struct {
+	//To create a detection, ID of "-1" must be passed
+	ID             int `json:"id,string" validate:"required" gorm:"primaryKey" example:"1" extensions:"x-order=1"`
+	GroundObjectID int `json:"ground_object_id" validate:"required" gorm:"index" example:"1" extensions:"x-order=2"`
+	//Filename of the Image
+	Image string `json:"image" validate:"required" gorm:"index" example:"1714898050.png" extensions:"x-order=3"`
+	//Bounding box in pixels, from the top left corner of the image
+	X      int `json:"x" example:"120" extensions:"x-order=4"`
+	Y      int `json:"y" example:"80" extensions:"x-order=5"`
+	Width  int `json:"width" example:"64" extensions:"x-order=6"`
+	Height int `json:"height" example:"64" extensions:"x-order=7"`
+	//How sure the detector is of the detection, from 0 to 1
+	Confidence float64 `json:"confidence" example:"0.87" extensions:"x-order=8"`
+	//Unix time of the detection, the time it was created if not given
+	Timestamp int64 `json:"timestamp" example:"1714898052" extensions:"x-order=9"`
+}
+
+// Bounds returns the bounding box as a rectangle
+func (d Detection) Bounds() image.Rectangle {
+	return image.Rect(d.X, d.Y, d.X+d.Width, d.Y+d.Height)
+}
+
+// Validate checks the bounding box has a size and lies below and right of
+// the top left corner, and the confidence is from 0 to 1
+func (d Detection) Validate() error {
+	if d.X < 0 || d.Y < 0 {
+		return fmt.Errorf("bounding box corner (%d, %d) must not be negative", d.X, d.Y)
+	}
+	if d.Width <= 0 || d.Height <= 0 {
+		return fmt.Errorf("bounding box %dx%d must have a width and height", d.Width, d.Height)
+	}
+	if d.Confidence < 0 || d.Confidence > 1 {
+		return fmt.Errorf("confidence %v must be from 0 to 1", d.Confidence)
+	}
+	return nil
+}
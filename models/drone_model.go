@@ -4,7 +4,7 @@ package models
 //
 // @Description describes the drone being flown
 type Drone struct {
-	Timestamp     int64   `json:"timestamp" gorm:"primaryKey" validate:"required" example:"1698544781" extensions:"x-order=1"`
+	Timestamp     int64   `json:"timestamp" gorm:"primaryKey;autoIncrement:false" validate:"required" example:"1698544781" extensions:"x-order=1"`
 	Latitude      float64 `json:"latitude" validate:"required" example:"49.267941" extensions:"x-order=2"`
 	Longitude     float64 `json:"longitude" validate:"required" example:"-123.247360" extensions:"x-order=3"`
 	Altitude      float64 `json:"altitude" validate:"required" example:"100.00" extensions:"x-order=4"`
@@ -0,0 +1,23 @@
+package models
+
+// FlightSession describes a flight from arming to disarming the drone
+//
+// @Description describes a flight from arming to disarming the drone
+type FlightSession struct {
+	ID int `json:"id" gorm:"primaryKey" example:"1" extensions:"x-order=1"`
+	//Unix time the drone was armed
+	StartedAt int64 `json:"started_at" example:"1698544700" extensions:"x-order=2"`
+	//Unix time the drone was disarmed, 0 while the flight is in progress
+	EndedAt int64 `json:"ended_at" example:"1698545300" extensions:"x-order=3"`
+	//Number of archived telemetry samples
+	Samples int64 `json:"samples" gorm:"-" example:"600" extensions:"x-order=4"`
+}
+
+// FlightSample is a telemetry sample archived as part of a FlightSession,
+// keyed by its session and timestamp so sessions can overlap in time
+//
+// @Description a telemetry sample archived as part of a flight session
+type FlightSample struct {
+	Drone
+	SessionID int `json:"session_id" gorm:"primaryKey;autoIncrement:false;index" example:"1" extensions:"x-order=10"`
+}
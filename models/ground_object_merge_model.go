@@ -0,0 +1,47 @@
+package models
+
+// GroundObjectMerge records ground objects being merged into one, so the
+// merge can be split again
+//
+// @Description records ground objects merged into one, and whether it was split
+type GroundObjectMerge struct {
+	ID int `json:"id" gorm:"primaryKey" example:"1" extensions:"x-order=1"`
+	//Object the others were merged into
+	KeptID int `json:"kept_id" gorm:"index" example:"1" extensions:"x-order=2"`
+	//Objects merged into the kept one, hidden from lists until the merge is split
+	MergedIDs []int `json:"merged_ids" gorm:"serializer:json" example:"2,3" extensions:"x-order=3"`
+	//Every object as it was before the merge, the kept one first
+	Before []GroundObject `json:"before" gorm:"serializer:json" extensions:"x-order=4"`
+	//Detections moved to the kept object and where they came from
+	MovedDetections []MovedDetection `json:"moved_detections" gorm:"serializer:json" extensions:"x-order=5"`
+	Author          string           `json:"author" example:"operator" extensions:"x-order=6"`
+	CreatedAt       int64            `json:"created_at" example:"1698544781" extensions:"x-order=7"`
+	//Who split the merge and when, empty until it is split
+	SplitBy string `json:"split_by,omitempty" example:"operator" extensions:"x-order=8"`
+	SplitAt int64  `json:"split_at,omitempty" example:"1698544900" extensions:"x-order=9"`
+}
+
+// MovedDetection records a Detection moved to another object by a merge
+//
+// @Description a detection moved by a merge
+type MovedDetection struct {
+	DetectionID int `json:"detection_id" example:"4" extensions:"x-order=1"`
+	FromID      int `json:"from_id" example:"2" extensions:"x-order=2"`
+}
+
+// MergeProposal suggests ground objects which look like the same target
+//
+// @Description ground objects which look like the same target, and the object merging them would give
+type MergeProposal struct {
+	//Object the others would be merged into, the most confident
+	KeepID    int   `json:"keep_id" example:"1" extensions:"x-order=1"`
+	ObjectIDs []int `json:"object_ids" example:"1,2,3" extensions:"x-order=2"`
+	//Position averaged over the objects, weighted by their confidence
+	Latitude   float64 `json:"lat" example:"49.267941" extensions:"x-order=3"`
+	Longitude  float64 `json:"long" example:"-123.247360" extensions:"x-order=4"`
+	Confidence float64 `json:"confidence" example:"0.9" extensions:"x-order=5"`
+	//Furthest any of the objects is from the averaged position, in metres
+	Spread float64 `json:"spread" example:"4.2" extensions:"x-order=6"`
+	//Detections of all the objects
+	Detections int `json:"detections" example:"5" extensions:"x-order=7"`
+}
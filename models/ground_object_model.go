@@ -1,5 +1,14 @@
 package models
 
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
 type ObjectType string
 
 const (
@@ -7,31 +16,72 @@ const (
 	Emergent ObjectType = "emergent"
 )
 
+// ObjectTypes lists every valid ObjectType
+var ObjectTypes = []ObjectType{Standard, Emergent}
+
 type Color string
 
 const (
-	Black  = "black"
-	Red    = "red"
-	Blue   = "blue"
-	Green  = "green"
-	Purple = "purple"
-	Brown  = "brown"
-	Orange = "orange"
+	White  Color = "white"
+	Black  Color = "black"
+	Red    Color = "red"
+	Blue   Color = "blue"
+	Green  Color = "green"
+	Purple Color = "purple"
+	Brown  Color = "brown"
+	Orange Color = "orange"
 )
 
+// Colors lists every valid Color
+var Colors = []Color{White, Black, Red, Blue, Green, Purple, Brown, Orange}
+
 type Shape string
 
 const (
-	Circle        = "circle"
-	SemiCircle    = "semicircle"
-	QuarterCircle = "quartercircle"
-	Triangle      = "triangle"
-	Rectangle     = "rectangle"
-	Pentagon      = "pentagon"
-	Star          = "star"
-	Cross         = "cross"
+	Circle        Shape = "circle"
+	SemiCircle    Shape = "semicircle"
+	QuarterCircle Shape = "quartercircle"
+	Triangle      Shape = "triangle"
+	Rectangle     Shape = "rectangle"
+	Pentagon      Shape = "pentagon"
+	Star          Shape = "star"
+	Cross         Shape = "cross"
 )
 
+// Shapes lists every valid Shape
+var Shapes = []Shape{Circle, SemiCircle, QuarterCircle, Triangle, Rectangle, Pentagon, Star, Cross}
+
+// Orientation is the compass direction the top of a standard object's
+// alphanumeric faces
+type Orientation string
+
+const (
+	North     Orientation = "N"
+	NorthEast Orientation = "NE"
+	East      Orientation = "E"
+	SouthEast Orientation = "SE"
+	South     Orientation = "S"
+	SouthWest Orientation = "SW"
+	West      Orientation = "W"
+	NorthWest Orientation = "NW"
+)
+
+// Orientations lists every valid Orientation
+var Orientations = []Orientation{North, NorthEast, East, SouthEast, South, SouthWest, West, NorthWest}
+
+// ReviewStatus is where a GroundObject is in review. Only approved objects
+// are submitted.
+type ReviewStatus string
+
+const (
+	Pending  ReviewStatus = "pending"
+	Approved ReviewStatus = "approved"
+	Rejected ReviewStatus = "rejected"
+)
+
+// ReviewStatuses lists every valid ReviewStatus
+var ReviewStatuses = []ReviewStatus{Pending, Approved, Rejected}
+
 // GroundObject describes both emergent and standard targets.
 //
 // @Description describes targets in GCOM
@@ -40,8 +90,191 @@ type GroundObject struct {
 	Type      ObjectType `json:"object_type" validate:"required" example:"emergent" extensions:"x-order=2"`
 	Latitude  float64    `json:"lat" validate:"required" example:"49.267941" extensions:"x-order=3"`
 	Longitude float64    `json:"long" validate:"required" example:"-123.247360" extensions:"x-order=4"`
-	Shape     Shape      `json:shape example:"circle" extensions:"x-order=5"`
-	Color     Color      `json:color example:"black" extensions:"x-order=6"`
-	Text      string     `json:text example:"A" extensions:"x-order=7`
-	TextColor Color      `json:text_color example"white" extensions:"x-order=8"`
+	//Shape and colours of a standard object
+	Shape Shape `json:"shape,omitempty" example:"circle" extensions:"x-order=5"`
+	Color Color `json:"color,omitempty" example:"black" extensions:"x-order=6"`
+	//Alphanumeric of a standard object, a single upper case letter or digit
+	Text        string      `json:"text,omitempty" example:"A" extensions:"x-order=7"`
+	TextColor   Color       `json:"text_color,omitempty" example:"white" extensions:"x-order=8"`
+	Orientation Orientation `json:"orientation,omitempty" example:"NE" extensions:"x-order=9"`
+	//What an emergent object is, such as a person lying down
+	Description string `json:"description,omitempty" example:"Mannequin lying on a blanket" extensions:"x-order=10"`
+	//Whether the object was detected without a person's help
+	Autonomous bool `json:"autonomous" example:"false" extensions:"x-order=11"`
+	//How sure whoever detected the object is of it, from 0 to 1
+	Confidence float64 `json:"confidence" example:"0.9" extensions:"x-order=12"`
+	//ID of the object this was merged into, set by merging and hidden from lists while set
+	MergedInto int `json:"merged_into,omitempty" gorm:"index" example:"0" extensions:"x-order=13"`
+	//Set by approving or rejecting the object. Objects detected autonomously start pending, others approved.
+	ReviewStatus ReviewStatus `json:"review_status" gorm:"index" example:"pending" extensions:"x-order=14"`
+	Reviewer     string       `json:"reviewer,omitempty" example:"operator" extensions:"x-order=15"`
+	ReviewNotes  string       `json:"review_notes,omitempty" example:"Letter could be an O" extensions:"x-order=16"`
+	//Unix time of the review
+	ReviewedAt int64 `json:"reviewed_at,omitempty" example:"1698544781" extensions:"x-order=17"`
+}
+
+// oneOf checks value is one of allowed, describing the allowed values if not
+func oneOf[T ~string](field string, value T, allowed []T) error {
+	names := make([]string, len(allowed))
+	for i, option := range allowed {
+		if value == option {
+			return nil
+		}
+		names[i] = string(option)
+	}
+	return fmt.Errorf("%s %q must be one of %s", field, value, strings.Join(names, ", "))
+}
+
+// ValidateEnums checks the type, shape, colours, orientation and review
+// status which are set are among the allowed values
+func (o GroundObject) ValidateEnums() error {
+	if o.Type != "" {
+		if err := oneOf("object_type", o.Type, ObjectTypes); err != nil {
+			return err
+		}
+	}
+	if o.Shape != "" {
+		if err := oneOf("shape", o.Shape, Shapes); err != nil {
+			return err
+		}
+	}
+	if o.Color != "" {
+		if err := oneOf("color", o.Color, Colors); err != nil {
+			return err
+		}
+	}
+	if o.TextColor != "" {
+		if err := oneOf("text_color", o.TextColor, Colors); err != nil {
+			return err
+		}
+	}
+	if o.Orientation != "" {
+		if err := oneOf("orientation", o.Orientation, Orientations); err != nil {
+			return err
+		}
+	}
+	if o.ReviewStatus != "" {
+		if err := oneOf("review_status", o.ReviewStatus, ReviewStatuses); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks the enum fields, then the fields each type of object needs
+// or can't have. Standard objects need a shape, colour and alphanumeric with
+// its colour, emergent objects need a description instead.
+func (o GroundObject) Validate() error {
+	if err := o.ValidateEnums(); err != nil {
+		return err
+	}
+	if o.Confidence < 0 || o.Confidence > 1 {
+		return fmt.Errorf("confidence %v must be from 0 to 1", o.Confidence)
+	}
+
+	var missing, unexpected []string
+	switch o.Type {
+	case Standard:
+		for field, value := range map[string]string{
+			"shape": string(o.Shape), "color": string(o.Color), "text": o.Text, "text_color": string(o.TextColor),
+		} {
+			if value == "" {
+				missing = append(missing, field)
+			}
+		}
+		if o.Text != "" && !alphanumeric.MatchString(o.Text) {
+			return fmt.Errorf("text %q must be a single upper case letter or digit", o.Text)
+		}
+	case Emergent:
+		if strings.TrimSpace(o.Description) == "" {
+			missing = append(missing, "description")
+		}
+		for field, value := range map[string]string{
+			"shape": string(o.Shape), "color": string(o.Color), "text": o.Text, "text_color": string(o.TextColor),
+			"orientation": string(o.Orientation),
+		} {
+			if value != "" {
+				unexpected = append(unexpected, field)
+			}
+		}
+	}
+
+	//Sorted so errors read the same every time
+	sort.Strings(missing)
+	sort.Strings(unexpected)
+	if len(missing) > 0 {
+		return fmt.Errorf("%s objects need %s", o.Type, strings.Join(missing, ", "))
+	}
+	if len(unexpected) > 0 {
+		return fmt.Errorf("%s objects can't have %s", o.Type, strings.Join(unexpected, ", "))
+	}
+	return nil
+}
+
+// alphanumeric matches the alphanumeric of a standard object
+var alphanumeric = regexp.MustCompile(`^[A-Z0-9]$`)
+
+// legacyShapes maps spellings found in rows stored before values were
+// validated to the allowed values
+var legacyShapes = map[string]Shape{
+	"semi-circle":    SemiCircle,
+	"semi_circle":    SemiCircle,
+	"quarter-circle": QuarterCircle,
+	"quarter_circle": QuarterCircle,
+	"square":         Rectangle,
+	"plus":           Cross,
+}
+
+// normaliseEnum trims and lower cases a stored enum value
+func normaliseEnum(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+// migrateGroundObjects rewrites enum values stored before they were
+// validated, such as "Circle" or "semi-circle", so existing rows stay
+// readable and editable. Values which can't be recognised are left alone.
+// Objects stored before they were reviewed are given a review status. Only
+// rows which may need rewriting are loaded, and all are saved or none are.
+func migrateGroundObjects(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var objects []GroundObject
+		err := tx.Where("review_status IS NULL OR review_status = ''").
+			Or("type <> '' AND type NOT IN ?", ObjectTypes).
+			Or("shape <> '' AND shape NOT IN ?", Shapes).
+			Or("color <> '' AND color NOT IN ?", Colors).
+			Or("text_color <> '' AND text_color NOT IN ?", Colors).
+			Find(&objects).Error
+		if err != nil {
+			return err
+		}
+
+		for _, object := range objects {
+			migrated := object
+			migrated.Type = ObjectType(normaliseEnum(string(object.Type)))
+			migrated.Shape = Shape(normaliseEnum(string(object.Shape)))
+			if shape, ok := legacyShapes[string(migrated.Shape)]; ok {
+				migrated.Shape = shape
+			}
+			migrated.Color = Color(normaliseEnum(string(object.Color)))
+			migrated.TextColor = Color(normaliseEnum(string(object.TextColor)))
+			//Objects stored before review were entered by operators, unless detected autonomously
+			if migrated.ReviewStatus == "" {
+				migrated.ReviewStatus = Approved
+				if migrated.Autonomous {
+					migrated.ReviewStatus = Pending
+				}
+			}
+
+			if migrated == object {
+				continue
+			}
+			if err := tx.Save(&migrated).Error; err != nil {
+				return err
+			}
+			if err := migrated.ValidateEnums(); err != nil {
+				fmt.Println("[MIGRATE] Ground object", object.ID, "has an unrecognised value:", err)
+			}
+		}
+		return nil
+	})
 }
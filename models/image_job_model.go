@@ -0,0 +1,34 @@
+package models
+
+// JobStatus is how far a processor has got with an image
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobRetrying  JobStatus = "retrying"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+)
+
+// ImageJob is one processor's run over an uploaded image
+//
+// @Description the status of a processor looking for ground objects in an image
+type ImageJob struct {
+	ID int `json:"id" gorm:"primaryKey" example:"1" extensions:"x-order=1"`
+	//Filename of the Image
+	Image string `json:"image" gorm:"index" example:"1714898050.png" extensions:"x-order=2"`
+	//Name of the processor
+	Processor string `json:"processor" example:"yolo" extensions:"x-order=3"`
+	//One of queued, running, retrying, succeeded or failed
+	Status JobStatus `json:"status" gorm:"index" example:"succeeded" extensions:"x-order=4"`
+	//How many times the processor has been run
+	Attempts int `json:"attempts" example:"1" extensions:"x-order=5"`
+	//Why the last attempt failed, or which results were skipped
+	Error string `json:"error,omitempty" example:"exit status 1" extensions:"x-order=6"`
+	//How many detections the processor's results created
+	Detections int `json:"detections" example:"2" extensions:"x-order=7"`
+	//Unix times the job was created and its status last changed
+	CreatedAt int64 `json:"created_at" example:"1714898051" extensions:"x-order=8"`
+	UpdatedAt int64 `json:"updated_at" example:"1714898053" extensions:"x-order=9"`
+}
@@ -3,4 +3,24 @@ package models
 type Image struct {
 	Timestamp int64  `json:"timestamp" gorm:"primaryKey" validate:"required" example:"1698544781" extensions:"x-order=1"`
 	Filename  string `json:"filename" example:"1714898050.png" extensions:"x-order=2"`
+	//Size of the image in pixels
+	Width  int `json:"width,omitempty" example:"6000" extensions:"x-order=3"`
+	Height int `json:"height,omitempty" example:"4000" extensions:"x-order=4"`
+	//Whether the pose and footprint were found, which needs telemetry from when the image was taken
+	Georeferenced bool `json:"georeferenced" example:"true" extensions:"x-order=5"`
+	//Drone pose when the image was taken, interpolated from telemetry
+	Latitude  float64 `json:"latitude,omitempty" example:"49.267941" extensions:"x-order=6"`
+	Longitude float64 `json:"longitude,omitempty" example:"-123.247360" extensions:"x-order=7"`
+	Altitude  float64 `json:"altitude,omitempty" example:"100.00" extensions:"x-order=8"`
+	Heading   float64 `json:"heading,omitempty" example:"298.12" extensions:"x-order=9"`
+	//Corners of the ground in the image, from the top left clockwise
+	Footprint []GeoPoint `json:"footprint,omitempty" gorm:"serializer:json" extensions:"x-order=10"`
+}
+
+// GeoPoint is a point on the ground in decimal degrees
+//
+// @Description a point on the ground
+type GeoPoint struct {
+	Latitude  float64 `json:"lat" example:"49.267941" extensions:"x-order=1"`
+	Longitude float64 `json:"long" example:"-123.247360" extensions:"x-order=2"`
 }
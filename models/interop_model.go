@@ -0,0 +1,68 @@
+package models
+
+// FlyZone is an area the drone must stay inside, between two altitudes
+//
+// @Description an area the drone must stay inside
+type FlyZone struct {
+	//Altitudes in metres, measured like waypoint altitudes
+	AltitudeMin float64 `json:"altitude_min" example:"30.48" extensions:"x-order=1"`
+	AltitudeMax float64 `json:"altitude_max" example:"228.6" extensions:"x-order=2"`
+	//Corners of the zone in order
+	Boundary []GeoPoint `json:"boundary" extensions:"x-order=3"`
+}
+
+// InteropMission is a mission fetched from the competition's interop server,
+// with the parts which don't fit in a Mission
+//
+// @Description a mission fetched from the competition's interop server
+type InteropMission struct {
+	//ID of the mission on the interop server
+	ID int `json:"id" gorm:"primaryKey;autoIncrement:false" example:"1" extensions:"x-order=1"`
+	//Mission created from the interop waypoints, in order
+	MissionID int `json:"mission_id" example:"3" extensions:"x-order=2"`
+	//Waypoints created for the stationary obstacles, with the obstacle designation
+	ObstacleIDs []int     `json:"obstacle_ids" gorm:"serializer:json" extensions:"x-order=3"`
+	FlyZones    []FlyZone `json:"fly_zones" gorm:"serializer:json" extensions:"x-order=4"`
+	//Corners of the area to search for ground objects
+	SearchGrid []GeoPoint `json:"search_grid" gorm:"serializer:json" extensions:"x-order=5"`
+	//Where the off-axis object is, and where the emergent object was last seen
+	OffAxisObject     GeoPoint `json:"off_axis_object" gorm:"serializer:json" extensions:"x-order=6"`
+	EmergentLastKnown GeoPoint `json:"emergent_last_known" gorm:"serializer:json" extensions:"x-order=7"`
+	//Where to fly if communication is lost
+	LostComms GeoPoint `json:"lost_comms" gorm:"serializer:json" extensions:"x-order=8"`
+	//Unix time the mission was fetched
+	FetchedAt int64 `json:"fetched_at" example:"1698544781" extensions:"x-order=9"`
+}
+
+// SubmissionStatus is how far a ground object's submission to interop got
+type SubmissionStatus string
+
+const (
+	// SubmissionFailed means the object could not be sent, see the error
+	SubmissionFailed SubmissionStatus = "failed"
+	// SubmissionSubmitted means the object was sent but not its thumbnail
+	SubmissionSubmitted SubmissionStatus = "submitted"
+	// SubmissionComplete means the object and its thumbnail were sent
+	SubmissionComplete SubmissionStatus = "complete"
+	// SubmissionWithdrawn means the object was deleted from interop after
+	// being rejected or merged into another
+	SubmissionWithdrawn SubmissionStatus = "withdrawn"
+)
+
+// InteropSubmission tracks a ground object submitted to interop
+//
+// @Description a ground object submitted to the competition's interop server
+type InteropSubmission struct {
+	GroundObjectID int `json:"ground_object_id" gorm:"primaryKey;autoIncrement:false" example:"1" extensions:"x-order=1"`
+	//ID of the object on the interop server, 0 until it is created
+	InteropID int `json:"interop_id" example:"12" extensions:"x-order=2"`
+	//One of failed, submitted, complete or withdrawn
+	Status SubmissionStatus `json:"status" example:"complete" extensions:"x-order=3"`
+	//Why the last attempt failed
+	Error string `json:"error,omitempty" example:"interop responded 400 Bad Request" extensions:"x-order=4"`
+	//The object as it was last sent, to resend it when it changes
+	Submitted GroundObject `json:"submitted" gorm:"serializer:json" extensions:"x-order=5"`
+	//Unix times it was first sent and last attempted
+	SubmittedAt int64 `json:"submitted_at,omitempty" example:"1698544781" extensions:"x-order=6"`
+	UpdatedAt   int64 `json:"updated_at" example:"1698544781" extensions:"x-order=7"`
+}
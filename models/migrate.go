@@ -9,8 +9,13 @@ import "gorm.io/gorm"
 */
 
 func Migrate(db *gorm.DB) {
-	err := db.AutoMigrate(&Waypoint{}, &Drone{}, &GroundObject{}, &Image{})
+	err := db.AutoMigrate(&Waypoint{}, &Drone{}, &GroundObject{}, &Image{}, &WaypointProgress{}, &Mission{}, &MissionWaypoint{}, &MissionVersion{}, &FlightSession{}, &FlightSample{}, &User{}, &Token{}, &AuditEntry{}, &Detection{}, &GroundObjectMerge{}, &ImageJob{}, &InteropMission{}, &InteropSubmission{})
 	if err != nil {
 		panic(err)
 	}
+
+	//Data migrations for rows stored by earlier versions
+	if err := migrateGroundObjects(db); err != nil {
+		panic(err)
+	}
 }
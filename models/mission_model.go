@@ -0,0 +1,40 @@
+package models
+
+// MissionStatus describes whether a Mission is the one uploaded to the drone
+//
+// @Description Describes whether a Mission is active
+type MissionStatus string
+
+const (
+	MissionDraft  MissionStatus = "draft"
+	MissionActive MissionStatus = "active"
+)
+
+// Mission describes a named, ordered route of waypoints
+//
+// @Description describes a named route of waypoints in GCOM
+type Mission struct {
+	//To create a mission, ID of "-1" must be passed
+	ID          int    `json:"id,string" validate:"required" gorm:"primaryKey" example:"1" extensions:"x-order=1"`
+	Name        string `json:"name" validate:"required" example:"Task 1 route" extensions:"x-order=2"`
+	Description string `json:"description,omitempty" example:"Waypoint lap for task 1" extensions:"x-order=3"`
+	//Status of the mission, set by activating it
+	Status MissionStatus `json:"status,omitempty" example:"draft" extensions:"x-order=4"`
+	//Latest MissionVersion, a new version is created whenever the waypoints change
+	Version int `json:"version,omitempty" example:"3" extensions:"x-order=5"`
+	//MissionVersion last uploaded to the drone
+	UploadedVersion int               `json:"uploaded_version,omitempty" example:"2" extensions:"x-order=6"`
+	Waypoints       []MissionWaypoint `json:"waypoints" validate:"dive" extensions:"x-order=7"`
+}
+
+// MissionWaypoint describes a reference to a Waypoint in a Mission
+//
+// @Description describes the position of a waypoint in a mission
+type MissionWaypoint struct {
+	ID         int `json:"-" gorm:"primaryKey"`
+	MissionID  int `json:"-" gorm:"index"`
+	Sequence   int `json:"sequence" example:"0" extensions:"x-order=1"`
+	WaypointID int `json:"waypoint_id" validate:"required" example:"1" extensions:"x-order=2"`
+	//Referenced waypoint, filled in when a mission is returned
+	Waypoint *Waypoint `json:"waypoint,omitempty" extensions:"x-order=3"`
+}
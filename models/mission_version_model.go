@@ -0,0 +1,66 @@
+package models
+
+// MissionVersion describes an immutable snapshot of a Mission's waypoints
+//
+// @Description describes a snapshot of a mission's waypoints at one point in time
+type MissionVersion struct {
+	ID        int `json:"-" gorm:"primaryKey"`
+	MissionID int `json:"mission_id" gorm:"index" example:"1" extensions:"x-order=1"`
+	//Versions are numbered from 1 for each mission
+	Version   int    `json:"version" example:"3" extensions:"x-order=2"`
+	Author    string `json:"author" example:"operator" extensions:"x-order=3"`
+	CreatedAt int64  `json:"created_at" example:"1698544781" extensions:"x-order=4"`
+	Reason    string `json:"reason,omitempty" example:"Mission edited" extensions:"x-order=5"`
+	//Unix time this version was last uploaded to the drone, 0 if never
+	UploadedAt int64                    `json:"uploaded_at,omitempty" example:"1698544790" extensions:"x-order=6"`
+	Waypoints  []MissionVersionWaypoint `json:"waypoints" gorm:"serializer:json" extensions:"x-order=7"`
+}
+
+// MissionVersionWaypoint describes a waypoint as it was when a MissionVersion was created
+//
+// @Description describes a waypoint in a mission snapshot
+type MissionVersionWaypoint struct {
+	Sequence int      `json:"sequence" example:"0" extensions:"x-order=1"`
+	Waypoint Waypoint `json:"waypoint" extensions:"x-order=2"`
+}
+
+// MissionDiff describes the differences between two MissionVersions
+//
+// @Description describes how a mission's waypoints changed between two versions
+type MissionDiff struct {
+	From    int                      `json:"from" example:"1" extensions:"x-order=1"`
+	To      int                      `json:"to" example:"3" extensions:"x-order=2"`
+	Added   []MissionVersionWaypoint `json:"added" extensions:"x-order=3"`
+	Removed []MissionVersionWaypoint `json:"removed" extensions:"x-order=4"`
+	Moved   []WaypointMove           `json:"moved" extensions:"x-order=5"`
+	Changed []WaypointChange         `json:"changed" extensions:"x-order=6"`
+}
+
+// WaypointMove describes a waypoint which changed position in a mission
+//
+// @Description describes a waypoint which was reordered
+type WaypointMove struct {
+	WaypointID int    `json:"waypoint_id" example:"1" extensions:"x-order=1"`
+	Name       string `json:"name" example:"Alpha" extensions:"x-order=2"`
+	//Positions in the ordered list of waypoints, starting at 0
+	From int `json:"from" example:"0" extensions:"x-order=3"`
+	To   int `json:"to" example:"2" extensions:"x-order=4"`
+}
+
+// WaypointChange describes the fields of a waypoint which were edited
+//
+// @Description describes a waypoint whose fields were edited
+type WaypointChange struct {
+	WaypointID int           `json:"waypoint_id" example:"1" extensions:"x-order=1"`
+	Name       string        `json:"name" example:"Alpha" extensions:"x-order=2"`
+	Fields     []FieldChange `json:"fields" extensions:"x-order=3"`
+}
+
+// FieldChange describes a single edited field
+//
+// @Description describes a single edited field by its JSON name
+type FieldChange struct {
+	Field string `json:"field" example:"alt" extensions:"x-order=1"`
+	From  any    `json:"from" extensions:"x-order=2"`
+	To    any    `json:"to" extensions:"x-order=3"`
+}
@@ -0,0 +1,60 @@
+package models
+
+// Role decides what a user is allowed to do
+type Role string
+
+// Roles from least to most privileged, each allowed everything the roles
+// before it are
+const (
+	//Can see everything but change nothing
+	Viewer Role = "viewer"
+	//Can edit waypoints, missions, ground objects and images
+	Operator Role = "operator"
+	//Can also command the drone
+	PilotInCommand Role = "pilot_in_command"
+	//Can also manage users and tokens
+	Admin Role = "admin"
+)
+
+var roleRanks = map[Role]int{Viewer: 1, Operator: 2, PilotInCommand: 3, Admin: 4}
+
+// Valid reports whether a role is one of the known roles
+func (r Role) Valid() bool {
+	return roleRanks[r] > 0
+}
+
+// Allows reports whether a user with this role may do what needs the
+// required role
+func (r Role) Allows(required Role) bool {
+	return r.Valid() && roleRanks[r] >= roleRanks[required]
+}
+
+// User describes someone who can sign in to GCOM
+//
+// @Description describes someone who can sign in to GCOM
+type User struct {
+	ID       int    `json:"id" gorm:"primaryKey" example:"1" extensions:"x-order=1"`
+	Username string `json:"username" gorm:"uniqueIndex" example:"pilot" extensions:"x-order=2"`
+	Role     Role   `json:"role" example:"operator" extensions:"x-order=3"`
+	//Disabled users cannot sign in and their tokens are refused
+	Disabled     bool   `json:"disabled" example:"false" extensions:"x-order=4"`
+	CreatedAt    int64  `json:"created_at" example:"1698544700" extensions:"x-order=5"`
+	PasswordHash string `json:"-"`
+}
+
+// Token describes an API token belonging to a User. Only a hash of the token
+// is stored, the token itself is shown once when it is created.
+//
+// @Description describes an API token, without the token itself
+type Token struct {
+	ID     int `json:"id" gorm:"primaryKey" example:"1" extensions:"x-order=1"`
+	UserID int `json:"user_id" gorm:"index" example:"1" extensions:"x-order=2"`
+	//What the token is for, such as "MPS" or "login"
+	Name string `json:"name" example:"MPS" extensions:"x-order=3"`
+	//The start of the token, to tell tokens apart
+	Prefix    string `json:"prefix" example:"gcom_3f9a" extensions:"x-order=4"`
+	CreatedAt int64  `json:"created_at" example:"1698544700" extensions:"x-order=5"`
+	//Unix time the token stops working, 0 if never
+	ExpiresAt int64  `json:"expires_at" example:"0" extensions:"x-order=6"`
+	Hash      string `json:"-" gorm:"uniqueIndex"`
+}
@@ -10,6 +10,7 @@ const (
 	Land     Designation = "land"
 	Obstacle Designation = "obstacle"
 	Payload  Designation = "payload"
+	Loiter   Designation = "loiter"
 )
 
 // Waypoint describes a location
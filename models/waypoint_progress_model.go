@@ -0,0 +1,19 @@
+package models
+
+// WaypointProgress describes the drone flying over a queued waypoint
+//
+// @Description records when and how accurately a queued waypoint was flown over
+type WaypointProgress struct {
+	ID int `json:"id" gorm:"primaryKey" example:"1" extensions:"x-order=1"`
+	//Unix time the queue was uploaded, groups records from the same run of a queue
+	Run        int64  `json:"run" example:"1698544700" extensions:"x-order=2"`
+	Sequence   int    `json:"sequence" example:"0" extensions:"x-order=3"`
+	WaypointID int    `json:"waypoint_id" example:"1" extensions:"x-order=4"`
+	Name       string `json:"name" example:"Alpha" extensions:"x-order=5"`
+	//Unix time of the closest approach to the waypoint
+	ReachedAt int64 `json:"reached_at" example:"1698544781" extensions:"x-order=6"`
+	//Closest horizontal distance to the waypoint in metres
+	Distance float64 `json:"distance" example:"2.41" extensions:"x-order=7"`
+	//Drone altitude minus waypoint altitude at the closest approach
+	AltitudeError float64 `json:"altitude_error" example:"-1.2" extensions:"x-order=8"`
+}
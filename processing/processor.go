@@ -0,0 +1,151 @@
+package processing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"gcom-backend/models"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+)
+
+// Input is what a processor is given to look at
+type Input struct {
+	Image models.Image `json:"image"`
+	//Absolute path of the image file
+	Path string `json:"path"`
+}
+
+// Result is a ground object a processor found in an image. The object is
+// placed at its lat and long if set, otherwise at the centre of the bounding
+// box, which needs the image to be georeferenced.
+type Result struct {
+	//Bounding box in pixels, from the top left corner of the image
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+	//How sure the processor is, from 0 to 1
+	Confidence float64 `json:"confidence"`
+	//Type, shape, colours, text or description of the object
+	Object models.GroundObject `json:"object"`
+}
+
+// Processor looks for ground objects in an image. Processors are run in
+// parallel and retried, so they must be safe to call concurrently and must
+// give up when ctx is done.
+type Processor interface {
+	Name() string
+	Process(ctx context.Context, input Input) ([]Result, error)
+}
+
+// Func is a processor written in Go
+type Func struct {
+	name string
+	fn   func(ctx context.Context, input Input) ([]Result, error)
+}
+
+// NewFunc creates a processor calling fn
+func NewFunc(name string, fn func(ctx context.Context, input Input) ([]Result, error)) *Func {
+	return &Func{name: name, fn: fn}
+}
+
+func (f *Func) Name() string {
+	return f.name
+}
+
+func (f *Func) Process(ctx context.Context, input Input) ([]Result, error) {
+	return f.fn(ctx, input)
+}
+
+// Command is a processor running an external program, which is given the
+// Input as JSON on stdin and writes a JSON list of Results to stdout
+type Command struct {
+	name string
+	path string
+	args []string
+}
+
+// NewCommand creates a processor running the program at path with args
+func NewCommand(name string, path string, args ...string) *Command {
+	return &Command{name: name, path: path, args: args}
+}
+
+func (p *Command) Name() string {
+	return p.name
+}
+
+func (p *Command) Process(ctx context.Context, input Input) ([]Result, error) {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.path, p.args...)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("%w: %s", err, message)
+		}
+		return nil, err
+	}
+
+	var results []Result
+	if err := json.Unmarshal(stdout.Bytes(), &results); err != nil {
+		return nil, fmt.Errorf("invalid output: %w", err)
+	}
+	return results, nil
+}
+
+// HTTP is a processor posting the Input as JSON to a detector, such as a
+// model served on the same machine, which replies with a JSON list of Results
+type HTTP struct {
+	name   string
+	url    string
+	client *http.Client
+}
+
+// NewHTTP creates a processor posting to url
+func NewHTTP(name string, url string) *HTTP {
+	return &HTTP{name: name, url: url, client: &http.Client{}}
+}
+
+func (p *HTTP) Name() string {
+	return p.name
+}
+
+func (p *HTTP) Process(ctx context.Context, input Input) ([]Result, error) {
+	body, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("detector responded %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+
+	var results []Result
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	return results, nil
+}
@@ -0,0 +1,307 @@
+package processing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gcom-backend/events"
+	"gcom-backend/imagery"
+	"gcom-backend/models"
+	"image"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// MaxAttempts is how many times a processor is run on an image before
+	// its job fails
+	MaxAttempts = 3
+
+	// RetryDelay is how long to wait after the first failed attempt, doubled
+	// after every other
+	RetryDelay = 2 * time.Second
+
+	// Timeout is how long a processor may take over one attempt
+	Timeout = time.Minute
+)
+
+// Runner runs every registered processor over each new image on a bounded
+// pool of workers. Jobs are stored so their status can be queried, and jobs
+// left unfinished by a restart of the backend are run when it starts.
+// Results become detections and ground objects waiting for review.
+type Runner struct {
+	mu         sync.Mutex
+	wake       *sync.Cond
+	db         *gorm.DB
+	bus        *events.Bus
+	camera     imagery.Camera
+	terrain    imagery.Terrain
+	directory  string
+	processors []Processor
+	queue      []int
+	stopped    bool
+	workers    sync.WaitGroup
+}
+
+// NewRunner creates a Runner for images in directory, located with the
+// camera and terrain. The bus may be nil.
+func NewRunner(db *gorm.DB, bus *events.Bus, camera imagery.Camera, terrain imagery.Terrain, directory string) *Runner {
+	r := &Runner{db: db, bus: bus, camera: camera, terrain: terrain, directory: directory}
+	r.wake = sync.NewCond(&r.mu)
+	return r
+}
+
+// Register adds a processor, which is run on images submitted afterwards
+func (r *Runner) Register(processor Processor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.processors = append(r.processors, processor)
+}
+
+// Processors lists the names of the registered processors
+func (r *Runner) Processors() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, len(r.processors))
+	for i, processor := range r.processors {
+		names[i] = processor.Name()
+	}
+	return names
+}
+
+func (r *Runner) processor(name string) Processor {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, processor := range r.processors {
+		if processor.Name() == name {
+			return processor
+		}
+	}
+	return nil
+}
+
+// Start queues unfinished jobs and starts the workers. It should only be
+// called once, after registering processors.
+func (r *Runner) Start(workers int) error {
+	var unfinished []models.ImageJob
+	if err := r.db.Where("status IN ?", []models.JobStatus{models.JobQueued, models.JobRunning, models.JobRetrying}).
+		Order("id").Find(&unfinished).Error; err != nil {
+		return err
+	}
+	for _, job := range unfinished {
+		r.enqueue(job.ID)
+	}
+
+	for i := 0; i < max(workers, 1); i++ {
+		r.workers.Add(1)
+		go r.work()
+	}
+	return nil
+}
+
+// Stop stops the workers once they finish their current jobs. Queued jobs
+// are kept and run when the backend next starts.
+func (r *Runner) Stop() {
+	r.mu.Lock()
+	r.stopped = true
+	r.wake.Broadcast()
+	r.mu.Unlock()
+
+	r.workers.Wait()
+}
+
+// Submit queues a job for every processor to look at an image
+func (r *Runner) Submit(img models.Image) ([]models.ImageJob, error) {
+	jobs := []models.ImageJob{}
+	for _, name := range r.Processors() {
+		jobs = append(jobs, models.ImageJob{Image: img.Filename, Processor: name, Status: models.JobQueued})
+	}
+	if len(jobs) == 0 {
+		return jobs, nil
+	}
+
+	if err := r.db.Create(&jobs).Error; err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		r.publish(events.Jobs, events.Queued, job)
+		r.enqueue(job.ID)
+	}
+	return jobs, nil
+}
+
+func (r *Runner) enqueue(id int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queue = append(r.queue, id)
+	r.wake.Signal()
+}
+
+func (r *Runner) publish(topic events.Topic, action events.Action, model any) {
+	if r.bus != nil {
+		r.bus.Publish(events.Event{Topic: topic, Action: action, Model: model})
+	}
+}
+
+func (r *Runner) work() {
+	defer r.workers.Done()
+
+	for {
+		r.mu.Lock()
+		for len(r.queue) == 0 && !r.stopped {
+			r.wake.Wait()
+		}
+		if r.stopped {
+			r.mu.Unlock()
+			return
+		}
+		id := r.queue[0]
+		r.queue = r.queue[1:]
+		r.mu.Unlock()
+
+		if err := r.run(id); err != nil {
+			fmt.Printf("[PROCESSING] Unable to run job %d: %v\n", id, err)
+		}
+	}
+}
+
+// save stores a job's new status and publishes it
+func (r *Runner) save(job *models.ImageJob, status models.JobStatus, message string) error {
+	job.Status = status
+	job.Error = message
+	if err := r.db.Save(job).Error; err != nil {
+		return err
+	}
+	r.publish(events.Jobs, events.Updated, *job)
+	return nil
+}
+
+// run makes one attempt at a job, scheduling another if it fails
+func (r *Runner) run(id int) error {
+	var job models.ImageJob
+	if err := r.db.First(&job, id).Error; err != nil {
+		return err
+	}
+
+	processor := r.processor(job.Processor)
+	if processor == nil {
+		return r.save(&job, models.JobFailed, "processor is no longer registered")
+	}
+	var img models.Image
+	if err := r.db.Where("filename = ?", job.Image).First(&img).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return r.save(&job, models.JobFailed, "image was deleted")
+	} else if err != nil {
+		return err
+	}
+	path, err := filepath.Abs(filepath.Join(r.directory, img.Filename))
+	if err != nil {
+		return err
+	}
+
+	job.Attempts++
+	if err := r.save(&job, models.JobRunning, ""); err != nil {
+		return err
+	}
+
+	results, err := r.process(processor, Input{Image: img, Path: path})
+	if err != nil {
+		if job.Attempts >= MaxAttempts {
+			return r.save(&job, models.JobFailed, err.Error())
+		}
+		time.AfterFunc(RetryDelay<<(job.Attempts-1), func() { r.enqueue(job.ID) })
+		return r.save(&job, models.JobRetrying, err.Error())
+	}
+
+	created, skipped := r.store(img, results)
+	job.Detections = created
+	return r.save(&job, models.JobSucceeded, strings.Join(skipped, "; "))
+}
+
+// process runs a processor with a timeout, turning a panic into an error
+func (r *Runner) process(processor Processor, input Input) (results []Result, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("processor panicked: %v", recovered)
+		}
+	}()
+	return processor.Process(ctx, input)
+}
+
+// store creates a detection and a ground object waiting for review for each
+// result, returning how many were created and why the others were skipped
+func (r *Runner) store(img models.Image, results []Result) (int, []string) {
+	created := 0
+	skipped := []string{}
+	for i, result := range results {
+		object, detection, err := r.object(img, result)
+		if err == nil {
+			err = r.db.Transaction(func(tx *gorm.DB) error {
+				if err := tx.Create(&object).Error; err != nil {
+					return err
+				}
+				detection.GroundObjectID = object.ID
+				return tx.Create(&detection).Error
+			})
+		}
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("result %d: %v", i, err))
+			continue
+		}
+
+		created++
+		r.publish(events.GroundObjects, events.Created, object)
+		r.publish(events.GroundObjects, events.Queued, object)
+	}
+	return created, skipped
+}
+
+// object checks a result, returning the ground object and detection to create
+func (r *Runner) object(img models.Image, result Result) (models.GroundObject, models.Detection, error) {
+	detection := models.Detection{Image: img.Filename, X: result.X, Y: result.Y, Width: result.Width,
+		Height: result.Height, Confidence: result.Confidence, Timestamp: time.Now().Unix()}
+	if err := detection.Validate(); err != nil {
+		return models.GroundObject{}, detection, err
+	}
+	if img.Width > 0 && img.Height > 0 && !detection.Bounds().In(image.Rect(0, 0, img.Width, img.Height)) {
+		return models.GroundObject{}, detection, fmt.Errorf("bounding box is outside the %dx%d image", img.Width, img.Height)
+	}
+
+	//Processors can only suggest what an object looks like and where it is
+	object := models.GroundObject{Type: result.Object.Type, Latitude: result.Object.Latitude,
+		Longitude: result.Object.Longitude, Shape: result.Object.Shape, Color: result.Object.Color,
+		Text: result.Object.Text, TextColor: result.Object.TextColor, Orientation: result.Object.Orientation,
+		Description: result.Object.Description, Autonomous: true, Confidence: result.Object.Confidence,
+		ReviewStatus: models.Pending}
+	if object.Confidence == 0 {
+		object.Confidence = result.Confidence
+	}
+	if object.Type == "" {
+		return object, detection, errors.New("object_type is required")
+	}
+
+	if object.Latitude == 0 && object.Longitude == 0 {
+		if !img.Georeferenced {
+			return object, detection, errors.New("no lat and long were given and the image is not georeferenced")
+		}
+		pose := models.Drone{Timestamp: img.Timestamp, Latitude: img.Latitude, Longitude: img.Longitude,
+			Altitude: img.Altitude, Heading: img.Heading}
+		centre := detection.Bounds().Min.Add(detection.Bounds().Size().Div(2))
+		location, err := r.camera.Project(pose, img.Width, img.Height, float64(centre.X), float64(centre.Y), r.terrain)
+		if err != nil {
+			return object, detection, err
+		}
+		object.Latitude = location.Latitude
+		object.Longitude = location.Longitude
+	}
+
+	return object, detection, object.Validate()
+}
@@ -0,0 +1,201 @@
+package progress
+
+import (
+	"fmt"
+	"gcom-backend/geo"
+	"gcom-backend/models"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DefaultRadius is used for queued waypoints which do not specify a Radius
+const DefaultRadius = 10.0
+
+// Waypoint states reported in a Snapshot
+const (
+	Pending = "pending"
+	Active  = "active"
+	Reached = "reached"
+)
+
+// WaypointState describes the progress of a single queued waypoint
+//
+// @Description progress of a single waypoint in the active queue
+type WaypointState struct {
+	Sequence int                      `json:"sequence" example:"0" extensions:"x-order=1"`
+	Waypoint models.Waypoint          `json:"waypoint" extensions:"x-order=2"`
+	State    string                   `json:"state" example:"reached" extensions:"x-order=3"`
+	Progress *models.WaypointProgress `json:"progress,omitempty" extensions:"x-order=4"`
+}
+
+// Snapshot describes the progress through the active queue
+//
+// @Description progress through the active queue
+type Snapshot struct {
+	Run       int64           `json:"run" example:"1698544700" extensions:"x-order=1"`
+	Reached   int             `json:"reached" example:"1" extensions:"x-order=2"`
+	Total     int             `json:"total" example:"4" extensions:"x-order=3"`
+	Waypoints []WaypointState `json:"waypoints" extensions:"x-order=4"`
+}
+
+// Tracker compares live telemetry against the active queue and records when
+// each waypoint is flown over. Waypoints are expected to be reached in order.
+type Tracker struct {
+	mu      sync.Mutex
+	db      *gorm.DB
+	run     int64
+	queue   []models.Waypoint
+	reached []models.WaypointProgress
+
+	//Closest approach to queue[len(reached)] while the drone is inside its radius
+	inside  bool
+	closest models.WaypointProgress
+
+	//Live progress, kept while samples which aren't live are being tracked
+	saved *checkpoint
+}
+
+// checkpoint is the progress of a Tracker through its queue
+type checkpoint struct {
+	run     int64
+	queue   []models.Waypoint
+	reached []models.WaypointProgress
+	inside  bool
+	closest models.WaypointProgress
+}
+
+// NewTracker creates a Tracker which persists reached waypoints to db
+func NewTracker(db *gorm.DB) *Tracker {
+	return &Tracker{db: db}
+}
+
+// SetQueue replaces the active queue and starts a new run
+func (t *Tracker) SetQueue(queue []models.Waypoint) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.run = time.Now().Unix()
+	t.queue = append([]models.Waypoint(nil), queue...)
+	t.reached = nil
+	t.inside = false
+
+	//The new queue is live, so it is what Resume goes back to
+	if t.saved != nil {
+		t.saved = &checkpoint{run: t.run, queue: t.queue}
+	}
+}
+
+// Suspend saves the progress through the queue, then tracks samples without
+// persisting reached waypoints until Resume puts the saved progress back
+func (t *Tracker) Suspend() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.saved != nil {
+		return
+	}
+	t.saved = &checkpoint{run: t.run, queue: t.queue, inside: t.inside, closest: t.closest,
+		reached: append([]models.WaypointProgress(nil), t.reached...)}
+}
+
+// Resume puts back the progress saved by Suspend
+func (t *Tracker) Resume() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.saved == nil {
+		return
+	}
+	t.run = t.saved.run
+	t.queue = t.saved.queue
+	t.reached = t.saved.reached
+	t.inside = t.saved.inside
+	t.closest = t.saved.closest
+	t.saved = nil
+}
+
+// Update feeds a telemetry sample to the tracker, returning any waypoints
+// which are now considered reached. A waypoint is reached once the drone has
+// entered and then left its radius, so that the closest approach is known.
+func (t *Tracker) Update(drone models.Drone) []models.WaypointProgress {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var done []models.WaypointProgress
+	for len(t.reached) < len(t.queue) {
+		sequence := len(t.reached)
+		wp := t.queue[sequence]
+
+		radius := wp.Radius
+		if radius <= 0 {
+			radius = DefaultRadius
+		}
+
+		distance := geo.Distance(drone.Latitude, drone.Longitude, wp.Latitude, wp.Longitude)
+		if distance <= radius {
+			if !t.inside || distance < t.closest.Distance {
+				t.closest = models.WaypointProgress{
+					Run:           t.run,
+					Sequence:      sequence,
+					WaypointID:    wp.ID,
+					Name:          wp.Name,
+					ReachedAt:     drone.Timestamp,
+					Distance:      distance,
+					AltitudeError: drone.Altitude - wp.Altitude,
+				}
+			}
+			t.inside = true
+			break
+		}
+
+		if !t.inside {
+			break
+		}
+
+		//The drone has left the radius, so the closest approach is final
+		record := t.closest
+		if t.db != nil && t.saved == nil {
+			if err := t.db.Create(&record).Error; err != nil {
+				fmt.Println("[PROGRESS] Unable to save waypoint progress:", err)
+			}
+		}
+		t.reached = append(t.reached, record)
+		t.inside = false
+		done = append(done, record)
+	}
+
+	return done
+}
+
+// Snapshot returns the progress through the active queue
+func (t *Tracker) Snapshot() Snapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	snapshot := Snapshot{
+		Run:       t.run,
+		Reached:   len(t.reached),
+		Total:     len(t.queue),
+		Waypoints: make([]WaypointState, len(t.queue)),
+	}
+
+	for i, wp := range t.queue {
+		state := WaypointState{Sequence: i, Waypoint: wp, State: Pending}
+		if i < len(t.reached) {
+			record := t.reached[i]
+			state.State = Reached
+			state.Progress = &record
+		} else if i == len(t.reached) {
+			state.State = Active
+			if t.inside {
+				closest := t.closest
+				state.Progress = &closest
+			}
+		}
+		snapshot.Waypoints[i] = state
+	}
+
+	return snapshot
+}
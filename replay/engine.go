@@ -0,0 +1,263 @@
+package replay
+
+import (
+	"errors"
+	"gcom-backend/models"
+	"gcom-backend/telemetry"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MaxSpeed is the fastest a flight can be replayed
+const MaxSpeed = 100.0
+
+// Errors returned by the Engine
+var (
+	ErrNoSamples = errors.New("flight has no telemetry to replay")
+	ErrNotLoaded = errors.New("no replay is loaded")
+	ErrBadSpeed  = errors.New("speed must be greater than 0 and at most 100")
+)
+
+// State describes the replay loaded in an Engine
+//
+// @Description describes the flight being replayed
+type State struct {
+	FlightID int     `json:"flight_id" example:"1" extensions:"x-order=1"`
+	Speed    float64 `json:"speed" example:"2" extensions:"x-order=2"`
+	Playing  bool    `json:"playing" example:"true" extensions:"x-order=3"`
+	Paused   bool    `json:"paused" example:"false" extensions:"x-order=4"`
+	//Timestamps of the first and last samples of the flight
+	Start int64 `json:"start" example:"1698544700" extensions:"x-order=5"`
+	End   int64 `json:"end" example:"1698545300" extensions:"x-order=6"`
+	//Timestamp of the last sample replayed
+	Position int64 `json:"position" example:"1698544981" extensions:"x-order=7"`
+	Sample   int   `json:"sample" example:"281" extensions:"x-order=8"`
+	Samples  int   `json:"samples" example:"600" extensions:"x-order=9"`
+}
+
+// Engine replays a recorded flight through a telemetry Pipeline, keeping the
+// original spacing between samples divided by the speed
+type Engine struct {
+	mu       sync.Mutex
+	db       *gorm.DB
+	pipeline *telemetry.Pipeline
+
+	flightId int
+	samples  []models.FlightSample
+	index    int
+	speed    float64
+	paused   bool
+	playing  bool
+	stop     chan struct{}
+	wake     chan struct{}
+}
+
+// NewEngine creates an Engine replaying flights from db into pipeline
+func NewEngine(db *gorm.DB, pipeline *telemetry.Pipeline) *Engine {
+	return &Engine{db: db, pipeline: pipeline}
+}
+
+// Start replays a flight from the beginning at the given speed, replacing
+// any replay already loaded
+func (e *Engine) Start(flightId int, speed float64) (State, error) {
+	if speed <= 0 || speed > MaxSpeed {
+		return State{}, ErrBadSpeed
+	}
+
+	var samples []models.FlightSample
+	if err := e.db.Where("session_id = ?", flightId).Order("timestamp").Find(&samples).Error; err != nil {
+		return State{}, err
+	}
+	if len(samples) == 0 {
+		return State{}, ErrNoSamples
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.halt()
+	e.flightId = flightId
+	e.samples = samples
+	e.index = 0
+	e.speed = speed
+	e.paused = false
+	e.play()
+	return e.state(), nil
+}
+
+// Stop ends the replay and returns the pipeline to live telemetry
+func (e *Engine) Stop() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.halt()
+	e.samples = nil
+}
+
+// play replays the loaded flight from the current sample instead of live
+// telemetry. The caller must hold e.mu.
+func (e *Engine) play() {
+	e.playing = true
+	e.stop = make(chan struct{})
+	e.wake = make(chan struct{}, 1)
+	e.pipeline.SetReplaying(true)
+	go e.run(e.stop, e.wake)
+}
+
+// halt stops replaying and returns the pipeline to live telemetry, keeping
+// the loaded flight. The caller must hold e.mu.
+func (e *Engine) halt() {
+	if e.stop != nil {
+		close(e.stop)
+	}
+	e.stop = nil
+	e.wake = nil
+	e.playing = false
+	e.pipeline.SetReplaying(false)
+}
+
+// Pause holds the replay at the current sample
+func (e *Engine) Pause() (State, error) {
+	return e.update(func() error {
+		e.paused = true
+		return nil
+	})
+}
+
+// Resume continues a paused replay
+func (e *Engine) Resume() (State, error) {
+	return e.update(func() error {
+		e.paused = false
+		return nil
+	})
+}
+
+// SetSpeed changes the speed of the replay
+func (e *Engine) SetSpeed(speed float64) (State, error) {
+	return e.update(func() error {
+		if speed <= 0 || speed > MaxSpeed {
+			return ErrBadSpeed
+		}
+		e.speed = speed
+		return nil
+	})
+}
+
+// SeekTo moves the replay to the first sample at or after timestamp. The sample
+// is replayed straight away unless the replay is paused.
+func (e *Engine) SeekTo(timestamp int64) (State, error) {
+	return e.update(func() error {
+		e.index = sort.Search(len(e.samples), func(i int) bool {
+			return e.samples[i].Timestamp >= timestamp
+		})
+		if e.index == len(e.samples) {
+			e.index = len(e.samples) - 1
+		}
+
+		//A finished replay is started again from the new position
+		if !e.playing {
+			e.play()
+		}
+		return nil
+	})
+}
+
+// State returns the state of the replay
+func (e *Engine) State() (State, error) {
+	return e.update(func() error { return nil })
+}
+
+// update changes the replay under lock and wakes the replay so the change
+// takes effect immediately
+func (e *Engine) update(change func() error) (State, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.samples == nil {
+		return State{}, ErrNotLoaded
+	}
+	if err := change(); err != nil {
+		return e.state(), err
+	}
+
+	if e.wake != nil {
+		select {
+		case e.wake <- struct{}{}:
+		default:
+		}
+	}
+	return e.state(), nil
+}
+
+func (e *Engine) state() State {
+	state := State{
+		FlightID: e.flightId,
+		Speed:    e.speed,
+		Playing:  e.playing,
+		Paused:   e.paused,
+		Sample:   e.index,
+		Samples:  len(e.samples),
+	}
+	if len(e.samples) > 0 {
+		state.Start = e.samples[0].Timestamp
+		state.End = e.samples[len(e.samples)-1].Timestamp
+		state.Position = state.Start
+		if e.index > 0 {
+			state.Position = e.samples[e.index-1].Timestamp
+		}
+	}
+	return state
+}
+
+// run replays samples until the flight ends or the replay is stopped. Live
+// telemetry is published again once the flight ends, but the loaded flight
+// stays available to seek.
+func (e *Engine) run(stop <-chan struct{}, wake <-chan struct{}) {
+	for {
+		e.mu.Lock()
+		//A replay started since this one must not be replayed twice
+		select {
+		case <-stop:
+			e.mu.Unlock()
+			return
+		default:
+		}
+
+		if e.paused {
+			e.mu.Unlock()
+			select {
+			case <-stop:
+				return
+			case <-wake:
+				continue
+			}
+		}
+
+		if e.index >= len(e.samples) {
+			e.halt()
+			e.mu.Unlock()
+			return
+		}
+
+		sample := e.samples[e.index]
+		e.index++
+		var delay time.Duration
+		if e.index < len(e.samples) {
+			gap := e.samples[e.index].Timestamp - sample.Timestamp
+			delay = time.Duration(float64(gap) * float64(time.Second) / e.speed)
+		}
+		e.mu.Unlock()
+
+		e.pipeline.Replay(sample.Drone)
+
+		select {
+		case <-stop:
+			return
+		case <-wake:
+		case <-time.After(delay):
+		}
+	}
+}
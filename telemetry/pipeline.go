@@ -0,0 +1,173 @@
+package telemetry
+
+import (
+	"errors"
+	"gcom-backend/events"
+	"gcom-backend/flight"
+	"gcom-backend/models"
+	"gcom-backend/progress"
+	"math"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Window is how many seconds of drone status are kept, both in memory and
+// in the Drone table
+const Window = 300
+
+// Pipeline is the single path telemetry takes through the backend. Live
+// samples are stored and archived, then published to the in-memory status,
+// the progress tracker and the event bus. Replayed samples are only
+// published, and live samples are not published while a replay is running.
+// The live progress is suspended for the replay and put back after it.
+type Pipeline struct {
+	mu        sync.Mutex
+	db        *gorm.DB
+	bus       *events.Bus
+	tracker   *progress.Tracker
+	recorder  *flight.Recorder
+	replaying bool
+
+	//Samples in the last Window seconds, oldest first
+	history []models.Drone
+}
+
+// NewPipeline creates a Pipeline. The bus, tracker and recorder may be nil.
+func NewPipeline(db *gorm.DB, bus *events.Bus, tracker *progress.Tracker, recorder *flight.Recorder) *Pipeline {
+	return &Pipeline{db: db, bus: bus, tracker: tracker, recorder: recorder}
+}
+
+// Validate checks a sample is usable. The Drone validate tags are not used
+// as zero is a valid speed, heading or vertical speed.
+func Validate(drone models.Drone) error {
+	for _, value := range []float64{drone.Latitude, drone.Longitude, drone.Altitude, drone.VerticalSpeed,
+		drone.Speed, drone.Heading, drone.BatteryVoltage} {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return errors.New("telemetry contains a value which is not a number")
+		}
+	}
+
+	switch {
+	case drone.Timestamp <= 0:
+		return errors.New("timestamp is required")
+	case drone.Latitude < -90 || drone.Latitude > 90:
+		return errors.New("latitude must be between -90 and 90")
+	case drone.Longitude < -180 || drone.Longitude > 180:
+		return errors.New("longitude must be between -180 and 180")
+	case drone.Latitude == 0 && drone.Longitude == 0:
+		return errors.New("position is required")
+	case drone.BatteryVoltage < 0:
+		return errors.New("battery_voltage cannot be negative")
+	}
+	return nil
+}
+
+// Ingest handles a live sample from the drone, rejecting it if it is invalid
+func (p *Pipeline) Ingest(drone models.Drone) error {
+	if err := Validate(drone); err != nil {
+		return err
+	}
+
+	//Add drone and delete drones older than the window
+	if err := p.db.Save(&drone).Error; err != nil {
+		return err
+	}
+	var drones []models.Drone
+	p.db.Delete(&drones, "timestamp < ?", time.Now().Unix()-Window)
+
+	//Archive the drone if a flight is in progress
+	if p.recorder != nil {
+		if err := p.recorder.Record(drone); err != nil {
+			return err
+		}
+	}
+
+	p.mu.Lock()
+	replaying := p.replaying
+	p.mu.Unlock()
+	if !replaying {
+		p.publish(drone)
+	}
+	return nil
+}
+
+// Replay handles a sample from a recorded flight as if it were live
+func (p *Pipeline) Replay(drone models.Drone) {
+	p.publish(drone)
+}
+
+// SetReplaying switches publishing between live and replayed samples. The
+// in-memory status is cleared on each switch so the two are not mixed.
+func (p *Pipeline) SetReplaying(replaying bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.replaying != replaying {
+		p.history = nil
+		if p.tracker != nil && replaying {
+			p.tracker.Suspend()
+		} else if p.tracker != nil {
+			p.tracker.Resume()
+		}
+	}
+	p.replaying = replaying
+}
+
+// Replaying reports whether a replay is being published instead of live samples
+func (p *Pipeline) Replaying() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.replaying
+}
+
+// publish updates the in-memory status and progress, then puts the sample
+// and any waypoints flown over on the event bus
+func (p *Pipeline) publish(drone models.Drone) {
+	p.mu.Lock()
+	//A sample older than the latest means a replay was seeked backwards
+	if len(p.history) > 0 && drone.Timestamp < p.history[len(p.history)-1].Timestamp {
+		p.history = nil
+	}
+	p.history = append(p.history, drone)
+	start := 0
+	for start < len(p.history) && p.history[start].Timestamp <= drone.Timestamp-Window {
+		start++
+	}
+	p.history = p.history[start:]
+	p.mu.Unlock()
+
+	var reached []models.WaypointProgress
+	if p.tracker != nil {
+		reached = p.tracker.Update(drone)
+	}
+
+	if p.bus == nil {
+		return
+	}
+	p.bus.Publish(events.Event{Topic: events.Telemetry, Action: events.Received, Model: drone})
+	for _, record := range reached {
+		p.bus.Publish(events.Event{Topic: events.Progress, Action: events.Reached, Model: record})
+	}
+}
+
+// Latest returns the most recent sample, if there has been one
+func (p *Pipeline) Latest() (models.Drone, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.history) == 0 {
+		return models.Drone{}, false
+	}
+	return p.history[len(p.history)-1], true
+}
+
+// History returns the samples from the last Window seconds, oldest first
+func (p *Pipeline) History() []models.Drone {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]models.Drone{}, p.history...)
+}
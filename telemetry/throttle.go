@@ -0,0 +1,52 @@
+package telemetry
+
+import (
+	"sync"
+	"time"
+)
+
+// Throttle limits how often values are emitted. The first value in an
+// interval is emitted straight away, and the latest of any others at the end
+// of the interval, so the last value is never lost.
+type Throttle struct {
+	mu       sync.Mutex
+	interval time.Duration
+	emit     func(any)
+	last     time.Time
+	pending  any
+	timer    *time.Timer
+}
+
+// NewThrottle creates a Throttle emitting at most once per interval
+func NewThrottle(interval time.Duration, emit func(any)) *Throttle {
+	return &Throttle{interval: interval, emit: emit}
+}
+
+// Push offers a value to be emitted
+func (t *Throttle) Push(value any) {
+	t.mu.Lock()
+	elapsed := time.Since(t.last)
+	if t.timer == nil && elapsed >= t.interval {
+		t.last = time.Now()
+		t.mu.Unlock()
+		t.emit(value)
+		return
+	}
+
+	t.pending = value
+	if t.timer == nil {
+		t.timer = time.AfterFunc(t.interval-elapsed, t.flush)
+	}
+	t.mu.Unlock()
+}
+
+func (t *Throttle) flush() {
+	t.mu.Lock()
+	value := t.pending
+	t.pending = nil
+	t.timer = nil
+	t.last = time.Now()
+	t.mu.Unlock()
+
+	t.emit(value)
+}
@@ -0,0 +1,198 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gcom-backend/audit"
+	"gcom-backend/auth"
+	"gcom-backend/commands"
+	"gcom-backend/configs"
+	"gcom-backend/controllers"
+	"gcom-backend/models"
+	"gcom-backend/responses"
+	"gcom-backend/util"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type AuditTestSuite struct {
+	suite.Suite
+	e             *echo.Echo
+	db            *gorm.DB
+	authenticator *auth.Authenticator
+	admin         string
+	operator      string
+	pilot         string
+}
+
+func TestRunAuditSuite(t *testing.T) {
+	suite.Run(t, new(AuditTestSuite))
+}
+
+func (s *AuditTestSuite) SetupSuite() {
+	s.db = configs.Connect(true)
+	s.authenticator = auth.NewAuthenticator(s.db)
+	sim := configs.NewSimulator(configs.DefaultSimulatorConfig())
+
+	s.e = echo.New()
+	s.e.Use(util.DBMiddleware(s.db))
+	s.e.Use(util.AuditMiddleware(audit.NewLog(s.db)))
+	s.e.Use(util.CommandsMiddleware(commands.NewCommander(sim, nil, nil, nil)))
+	viewer := s.e.Group("", util.AuthMiddleware(s.authenticator, models.Viewer))
+	operator := s.e.Group("", util.AuthMiddleware(s.authenticator, models.Operator))
+	pilot := s.e.Group("", util.AuthMiddleware(s.authenticator, models.PilotInCommand))
+	admin := s.e.Group("", util.AuthMiddleware(s.authenticator, models.Admin))
+	viewer.GET("/waypoint/:waypointId", controllers.GetWaypoint)
+	operator.POST("/waypoint", controllers.CreateWaypoint)
+	operator.PATCH("/waypoint/:waypointId", controllers.EditWaypoint)
+	operator.DELETE("/waypoint/:waypointId", controllers.DeleteWaypoint)
+	pilot.POST("/drone/takeoff", controllers.Takeoff)
+	pilot.GET("/drone/land", controllers.Land)
+	admin.GET("/audit", controllers.GetAuditLog)
+	admin.GET("/audit/export", controllers.ExportAuditLog)
+
+	s.admin = s.token("admin", models.Admin)
+	s.operator = s.token("operator", models.Operator)
+	s.pilot = s.token("pilot", models.PilotInCommand)
+}
+
+func (s *AuditTestSuite) TearDownSuite() {
+	sqlDB, _ := s.db.DB()
+	if err := sqlDB.Close(); err != nil {
+		fmt.Println("[Teardown] Error closing database connection!")
+	}
+
+	if err := os.Remove("database.db"); err != nil {
+		fmt.Println("[Teardown] Error deleting database!")
+	}
+}
+
+func (s *AuditTestSuite) TearDownTest() {
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.Waypoint{})
+	//Entries refuse to be deleted through GORM
+	s.db.Exec("DELETE FROM audit_entries")
+}
+
+func (s *AuditTestSuite) token(username string, role models.Role) string {
+	user, err := s.authenticator.CreateUser(username, "password123", role)
+	require.NoError(s.T(), err)
+	_, token, err := s.authenticator.IssueToken(user.ID, "test", 0)
+	require.NoError(s.T(), err)
+	return token
+}
+
+func (s *AuditTestSuite) request(method string, path string, token string, body string) *httptest.ResponseRecorder {
+	var req = httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set(echo.HeaderXRealIP, "192.168.1.20")
+	var rec = httptest.NewRecorder()
+	s.e.ServeHTTP(rec, req)
+	return rec
+}
+
+func (s *AuditTestSuite) entries(query string) []models.AuditEntry {
+	rec := s.request(http.MethodGet, "/audit"+query, s.admin, "")
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	var entries responses.MultipleResponse[models.AuditEntry]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &entries))
+	return entries.Models
+}
+
+func (s *AuditTestSuite) TestRecordsChanges() {
+	rec := s.request(http.MethodPost, "/waypoint", s.operator, `{"id": "-1", "name": "Alpha", "lat": 49.26, "long": -123.24, "alt": 100}`)
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	var created responses.SingleResponse[models.Waypoint]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &created))
+	id := fmt.Sprint(created.Model.ID)
+
+	require.Equal(s.T(), http.StatusOK, s.request(http.MethodGet, "/waypoint/"+id, s.operator, "").Code)
+	require.Equal(s.T(), http.StatusOK, s.request(http.MethodPatch, "/waypoint/"+id, s.operator, `{"name": "Bravo"}`).Code)
+	require.Equal(s.T(), http.StatusOK, s.request(http.MethodDelete, "/waypoint/"+id, s.operator, "").Code)
+
+	entries := s.entries("?entity_type=waypoint&entity_id=" + id)
+	require.Len(s.T(), entries, 3, "reads are not recorded")
+	for _, entry := range entries {
+		assert.Equal(s.T(), "operator", entry.Actor)
+		assert.Equal(s.T(), "192.168.1.20", entry.ClientIP)
+		assert.Equal(s.T(), http.StatusOK, entry.Status)
+		assert.NotZero(s.T(), entry.Time)
+	}
+	assert.Equal(s.T(), "/waypoint", entries[0].Route)
+	assert.Equal(s.T(), http.MethodPatch, entries[1].Method)
+	assert.Equal(s.T(), "/waypoint/:waypointId", entries[1].Route)
+
+	var diff map[string]map[string]any
+	require.NoError(s.T(), json.Unmarshal(entries[1].Diff, &diff))
+	assert.Equal(s.T(), map[string]map[string]any{"name": {"before": "Alpha", "after": "Bravo"}}, diff,
+		"only the fields which changed")
+	require.NoError(s.T(), json.Unmarshal(entries[2].Diff, &diff))
+	assert.Equal(s.T(), "Bravo", diff["name"]["before"])
+	assert.Nil(s.T(), diff["name"]["after"])
+
+	assert.Len(s.T(), s.entries("?actor=pilot"), 0)
+	assert.Len(s.T(), s.entries("?method=DELETE"), 1)
+	assert.Len(s.T(), s.entries("?limit=2"), 2)
+	assert.Equal(s.T(), http.StatusBadRequest, s.request(http.MethodGet, "/audit?from=yesterday", s.admin, "").Code)
+}
+
+func (s *AuditTestSuite) TestCommandsAndRefusals() {
+	//The simulator refuses to take off before arming
+	rec := s.request(http.MethodPost, "/drone/takeoff", s.pilot, `{"altitude": 30}`)
+	require.Equal(s.T(), http.StatusInternalServerError, rec.Code)
+	//Operators can't command the drone, which is recorded too
+	rec = s.request(http.MethodPost, "/drone/takeoff", s.operator, `{"altitude": 30}`)
+	require.Equal(s.T(), http.StatusForbidden, rec.Code)
+
+	entries := s.entries("?route=/drone/takeoff")
+	require.Len(s.T(), entries, 2)
+	assert.Equal(s.T(), "pilot", entries[0].Actor)
+	assert.Equal(s.T(), "command", entries[0].EntityType)
+	assert.Equal(s.T(), commands.Failed, entries[0].Result)
+	assert.Contains(s.T(), string(entries[0].Diff), `"takeoff"`)
+	assert.Equal(s.T(), http.StatusForbidden, entries[1].Status)
+	assert.Equal(s.T(), "operator", entries[1].Actor)
+	assert.Empty(s.T(), entries[1].EntityType)
+
+	//Commands sent with GET are recorded too, here refused as the drone is on the ground
+	rec = s.request(http.MethodGet, "/drone/land", s.pilot, "")
+	require.Equal(s.T(), http.StatusInternalServerError, rec.Code)
+	entries = s.entries("?route=/drone/land")
+	require.Len(s.T(), entries, 1)
+	assert.Equal(s.T(), "pilot", entries[0].Actor)
+	assert.Equal(s.T(), http.MethodGet, entries[0].Method)
+	assert.Contains(s.T(), string(entries[0].Diff), `"land"`)
+}
+
+func (s *AuditTestSuite) TestAppendOnlyAndExport() {
+	s.request(http.MethodPost, "/waypoint", s.operator, `{"id": "-1", "name": "Alpha", "lat": 49.26, "long": -123.24, "alt": 100}`)
+	s.request(http.MethodPost, "/waypoint", s.operator, `{"id": "-1", "name": "Bravo", "lat": 49.27, "long": -123.24, "alt": 100}`)
+
+	entries := s.entries("")
+	require.Len(s.T(), entries, 2)
+	assert.ErrorIs(s.T(), s.db.Delete(&entries[0]).Error, models.ErrAppendOnly)
+	assert.ErrorIs(s.T(), s.db.Model(&entries[0]).Update("actor", "someone else").Error, models.ErrAppendOnly)
+
+	rec := s.request(http.MethodGet, "/audit/export", s.admin, "")
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	assert.Contains(s.T(), rec.Header().Get(echo.HeaderContentDisposition), "audit.jsonl")
+
+	var lines []models.AuditEntry
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		var entry models.AuditEntry
+		require.NoError(s.T(), json.Unmarshal(scanner.Bytes(), &entry))
+		lines = append(lines, entry)
+	}
+	assert.Equal(s.T(), entries, lines)
+}
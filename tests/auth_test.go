@@ -0,0 +1,228 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gcom-backend/auth"
+	"gcom-backend/configs"
+	"gcom-backend/controllers"
+	"gcom-backend/models"
+	"gcom-backend/responses"
+	"gcom-backend/util"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type AuthTestSuite struct {
+	suite.Suite
+	e             *echo.Echo
+	db            *gorm.DB
+	authenticator *auth.Authenticator
+}
+
+func TestRunAuthSuite(t *testing.T) {
+	suite.Run(t, new(AuthTestSuite))
+}
+
+func (s *AuthTestSuite) SetupSuite() {
+	s.db = configs.Connect(true)
+	s.authenticator = auth.NewAuthenticator(s.db)
+
+	//The same groups as main.go, with a route for each role
+	s.e = echo.New()
+	s.e.Use(util.DBMiddleware(s.db))
+	s.e.Use(util.AuthenticatorMiddleware(s.authenticator))
+	s.e.POST("/auth/login", controllers.Login)
+	viewer := s.e.Group("", util.AuthMiddleware(s.authenticator, models.Viewer))
+	operator := s.e.Group("", util.AuthMiddleware(s.authenticator, models.Operator))
+	pilot := s.e.Group("", util.AuthMiddleware(s.authenticator, models.PilotInCommand))
+	admin := s.e.Group("", util.AuthMiddleware(s.authenticator, models.Admin))
+	viewer.GET("/auth/me", controllers.GetCurrentUser)
+	streams := s.e.Group("", util.StreamAuthMiddleware(s.authenticator, models.Viewer))
+	streams.GET("/stream", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	operator.POST("/waypoint", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	pilot.POST("/drone/takeoff", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+	admin.GET("/users", controllers.GetAllUsers)
+	admin.POST("/user", controllers.CreateUser)
+	admin.PATCH("/user/:userId", controllers.EditUser)
+	admin.DELETE("/user/:userId", controllers.DeleteUser)
+	admin.GET("/user/:userId/tokens", controllers.GetUserTokens)
+	admin.POST("/user/:userId/token", controllers.CreateUserToken)
+	admin.DELETE("/token/:tokenId", controllers.DeleteToken)
+}
+
+func (s *AuthTestSuite) TearDownSuite() {
+	sqlDB, _ := s.db.DB()
+	if err := sqlDB.Close(); err != nil {
+		fmt.Println("[Teardown] Error closing database connection!")
+	}
+
+	if err := os.Remove("database.db"); err != nil {
+		fmt.Println("[Teardown] Error deleting database!")
+	}
+}
+
+func (s *AuthTestSuite) TearDownTest() {
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.Token{})
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.User{})
+}
+
+func (s *AuthTestSuite) request(method string, path string, token string, body any) *httptest.ResponseRecorder {
+	bodyBytes, _ := json.Marshal(body)
+	var req = httptest.NewRequest(method, path, bytes.NewReader(bodyBytes))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	var rec = httptest.NewRecorder()
+	s.e.ServeHTTP(rec, req)
+	return rec
+}
+
+// user creates a user and returns a token for them
+func (s *AuthTestSuite) user(username string, role models.Role) (models.User, string) {
+	user, err := s.authenticator.CreateUser(username, "password123", role)
+	require.NoError(s.T(), err)
+	_, token, err := s.authenticator.IssueToken(user.ID, "test", 0)
+	require.NoError(s.T(), err)
+	return user, token
+}
+
+func (s *AuthTestSuite) TestBootstrap() {
+	os.Setenv("ADMIN_PASSWORD", "bootstrap-password")
+	defer os.Unsetenv("ADMIN_PASSWORD")
+
+	password, err := s.authenticator.Bootstrap()
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), password, "the password from the environment is not echoed")
+
+	//Only when there are no users
+	_, err = s.authenticator.Bootstrap()
+	require.NoError(s.T(), err)
+	var count int64
+	s.db.Model(&models.User{}).Count(&count)
+	assert.Equal(s.T(), int64(1), count)
+
+	rec := s.request(http.MethodPost, "/auth/login", "", map[string]string{"username": "admin", "password": "bootstrap-password"})
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	var issued controllers.IssuedToken
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &issued))
+	assert.Equal(s.T(), models.Admin, issued.User.Role)
+	assert.NotContains(s.T(), rec.Body.String(), "password_hash")
+	assert.InDelta(s.T(), time.Now().Add(auth.SessionLifetime).Unix(), issued.ExpiresAt, 5)
+
+	rec = s.request(http.MethodGet, "/auth/me", issued.Token, nil)
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	assert.Contains(s.T(), rec.Body.String(), `"username":"admin"`)
+
+	rec = s.request(http.MethodPost, "/auth/login", "", map[string]string{"username": "admin", "password": "wrong-password"})
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+}
+
+func (s *AuthTestSuite) TestRoles() {
+	_, viewer := s.user("viewer", models.Viewer)
+	_, operator := s.user("operator", models.Operator)
+	_, pilot := s.user("pilot", models.PilotInCommand)
+
+	assert.Equal(s.T(), http.StatusUnauthorized, s.request(http.MethodGet, "/auth/me", "", nil).Code)
+	assert.Equal(s.T(), http.StatusUnauthorized, s.request(http.MethodGet, "/auth/me", "gcom_nonsense", nil).Code)
+	assert.Equal(s.T(), http.StatusOK, s.request(http.MethodGet, "/auth/me", viewer, nil).Code)
+	//Only streams accept the token as a query param
+	assert.Equal(s.T(), http.StatusUnauthorized, s.request(http.MethodGet, "/auth/me?token="+viewer, "", nil).Code)
+	assert.Equal(s.T(), http.StatusOK, s.request(http.MethodGet, "/stream?token="+viewer, "", nil).Code)
+
+	assert.Equal(s.T(), http.StatusForbidden, s.request(http.MethodPost, "/waypoint", viewer, nil).Code)
+	assert.Equal(s.T(), http.StatusOK, s.request(http.MethodPost, "/waypoint", operator, nil).Code)
+	assert.Equal(s.T(), http.StatusForbidden, s.request(http.MethodPost, "/drone/takeoff", operator, nil).Code)
+	assert.Equal(s.T(), http.StatusOK, s.request(http.MethodPost, "/drone/takeoff", pilot, nil).Code)
+	assert.Equal(s.T(), http.StatusForbidden, s.request(http.MethodGet, "/users", pilot, nil).Code)
+}
+
+func (s *AuthTestSuite) TestTokenNotLogged() {
+	var logged bytes.Buffer
+	e := echo.New()
+	e.Use(middleware.LoggerWithConfig(middleware.LoggerConfig{
+		Format:        util.RequestLogFormat,
+		CustomTagFunc: util.RedactURI,
+		Output:        &logged,
+	}))
+	e.GET("/stream", func(c echo.Context) error { return c.NoContent(http.StatusOK) })
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/stream?token=gcom_secret&since=4", nil))
+	assert.NotContains(s.T(), logged.String(), "gcom_secret")
+	assert.Contains(s.T(), logged.String(), "uri=/stream?since=4&token=REDACTED ")
+}
+
+func (s *AuthTestSuite) TestManageUsers() {
+	admin, adminToken := s.user("admin", models.Admin)
+
+	rec := s.request(http.MethodPost, "/user", adminToken, map[string]string{"username": "pilot", "password": "short", "role": "pilot_in_command"})
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	rec = s.request(http.MethodPost, "/user", adminToken, map[string]string{"username": "pilot", "password": "password123", "role": "captain"})
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	rec = s.request(http.MethodPost, "/user", adminToken, map[string]string{"username": "pilot", "password": "password123", "role": "pilot_in_command"})
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	var created responses.SingleResponse[models.User]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &created))
+	rec = s.request(http.MethodPost, "/user", adminToken, map[string]string{"username": "pilot", "password": "password123", "role": "viewer"})
+	assert.Equal(s.T(), http.StatusConflict, rec.Code)
+
+	//A long lived token for the new user
+	rec = s.request(http.MethodPost, fmt.Sprintf("/user/%d/token", created.Model.ID), adminToken, map[string]any{"name": "MPS"})
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	var issued controllers.IssuedToken
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &issued))
+	assert.Equal(s.T(), http.StatusOK, s.request(http.MethodPost, "/drone/takeoff", issued.Token, nil).Code)
+
+	rec = s.request(http.MethodGet, fmt.Sprintf("/user/%d/tokens", created.Model.ID), adminToken, nil)
+	var tokens responses.MultipleResponse[models.Token]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &tokens))
+	require.Len(s.T(), tokens.Models, 1)
+	assert.Equal(s.T(), "MPS", tokens.Models[0].Name)
+	assert.NotContains(s.T(), rec.Body.String(), issued.Token)
+
+	//Demoting takes effect on the next request
+	rec = s.request(http.MethodPatch, fmt.Sprintf("/user/%d", created.Model.ID), adminToken, map[string]string{"role": "viewer"})
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(s.T(), http.StatusForbidden, s.request(http.MethodPost, "/drone/takeoff", issued.Token, nil).Code)
+
+	//Revoked and disabled
+	rec = s.request(http.MethodDelete, fmt.Sprintf("/token/%d", tokens.Models[0].ID), adminToken, nil)
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	assert.Equal(s.T(), http.StatusUnauthorized, s.request(http.MethodGet, "/auth/me", issued.Token, nil).Code)
+	_, token, err := s.authenticator.IssueToken(created.Model.ID, "test", 0)
+	require.NoError(s.T(), err)
+	rec = s.request(http.MethodPatch, fmt.Sprintf("/user/%d", created.Model.ID), adminToken, map[string]bool{"disabled": true})
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	assert.Equal(s.T(), http.StatusUnauthorized, s.request(http.MethodGet, "/auth/me", token, nil).Code)
+	rec = s.request(http.MethodPost, "/auth/login", "", map[string]string{"username": "pilot", "password": "password123"})
+	assert.Equal(s.T(), http.StatusUnauthorized, rec.Code)
+
+	//Expired
+	_, expired, err := s.authenticator.IssueToken(admin.ID, "old", time.Now().Unix()-1)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), http.StatusUnauthorized, s.request(http.MethodGet, "/auth/me", expired, nil).Code)
+
+	//The last admin stays
+	rec = s.request(http.MethodPatch, fmt.Sprintf("/user/%d", admin.ID), adminToken, map[string]string{"role": "operator"})
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	rec = s.request(http.MethodDelete, fmt.Sprintf("/user/%d", admin.ID), adminToken, nil)
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+
+	rec = s.request(http.MethodDelete, fmt.Sprintf("/user/%d", created.Model.ID), adminToken, nil)
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	var count int64
+	s.db.Model(&models.Token{}).Where("user_id = ?", created.Model.ID).Count(&count)
+	assert.Zero(s.T(), count)
+}
@@ -0,0 +1,273 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gcom-backend/commands"
+	"gcom-backend/configs"
+	"gcom-backend/controllers"
+	"gcom-backend/events"
+	"gcom-backend/models"
+	"gcom-backend/progress"
+	"gcom-backend/telemetry"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type CommandsTestSuite struct {
+	suite.Suite
+	e            *echo.Echo
+	sim          *configs.Simulator
+	bus          *events.Bus
+	tracker      *progress.Tracker
+	commander    *commands.Commander
+	subscription *events.Subscription
+}
+
+func TestRunCommandsSuite(t *testing.T) {
+	suite.Run(t, new(CommandsTestSuite))
+}
+
+func (s *CommandsTestSuite) SetupTest() {
+	s.e = echo.New()
+	s.sim = configs.NewSimulator(configs.DefaultSimulatorConfig())
+	s.bus = events.NewBus()
+	s.tracker = progress.NewTracker(nil)
+	s.commander = commands.NewCommander(s.sim, s.bus, s.tracker, nil)
+	s.subscription = s.bus.Subscribe("test", events.Options{Topics: []events.Topic{events.Commands}})
+}
+
+func (s *CommandsTestSuite) TearDownTest() {
+	s.subscription.Close()
+}
+
+// next waits for the next command event
+func (s *CommandsTestSuite) next() (events.Action, events.Command) {
+	select {
+	case event := <-s.subscription.Events():
+		return event.Action, event.Model.(events.Command)
+	case <-time.After(2 * time.Second):
+		require.FailNow(s.T(), "no command event")
+		return "", events.Command{}
+	}
+}
+
+func (s *CommandsTestSuite) telemetry(altitude float64) {
+	s.bus.Publish(events.Event{Topic: events.Telemetry, Action: events.Received,
+		Model: sample(time.Now().Unix(), 49.26, -123.24, altitude)})
+}
+
+func (s *CommandsTestSuite) request(handler echo.HandlerFunc, body string, param string) *httptest.ResponseRecorder {
+	return s.requestAs("pilot", handler, body, param)
+}
+
+// requestAs makes a request as if signed in as username
+func (s *CommandsTestSuite) requestAs(username string, handler echo.HandlerFunc, body string, param string) *httptest.ResponseRecorder {
+	var req = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(body)))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	var rec = httptest.NewRecorder()
+	var c = s.e.NewContext(req, rec)
+	c.Set("commands", s.commander)
+	c.Set("user", models.User{Username: username, Role: models.PilotInCommand})
+	if param != "" {
+		c.SetParamNames("commandId")
+		c.SetParamValues(param)
+	}
+	require.NoError(s.T(), handler(c))
+	return rec
+}
+
+func (s *CommandsTestSuite) TestValidation() {
+	_, err := s.commander.Takeoff("pilot", 0)
+	assert.Error(s.T(), err)
+	_, err = s.commander.RTL("pilot", -10)
+	assert.Error(s.T(), err)
+	_, err = s.commander.Arm("pilot", 2)
+	assert.Error(s.T(), err)
+	_, err = s.commander.SetQueue("pilot", []models.Waypoint{{ID: 1, Name: "Alpha"}})
+	assert.Error(s.T(), err)
+
+	rec := s.request(controllers.Takeoff, `{"altitude": -5}`, "")
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+
+	//Nothing was sent
+	assert.Len(s.T(), s.subscription.Events(), 0)
+}
+
+func (s *CommandsTestSuite) TestRefusedCommand() {
+	rec := s.request(controllers.Takeoff, `{"altitude": 30}`, "")
+	assert.Equal(s.T(), http.StatusInternalServerError, rec.Code)
+	id := rec.Header().Get(controllers.CommandIDHeader)
+	require.NotEmpty(s.T(), id)
+
+	action, command := s.next()
+	assert.Equal(s.T(), events.Sent, action)
+	assert.Equal(s.T(), id, fmt.Sprint(command.ID))
+	assert.Equal(s.T(), commands.Failed, command.Status, "not armed so takeoff is refused")
+	assert.False(s.T(), command.Success)
+}
+
+func (s *CommandsTestSuite) TestTakeoffCompletes() {
+	armed, err := s.commander.Arm("pilot", 1)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), commands.Completed, armed.Status)
+	s.next()
+
+	takeoff, err := s.commander.Takeoff("pilot", 30)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), commands.Sent, takeoff.Status)
+	assert.Equal(s.T(), takeoff.ID, armed.ID+1)
+	s.next()
+
+	s.telemetry(10)
+	s.telemetry(29.5)
+	action, command := s.next()
+	assert.Equal(s.T(), events.Updated, action)
+	assert.Equal(s.T(), takeoff.ID, command.ID)
+	assert.Equal(s.T(), commands.Completed, command.Status)
+	assert.Equal(s.T(), "command_updated", events.Event{Topic: events.Commands, Action: action}.Name())
+
+	rec := s.request(controllers.GetCommand, "", fmt.Sprint(takeoff.ID))
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	var fetched events.Command
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &fetched))
+	assert.Equal(s.T(), commands.Completed, fetched.Status)
+
+	rec = s.request(controllers.GetCommand, "", "9999")
+	assert.Equal(s.T(), http.StatusNotFound, rec.Code)
+}
+
+func (s *CommandsTestSuite) TestSupersededAndTimedOut() {
+	_, err := s.commander.Arm("pilot", 1)
+	require.NoError(s.T(), err)
+	takeoff, _ := s.commander.Takeoff("pilot", 30)
+	land, _ := s.commander.Land("pilot")
+	s.next()
+	s.next()
+
+	action, command := s.next()
+	assert.Equal(s.T(), events.Sent, action)
+	assert.Equal(s.T(), land.ID, command.ID)
+	action, command = s.next()
+	assert.Equal(s.T(), events.Updated, action)
+	assert.Equal(s.T(), takeoff.ID, command.ID)
+	assert.Equal(s.T(), commands.Superseded, command.Status)
+
+	timeout := commands.Timeout
+	commands.Timeout = -time.Second
+	defer func() { commands.Timeout = timeout }()
+
+	s.telemetry(20)
+	_, command = s.next()
+	assert.Equal(s.T(), land.ID, command.ID)
+	assert.Equal(s.T(), commands.TimedOut, command.Status)
+}
+
+func (s *CommandsTestSuite) TestQueueCompletes() {
+	rec := s.request(controllers.PostQueue, `[{"id": "1", "name": "Alpha", "lat": 49.26, "long": -123.24, "alt": 100}]`, "")
+	require.Equal(s.T(), http.StatusAccepted, rec.Code, rec.Body.String())
+	_, queued := s.next()
+	assert.Equal(s.T(), commands.QueueSet, queued.Name)
+	assert.Equal(s.T(), commands.Sent, queued.Status)
+	assert.Equal(s.T(), 1, s.tracker.Snapshot().Total)
+
+	//Flying north over the waypoint
+	pipeline := telemetry.NewPipeline(nil, s.bus, s.tracker, nil)
+	for i, lat := range []float64{49.2590, 49.2595, 49.2600, 49.2605, 49.2610} {
+		pipeline.Replay(sample(1698544700+int64(i), lat, -123.24, 100))
+	}
+
+	_, command := s.next()
+	assert.Equal(s.T(), queued.ID, command.ID)
+	assert.Equal(s.T(), commands.Completed, command.Status)
+}
+
+func (s *CommandsTestSuite) TestConfirmation() {
+	s.commander.RequireConfirmation(commands.Arm, commands.Takeoff)
+
+	rec := s.request(controllers.Arm, `{"arm": 1}`, "")
+	require.Equal(s.T(), http.StatusAccepted, rec.Code, rec.Body.String())
+	id := rec.Header().Get(controllers.CommandIDHeader)
+	action, requested := s.next()
+	assert.Equal(s.T(), events.Requested, action)
+	assert.Equal(s.T(), "command_requested", events.Event{Topic: events.Commands, Action: action}.Name())
+	assert.Equal(s.T(), commands.AwaitingConfirmation, requested.Status)
+	assert.Equal(s.T(), "pilot", requested.RequestedBy)
+	assert.NotZero(s.T(), requested.ExpiresAt)
+
+	//Commands which do not need confirming go straight through
+	land, err := s.commander.Land("pilot")
+	require.NoError(s.T(), err)
+	assert.NotEqual(s.T(), commands.AwaitingConfirmation, land.Status)
+	s.next()
+	require.Len(s.T(), s.commander.Pending(), 1)
+
+	rec = s.requestAs("pilot", controllers.ConfirmCommand, "", id)
+	assert.Equal(s.T(), http.StatusForbidden, rec.Code, "the requester cannot confirm their own command")
+
+	rec = s.requestAs("safety", controllers.ConfirmCommand, "", id)
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	var confirmed events.Command
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &confirmed))
+	assert.Equal(s.T(), commands.Completed, confirmed.Status)
+	assert.Equal(s.T(), "safety", confirmed.ReviewedBy)
+	assert.True(s.T(), s.sim.Takeoff(30), "armed once confirmed")
+	assert.Empty(s.T(), s.commander.Pending())
+
+	action, _ = s.next()
+	assert.Equal(s.T(), events.Confirmed, action)
+	action, sent := s.next()
+	assert.Equal(s.T(), events.Sent, action)
+	assert.Equal(s.T(), requested.ID, sent.ID)
+
+	//Only once
+	rec = s.requestAs("safety", controllers.ConfirmCommand, "", id)
+	assert.Equal(s.T(), http.StatusConflict, rec.Code)
+	rec = s.requestAs("safety", controllers.ConfirmCommand, "", "9999")
+	assert.Equal(s.T(), http.StatusNotFound, rec.Code)
+}
+
+func (s *CommandsTestSuite) TestRejectAndExpire() {
+	s.commander.RequireConfirmation(commands.Takeoff)
+
+	takeoff, err := s.commander.Takeoff("pilot", 30)
+	require.NoError(s.T(), err)
+	s.next()
+
+	//Rejecting needs a second user as much as confirming does
+	rec := s.requestAs("pilot", controllers.RejectCommand, "", fmt.Sprint(takeoff.ID))
+	assert.Equal(s.T(), http.StatusForbidden, rec.Code)
+
+	rec = s.requestAs("safety", controllers.RejectCommand, "", fmt.Sprint(takeoff.ID))
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	action, command := s.next()
+	assert.Equal(s.T(), events.Rejected, action)
+	assert.Equal(s.T(), commands.Rejected, command.Status)
+	assert.Equal(s.T(), "safety", command.ReviewedBy)
+	_, err = s.commander.Confirm(takeoff.ID, "safety")
+	assert.ErrorIs(s.T(), err, commands.ErrNotPending)
+
+	timeout := commands.ConfirmationTimeout
+	commands.ConfirmationTimeout = 10 * time.Millisecond
+	defer func() { commands.ConfirmationTimeout = timeout }()
+
+	takeoff, err = s.commander.Takeoff("pilot", 30)
+	require.NoError(s.T(), err)
+	s.next()
+	action, command = s.next()
+	assert.Equal(s.T(), events.Expired, action)
+	assert.Equal(s.T(), takeoff.ID, command.ID)
+	assert.Equal(s.T(), commands.Expired, command.Status)
+
+	fetched, err := s.commander.Get(takeoff.ID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), commands.Expired, fetched.Status)
+}
@@ -0,0 +1,187 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gcom-backend/configs"
+	"gcom-backend/controllers"
+	"gcom-backend/models"
+	"gcom-backend/responses"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type DetectionTestSuite struct {
+	suite.Suite
+	e      *echo.Echo
+	db     *gorm.DB
+	object models.GroundObject
+}
+
+func TestRunDetectionSuite(t *testing.T) {
+	suite.Run(t, new(DetectionTestSuite))
+}
+
+func (s *DetectionTestSuite) SetupSuite() {
+	s.db = configs.Connect(true)
+	s.e = echo.New()
+	require.NoError(s.T(), os.MkdirAll("imgs", 0755))
+}
+
+func (s *DetectionTestSuite) TearDownSuite() {
+	sqlDB, _ := s.db.DB()
+	if err := sqlDB.Close(); err != nil {
+		fmt.Println("[Teardown] Error closing database connection!")
+	}
+
+	if err := os.Remove("database.db"); err != nil {
+		fmt.Println("[Teardown] Error deleting database!")
+	}
+	if err := os.RemoveAll("imgs"); err != nil {
+		fmt.Println("[Teardown] Error deleting images!")
+	}
+}
+
+func (s *DetectionTestSuite) SetupTest() {
+	s.object = models.GroundObject{Type: models.Emergent, Latitude: 49.26, Longitude: -123.24, Description: "Mannequin"}
+	require.NoError(s.T(), s.db.Create(&s.object).Error)
+}
+
+func (s *DetectionTestSuite) TearDownTest() {
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.GroundObject{})
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.Detection{})
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.Image{})
+}
+
+// saveImage writes a 100x80 image whose left half is red and right half blue
+func (s *DetectionTestSuite) saveImage(timestamp int64) string {
+	img := image.NewRGBA(image.Rect(0, 0, 100, 80))
+	for x := 0; x < 100; x++ {
+		for y := 0; y < 80; y++ {
+			if x < 50 {
+				img.Set(x, y, color.RGBA{R: 255, A: 255})
+			} else {
+				img.Set(x, y, color.RGBA{B: 255, A: 255})
+			}
+		}
+	}
+
+	filename := fmt.Sprintf("%d.png", timestamp)
+	file, err := os.Create("imgs/" + filename)
+	require.NoError(s.T(), err)
+	require.NoError(s.T(), png.Encode(file, img))
+	require.NoError(s.T(), file.Close())
+	require.NoError(s.T(), s.db.Create(&models.Image{Timestamp: timestamp, Filename: filename}).Error)
+	return filename
+}
+
+func (s *DetectionTestSuite) request(handler echo.HandlerFunc, body string, name string, param string) *httptest.ResponseRecorder {
+	var req = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(body)))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	var rec = httptest.NewRecorder()
+	var c = s.e.NewContext(req, rec)
+	c.Set("db", s.db)
+	if param != "" {
+		c.SetParamNames(name)
+		c.SetParamValues(param)
+	}
+	require.NoError(s.T(), handler(c))
+	return rec
+}
+
+func (s *DetectionTestSuite) detect(filename string, x, y, width, height int, confidence float64) *httptest.ResponseRecorder {
+	body := fmt.Sprintf(`{"id": "-1", "ground_object_id": %d, "image": %q, "x": %d, "y": %d, "width": %d, "height": %d, "confidence": %v}`,
+		s.object.ID, filename, x, y, width, height, confidence)
+	return s.request(controllers.CreateDetection, body, "", "")
+}
+
+func (s *DetectionTestSuite) TestCreateAndList() {
+	first := s.saveImage(1714898050)
+	second := s.saveImage(1714898060)
+
+	rec := s.detect(first, 10, 10, 20, 20, 0.6)
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	var created responses.SingleResponse[models.Detection]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.NotZero(s.T(), created.Model.ID)
+	assert.NotZero(s.T(), created.Model.Timestamp, "defaults to now")
+	require.Equal(s.T(), http.StatusOK, s.detect(second, 60, 10, 20, 20, 0.9).Code)
+
+	for name, rec := range map[string]*httptest.ResponseRecorder{
+		"outside the image":  s.detect(first, 90, 10, 20, 20, 0.5),
+		"no size":            s.detect(first, 10, 10, 0, 20, 0.5),
+		"too confident":      s.detect(first, 10, 10, 20, 20, 1.5),
+		"unknown image":      s.detect("1714898099.png", 10, 10, 20, 20, 0.5),
+		"unknown object":     s.request(controllers.CreateDetection, `{"id": "-1", "ground_object_id": 999, "image": "`+first+`", "width": 5, "height": 5}`, "", ""),
+		"non-sentinel ID":    s.request(controllers.CreateDetection, `{"id": "4", "ground_object_id": 1, "image": "`+first+`", "width": 5, "height": 5}`, "", ""),
+		"negative corner":    s.detect(first, -1, 10, 20, 20, 0.5),
+		"missing image name": s.request(controllers.CreateDetection, `{"id": "-1", "ground_object_id": 1, "width": 5, "height": 5}`, "", ""),
+	} {
+		assert.Equal(s.T(), http.StatusBadRequest, rec.Code, name)
+	}
+
+	rec = s.request(controllers.GetGroundObjectDetections, "", "objectId", fmt.Sprint(s.object.ID))
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	var detections responses.MultipleResponse[models.Detection]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &detections))
+	require.Len(s.T(), detections.Models, 2)
+	assert.Equal(s.T(), second, detections.Models[0].Image, "most confident first")
+
+	rec = s.request(controllers.GetImageDetections, "", "filename", first)
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &detections))
+	require.Len(s.T(), detections.Models, 1)
+	assert.Equal(s.T(), 10, detections.Models[0].X)
+
+	assert.Equal(s.T(), http.StatusNotFound, s.request(controllers.GetImageDetections, "", "filename", "1714898099.png").Code)
+	assert.Equal(s.T(), http.StatusNotFound, s.request(controllers.GetGroundObjectDetections, "", "objectId", "999").Code)
+
+	//Deleting a detection which doesn't exist changes nothing
+	assert.Equal(s.T(), http.StatusNotFound, s.request(controllers.DeleteDetection, "", "detectionId", "999").Code)
+	require.Equal(s.T(), http.StatusOK, s.request(controllers.DeleteDetection, "", "detectionId", fmt.Sprint(detections.Models[0].ID)).Code)
+	var count int64
+	s.db.Model(&models.Detection{}).Count(&count)
+	assert.Equal(s.T(), int64(1), count)
+
+	//Deleting the object deletes its detections
+	require.Equal(s.T(), http.StatusOK, s.request(controllers.DeleteGroundObject, "", "objectId", fmt.Sprint(s.object.ID)).Code)
+	s.db.Model(&models.Detection{}).Count(&count)
+	assert.Zero(s.T(), count)
+	assert.Equal(s.T(), http.StatusNotFound, s.request(controllers.DeleteGroundObject, "", "objectId", fmt.Sprint(s.object.ID)).Code)
+}
+
+func (s *DetectionTestSuite) TestThumbnail() {
+	rec := s.request(controllers.GetGroundObjectThumbnail, "", "objectId", fmt.Sprint(s.object.ID))
+	assert.Equal(s.T(), http.StatusNotFound, rec.Code, "no detections yet")
+
+	filename := s.saveImage(1714898070)
+	require.Equal(s.T(), http.StatusOK, s.detect(filename, 5, 5, 30, 20, 0.4).Code)
+	require.Equal(s.T(), http.StatusOK, s.detect(filename, 60, 10, 10, 10, 0.8).Code)
+	//The most confident detection's image is gone, so the next best is used
+	missing := models.Detection{GroundObjectID: s.object.ID, Image: "1714898000.png", Width: 10, Height: 10, Confidence: 0.95}
+	require.NoError(s.T(), s.db.Create(&missing).Error)
+
+	rec = s.request(controllers.GetGroundObjectThumbnail, "", "objectId", fmt.Sprint(s.object.ID))
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(s.T(), "image/png", rec.Header().Get(echo.HeaderContentType))
+
+	thumbnail, err := png.Decode(rec.Body)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 10, thumbnail.Bounds().Dx())
+	assert.Equal(s.T(), 10, thumbnail.Bounds().Dy())
+	r, _, b, _ := thumbnail.At(thumbnail.Bounds().Min.X, thumbnail.Bounds().Min.Y).RGBA()
+	assert.Zero(s.T(), r)
+	assert.NotZero(s.T(), b, "cropped from the blue half")
+}
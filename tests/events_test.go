@@ -0,0 +1,443 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gcom-backend/commands"
+	"gcom-backend/configs"
+	"gcom-backend/controllers"
+	"gcom-backend/events"
+	"gcom-backend/models"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type EventsTestSuite struct {
+	suite.Suite
+	e            *echo.Echo
+	db           *gorm.DB
+	bus          *events.Bus
+	subscription *events.Subscription
+	received     []events.Event
+}
+
+func TestRunEventsSuite(t *testing.T) {
+	suite.Run(t, new(EventsTestSuite))
+}
+
+func (s *EventsTestSuite) SetupSuite() {
+	s.db = configs.Connect(true)
+	s.e = echo.New()
+}
+
+func (s *EventsTestSuite) TearDownSuite() {
+	sqlDB, _ := s.db.DB()
+	if err := sqlDB.Close(); err != nil {
+		fmt.Println("[Teardown] Error closing database connection!")
+	}
+
+	if err := os.Remove("database.db"); err != nil {
+		fmt.Println("[Teardown] Error deleting database!")
+	}
+}
+
+func (s *EventsTestSuite) SetupTest() {
+	s.bus = events.NewBus()
+	s.received = nil
+	s.subscription = s.bus.Subscribe("test", events.Options{})
+}
+
+func (s *EventsTestSuite) TearDownTest() {
+	s.subscription.Close()
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.Waypoint{})
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.GroundObject{})
+}
+
+func (s *EventsTestSuite) request(handler echo.HandlerFunc, method string, body any, param string) int {
+	bodyBytes, _ := json.Marshal(body)
+	var req = httptest.NewRequest(method, "/", bytes.NewReader(bodyBytes))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(controllers.ClientIDHeader, "ui-1")
+	var rec = httptest.NewRecorder()
+	var c = s.e.NewContext(req, rec)
+	c.Set("db", s.db)
+	c.Set("events", s.bus)
+	if param != "" {
+		c.SetParamNames("waypointId", "objectId")
+		c.SetParamValues(param, param)
+	}
+
+	require.NoError(s.T(), handler(c))
+	s.collect()
+	return rec.Code
+}
+
+// collect moves the events queued for the test subscription into received
+func (s *EventsTestSuite) collect() {
+	for {
+		select {
+		case event, ok := <-s.subscription.Events():
+			if !ok {
+				return
+			}
+			s.received = append(s.received, event)
+		default:
+			return
+		}
+	}
+}
+
+func (s *EventsTestSuite) TestWaypointLifecycle() {
+	waypoint := models.Waypoint{ID: -1, Name: "Alpha", Latitude: 49.26, Longitude: -123.24, Altitude: 100}
+	require.Equal(s.T(), http.StatusOK, s.request(controllers.CreateWaypoint, http.MethodPost, waypoint, ""))
+	require.Len(s.T(), s.received, 1)
+
+	created := s.received[0]
+	assert.Equal(s.T(), events.Waypoints, created.Topic)
+	assert.Equal(s.T(), events.Created, created.Action)
+	assert.Equal(s.T(), "waypoint_created", created.Name())
+	assert.Equal(s.T(), "ui-1", created.Client)
+	assert.NotZero(s.T(), created.Time)
+	id := created.Model.(models.Waypoint).ID
+	assert.NotZero(s.T(), id)
+
+	require.Equal(s.T(), http.StatusOK, s.request(controllers.EditWaypoint, http.MethodPatch, map[string]string{"name": "Bravo"}, fmt.Sprint(id)))
+	require.Len(s.T(), s.received, 2)
+	assert.Equal(s.T(), events.Updated, s.received[1].Action)
+	assert.Equal(s.T(), "Bravo", s.received[1].Model.(models.Waypoint).Name)
+	assert.Equal(s.T(), created.ID+1, s.received[1].ID)
+
+	require.Equal(s.T(), http.StatusOK, s.request(controllers.DeleteWaypoint, http.MethodDelete, nil, fmt.Sprint(id)))
+	require.Len(s.T(), s.received, 3)
+	assert.Equal(s.T(), "waypoint_deleted", s.received[2].Name())
+	assert.Equal(s.T(), "Bravo", s.received[2].Model.(models.Waypoint).Name)
+
+	//Failed changes publish nothing
+	require.Equal(s.T(), http.StatusNotFound, s.request(controllers.DeleteWaypoint, http.MethodDelete, nil, fmt.Sprint(id)))
+	assert.Len(s.T(), s.received, 3)
+}
+
+func (s *EventsTestSuite) TestBatchesAndUnsubscribe() {
+	objects := []models.GroundObject{
+		{ID: -1, Type: models.Standard, Latitude: 49.26, Longitude: -123.24, Shape: models.Circle, Color: models.Red, Text: "A", TextColor: models.Black},
+		{ID: -1, Type: models.Emergent, Latitude: 49.27, Longitude: -123.25, Description: "B"},
+	}
+	require.Equal(s.T(), http.StatusOK, s.request(controllers.CreateGroundObjectBatch, http.MethodPost, objects, ""))
+	require.Len(s.T(), s.received, 2)
+	assert.Equal(s.T(), "groundobject_created", s.received[1].Name())
+
+	var ids []int
+	for _, event := range s.received {
+		ids = append(ids, event.Model.(models.GroundObject).ID)
+	}
+	require.Equal(s.T(), http.StatusOK, s.request(controllers.DeleteGroundObjectBatch, http.MethodDelete, ids, ""))
+	require.Len(s.T(), s.received, 4)
+	assert.Equal(s.T(), events.Deleted, s.received[3].Action)
+	assert.Equal(s.T(), "B", s.received[3].Model.(models.GroundObject).Description)
+
+	s.subscription.Close()
+	s.bus.Publish(events.Event{Topic: events.Images, Action: events.Created})
+	s.collect()
+	assert.Len(s.T(), s.received, 4)
+}
+
+// serverEvent is a parsed text/event-stream frame
+type serverEvent struct {
+	id   string
+	name string
+	data string
+}
+
+// openStream connects to the SSE endpoint and returns the frames it sends
+func (s *EventsTestSuite) openStream(query string, lastEventId string) (<-chan serverEvent, func()) {
+	e := echo.New()
+	e.GET("/events", controllers.StreamEvents, func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("events", s.bus)
+			return next(c)
+		}
+	})
+	server := httptest.NewServer(e)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL+"/events"+query, nil)
+	if lastEventId != "" {
+		req.Header.Set("Last-Event-ID", lastEventId)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "text/event-stream", resp.Header.Get(echo.HeaderContentType))
+
+	frames := make(chan serverEvent, 16)
+	go func() {
+		defer close(frames)
+		scanner := bufio.NewScanner(resp.Body)
+		frame := serverEvent{}
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				frames <- frame
+				frame = serverEvent{}
+			case strings.HasPrefix(line, ":"):
+				frame.name = "comment"
+			default:
+				key, value, _ := strings.Cut(line, ": ")
+				switch key {
+				case "id":
+					frame.id = value
+				case "event":
+					frame.name = value
+				case "data":
+					frame.data = value
+				}
+			}
+		}
+	}()
+
+	return frames, func() {
+		resp.Body.Close()
+		server.CloseClientConnections()
+		server.Close()
+	}
+}
+
+func (s *EventsTestSuite) next(frames <-chan serverEvent) serverEvent {
+	select {
+	case frame := <-frames:
+		return frame
+	case <-time.After(2 * time.Second):
+		require.FailNow(s.T(), "no event received")
+		return serverEvent{}
+	}
+}
+
+func (s *EventsTestSuite) TestStreamFiltersTopics() {
+	frames, stop := s.openStream("?topics=telemetry,alerts", "")
+	defer stop()
+
+	s.bus.Publish(events.Event{Topic: events.Waypoints, Action: events.Created, Model: models.Waypoint{Name: "Alpha"}})
+	published := s.bus.Publish(events.Event{Topic: events.Telemetry, Action: events.Received, Model: sample(1698544700, 49.26, -123.24, 100)})
+
+	frame := s.next(frames)
+	assert.Equal(s.T(), fmt.Sprint(published.ID), frame.id)
+	assert.Equal(s.T(), "telemetry_received", frame.name)
+
+	var event struct {
+		Topic string       `json:"topic"`
+		Model models.Drone `json:"model"`
+	}
+	require.NoError(s.T(), json.Unmarshal([]byte(frame.data), &event))
+	assert.Equal(s.T(), "telemetry", event.Topic)
+	assert.Equal(s.T(), 49.26, event.Model.Latitude)
+}
+
+func (s *EventsTestSuite) TestStreamResumeAndHeartbeat() {
+	heartbeat := controllers.HeartbeatInterval
+	controllers.HeartbeatInterval = 100 * time.Millisecond
+	defer func() { controllers.HeartbeatInterval = heartbeat }()
+
+	first := s.bus.Publish(events.Event{Topic: events.Waypoints, Action: events.Created})
+	s.bus.Publish(events.Event{Topic: events.Waypoints, Action: events.Updated})
+	s.bus.Publish(events.Event{Topic: events.GroundObjects, Action: events.Deleted})
+
+	frames, stop := s.openStream("", fmt.Sprint(first.ID))
+	defer stop()
+
+	assert.Equal(s.T(), "waypoint_updated", s.next(frames).name)
+	assert.Equal(s.T(), "groundobject_deleted", s.next(frames).name)
+	assert.Equal(s.T(), "comment", s.next(frames).name)
+
+	live := s.bus.Publish(events.Event{Topic: events.Images, Action: events.Created})
+	frame := s.next(frames)
+	assert.Equal(s.T(), fmt.Sprint(live.ID), frame.id)
+}
+
+func (s *EventsTestSuite) TestStreamResyncAfterRestart() {
+	frames, stop := s.openStream("", "9999")
+	defer stop()
+
+	assert.Equal(s.T(), "resync", s.next(frames).name)
+	live := s.bus.Publish(events.Event{Topic: events.Images, Action: events.Created})
+	assert.Equal(s.T(), fmt.Sprint(live.ID), s.next(frames).id)
+}
+
+func (s *EventsTestSuite) TestDropPolicy() {
+	subscription := s.bus.Subscribe("slow", events.Options{Topics: []events.Topic{events.Images}, Buffer: 2})
+	defer subscription.Close()
+
+	for i := 0; i < 4; i++ {
+		s.bus.Publish(events.Event{Topic: events.Images, Action: events.Created})
+	}
+	s.bus.Publish(events.Event{Topic: events.Waypoints, Action: events.Created})
+
+	assert.Len(s.T(), subscription.Events(), 2)
+	assert.Equal(s.T(), uint64(2), subscription.Dropped())
+
+	//The oldest events are kept, in order
+	first := <-subscription.Events()
+	second := <-subscription.Events()
+	assert.Equal(s.T(), first.ID+1, second.ID)
+}
+
+func (s *EventsTestSuite) TestBlockPolicy() {
+	subscription := s.bus.Subscribe("audit", events.Options{Buffer: 1, Policy: events.Block})
+
+	s.bus.Publish(events.Event{Topic: events.Images, Action: events.Created})
+	published := make(chan struct{})
+	go func() {
+		s.bus.Publish(events.Event{Topic: events.Images, Action: events.Created})
+		close(published)
+	}()
+
+	//The publisher waits for room rather than dropping the event
+	select {
+	case <-published:
+		require.FailNow(s.T(), "publish did not wait for the subscriber")
+	case <-time.After(50 * time.Millisecond):
+	}
+	<-subscription.Events()
+	<-published
+	assert.Zero(s.T(), subscription.Dropped())
+
+	//Closing releases a waiting publisher
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		subscription.Close()
+	}()
+	s.bus.Publish(events.Event{Topic: events.Images, Action: events.Created})
+}
+
+func (s *EventsTestSuite) TestBlockOnlyHoldsUpItsPublisher() {
+	subscription := s.bus.Subscribe("audit", events.Options{Topics: []events.Topic{events.Images}, Buffer: 1, Policy: events.Block})
+	defer subscription.Close()
+
+	s.bus.Publish(events.Event{Topic: events.Images, Action: events.Created})
+	go s.bus.Publish(events.Event{Topic: events.Images, Action: events.Created})
+
+	//Other topics and the rest of the bus carry on while it waits
+	done := make(chan struct{})
+	go func() {
+		s.bus.Publish(events.Event{Topic: events.Waypoints, Action: events.Created})
+		s.bus.Metrics()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		require.FailNow(s.T(), "a blocked subscriber held up the bus")
+	}
+	<-subscription.Events()
+}
+
+func (s *EventsTestSuite) TestBlockTimeout() {
+	subscription := s.bus.Subscribe("socket", events.Options{Buffer: 1, Policy: events.Block, Timeout: 50 * time.Millisecond})
+	defer subscription.Close()
+
+	s.bus.Publish(events.Event{Topic: events.Images, Action: events.Created})
+	start := time.Now()
+	s.bus.Publish(events.Event{Topic: events.Images, Action: events.Created})
+	assert.GreaterOrEqual(s.T(), time.Since(start), 50*time.Millisecond)
+	assert.Equal(s.T(), uint64(1), subscription.Dropped())
+	assert.Len(s.T(), subscription.Events(), 1)
+}
+
+func (s *EventsTestSuite) TestConcurrentPublishersInOrder() {
+	dropping := s.bus.Subscribe("sse", events.Options{Buffer: 2000})
+	defer dropping.Close()
+	blocking := s.bus.Subscribe("audit", events.Options{Buffer: 1, Policy: events.Block})
+	defer blocking.Close()
+
+	publishers := make(chan struct{})
+	for i := 0; i < 8; i++ {
+		go func() {
+			for j := 0; j < 200; j++ {
+				s.bus.Publish(events.Event{Topic: events.Telemetry, Action: events.Received})
+			}
+			publishers <- struct{}{}
+		}()
+	}
+
+	//Each subscriber sees every event in the order they were numbered
+	var last uint64
+	for i := 0; i < 1600; i++ {
+		event := <-blocking.Events()
+		require.Greater(s.T(), event.ID, last)
+		last = event.ID
+	}
+	for i := 0; i < 8; i++ {
+		<-publishers
+	}
+
+	last = 0
+	require.Len(s.T(), dropping.Events(), 1600)
+	for i := 0; i < 1600; i++ {
+		event := <-dropping.Events()
+		require.Greater(s.T(), event.ID, last)
+		last = event.ID
+	}
+}
+
+func (s *EventsTestSuite) TestTypedHandler() {
+	names := make(chan string, 2)
+	handled := s.bus.Handle("typed", events.Options{Topics: []events.Topic{events.Commands}},
+		events.Typed(func(event events.Event, command events.Command) {
+			names <- command.Name
+		}))
+	defer handled.Close()
+
+	s.bus.Publish(events.Event{Topic: events.Commands, Action: events.Sent, Model: "not a command"})
+	s.bus.Publish(events.Event{Topic: events.Commands, Action: events.Sent, Model: events.Command{Name: "land", Success: true}})
+
+	select {
+	case name := <-names:
+		assert.Equal(s.T(), "land", name)
+	case <-time.After(time.Second):
+		require.FailNow(s.T(), "command not handled")
+	}
+}
+
+func (s *EventsTestSuite) TestCommandsAndMetrics() {
+	mp := configs.NewSimulator(configs.DefaultSimulatorConfig())
+	req := httptest.NewRequest(http.MethodGet, "/drone/lock", nil)
+	c := s.e.NewContext(req, httptest.NewRecorder())
+	c.Set("commands", commands.NewCommander(mp, s.bus, nil, nil))
+	require.NoError(s.T(), controllers.Lock(c))
+	s.collect()
+
+	require.Len(s.T(), s.received, 1)
+	assert.Equal(s.T(), "command_sent", s.received[0].Name())
+	assert.Equal(s.T(), "lock", s.received[0].Model.(events.Command).Name)
+
+	s.bus.Subscribe("slow", events.Options{Topics: []events.Topic{events.Commands}, Buffer: 1})
+	s.bus.Publish(events.Event{Topic: events.Commands, Action: events.Sent})
+	s.bus.Publish(events.Event{Topic: events.Commands, Action: events.Sent})
+
+	rec := httptest.NewRecorder()
+	c = s.e.NewContext(httptest.NewRequest(http.MethodGet, "/events/metrics", nil), rec)
+	c.Set("events", s.bus)
+	require.NoError(s.T(), controllers.GetEventMetrics(c))
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+
+	var metrics events.Metrics
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &metrics))
+	assert.Equal(s.T(), uint64(3), metrics.Published[events.Commands])
+	//The test subscription, the commander following telemetry and the slow one
+	require.Len(s.T(), metrics.Subscribers, 3)
+	assert.Equal(s.T(), "test", metrics.Subscribers[0].Name)
+	assert.Equal(s.T(), 2, metrics.Subscribers[0].Queued)
+	assert.Equal(s.T(), events.SubscriberMetrics{
+		Name: "slow", Topics: []events.Topic{events.Commands}, Policy: events.Drop,
+		Buffer: 1, Queued: 1, Delivered: 1, Dropped: 1}, metrics.Subscribers[2])
+}
@@ -0,0 +1,195 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gcom-backend/commands"
+	"gcom-backend/configs"
+	"gcom-backend/controllers"
+	"gcom-backend/flight"
+	"gcom-backend/models"
+	"gcom-backend/responses"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type FlightTestSuite struct {
+	suite.Suite
+	e        *echo.Echo
+	db       *gorm.DB
+	mps      *httptest.Server
+	mp       *configs.MissionPlanner
+	recorder *flight.Recorder
+}
+
+func TestRunFlightSuite(t *testing.T) {
+	suite.Run(t, new(FlightTestSuite))
+}
+
+func (s *FlightTestSuite) SetupSuite() {
+	s.db = configs.Connect(true)
+	s.e = echo.New()
+	s.mps = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	s.mp, _ = configs.ConnectMissionPlanner(s.mps.URL)
+}
+
+func (s *FlightTestSuite) TearDownSuite() {
+	s.mps.Close()
+	sqlDB, _ := s.db.DB()
+	if err := sqlDB.Close(); err != nil {
+		fmt.Println("[Teardown] Error closing database connection!")
+	}
+
+	if err := os.Remove("database.db"); err != nil {
+		fmt.Println("[Teardown] Error deleting database!")
+	}
+}
+
+func (s *FlightTestSuite) SetupTest() {
+	s.recorder = flight.NewRecorder(s.db)
+}
+
+func (s *FlightTestSuite) TearDownTest() {
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.FlightSample{})
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.FlightSession{})
+}
+
+func (s *FlightTestSuite) context(method string, uri string, body string) (echo.Context, *httptest.ResponseRecorder) {
+	var req = httptest.NewRequest(method, uri, bytes.NewReader([]byte(body)))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	var rec = httptest.NewRecorder()
+	var c = s.e.NewContext(req, rec)
+	c.Set("db", s.db)
+	c.Set("mp", s.mp)
+	c.Set("commands", commands.NewCommander(s.mp, nil, nil, s.recorder))
+	c.Set("flight", s.recorder)
+
+	return c, rec
+}
+
+func (s *FlightTestSuite) arm(arm int) {
+	c, rec := s.context(http.MethodPost, "/drone/arm", fmt.Sprintf(`{"arm": %d}`, arm))
+	require.NoError(s.T(), controllers.Arm(c))
+	require.Equal(s.T(), http.StatusAccepted, rec.Code, rec.Body.String())
+}
+
+// fly arms the drone, records three samples and disarms it
+func (s *FlightTestSuite) fly() models.FlightSession {
+	s.arm(1)
+	session, active := s.recorder.Active()
+	require.True(s.T(), active)
+
+	for i, lat := range []float64{49.26, 49.261, 49.262} {
+		drone := sample(1698544700+int64(i), lat, -123.24, 50+float64(i)*10)
+		drone.BatteryVoltage = []float64{16.8, 16.7, 16.6}[i]
+		require.NoError(s.T(), s.recorder.Record(drone))
+	}
+
+	s.arm(0)
+	_, active = s.recorder.Active()
+	require.False(s.T(), active)
+	return session
+}
+
+func (s *FlightTestSuite) TestSessionLifecycle() {
+	//Nothing is archived before arming
+	require.NoError(s.T(), s.recorder.Record(sample(1698544600, 49.25, -123.24, 0)))
+
+	session := s.fly()
+
+	c, rec := s.context(http.MethodGet, "/flights", "")
+	require.NoError(s.T(), controllers.GetAllFlights(c))
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+
+	var response responses.MultipleResponse[models.FlightSession]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &response))
+	require.Len(s.T(), response.Models, 1)
+	assert.Equal(s.T(), session.ID, response.Models[0].ID)
+	assert.Equal(s.T(), int64(3), response.Models[0].Samples)
+	assert.NotZero(s.T(), response.Models[0].EndedAt)
+
+	//Disarming again is harmless
+	s.arm(0)
+}
+
+func (s *FlightTestSuite) TestResumeOpenSession() {
+	s.arm(1)
+	session, _ := s.recorder.Active()
+
+	resumed, active := flight.NewRecorder(s.db).Active()
+	require.True(s.T(), active)
+	assert.Equal(s.T(), session.ID, resumed.ID)
+	s.arm(0)
+}
+
+func (s *FlightTestSuite) TestOverlappingSessions() {
+	//Both flights archive samples with the same timestamps
+	first := s.fly()
+	second := s.fly()
+	require.NotEqual(s.T(), first.ID, second.ID)
+
+	for _, session := range []models.FlightSession{first, second} {
+		var samples []models.FlightSample
+		require.NoError(s.T(), s.db.Where("session_id = ?", session.ID).Order("timestamp").Find(&samples).Error)
+		require.Len(s.T(), samples, 3)
+		assert.Equal(s.T(), 49.261, samples[1].Latitude)
+	}
+
+	//A repeated timestamp within a session keeps the first sample
+	s.arm(1)
+	session, _ := s.recorder.Active()
+	require.NoError(s.T(), s.recorder.Record(sample(1698544800, 49.26, -123.24, 50)))
+	require.NoError(s.T(), s.recorder.Record(sample(1698544800, 49.27, -123.24, 60)))
+	s.arm(0)
+
+	var samples []models.FlightSample
+	require.NoError(s.T(), s.db.Where("session_id = ?", session.ID).Find(&samples).Error)
+	require.Len(s.T(), samples, 1)
+	assert.Equal(s.T(), 49.26, samples[0].Latitude)
+}
+
+func (s *FlightTestSuite) TestExport() {
+	session := s.fly()
+	uri := fmt.Sprintf("/flight/%d/export", session.ID)
+
+	export := func(format string) *httptest.ResponseRecorder {
+		c, rec := s.context(http.MethodGet, uri+"?format="+format, "")
+		c.SetParamNames("flightId")
+		c.SetParamValues(fmt.Sprint(session.ID))
+		require.NoError(s.T(), controllers.ExportFlight(c))
+		return rec
+	}
+
+	rec := export("csv")
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	require.Len(s.T(), lines, 4)
+	assert.True(s.T(), strings.HasPrefix(lines[0], "timestamp,time,latitude"))
+	assert.Equal(s.T(), "1698544701,2023-10-29T01:58:21Z,49.261,-123.24,60,0,0,0,16.7", lines[2])
+
+	rec = export("gpx")
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	assert.Contains(s.T(), rec.Body.String(), `<trkpt lat="49.262" lon="-123.24">`)
+	assert.Contains(s.T(), rec.Body.String(), "<ele>70</ele>")
+	assert.Contains(s.T(), rec.Body.String(), "16.6</battery_voltage>")
+
+	rec = export("kml")
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	assert.Contains(s.T(), rec.Body.String(), "<LineString>")
+	assert.Contains(s.T(), rec.Body.String(), "<when>2023-10-29T01:58:20Z</when>")
+
+	rec = export("shp")
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+}
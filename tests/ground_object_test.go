@@ -0,0 +1,201 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gcom-backend/configs"
+	"gcom-backend/controllers"
+	"gcom-backend/models"
+	"gcom-backend/responses"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type GroundObjectTestSuite struct {
+	suite.Suite
+	e  *echo.Echo
+	db *gorm.DB
+}
+
+func TestRunGroundObjectSuite(t *testing.T) {
+	suite.Run(t, new(GroundObjectTestSuite))
+}
+
+func (s *GroundObjectTestSuite) SetupSuite() {
+	s.db = configs.Connect(true)
+	s.e = echo.New()
+}
+
+func (s *GroundObjectTestSuite) TearDownSuite() {
+	sqlDB, _ := s.db.DB()
+	if err := sqlDB.Close(); err != nil {
+		fmt.Println("[Teardown] Error closing database connection!")
+	}
+
+	if err := os.Remove("database.db"); err != nil {
+		fmt.Println("[Teardown] Error deleting database!")
+	}
+}
+
+func (s *GroundObjectTestSuite) TearDownTest() {
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.GroundObject{})
+}
+
+func (s *GroundObjectTestSuite) request(handler echo.HandlerFunc, body string, param string) *httptest.ResponseRecorder {
+	var req = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(body)))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	var rec = httptest.NewRecorder()
+	var c = s.e.NewContext(req, rec)
+	c.Set("db", s.db)
+	if param != "" {
+		c.SetParamNames("objectId")
+		c.SetParamValues(param)
+	}
+	require.NoError(s.T(), handler(c))
+	return rec
+}
+
+func (s *GroundObjectTestSuite) TestJSONFields() {
+	rec := s.request(controllers.CreateGroundObject, `{"id": "-1", "object_type": "standard", "lat": 49.26, "long": -123.24,
+		"shape": "circle", "color": "red", "text": "A", "text_color": "white"}`, "")
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+
+	var created map[string]any
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &created))
+	object := created["waypoint"].(map[string]any)
+	assert.Equal(s.T(), "circle", object["shape"])
+	assert.Equal(s.T(), "red", object["color"])
+	assert.Equal(s.T(), "A", object["text"])
+	assert.Equal(s.T(), "white", object["text_color"])
+	assert.NotContains(s.T(), object, "Shape")
+	assert.NotContains(s.T(), object, "TextColor")
+}
+
+func (s *GroundObjectTestSuite) TestEnumValidation() {
+	rec := s.request(controllers.CreateGroundObject, `{"id": "-1", "object_type": "standard", "lat": 49.26, "long": -123.24, "shape": "hexagon"}`, "")
+	require.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	var response responses.ErrorResponse
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Contains(s.T(), response.Data, `shape "hexagon" must be one of circle, semicircle`)
+
+	rec = s.request(controllers.CreateGroundObject, `{"id": "-1", "object_type": "mystery", "lat": 49.26, "long": -123.24}`, "")
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	rec = s.request(controllers.CreateGroundObjectBatch, `[{"id": "-1", "object_type": "emergent", "lat": 49.26, "long": -123.24, "description": "Mannequin"},
+		{"id": "-1", "object_type": "standard", "lat": 49.26, "long": -123.24, "text_color": "Black"}]`, "")
+	require.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	assert.Contains(s.T(), rec.Body.String(), "object 1")
+
+	rec = s.request(controllers.CreateGroundObject, `{"id": "-1", "object_type": "standard", "lat": 49.26, "long": -123.24,
+		"shape": "circle", "color": "red", "text": "A", "text_color": "white"}`, "")
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	var created responses.SingleResponse[models.GroundObject]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &created))
+
+	rec = s.request(controllers.EditGroundObject, `{"color": "pink"}`, fmt.Sprint(created.Model.ID))
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	rec = s.request(controllers.EditGroundObject, `{"color": "purple"}`, fmt.Sprint(created.Model.ID))
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+}
+
+func (s *GroundObjectTestSuite) TestPerTypeValidation() {
+	for body, message := range map[string]string{
+		`{"object_type": "standard", "shape": "star", "color": "red"}`:                                                          "standard objects need text, text_color",
+		`{"object_type": "standard", "shape": "star", "color": "red", "text": "ab", "text_color": "white"}`:                     `text "ab" must be a single upper case letter or digit`,
+		`{"object_type": "standard", "shape": "star", "color": "red", "text": "A", "text_color": "white", "orientation": "up"}`: `orientation "up" must be one of N, NE`,
+		`{"object_type": "emergent"}`:                                           "emergent objects need description",
+		`{"object_type": "emergent", "description": "Tent", "shape": "star"}`:   "emergent objects can't have shape",
+		`{"object_type": "emergent", "description": "Tent", "confidence": 1.5}`: "confidence 1.5 must be from 0 to 1",
+	} {
+		rec := s.request(controllers.CreateGroundObject, `{"id": "-1", "lat": 49.26, "long": -123.24, `+body[1:], "")
+		require.Equal(s.T(), http.StatusBadRequest, rec.Code, body)
+		var response responses.ErrorResponse
+		require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Contains(s.T(), response.Data, message, body)
+	}
+}
+
+func (s *GroundObjectTestSuite) TestODLCFields() {
+	rec := s.request(controllers.CreateGroundObject, `{"id": "-1", "object_type": "standard", "lat": 49.26, "long": -123.24,
+		"shape": "star", "color": "orange", "text": "7", "text_color": "black", "orientation": "SW", "autonomous": true, "confidence": 0.8}`, "")
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	var standard responses.SingleResponse[models.GroundObject]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &standard))
+	assert.Equal(s.T(), models.SouthWest, standard.Model.Orientation)
+	assert.True(s.T(), standard.Model.Autonomous)
+
+	rec = s.request(controllers.CreateGroundObject, `{"id": "-1", "object_type": "emergent", "lat": 49.27, "long": -123.25,
+		"description": "Mannequin lying on a blanket", "confidence": 0.4}`, "")
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	var emergent responses.SingleResponse[models.GroundObject]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &emergent))
+
+	//Turning an emergent object into a standard one needs the standard fields
+	id := fmt.Sprint(emergent.Model.ID)
+	rec = s.request(controllers.EditGroundObject, `{"object_type": "standard"}`, id)
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	rec = s.request(controllers.EditGroundObject, `{"object_type": "standard", "description": "", "shape": "cross",
+		"color": "white", "text": "Q", "text_color": "red", "autonomous": false}`, id)
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	var edited responses.SingleResponse[models.GroundObject]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &edited))
+	assert.Equal(s.T(), models.Cross, edited.Model.Shape)
+	assert.Empty(s.T(), edited.Model.Description)
+	assert.Equal(s.T(), 0.4, edited.Model.Confidence, "left out so kept")
+	rec = s.request(controllers.EditGroundObject, `{"id": "999"}`, id)
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	rec = s.request(controllers.EditGroundObject, `{"text": "R"}`, "999")
+	assert.Equal(s.T(), http.StatusNotFound, rec.Code)
+
+	list := func(query string) []models.GroundObject {
+		var req = httptest.NewRequest(http.MethodGet, "/groundobjects?"+query, nil)
+		var rec = httptest.NewRecorder()
+		var c = s.e.NewContext(req, rec)
+		c.Set("db", s.db)
+		require.NoError(s.T(), controllers.GetAllGroundObjects(c))
+		require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+		var objects responses.MultipleResponse[models.GroundObject]
+		require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &objects))
+		return objects.Models
+	}
+	assert.Len(s.T(), list(""), 2)
+	assert.Len(s.T(), list("autonomous=true"), 1)
+	assert.Len(s.T(), list("min_confidence=0.5"), 1)
+	assert.Len(s.T(), list("object_type=emergent"), 0)
+}
+
+func (s *GroundObjectTestSuite) TestSchema() {
+	rec := s.request(controllers.GetGroundObjectSchema, "", "")
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	var schema controllers.GroundObjectSchema
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &schema))
+	assert.Equal(s.T(), models.ObjectTypes, schema.ObjectTypes)
+	assert.Contains(s.T(), schema.Shapes, models.QuarterCircle)
+	assert.Contains(s.T(), schema.Colors, models.White)
+	assert.Equal(s.T(), models.Orientations, schema.Orientations)
+}
+
+func (s *GroundObjectTestSuite) TestMigration() {
+	//As stored before values were validated
+	require.NoError(s.T(), s.db.Exec(`INSERT INTO ground_objects (id, type, latitude, longitude, shape, color, text, text_color)
+		VALUES (50, 'Standard', 49.26, -123.24, 'Semi-Circle', ' RED', 'A', 'White')`).Error)
+
+	models.Migrate(s.db)
+
+	var object models.GroundObject
+	require.NoError(s.T(), s.db.First(&object, 50).Error)
+	assert.Equal(s.T(), models.Standard, object.Type)
+	assert.Equal(s.T(), models.SemiCircle, object.Shape)
+	assert.Equal(s.T(), models.Red, object.Color)
+	assert.Equal(s.T(), models.White, object.TextColor)
+	assert.Equal(s.T(), models.Approved, object.ReviewStatus)
+	assert.NoError(s.T(), object.ValidateEnums())
+}
@@ -0,0 +1,304 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gcom-backend/configs"
+	"gcom-backend/controllers"
+	"gcom-backend/geo"
+	"gcom-backend/imagery"
+	"gcom-backend/models"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type ImageTestSuite struct {
+	suite.Suite
+	e      *echo.Echo
+	db     *gorm.DB
+	camera imagery.Camera
+}
+
+func TestRunImageSuite(t *testing.T) {
+	suite.Run(t, new(ImageTestSuite))
+}
+
+func (s *ImageTestSuite) SetupSuite() {
+	s.db = configs.Connect(true)
+	s.e = echo.New()
+	s.camera = configs.DefaultCamera()
+	require.NoError(s.T(), os.MkdirAll("imgs", 0755))
+}
+
+func (s *ImageTestSuite) TearDownSuite() {
+	sqlDB, _ := s.db.DB()
+	if err := sqlDB.Close(); err != nil {
+		fmt.Println("[Teardown] Error closing database connection!")
+	}
+
+	if err := os.Remove("database.db"); err != nil {
+		fmt.Println("[Teardown] Error deleting database!")
+	}
+	if err := os.RemoveAll("imgs"); err != nil {
+		fmt.Println("[Teardown] Error deleting images!")
+	}
+}
+
+func (s *ImageTestSuite) TearDownTest() {
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.Image{})
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.Drone{})
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.FlightSample{})
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.GroundObject{})
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.Detection{})
+}
+
+// post posts a blank 600x400 PNG as filename over terrain, if there is one,
+// returning the response and the bytes sent
+func (s *ImageTestSuite) post(filename string, terrain imagery.Terrain) (*httptest.ResponseRecorder, []byte) {
+	var encoded bytes.Buffer
+	require.NoError(s.T(), png.Encode(&encoded, image.NewGray(image.Rect(0, 0, 600, 400))))
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filename)
+	require.NoError(s.T(), err)
+	_, err = part.Write(encoded.Bytes())
+	require.NoError(s.T(), err)
+	require.NoError(s.T(), writer.Close())
+
+	var req = httptest.NewRequest(http.MethodPost, "/image", &body)
+	req.Header.Set(echo.HeaderContentType, writer.FormDataContentType())
+	var rec = httptest.NewRecorder()
+	var c = s.e.NewContext(req, rec)
+	c.Set("db", s.db)
+	c.Set("camera", s.camera)
+	if terrain != nil {
+		c.Set("terrain", terrain)
+	}
+	require.NoError(s.T(), controllers.UploadImage(c))
+	return rec, encoded.Bytes()
+}
+
+// upload posts a blank 600x400 PNG as filename, returning the stored image
+// and the bytes sent
+func (s *ImageTestSuite) upload(filename string) (models.Image, []byte) {
+	rec, sent := s.post(filename, nil)
+	require.Equal(s.T(), http.StatusAccepted, rec.Code, rec.Body.String())
+
+	var stored models.Image
+	require.NoError(s.T(), s.db.Where("filename = ?", filename).First(&stored).Error)
+	return stored, sent
+}
+
+func (s *ImageTestSuite) TestUploadGeoreferences() {
+	require.NoError(s.T(), s.db.Create(&[]models.Drone{
+		{Timestamp: 1714898048, Latitude: 49.26, Longitude: -123.24, Altitude: 100, Heading: 350},
+		{Timestamp: 1714898052, Latitude: 49.262, Longitude: -123.24, Altitude: 110, Heading: 10},
+	}).Error)
+
+	stored, sent := s.upload("1714898050.png")
+	saved, err := os.ReadFile("imgs/1714898050.png")
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), sent, saved, "the upload is written to disk")
+
+	assert.Equal(s.T(), 600, stored.Width)
+	assert.Equal(s.T(), 400, stored.Height)
+	require.True(s.T(), stored.Georeferenced)
+	assert.InDelta(s.T(), 49.261, stored.Latitude, 1e-9)
+	assert.InDelta(s.T(), 105, stored.Altitude, 1e-9)
+	assert.InDelta(s.T(), 0, stored.Heading, 1e-9, "turns the short way through north")
+
+	//Facing north, the top of the image is north and its width spans altitude * sensor width / focal length
+	require.Len(s.T(), stored.Footprint, 4)
+	topLeft, topRight, bottomRight := stored.Footprint[0], stored.Footprint[1], stored.Footprint[2]
+	assert.Greater(s.T(), topLeft.Latitude, stored.Latitude)
+	assert.Less(s.T(), topLeft.Longitude, stored.Longitude)
+	assert.InDelta(s.T(), 105*23.5/16, geo.Distance(topLeft.Latitude, topLeft.Longitude, topRight.Latitude, topRight.Longitude), 0.1)
+	assert.InDelta(s.T(), 105*15.6/16, geo.Distance(topRight.Latitude, topRight.Longitude, bottomRight.Latitude, bottomRight.Longitude), 0.1)
+}
+
+func (s *ImageTestSuite) TestUploadOverTerrain() {
+	require.NoError(s.T(), s.db.Create(&[]models.Drone{
+		{Timestamp: 1714898048, Latitude: 49.26, Longitude: -123.24, Altitude: 100},
+		{Timestamp: 1714898052, Latitude: 49.262, Longitude: -123.24, Altitude: 110},
+	}).Error)
+
+	//Ground 40m up leaves the drone 65m above it, so the footprint is smaller
+	rec, _ := s.post("1714898050.png", imagery.FlatGround{Height: 40})
+	require.Equal(s.T(), http.StatusAccepted, rec.Code, rec.Body.String())
+	var stored models.Image
+	require.NoError(s.T(), s.db.First(&stored, 1714898050).Error)
+	require.Len(s.T(), stored.Footprint, 4)
+	topLeft, topRight := stored.Footprint[0], stored.Footprint[1]
+	assert.InDelta(s.T(), 65*23.5/16, geo.Distance(topLeft.Latitude, topLeft.Longitude, topRight.Latitude, topRight.Longitude), 0.1)
+}
+
+func (s *ImageTestSuite) TestUploadDuplicate() {
+	stored, sent := s.upload("1714898080.png")
+
+	//A second image at the same time is refused without touching the first
+	rec, _ := s.post("1714898080_2.png", nil)
+	assert.Equal(s.T(), http.StatusConflict, rec.Code)
+	rec, _ = s.post("1714898080.png", nil)
+	assert.Equal(s.T(), http.StatusConflict, rec.Code)
+
+	saved, err := os.ReadFile("imgs/1714898080.png")
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), sent, saved)
+	_, err = os.Stat("imgs/1714898080_2.png")
+	assert.True(s.T(), os.IsNotExist(err), "the refused upload is not written")
+
+	var images []models.Image
+	require.NoError(s.T(), s.db.Find(&images).Error)
+	require.Len(s.T(), images, 1)
+	assert.Equal(s.T(), stored.Filename, images[0].Filename)
+}
+
+func (s *ImageTestSuite) TestUploadWithoutTelemetry() {
+	//Too long before the image was taken to be used
+	require.NoError(s.T(), s.db.Create(&models.Drone{Timestamp: 1714898000, Latitude: 49.26, Longitude: -123.24, Altitude: 100}).Error)
+
+	stored, _ := s.upload("1714898060.png")
+	assert.False(s.T(), stored.Georeferenced)
+	assert.Equal(s.T(), 600, stored.Width)
+	assert.Empty(s.T(), stored.Footprint)
+}
+
+func (s *ImageTestSuite) TestPoseFromFlightArchive() {
+	require.NoError(s.T(), s.db.Create(&models.FlightSample{
+		Drone:     models.Drone{Timestamp: 1714898071, Latitude: 49.27, Longitude: -123.25, Altitude: 80, Heading: 90},
+		SessionID: 1,
+	}).Error)
+
+	pose, err := imagery.PoseAt(s.db, 1714898070)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 49.27, pose.Latitude)
+
+	_, err = imagery.PoseAt(s.db, 1714898090)
+	assert.ErrorIs(s.T(), err, imagery.ErrNoTelemetry)
+}
+
+func (s *ImageTestSuite) TestLocate() {
+	pose := models.Drone{Latitude: 49.26, Longitude: -123.24, Altitude: 100, Heading: 90}
+	centre, err := s.camera.Locate(pose, 600, 400, 300, 200)
+	require.NoError(s.T(), err)
+	assert.InDelta(s.T(), pose.Latitude, centre.Latitude, 1e-9)
+	assert.InDelta(s.T(), pose.Longitude, centre.Longitude, 1e-9)
+
+	//Facing east, the top of the image is east of the drone
+	top, err := s.camera.Locate(pose, 600, 400, 300, 0)
+	require.NoError(s.T(), err)
+	assert.Greater(s.T(), top.Longitude, pose.Longitude)
+	assert.InDelta(s.T(), 90, geo.Bearing(pose.Latitude, pose.Longitude, top.Latitude, top.Longitude), 0.01)
+
+	pose.Altitude = 0
+	_, err = s.camera.Locate(pose, 600, 400, 300, 200)
+	assert.ErrorIs(s.T(), err, imagery.ErrNoAltitude)
+	_, err = imagery.Camera{}.Locate(models.Drone{Altitude: 100}, 600, 400, 300, 200)
+	assert.ErrorIs(s.T(), err, imagery.ErrNoCamera)
+}
+
+func (s *ImageTestSuite) locate(filename string, body string) *httptest.ResponseRecorder {
+	var req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	var rec = httptest.NewRecorder()
+	var c = s.e.NewContext(req, rec)
+	c.Set("db", s.db)
+	c.Set("camera", s.camera)
+	c.SetParamNames("filename")
+	c.SetParamValues(filename)
+	require.NoError(s.T(), controllers.LocatePixel(c))
+	return rec
+}
+
+func (s *ImageTestSuite) TestLocatePixel() {
+	require.NoError(s.T(), s.db.Create(&[]models.Image{
+		{Timestamp: 1714898080, Filename: "1714898080.png", Width: 600, Height: 400, Georeferenced: true,
+			Latitude: 49.26, Longitude: -123.24, Altitude: 100},
+		{Timestamp: 1714898081, Filename: "1714898081.png", Width: 600, Height: 400},
+	}).Error)
+
+	rec := s.locate("1714898080.png", `{"x": 300, "y": 0}`)
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	var result controllers.LocateResult
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &result))
+	assert.InDelta(s.T(), 100*15.6/16/2, geo.Distance(49.26, -123.24, result.Location.Latitude, result.Location.Longitude), 0.01)
+	assert.Greater(s.T(), result.Location.Latitude, 49.26, "the top of the image faces north")
+	assert.Nil(s.T(), result.Object)
+
+	assert.Equal(s.T(), http.StatusConflict, s.locate("1714898081.png", `{"x": 300, "y": 0}`).Code)
+	assert.Equal(s.T(), http.StatusNotFound, s.locate("1714898099.png", `{"x": 300, "y": 0}`).Code)
+	assert.Equal(s.T(), http.StatusBadRequest, s.locate("1714898080.png", `{"x": 601, "y": 0}`).Code)
+	assert.Equal(s.T(), http.StatusBadRequest, s.locate("1714898080.png", `{"x": 10, "y": 10, "object": {"object_type": "emergent"}}`).Code)
+	var count int64
+	s.db.Model(&models.GroundObject{}).Count(&count)
+	assert.Zero(s.T(), count, "invalid objects aren't created")
+
+	rec = s.locate("1714898080.png", `{"x": 590, "y": 200, "object": {"object_type": "emergent", "description": "Tent", "confidence": 0.7}}`)
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &result))
+	require.NotNil(s.T(), result.Object)
+	require.NotNil(s.T(), result.Detection)
+	assert.Equal(s.T(), result.Location.Latitude, result.Object.Latitude)
+	assert.Equal(s.T(), result.Object.ID, result.Detection.GroundObjectID)
+	assert.Equal(s.T(), "1714898080.png", result.Detection.Image)
+	assert.Equal(s.T(), 0.7, result.Detection.Confidence)
+	assert.Equal(s.T(), image.Rect(536, 168, 600, 232), result.Detection.Bounds(), "moved inside the image")
+
+	var stored models.Detection
+	require.NoError(s.T(), s.db.First(&stored, result.Detection.ID).Error)
+	assert.Equal(s.T(), result.Object.ID, stored.GroundObjectID)
+}
+
+func (s *ImageTestSuite) TestTerrain() {
+	dem, err := imagery.ReadDEM(strings.NewReader(`ncols 2
+nrows 2
+xllcorner -123.25
+yllcorner 49.25
+cellsize 0.01
+NODATA_value -9999
+20 40
+0 -9999`))
+	require.NoError(s.T(), err)
+
+	//Cell centres are at 49.255 and 49.265 north, -123.245 and -123.235 east
+	height, err := dem.Elevation(49.265, -123.24)
+	require.NoError(s.T(), err)
+	assert.InDelta(s.T(), 30, height, 1e-9)
+	height, err = dem.Elevation(49.2675, -123.249)
+	require.NoError(s.T(), err)
+	assert.InDelta(s.T(), 20, height, 1e-9, "edge cells are extended to the edge of the grid")
+	_, err = dem.Elevation(49.255, -123.235)
+	assert.ErrorIs(s.T(), err, imagery.ErrOutsideTerrain, "no data")
+	_, err = dem.Elevation(49.3, -123.24)
+	assert.ErrorIs(s.T(), err, imagery.ErrOutsideTerrain)
+
+	_, err = imagery.ReadDEM(strings.NewReader("ncols 2\nnrows 2\ncellsize 1\n1 2 3 4"))
+	assert.Error(s.T(), err, "no corner")
+	_, err = imagery.ReadDEM(strings.NewReader("ncols 2\nnrows 2\nxllcorner 0\nyllcorner 0\ncellsize 1\n1 2 3"))
+	assert.Error(s.T(), err, "too few heights")
+
+	//Ground 40m up leaves the drone 60m above it, so pixels land closer
+	pose := models.Drone{Latitude: 49.26, Longitude: -123.24, Altitude: 100}
+	point, err := s.camera.Project(pose, 600, 400, 300, 0, imagery.FlatGround{Height: 40})
+	require.NoError(s.T(), err)
+	assert.InDelta(s.T(), 60*15.6/16/2, geo.Distance(49.26, -123.24, point.Latitude, point.Longitude), 0.01)
+
+	flat, err := s.camera.Project(pose, 600, 400, 300, 0, &imagery.DEM{Rows: 1, Columns: 1, South: 49.26, West: -123.24,
+		CellSize: 1, Heights: [][]float64{{140}}, Offset: 100})
+	require.NoError(s.T(), err)
+	assert.InDelta(s.T(), point.Latitude, flat.Latitude, 1e-9, "offset by the height of home")
+}
@@ -0,0 +1,423 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"gcom-backend/configs"
+	"gcom-backend/controllers"
+	"gcom-backend/interop"
+	"gcom-backend/models"
+	"gcom-backend/responses"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+// stubInterop imitates the competition's interop server
+type stubInterop struct {
+	mu        sync.Mutex
+	sessions  map[string]bool
+	logins    int
+	telemetry []interop.Telemetry
+	odlcs     map[int]interop.ODLC
+	images    map[int][]byte
+	lastID    int
+	requests  []string
+	failODLCs bool
+}
+
+func newStubInterop() *stubInterop {
+	return &stubInterop{sessions: map[string]bool{}, odlcs: map[int]interop.ODLC{}, images: map[int][]byte{}}
+}
+
+// expire forgets every session, as when the server restarts
+func (s *stubInterop) expire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions = map[string]bool{}
+}
+
+// take returns and forgets the requests made so far, other than logins
+func (s *stubInterop) take() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	requests := s.requests
+	s.requests = nil
+	return requests
+}
+
+func (s *stubInterop) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path == "/api/login" {
+		var credentials map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&credentials)
+		if credentials["username"] != "testuser" || credentials["password"] != "testpass" {
+			http.Error(w, "Invalid credentials", http.StatusBadRequest)
+			return
+		}
+		s.logins++
+		session := strconv.Itoa(s.logins)
+		s.sessions[session] = true
+		http.SetCookie(w, &http.Cookie{Name: "sessionid", Value: session, Path: "/"})
+		return
+	}
+	if cookie, err := r.Cookie("sessionid"); err != nil || !s.sessions[cookie.Value] {
+		http.Error(w, "User not authenticated", http.StatusForbidden)
+		return
+	}
+
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.URL.Path == "/api/missions/1":
+		_, _ = w.Write([]byte(`{"id": 1, "lostCommsPos": {"latitude": 38.144, "longitude": -76.428},
+			"flyZones": [{"altitudeMin": 100, "altitudeMax": 750, "boundaryPoints": [{"latitude": 38.142, "longitude": -76.434},
+				{"latitude": 38.149, "longitude": -76.432}, {"latitude": 38.146, "longitude": -76.426}]}],
+			"waypoints": [{"latitude": 38.150, "longitude": -76.430, "altitude": 200}, {"latitude": 38.147, "longitude": -76.427, "altitude": 300}],
+			"searchGridPoints": [{"latitude": 38.143, "longitude": -76.434}, {"latitude": 38.144, "longitude": -76.431}],
+			"offAxisOdlcPos": {"latitude": 38.145, "longitude": -76.426},
+			"emergentLastKnownPos": {"latitude": 38.146, "longitude": -76.427},
+			"stationaryObstacles": [{"latitude": 38.146, "longitude": -76.429, "radius": 50, "height": 200}]}`))
+	case r.URL.Path == "/api/missions/2":
+		http.Error(w, "Mission not found", http.StatusNotFound)
+	case r.URL.Path == "/api/telemetry":
+		var telemetry interop.Telemetry
+		_ = json.NewDecoder(r.Body).Decode(&telemetry)
+		s.telemetry = append(s.telemetry, telemetry)
+	case r.URL.Path == "/api/odlcs" && r.Method == http.MethodPost:
+		if s.failODLCs {
+			http.Error(w, "Server error", http.StatusInternalServerError)
+			return
+		}
+		var odlc interop.ODLC
+		_ = json.NewDecoder(r.Body).Decode(&odlc)
+		s.lastID++
+		odlc.ID = s.lastID
+		s.odlcs[odlc.ID] = odlc
+		_ = json.NewEncoder(w).Encode(odlc)
+	case len(parts) >= 3 && parts[1] == "odlcs":
+		id, _ := strconv.Atoi(parts[2])
+		if _, ok := s.odlcs[id]; !ok {
+			http.Error(w, "ODLC not found", http.StatusNotFound)
+			return
+		}
+		switch {
+		case len(parts) == 4 && parts[3] == "image":
+			if r.Header.Get("Content-Type") != "image/png" {
+				http.Error(w, "Expected a PNG", http.StatusBadRequest)
+				return
+			}
+			s.images[id], _ = io.ReadAll(r.Body)
+		case r.Method == http.MethodPut:
+			var odlc interop.ODLC
+			_ = json.NewDecoder(r.Body).Decode(&odlc)
+			s.odlcs[id] = odlc
+			_ = json.NewEncoder(w).Encode(odlc)
+		case r.Method == http.MethodDelete:
+			delete(s.odlcs, id)
+		default:
+			_ = json.NewEncoder(w).Encode(s.odlcs[id])
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// stubSource is live telemetry which can be paused or replayed
+type stubSource struct {
+	mu        sync.Mutex
+	drone     models.Drone
+	ok        bool
+	replaying bool
+}
+
+func (s *stubSource) Latest() (models.Drone, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ok {
+		s.drone.Timestamp = time.Now().Unix()
+	}
+	return s.drone, s.ok
+}
+
+func (s *stubSource) Replaying() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.replaying
+}
+
+type InteropTestSuite struct {
+	suite.Suite
+	e          *echo.Echo
+	db         *gorm.DB
+	stub       *stubInterop
+	server     *httptest.Server
+	source     *stubSource
+	connection *interop.Interop
+}
+
+func TestRunInteropSuite(t *testing.T) {
+	suite.Run(t, new(InteropTestSuite))
+}
+
+func (s *InteropTestSuite) SetupSuite() {
+	s.db = configs.Connect(true)
+	s.e = echo.New()
+	require.NoError(s.T(), os.MkdirAll("imgs", 0755))
+}
+
+func (s *InteropTestSuite) TearDownSuite() {
+	sqlDB, _ := s.db.DB()
+	if err := sqlDB.Close(); err != nil {
+		fmt.Println("[Teardown] Error closing database connection!")
+	}
+
+	if err := os.Remove("database.db"); err != nil {
+		fmt.Println("[Teardown] Error deleting database!")
+	}
+	if err := os.RemoveAll("imgs"); err != nil {
+		fmt.Println("[Teardown] Error deleting images!")
+	}
+}
+
+func (s *InteropTestSuite) SetupTest() {
+	s.stub = newStubInterop()
+	s.server = httptest.NewServer(s.stub)
+	s.source = &stubSource{}
+	s.connection = interop.New(s.db, s.source, interop.Config{URL: s.server.URL, Username: "testuser",
+		Password: "testpass", Mission: 1, TelemetryRate: 20, HomeAltitude: 10})
+}
+
+func (s *InteropTestSuite) TearDownTest() {
+	s.connection.Uploader.Stop()
+	s.server.Close()
+	for _, model := range []any{&models.GroundObject{}, &models.Detection{}, &models.Image{}, &models.Waypoint{},
+		&models.Mission{}, &models.MissionWaypoint{}, &models.MissionVersion{}, &models.InteropMission{}, &models.InteropSubmission{}} {
+		s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(model)
+	}
+}
+
+func (s *InteropTestSuite) request(handler echo.HandlerFunc, body string) *httptest.ResponseRecorder {
+	var req = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader([]byte(body)))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	var rec = httptest.NewRecorder()
+	var c = s.e.NewContext(req, rec)
+	c.Set("db", s.db)
+	c.Set("interop", s.connection)
+	c.Set("user", models.User{Username: "operator", Role: models.Operator})
+	require.NoError(s.T(), handler(c))
+	return rec
+}
+
+func (s *InteropTestSuite) TestLogin() {
+	wrong := interop.NewClient(s.server.URL, "testuser", "guess")
+	err := wrong.Login(context.Background())
+	require.Error(s.T(), err)
+	assert.Contains(s.T(), err.Error(), "400")
+	assert.False(s.T(), wrong.LoggedIn())
+
+	//The first request logs in, and a refused session logs in again
+	client := s.connection.Client
+	_, err = client.Mission(context.Background(), 1)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), client.LoggedIn())
+	s.stub.expire()
+	_, err = client.Mission(context.Background(), 1)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 2, s.stub.logins)
+
+	_, err = client.Mission(context.Background(), 2)
+	assert.Equal(s.T(), http.StatusNotFound, err.(*interop.StatusError).Status)
+}
+
+func (s *InteropTestSuite) TestImportMission() {
+	rec := s.request(controllers.ImportInteropMission, `{}`)
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	var imported responses.SingleResponse[models.InteropMission]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &imported))
+	assert.Equal(s.T(), 1, imported.Model.ID)
+	require.Len(s.T(), imported.Model.FlyZones, 1)
+	assert.InDelta(s.T(), 100*interop.MetresPerFoot-10, imported.Model.FlyZones[0].AltitudeMin, 1e-9, "above home")
+	assert.Len(s.T(), imported.Model.FlyZones[0].Boundary, 3)
+	assert.Len(s.T(), imported.Model.SearchGrid, 2)
+	assert.Equal(s.T(), 38.146, imported.Model.EmergentLastKnown.Latitude)
+
+	mission := models.Mission{}
+	require.NoError(s.T(), s.db.Preload("Waypoints.Waypoint").First(&mission, imported.Model.MissionID).Error)
+	assert.Equal(s.T(), models.MissionDraft, mission.Status)
+	assert.Equal(s.T(), 1, mission.Version)
+	require.Len(s.T(), mission.Waypoints, 2)
+	assert.InDelta(s.T(), 200*interop.MetresPerFoot-10, mission.Waypoints[0].Waypoint.Altitude, 1e-9)
+
+	require.Len(s.T(), imported.Model.ObstacleIDs, 1)
+	var obstacle models.Waypoint
+	require.NoError(s.T(), s.db.First(&obstacle, imported.Model.ObstacleIDs[0]).Error)
+	assert.Equal(s.T(), models.Obstacle, obstacle.Designation)
+	assert.InDelta(s.T(), 50*interop.MetresPerFoot, obstacle.Radius, 1e-9)
+	assert.InDelta(s.T(), 200*interop.MetresPerFoot, obstacle.Altitude, 1e-9, "obstacles stand on the ground")
+
+	rec = s.request(controllers.GetInteropMission, "")
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	var fetched responses.SingleResponse[models.InteropMission]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &fetched))
+	assert.Equal(s.T(), imported.Model.MissionID, fetched.Model.MissionID)
+
+	assert.Equal(s.T(), http.StatusBadGateway, s.request(controllers.ImportInteropMission, `{"id": 2}`).Code)
+}
+
+func (s *InteropTestSuite) TestTelemetryUpload() {
+	s.source.drone = models.Drone{Latitude: 38.145, Longitude: -76.428, Altitude: 90, Heading: 45}
+	s.source.ok = true
+	s.connection.Uploader.Start()
+
+	require.Eventually(s.T(), func() bool {
+		return s.connection.Uploader.Status().Uploaded >= 5
+	}, 5*time.Second, 10*time.Millisecond)
+	status := s.connection.Uploader.Status()
+	assert.True(s.T(), status.Running)
+	assert.Greater(s.T(), status.Rate, 0.0)
+	assert.Zero(s.T(), status.Failed)
+
+	s.stub.mu.Lock()
+	sent := s.stub.telemetry[0]
+	s.stub.mu.Unlock()
+	assert.Equal(s.T(), 38.145, sent.Latitude)
+	assert.InDelta(s.T(), 100/interop.MetresPerFoot, sent.Altitude, 1e-9, "feet above sea level")
+
+	//Nothing is sent while replaying
+	s.source.mu.Lock()
+	s.source.replaying = true
+	s.source.mu.Unlock()
+	time.Sleep(100 * time.Millisecond)
+	uploaded := s.connection.Uploader.Status().Uploaded
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(s.T(), uploaded, s.connection.Uploader.Status().Uploaded)
+
+	s.connection.Uploader.Stop()
+	assert.False(s.T(), s.connection.Uploader.Status().Running)
+
+	rec := s.request(controllers.GetInteropStatus, "")
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	var interopStatus responses.SingleResponse[controllers.InteropStatus]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &interopStatus))
+	assert.True(s.T(), interopStatus.Model.LoggedIn)
+	assert.Equal(s.T(), uploaded, interopStatus.Model.Telemetry.Uploaded)
+}
+
+// object creates a reviewed ground object, with a detection in a saved image
+func (s *InteropTestSuite) object(status models.ReviewStatus, shape models.Shape) models.GroundObject {
+	object := models.GroundObject{Type: models.Standard, Latitude: 38.145, Longitude: -76.428, Shape: shape,
+		Color: models.White, Text: "A", TextColor: models.Black, Orientation: models.NorthEast, ReviewStatus: status}
+	require.NoError(s.T(), s.db.Create(&object).Error)
+
+	filename := fmt.Sprintf("%d.png", 1714898000+object.ID)
+	file, err := os.Create("imgs/" + filename)
+	require.NoError(s.T(), err)
+	require.NoError(s.T(), png.Encode(file, image.NewGray(image.Rect(0, 0, 100, 80))))
+	require.NoError(s.T(), file.Close())
+	require.NoError(s.T(), s.db.Create(&models.Image{Timestamp: int64(1714898000 + object.ID), Filename: filename}).Error)
+	require.NoError(s.T(), s.db.Create(&models.Detection{GroundObjectID: object.ID, Image: filename, X: 10, Y: 10,
+		Width: 30, Height: 20}).Error)
+	return object
+}
+
+func (s *InteropTestSuite) submit() []models.InteropSubmission {
+	rec := s.request(controllers.SubmitGroundObjects, "")
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	var submissions responses.MultipleResponse[models.InteropSubmission]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &submissions))
+	return submissions.Models
+}
+
+func (s *InteropTestSuite) TestSubmitObjects() {
+	approved := s.object(models.Approved, models.QuarterCircle)
+	s.object(models.Pending, models.Circle)
+
+	submissions := s.submit()
+	require.Len(s.T(), submissions, 1, "only approved objects are submitted")
+	assert.Equal(s.T(), approved.ID, submissions[0].GroundObjectID)
+	assert.Equal(s.T(), models.SubmissionComplete, submissions[0].Status)
+	interopID := submissions[0].InteropID
+	assert.Equal(s.T(), []string{"POST /api/odlcs", fmt.Sprintf("PUT /api/odlcs/%d/image", interopID)}, s.stub.take())
+
+	s.stub.mu.Lock()
+	odlc := s.stub.odlcs[interopID]
+	thumbnail, err := png.Decode(bytes.NewReader(s.stub.images[interopID]))
+	s.stub.mu.Unlock()
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), image.Pt(30, 20), thumbnail.Bounds().Size(), "cropped to the detection")
+	assert.Equal(s.T(), interop.ODLC{ID: interopID, Mission: 1, Type: "STANDARD", Latitude: 38.145, Longitude: -76.428,
+		Orientation: "NE", Shape: "QUARTER_CIRCLE", ShapeColor: "WHITE", Alphanumeric: "A", AlphanumericColor: "BLACK"}, odlc)
+
+	//Unchanged objects aren't sent again, changed ones are updated
+	s.submit()
+	assert.Empty(s.T(), s.stub.take())
+	require.NoError(s.T(), s.db.Model(&approved).Update("text", "B").Error)
+	submissions = s.submit()
+	assert.Equal(s.T(), []string{fmt.Sprintf("PUT /api/odlcs/%d", interopID)}, s.stub.take())
+	assert.Equal(s.T(), interopID, submissions[0].InteropID)
+
+	//Objects rejected after submission are withdrawn
+	require.NoError(s.T(), s.db.Model(&approved).Update("review_status", models.Rejected).Error)
+	submissions = s.submit()
+	require.Len(s.T(), submissions, 1)
+	assert.Equal(s.T(), models.SubmissionWithdrawn, submissions[0].Status)
+	assert.Equal(s.T(), []string{fmt.Sprintf("DELETE /api/odlcs/%d", interopID)}, s.stub.take())
+
+	rec := s.request(controllers.GetInteropSubmissions, "")
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	var listed responses.MultipleResponse[models.InteropSubmission]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &listed))
+	require.Len(s.T(), listed.Models, 1)
+	assert.Equal(s.T(), "B", listed.Models[0].Submitted.Text)
+}
+
+func (s *InteropTestSuite) TestSubmitFailure() {
+	object := s.object(models.Approved, models.Star)
+	s.stub.failODLCs = true
+
+	rec := s.request(controllers.SubmitGroundObjects, "")
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	var submissions responses.MultipleResponse[models.InteropSubmission]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &submissions))
+	assert.Equal(s.T(), "1 of 1 submissions failed", submissions.Message)
+	require.Len(s.T(), submissions.Models, 1)
+	assert.Equal(s.T(), models.SubmissionFailed, submissions.Models[0].Status)
+	assert.Contains(s.T(), submissions.Models[0].Error, "500")
+
+	//It is sent once interop recovers
+	s.stub.mu.Lock()
+	s.stub.failODLCs = false
+	s.stub.mu.Unlock()
+	resubmitted := s.submit()
+	assert.Equal(s.T(), object.ID, resubmitted[0].GroundObjectID)
+	assert.Equal(s.T(), models.SubmissionComplete, resubmitted[0].Status)
+	assert.Empty(s.T(), resubmitted[0].Error)
+}
+
+func (s *InteropTestSuite) TestNotConfigured() {
+	var req = httptest.NewRequest(http.MethodPost, "/", nil)
+	var rec = httptest.NewRecorder()
+	var c = s.e.NewContext(req, rec)
+	c.Set("db", s.db)
+	require.NoError(s.T(), controllers.SubmitGroundObjects(c))
+	assert.Equal(s.T(), http.StatusServiceUnavailable, rec.Code)
+}
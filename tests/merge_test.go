@@ -0,0 +1,272 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gcom-backend/clustering"
+	"gcom-backend/configs"
+	"gcom-backend/controllers"
+	"gcom-backend/geo"
+	"gcom-backend/models"
+	"gcom-backend/responses"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type MergeTestSuite struct {
+	suite.Suite
+	e  *echo.Echo
+	db *gorm.DB
+}
+
+func TestRunMergeSuite(t *testing.T) {
+	suite.Run(t, new(MergeTestSuite))
+}
+
+func (s *MergeTestSuite) SetupSuite() {
+	s.db = configs.Connect(true)
+	s.e = echo.New()
+}
+
+func (s *MergeTestSuite) TearDownSuite() {
+	sqlDB, _ := s.db.DB()
+	if err := sqlDB.Close(); err != nil {
+		fmt.Println("[Teardown] Error closing database connection!")
+	}
+
+	if err := os.Remove("database.db"); err != nil {
+		fmt.Println("[Teardown] Error deleting database!")
+	}
+}
+
+func (s *MergeTestSuite) TearDownTest() {
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.GroundObject{})
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.Detection{})
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.GroundObjectMerge{})
+}
+
+func (s *MergeTestSuite) request(handler echo.HandlerFunc, target string, body string, param string) *httptest.ResponseRecorder {
+	var req = httptest.NewRequest(http.MethodPost, target, bytes.NewReader([]byte(body)))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	var rec = httptest.NewRecorder()
+	var c = s.e.NewContext(req, rec)
+	c.Set("db", s.db)
+	c.Set("user", models.User{Username: "operator", Role: models.Operator})
+	if param != "" {
+		c.SetParamNames("mergeId")
+		c.SetParamValues(param)
+	}
+	require.NoError(s.T(), handler(c))
+	return rec
+}
+
+// star creates a red star with a white alphanumeric distance metres north
+// of the same point
+func (s *MergeTestSuite) star(text string, distance float64, confidence float64) models.GroundObject {
+	lat, long := geo.Destination(49.26, -123.24, 0, distance)
+	object := models.GroundObject{Type: models.Standard, Latitude: lat, Longitude: long, Shape: models.Star,
+		Color: models.Red, Text: text, TextColor: models.White, Confidence: confidence}
+	require.NoError(s.T(), s.db.Create(&object).Error)
+	return object
+}
+
+func (s *MergeTestSuite) detect(object models.GroundObject, count int) {
+	for i := 0; i < count; i++ {
+		require.NoError(s.T(), s.db.Create(&models.Detection{GroundObjectID: object.ID, Image: "1714898050.png",
+			Width: 10, Height: 10}).Error)
+	}
+}
+
+func (s *MergeTestSuite) listed(query string) []int {
+	var req = httptest.NewRequest(http.MethodGet, "/groundobjects"+query, nil)
+	var rec = httptest.NewRecorder()
+	var c = s.e.NewContext(req, rec)
+	c.Set("db", s.db)
+	require.NoError(s.T(), controllers.GetAllGroundObjects(c))
+	var objects responses.MultipleResponse[models.GroundObject]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &objects))
+	var ids []int
+	for _, object := range objects.Models {
+		ids = append(ids, object.ID)
+	}
+	return ids
+}
+
+func (s *MergeTestSuite) TestProposals() {
+	a := s.star("A", 0, 0.9)
+	b := s.star("A", 5, 0.3)
+	s.star("A", 100, 0.8)
+	s.star("B", 2, 0.8)
+	tent := models.GroundObject{Type: models.Emergent, Latitude: 49.26, Longitude: -123.24, Description: "Tent", Confidence: 0.5}
+	require.NoError(s.T(), s.db.Create(&tent).Error)
+	lat, long := geo.Destination(49.26, -123.24, 90, 3)
+	camp := models.GroundObject{Type: models.Emergent, Latitude: lat, Longitude: long, Description: "Campfire", Confidence: 0.5}
+	require.NoError(s.T(), s.db.Create(&camp).Error)
+	s.detect(a, 2)
+	s.detect(b, 1)
+
+	rec := s.request(controllers.GetMergeProposals, "/groundobjects/clusters", "", "")
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	var proposals responses.MultipleResponse[models.MergeProposal]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &proposals))
+	require.Len(s.T(), proposals.Models, 2, "only alike objects close together")
+
+	stars := proposals.Models[0]
+	assert.Equal(s.T(), []int{a.ID, b.ID}, stars.ObjectIDs)
+	assert.Equal(s.T(), a.ID, stars.KeepID)
+	assert.Equal(s.T(), 0.9, stars.Confidence)
+	assert.Equal(s.T(), 3, stars.Detections)
+	//Weighted 3 to 1 towards the first star
+	assert.InDelta(s.T(), 1.25, geo.Distance(49.26, -123.24, stars.Latitude, stars.Longitude), 0.01)
+	assert.InDelta(s.T(), 3.75, stars.Spread, 0.01)
+	assert.Equal(s.T(), []int{tent.ID, camp.ID}, proposals.Models[1].ObjectIDs)
+
+	rec = s.request(controllers.GetMergeProposals, "/groundobjects/clusters?radius=1", "", "")
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &proposals))
+	assert.Empty(s.T(), proposals.Models)
+	assert.Equal(s.T(), http.StatusBadRequest, s.request(controllers.GetMergeProposals, "/groundobjects/clusters?radius=-1", "", "").Code)
+
+	assert.True(s.T(), clustering.Alike(a, models.GroundObject{Type: models.Standard, Shape: models.Star,
+		Color: models.Red, Text: "a", TextColor: models.White}), "alphanumerics ignore case")
+}
+
+func (s *MergeTestSuite) merge(body string) (*httptest.ResponseRecorder, models.GroundObjectMerge) {
+	rec := s.request(controllers.MergeGroundObjects, "/groundobjects/merge", body, "")
+	var merge responses.SingleResponse[models.GroundObjectMerge]
+	if rec.Code == http.StatusOK {
+		require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &merge))
+	}
+	return rec, merge.Model
+}
+
+func (s *MergeTestSuite) TestMergeAndSplit() {
+	a := s.star("A", 0, 0.6)
+	b := s.star("A", 4, 0.2)
+	c := s.star("A", 8, 0.8)
+	s.detect(a, 1)
+	s.detect(b, 2)
+
+	rec, merge := s.merge(fmt.Sprintf(`{"ids": [%d, %d]}`, a.ID, b.ID))
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(s.T(), a.ID, merge.KeptID, "the most confident is kept")
+	assert.Equal(s.T(), []int{b.ID}, merge.MergedIDs)
+	assert.Equal(s.T(), "operator", merge.Author)
+	assert.Len(s.T(), merge.MovedDetections, 2)
+	require.Len(s.T(), merge.Before, 2)
+	assert.Equal(s.T(), a, merge.Before[0])
+
+	var kept models.GroundObject
+	require.NoError(s.T(), s.db.First(&kept, a.ID).Error)
+	assert.InDelta(s.T(), 1, geo.Distance(49.26, -123.24, kept.Latitude, kept.Longitude), 0.01)
+	var count int64
+	s.db.Model(&models.Detection{}).Where("ground_object_id = ?", a.ID).Count(&count)
+	assert.Equal(s.T(), int64(3), count)
+	assert.Equal(s.T(), []int{a.ID, c.ID}, s.listed(""), "merged objects are hidden")
+	assert.Equal(s.T(), []int{a.ID, b.ID, c.ID}, s.listed("?include_merged=true"))
+
+	rec, _ = s.merge(fmt.Sprintf(`{"ids": [%d, %d]}`, b.ID, c.ID))
+	assert.Equal(s.T(), http.StatusConflict, rec.Code, "already merged")
+	for _, body := range []string{`{"ids": [1]}`, fmt.Sprintf(`{"ids": [%d, %d]}`, a.ID, a.ID),
+		fmt.Sprintf(`{"ids": [%d, %d], "keep": 999}`, a.ID, c.ID)} {
+		rec, _ = s.merge(body)
+		assert.Equal(s.T(), http.StatusBadRequest, rec.Code, body)
+	}
+	rec, _ = s.merge(fmt.Sprintf(`{"ids": [%d, 999]}`, a.ID))
+	assert.Equal(s.T(), http.StatusNotFound, rec.Code)
+
+	//A second merge into the same object, keeping the less confident one
+	rec, second := s.merge(fmt.Sprintf(`{"ids": [%d, %d], "keep": %d}`, c.ID, a.ID, a.ID))
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(s.T(), a.ID, second.KeptID)
+	require.NoError(s.T(), s.db.First(&kept, a.ID).Error)
+	assert.Equal(s.T(), 0.8, kept.Confidence)
+
+	rec = s.request(controllers.SplitGroundObjectMerge, "/", "", fmt.Sprint(merge.ID))
+	assert.Equal(s.T(), http.StatusConflict, rec.Code, "the later merge is split first")
+	rec = s.request(controllers.SplitGroundObjectMerge, "/", "", fmt.Sprint(second.ID))
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	rec = s.request(controllers.SplitGroundObjectMerge, "/", "", fmt.Sprint(merge.ID))
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	rec = s.request(controllers.SplitGroundObjectMerge, "/", "", fmt.Sprint(merge.ID))
+	assert.Equal(s.T(), http.StatusConflict, rec.Code, "already split")
+	assert.Equal(s.T(), http.StatusNotFound, s.request(controllers.SplitGroundObjectMerge, "/", "", "999").Code)
+
+	require.NoError(s.T(), s.db.First(&kept, a.ID).Error)
+	assert.Equal(s.T(), a, kept, "back where it was")
+	s.db.Model(&models.Detection{}).Where("ground_object_id = ?", b.ID).Count(&count)
+	assert.Equal(s.T(), int64(2), count)
+	assert.Equal(s.T(), []int{a.ID, b.ID, c.ID}, s.listed(""))
+
+	//Both merges are kept in the history
+	rec = s.request(controllers.GetGroundObjectMerges, fmt.Sprintf("/groundobjects/merges?object_id=%d", a.ID), "", "")
+	var merges responses.MultipleResponse[models.GroundObjectMerge]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &merges))
+	require.Len(s.T(), merges.Models, 2)
+	assert.Equal(s.T(), second.ID, merges.Models[0].ID)
+	assert.Equal(s.T(), "operator", merges.Models[1].SplitBy)
+	assert.NotZero(s.T(), merges.Models[1].SplitAt)
+}
+
+func (s *MergeTestSuite) TestMergedIntoIsNotEditable() {
+	a := s.star("A", 0, 0.6)
+	b := s.star("A", 4, 0.2)
+
+	rec := s.request(controllers.CreateGroundObject, "/", `{"id": "-1", "object_type": "emergent", "lat": 49.26, "long": -123.24,
+		"description": "Tent", "merged_into": 1}`, "")
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+
+	var req = httptest.NewRequest(http.MethodPatch, "/", bytes.NewReader([]byte(fmt.Sprintf(`{"merged_into": %d}`, a.ID))))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec = httptest.NewRecorder()
+	var c = s.e.NewContext(req, rec)
+	c.Set("db", s.db)
+	c.SetParamNames("objectId")
+	c.SetParamValues(fmt.Sprint(b.ID))
+	require.NoError(s.T(), controllers.EditGroundObject(c))
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+}
+
+func (s *MergeTestSuite) TestMergedIsNotDeletable() {
+	a := s.star("A", 0, 0.6)
+	b := s.star("A", 4, 0.2)
+	s.detect(b, 2)
+	rec, merge := s.merge(fmt.Sprintf(`{"ids": [%d, %d]}`, a.ID, b.ID))
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+
+	remove := func(id int) int {
+		var req = httptest.NewRequest(http.MethodDelete, "/", nil)
+		var rec = httptest.NewRecorder()
+		var c = s.e.NewContext(req, rec)
+		c.Set("db", s.db)
+		c.SetParamNames("objectId")
+		c.SetParamValues(fmt.Sprint(id))
+		require.NoError(s.T(), controllers.DeleteGroundObject(c))
+		return rec.Code
+	}
+	removeBatch := func(ids string) int {
+		return s.request(controllers.DeleteGroundObjectBatch, "/", ids, "").Code
+	}
+
+	//Deleting either would lose the detections moved onto the kept object
+	assert.Equal(s.T(), http.StatusConflict, remove(a.ID), "kept")
+	assert.Equal(s.T(), http.StatusConflict, remove(b.ID), "merged")
+	assert.Equal(s.T(), http.StatusConflict, removeBatch(fmt.Sprintf("[%d]", a.ID)))
+	var count int64
+	s.db.Model(&models.Detection{}).Where("ground_object_id = ?", a.ID).Count(&count)
+	assert.Equal(s.T(), int64(2), count)
+
+	rec = s.request(controllers.SplitGroundObjectMerge, "/", "", fmt.Sprint(merge.ID))
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	s.db.Model(&models.Detection{}).Where("ground_object_id = ?", b.ID).Count(&count)
+	assert.Equal(s.T(), int64(2), count)
+	assert.Equal(s.T(), http.StatusOK, removeBatch(fmt.Sprintf("[%d, %d]", a.ID, b.ID)), "split merges don't stop deletion")
+}
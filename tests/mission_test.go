@@ -0,0 +1,395 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gcom-backend/configs"
+	"gcom-backend/controllers"
+	"gcom-backend/models"
+	"gcom-backend/progress"
+	"gcom-backend/responses"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type MissionTestSuite struct {
+	suite.Suite
+	e         *echo.Echo
+	db        *gorm.DB
+	mps       *httptest.Server
+	mp        *configs.MissionPlanner
+	tracker   *progress.Tracker
+	queue     []byte
+	waypoints []models.Waypoint
+}
+
+func TestRunMissionSuite(t *testing.T) {
+	suite.Run(t, new(MissionTestSuite))
+}
+
+func (s *MissionTestSuite) SetupSuite() {
+	s.db = configs.Connect(true)
+	s.e = echo.New()
+	s.tracker = progress.NewTracker(s.db)
+
+	//Stands in for MPS, remembering the last queue it was sent
+	s.mps = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/queue" && r.Method == http.MethodPost {
+			s.queue, _ = io.ReadAll(r.Body)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	s.mp, _ = configs.ConnectMissionPlanner(s.mps.URL)
+}
+
+func (s *MissionTestSuite) TearDownSuite() {
+	s.mps.Close()
+	sqlDB, _ := s.db.DB()
+	if err := sqlDB.Close(); err != nil {
+		fmt.Println("[Teardown] Error closing database connection!")
+	}
+
+	if err := os.Remove("database.db"); err != nil {
+		fmt.Println("[Teardown] Error deleting database!")
+	}
+}
+
+func (s *MissionTestSuite) SetupTest() {
+	s.waypoints = []models.Waypoint{
+		{Name: "Alpha", Latitude: 49.26, Longitude: -123.24, Altitude: 100},
+		{Name: "Beta", Latitude: 49.27, Longitude: -123.25, Altitude: 110},
+		{Name: "Charlie", Latitude: 49.28, Longitude: -123.26, Altitude: 120},
+	}
+	require.NoError(s.T(), s.db.Create(&s.waypoints).Error)
+}
+
+func (s *MissionTestSuite) TearDownTest() {
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.MissionVersion{})
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.MissionWaypoint{})
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.Mission{})
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.Waypoint{})
+}
+
+func (s *MissionTestSuite) context(method string, uri string, body []byte) (echo.Context, *httptest.ResponseRecorder) {
+	var req = httptest.NewRequest(method, uri, bytes.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	var rec = httptest.NewRecorder()
+	var c = s.e.NewContext(req, rec)
+	c.Set("db", s.db)
+	c.Set("mp", s.mp)
+	c.Set("progress", s.tracker)
+
+	return c, rec
+}
+
+// createMission creates a mission visiting the given waypoints in the order given
+func (s *MissionTestSuite) createMission(name string, waypoints ...models.Waypoint) models.Mission {
+	mission := models.Mission{ID: -1, Name: name}
+	for i, wp := range waypoints {
+		mission.Waypoints = append(mission.Waypoints, models.MissionWaypoint{Sequence: i, WaypointID: wp.ID})
+	}
+	missionBytes, _ := json.Marshal(&mission)
+
+	c, rec := s.context(http.MethodPost, "/mission", missionBytes)
+	require.NoError(s.T(), controllers.CreateMission(c))
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+
+	var response responses.SingleResponse[models.Mission]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &response))
+	return response.Model
+}
+
+func (s *MissionTestSuite) TestCreateMission() {
+	mission := s.createMission("Task 1 route", s.waypoints[2], s.waypoints[0])
+
+	assert.Equal(s.T(), "Task 1 route", mission.Name)
+	assert.Equal(s.T(), models.MissionDraft, mission.Status)
+	require.Len(s.T(), mission.Waypoints, 2)
+	assert.Equal(s.T(), "Charlie", mission.Waypoints[0].Waypoint.Name)
+	assert.Equal(s.T(), "Alpha", mission.Waypoints[1].Waypoint.Name)
+}
+
+func (s *MissionTestSuite) TestCreateMissionMissingWaypoint() {
+	missing := models.Waypoint{ID: s.waypoints[2].ID + 100}
+	missionBytes, _ := json.Marshal(models.Mission{
+		ID:        -1,
+		Name:      "Broken",
+		Waypoints: []models.MissionWaypoint{{Sequence: 0, WaypointID: missing.ID}},
+	})
+
+	c, rec := s.context(http.MethodPost, "/mission", missionBytes)
+	require.NoError(s.T(), controllers.CreateMission(c))
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+}
+
+func (s *MissionTestSuite) TestMissionRevisitsWaypoint() {
+	//Laps fly over the same waypoint more than once
+	alpha, beta := s.waypoints[0], s.waypoints[1]
+	mission := s.createMission("Twice around", alpha, beta, alpha, beta)
+	require.Len(s.T(), mission.Waypoints, 4)
+	assert.Equal(s.T(), "Alpha", mission.Waypoints[2].Waypoint.Name)
+
+	c, rec := s.context(http.MethodPost, "/mission", nil)
+	c.SetPath("/mission/:missionId/activate")
+	c.SetParamNames("missionId")
+	c.SetParamValues(strconv.Itoa(mission.ID))
+	require.NoError(s.T(), controllers.ActivateMission(c))
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+
+	var queue []map[string]interface{}
+	require.NoError(s.T(), json.Unmarshal(s.queue, &queue))
+	require.Len(s.T(), queue, 4)
+	assert.Equal(s.T(), "Alpha", queue[2]["name"])
+	assert.Equal(s.T(), 4, s.tracker.Snapshot().Total)
+}
+
+func (s *MissionTestSuite) TestEditMissionReordersWaypoints() {
+	mission := s.createMission("Search route", s.waypoints[0], s.waypoints[1])
+
+	editJSON := fmt.Sprintf(`{"description": "reversed", "waypoints": [
+		{"sequence": 5, "waypoint_id": %d},
+		{"sequence": 1, "waypoint_id": %d}
+	]}`, s.waypoints[0].ID, s.waypoints[1].ID)
+
+	c, rec := s.context(http.MethodPatch, "/mission", []byte(editJSON))
+	c.SetPath("/mission/:missionId")
+	c.SetParamNames("missionId")
+	c.SetParamValues(strconv.Itoa(mission.ID))
+	require.NoError(s.T(), controllers.EditMission(c))
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+
+	var response responses.SingleResponse[models.Mission]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(s.T(), "Search route", response.Model.Name)
+	assert.Equal(s.T(), "reversed", response.Model.Description)
+	require.Len(s.T(), response.Model.Waypoints, 2)
+	assert.Equal(s.T(), "Beta", response.Model.Waypoints[0].Waypoint.Name)
+	assert.Equal(s.T(), "Alpha", response.Model.Waypoints[1].Waypoint.Name)
+}
+
+func (s *MissionTestSuite) TestDuplicateMission() {
+	mission := s.createMission("Task 1 route", s.waypoints[0], s.waypoints[1])
+
+	c, rec := s.context(http.MethodPost, "/mission", nil)
+	c.SetPath("/mission/:missionId/duplicate")
+	c.SetParamNames("missionId")
+	c.SetParamValues(strconv.Itoa(mission.ID))
+	require.NoError(s.T(), controllers.DuplicateMission(c))
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+
+	var response responses.SingleResponse[models.Mission]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.NotEqual(s.T(), mission.ID, response.Model.ID)
+	assert.Equal(s.T(), "Task 1 route (copy)", response.Model.Name)
+	require.Len(s.T(), response.Model.Waypoints, 2)
+	assert.Equal(s.T(), mission.Waypoints[0].WaypointID, response.Model.Waypoints[0].WaypointID)
+}
+
+func (s *MissionTestSuite) TestActivateMission() {
+	first := s.createMission("Task 1 route", s.waypoints[0])
+	second := s.createMission("Search route", s.waypoints[2], s.waypoints[1])
+
+	for _, mission := range []models.Mission{first, second} {
+		c, rec := s.context(http.MethodPost, "/mission", nil)
+		c.SetPath("/mission/:missionId/activate")
+		c.SetParamNames("missionId")
+		c.SetParamValues(strconv.Itoa(mission.ID))
+		require.NoError(s.T(), controllers.ActivateMission(c))
+		require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	}
+
+	var queue []map[string]interface{}
+	require.NoError(s.T(), json.Unmarshal(s.queue, &queue))
+	require.Len(s.T(), queue, 2)
+	assert.Equal(s.T(), "Charlie", queue[0]["name"])
+	assert.Equal(s.T(), "Beta", queue[1]["name"])
+	assert.Equal(s.T(), 2, s.tracker.Snapshot().Total)
+
+	var missions []models.Mission
+	require.NoError(s.T(), s.db.Order("id").Find(&missions).Error)
+	assert.Equal(s.T(), models.MissionDraft, missions[0].Status)
+	assert.Equal(s.T(), models.MissionActive, missions[1].Status)
+	assert.Equal(s.T(), 1, missions[1].UploadedVersion)
+
+	var uploaded models.MissionVersion
+	require.NoError(s.T(), s.db.Where("mission_id = ? AND version = 1", second.ID).First(&uploaded).Error)
+	assert.NotZero(s.T(), uploaded.UploadedAt)
+}
+
+func (s *MissionTestSuite) editMission(mission models.Mission, editJSON string) {
+	c, rec := s.context(http.MethodPatch, "/mission", []byte(editJSON))
+	c.SetPath("/mission/:missionId")
+	c.SetParamNames("missionId")
+	c.SetParamValues(strconv.Itoa(mission.ID))
+	require.NoError(s.T(), controllers.EditMission(c))
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+}
+
+func (s *MissionTestSuite) TestMissionVersionsAndDiff() {
+	mission := s.createMission("Task 1 route", s.waypoints[0], s.waypoints[1])
+
+	//Reorder, swap Alpha for Charlie and edit Beta's altitude
+	s.editMission(mission, fmt.Sprintf(`{"waypoints": [
+		{"sequence": 0, "waypoint_id": %d},
+		{"sequence": 1, "waypoint_id": %d}
+	]}`, s.waypoints[1].ID, s.waypoints[2].ID))
+
+	c, rec := s.context(http.MethodPatch, "/waypoint", []byte(`{"alt": 150}`))
+	c.SetPath("/waypoint/:waypointId")
+	c.SetParamNames("waypointId")
+	c.SetParamValues(strconv.Itoa(s.waypoints[1].ID))
+	c.Set("user", models.User{Username: "safety", Role: models.Operator})
+	require.NoError(s.T(), controllers.EditWaypoint(c))
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+
+	c, rec = s.context(http.MethodGet, "/mission", nil)
+	c.SetPath("/mission/:missionId/versions")
+	c.SetParamNames("missionId")
+	c.SetParamValues(strconv.Itoa(mission.ID))
+	require.NoError(s.T(), controllers.GetMissionVersions(c))
+
+	var versions responses.MultipleResponse[models.MissionVersion]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &versions))
+	require.Len(s.T(), versions.Models, 3)
+	assert.Equal(s.T(), "safety", versions.Models[2].Author)
+	assert.Equal(s.T(), 150.0, versions.Models[2].Waypoints[0].Waypoint.Altitude)
+
+	c, rec = s.context(http.MethodGet, "/mission?from=1&to=3", nil)
+	c.SetPath("/mission/:missionId/diff")
+	c.SetParamNames("missionId")
+	c.SetParamValues(strconv.Itoa(mission.ID))
+	require.NoError(s.T(), controllers.DiffMissionVersions(c))
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+
+	var diff responses.SingleResponse[models.MissionDiff]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &diff))
+	require.Len(s.T(), diff.Model.Added, 1)
+	assert.Equal(s.T(), "Charlie", diff.Model.Added[0].Waypoint.Name)
+	require.Len(s.T(), diff.Model.Removed, 1)
+	assert.Equal(s.T(), "Alpha", diff.Model.Removed[0].Waypoint.Name)
+	require.Len(s.T(), diff.Model.Changed, 1)
+	assert.Equal(s.T(), "alt", diff.Model.Changed[0].Fields[0].Field)
+	assert.Equal(s.T(), 110.0, diff.Model.Changed[0].Fields[0].From)
+	assert.Equal(s.T(), 150.0, diff.Model.Changed[0].Fields[0].To)
+}
+
+func (s *MissionTestSuite) TestDiffDetectsMovedWaypoints() {
+	mission := s.createMission("Search route", s.waypoints[0], s.waypoints[1], s.waypoints[2])
+	s.editMission(mission, fmt.Sprintf(`{"waypoints": [
+		{"sequence": 0, "waypoint_id": %d},
+		{"sequence": 1, "waypoint_id": %d},
+		{"sequence": 2, "waypoint_id": %d}
+	]}`, s.waypoints[1].ID, s.waypoints[2].ID, s.waypoints[0].ID))
+
+	c, rec := s.context(http.MethodGet, "/mission?from=1&to=2", nil)
+	c.SetPath("/mission/:missionId/diff")
+	c.SetParamNames("missionId")
+	c.SetParamValues(strconv.Itoa(mission.ID))
+	require.NoError(s.T(), controllers.DiffMissionVersions(c))
+
+	var diff responses.SingleResponse[models.MissionDiff]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &diff))
+	require.Len(s.T(), diff.Model.Moved, 1)
+	assert.Equal(s.T(), "Alpha", diff.Model.Moved[0].Name)
+	assert.Equal(s.T(), 0, diff.Model.Moved[0].From)
+	assert.Equal(s.T(), 2, diff.Model.Moved[0].To)
+	assert.Empty(s.T(), diff.Model.Added)
+	assert.Empty(s.T(), diff.Model.Changed)
+}
+
+func (s *MissionTestSuite) TestDiffRepeatedWaypoint() {
+	alpha, beta := s.waypoints[0], s.waypoints[1]
+	mission := s.createMission("Twice around", alpha, beta, alpha)
+	s.editMission(mission, fmt.Sprintf(`{"waypoints": [
+		{"sequence": 0, "waypoint_id": %d},
+		{"sequence": 1, "waypoint_id": %d}
+	]}`, alpha.ID, beta.ID))
+
+	c, rec := s.context(http.MethodGet, "/mission?from=1&to=2", nil)
+	c.SetPath("/mission/:missionId/diff")
+	c.SetParamNames("missionId")
+	c.SetParamValues(strconv.Itoa(mission.ID))
+	require.NoError(s.T(), controllers.DiffMissionVersions(c))
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+
+	var diff responses.SingleResponse[models.MissionDiff]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &diff))
+	require.Len(s.T(), diff.Model.Removed, 1, "the second visit is removed")
+	assert.Equal(s.T(), 2, diff.Model.Removed[0].Sequence)
+	assert.Empty(s.T(), diff.Model.Moved)
+	assert.Empty(s.T(), diff.Model.Added)
+}
+
+func (s *MissionTestSuite) TestRestoreMissionVersion() {
+	mission := s.createMission("Task 1 route", s.waypoints[0], s.waypoints[1])
+
+	//Deleting a waypoint removes it from the mission as version 2
+	c, rec := s.context(http.MethodDelete, "/waypoint", nil)
+	c.SetPath("/waypoint/:waypointId")
+	c.SetParamNames("waypointId")
+	c.SetParamValues(strconv.Itoa(s.waypoints[0].ID))
+	require.NoError(s.T(), controllers.DeleteWaypoint(c))
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+
+	c, rec = s.context(http.MethodPost, "/mission", nil)
+	c.SetPath("/mission/:missionId/versions/:version/restore")
+	c.SetParamNames("missionId", "version")
+	c.SetParamValues(strconv.Itoa(mission.ID), "1")
+	require.NoError(s.T(), controllers.RestoreMissionVersion(c))
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+
+	var response responses.SingleResponse[models.Mission]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(s.T(), 3, response.Model.Version)
+	require.Len(s.T(), response.Model.Waypoints, 2)
+	assert.Equal(s.T(), "Alpha", response.Model.Waypoints[0].Waypoint.Name)
+	assert.NotEqual(s.T(), s.waypoints[0].ID, response.Model.Waypoints[0].WaypointID)
+}
+
+func (s *MissionTestSuite) TestChangesNeedVersions() {
+	mission := s.createMission("Task 1 route", s.waypoints[0], s.waypoints[1])
+	s.queue = nil
+
+	//Without anywhere to record versions, nothing a version records is changed
+	require.NoError(s.T(), s.db.Migrator().DropTable(&models.MissionVersion{}))
+	defer func() {
+		require.NoError(s.T(), s.db.AutoMigrate(&models.MissionVersion{}))
+	}()
+
+	waypoint := func(method string, body []byte, handler echo.HandlerFunc) int {
+		c, rec := s.context(method, "/waypoint", body)
+		c.SetPath("/waypoint/:waypointId")
+		c.SetParamNames("waypointId")
+		c.SetParamValues(strconv.Itoa(s.waypoints[0].ID))
+		require.NoError(s.T(), handler(c))
+		return rec.Code
+	}
+	assert.Equal(s.T(), http.StatusInternalServerError, waypoint(http.MethodPatch, []byte(`{"alt": 150}`), controllers.EditWaypoint))
+	assert.Equal(s.T(), http.StatusInternalServerError, waypoint(http.MethodDelete, nil, controllers.DeleteWaypoint))
+
+	c, rec := s.context(http.MethodPost, "/mission", nil)
+	c.SetPath("/mission/:missionId/activate")
+	c.SetParamNames("missionId")
+	c.SetParamValues(strconv.Itoa(mission.ID))
+	require.NoError(s.T(), controllers.ActivateMission(c))
+	assert.Equal(s.T(), http.StatusInternalServerError, rec.Code)
+	assert.Nil(s.T(), s.queue, "nothing is uploaded")
+
+	var unchanged models.Mission
+	require.NoError(s.T(), s.db.Preload("Waypoints.Waypoint").First(&unchanged, mission.ID).Error)
+	assert.Equal(s.T(), models.MissionDraft, unchanged.Status)
+	require.Len(s.T(), unchanged.Waypoints, 2)
+	require.NotNil(s.T(), unchanged.Waypoints[0].Waypoint)
+	assert.Equal(s.T(), 100.0, unchanged.Waypoints[0].Waypoint.Altitude)
+}
@@ -0,0 +1,281 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gcom-backend/configs"
+	"gcom-backend/controllers"
+	"gcom-backend/events"
+	"gcom-backend/imagery"
+	"gcom-backend/models"
+	"gcom-backend/processing"
+	"gcom-backend/responses"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type ProcessingTestSuite struct {
+	suite.Suite
+	e            *echo.Echo
+	db           *gorm.DB
+	bus          *events.Bus
+	subscription *events.Subscription
+	runner       *processing.Runner
+	retryDelay   time.Duration
+}
+
+func TestRunProcessingSuite(t *testing.T) {
+	suite.Run(t, new(ProcessingTestSuite))
+}
+
+func (s *ProcessingTestSuite) SetupSuite() {
+	s.db = configs.Connect(true)
+	s.e = echo.New()
+	require.NoError(s.T(), os.MkdirAll("imgs", 0755))
+	s.retryDelay = processing.RetryDelay
+	processing.RetryDelay = 10 * time.Millisecond
+}
+
+func (s *ProcessingTestSuite) TearDownSuite() {
+	processing.RetryDelay = s.retryDelay
+	sqlDB, _ := s.db.DB()
+	if err := sqlDB.Close(); err != nil {
+		fmt.Println("[Teardown] Error closing database connection!")
+	}
+
+	if err := os.Remove("database.db"); err != nil {
+		fmt.Println("[Teardown] Error deleting database!")
+	}
+	if err := os.RemoveAll("imgs"); err != nil {
+		fmt.Println("[Teardown] Error deleting images!")
+	}
+}
+
+func (s *ProcessingTestSuite) SetupTest() {
+	s.bus = events.NewBus()
+	s.subscription = s.bus.Subscribe("test", events.Options{Topics: []events.Topic{events.GroundObjects}})
+	s.runner = processing.NewRunner(s.db, s.bus, configs.DefaultCamera(), imagery.FlatGround{}, "imgs")
+}
+
+func (s *ProcessingTestSuite) TearDownTest() {
+	s.runner.Stop()
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.ImageJob{})
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.Image{})
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.Detection{})
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.GroundObject{})
+}
+
+// image stores a 600x400 image taken from 100m above the field
+func (s *ProcessingTestSuite) image(timestamp int64) models.Image {
+	img := models.Image{Timestamp: timestamp, Filename: fmt.Sprintf("%d.png", timestamp), Width: 600, Height: 400,
+		Georeferenced: true, Latitude: 49.26, Longitude: -123.24, Altitude: 100}
+	require.NoError(s.T(), s.db.Create(&img).Error)
+	return img
+}
+
+// finished waits for every job on an image to succeed or fail
+func (s *ProcessingTestSuite) finished(filename string) []models.ImageJob {
+	var jobs []models.ImageJob
+	require.Eventually(s.T(), func() bool {
+		require.NoError(s.T(), s.db.Where("image = ?", filename).Order("id").Find(&jobs).Error)
+		for _, job := range jobs {
+			if job.Status != models.JobSucceeded && job.Status != models.JobFailed {
+				return false
+			}
+		}
+		return len(jobs) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	return jobs
+}
+
+func (s *ProcessingTestSuite) jobs(filename string) *httptest.ResponseRecorder {
+	var req = httptest.NewRequest(http.MethodGet, "/", nil)
+	var rec = httptest.NewRecorder()
+	var c = s.e.NewContext(req, rec)
+	c.Set("db", s.db)
+	c.SetParamNames("filename")
+	c.SetParamValues(filename)
+	require.NoError(s.T(), controllers.GetImageJobs(c))
+	return rec
+}
+
+func (s *ProcessingTestSuite) TestResultsAwaitReview() {
+	s.runner.Register(processing.NewFunc("shapes", func(ctx context.Context, input processing.Input) ([]processing.Result, error) {
+		return []processing.Result{
+			//Centred in the image, so directly below the drone
+			{X: 290, Y: 190, Width: 20, Height: 20, Confidence: 0.7, Object: models.GroundObject{Type: models.Standard,
+				Shape: models.Circle, Color: models.White, Text: "A", TextColor: models.Black}},
+			{X: 10, Y: 10, Width: 20, Height: 20, Confidence: 0.4, Object: models.GroundObject{Type: models.Emergent,
+				Latitude: 49.27, Longitude: -123.25, Description: "Mannequin", ReviewStatus: models.Approved}},
+			{X: 10, Y: 10, Width: 20, Height: 20, Confidence: 0.3, Object: models.GroundObject{Type: models.Emergent}},
+			{X: 590, Y: 10, Width: 20, Height: 20, Confidence: 0.3, Object: models.GroundObject{Type: models.Emergent, Description: "Tent"}},
+		}, nil
+	}))
+	require.NoError(s.T(), s.runner.Start(2))
+	img := s.image(1714898050)
+
+	jobs, err := s.runner.Submit(img)
+	require.NoError(s.T(), err)
+	require.Len(s.T(), jobs, 1)
+	assert.Equal(s.T(), models.JobQueued, jobs[0].Status)
+
+	jobs = s.finished(img.Filename)
+	assert.Equal(s.T(), models.JobSucceeded, jobs[0].Status)
+	assert.Equal(s.T(), 1, jobs[0].Attempts)
+	assert.Equal(s.T(), 2, jobs[0].Detections)
+	assert.Contains(s.T(), jobs[0].Error, "result 2: emergent objects need description")
+	assert.Contains(s.T(), jobs[0].Error, "result 3: bounding box is outside")
+
+	var objects []models.GroundObject
+	require.NoError(s.T(), s.db.Order("id").Find(&objects).Error)
+	require.Len(s.T(), objects, 2)
+	assert.InDelta(s.T(), 49.26, objects[0].Latitude, 1e-6, "projected from the bounding box")
+	assert.InDelta(s.T(), -123.24, objects[0].Longitude, 1e-6)
+	assert.Equal(s.T(), 0.7, objects[0].Confidence)
+	assert.Equal(s.T(), 49.27, objects[1].Latitude, "placed where the processor says")
+	for _, object := range objects {
+		assert.True(s.T(), object.Autonomous)
+		assert.Equal(s.T(), models.Pending, object.ReviewStatus, "processors cannot approve their own objects")
+	}
+
+	var detections []models.Detection
+	require.NoError(s.T(), s.db.Order("id").Find(&detections).Error)
+	require.Len(s.T(), detections, 2)
+	assert.Equal(s.T(), objects[0].ID, detections[0].GroundObjectID)
+	assert.Equal(s.T(), img.Filename, detections[0].Image)
+
+	var names []string
+	for len(s.subscription.Events()) > 0 {
+		names = append(names, (<-s.subscription.Events()).Name())
+	}
+	assert.Equal(s.T(), []string{"groundobject_created", "groundobject_queued", "groundobject_created", "groundobject_queued"}, names)
+}
+
+func (s *ProcessingTestSuite) TestRetry() {
+	var calls atomic.Int32
+	s.runner.Register(processing.NewFunc("flaky", func(ctx context.Context, input processing.Input) ([]processing.Result, error) {
+		if calls.Add(1) == 1 {
+			return nil, errors.New("model is still loading")
+		}
+		return nil, nil
+	}))
+	s.runner.Register(processing.NewFunc("broken", func(ctx context.Context, input processing.Input) ([]processing.Result, error) {
+		panic("out of memory")
+	}))
+	require.NoError(s.T(), s.runner.Start(1))
+	img := s.image(1714898060)
+	_, err := s.runner.Submit(img)
+	require.NoError(s.T(), err)
+
+	jobs := s.finished(img.Filename)
+	require.Len(s.T(), jobs, 2)
+	assert.Equal(s.T(), "flaky", jobs[0].Processor)
+	assert.Equal(s.T(), models.JobSucceeded, jobs[0].Status)
+	assert.Equal(s.T(), 2, jobs[0].Attempts)
+	assert.Empty(s.T(), jobs[0].Error)
+	assert.Equal(s.T(), models.JobFailed, jobs[1].Status)
+	assert.Equal(s.T(), processing.MaxAttempts, jobs[1].Attempts)
+	assert.Contains(s.T(), jobs[1].Error, "out of memory")
+}
+
+func (s *ProcessingTestSuite) TestUnfinishedJobsResume() {
+	img := s.image(1714898070)
+	require.NoError(s.T(), s.db.Create(&[]models.ImageJob{
+		{Image: img.Filename, Processor: "shapes", Status: models.JobRunning, Attempts: 1},
+		{Image: img.Filename, Processor: "removed", Status: models.JobQueued},
+	}).Error)
+
+	s.runner.Register(processing.NewFunc("shapes", func(ctx context.Context, input processing.Input) ([]processing.Result, error) {
+		return nil, nil
+	}))
+	require.NoError(s.T(), s.runner.Start(1))
+
+	jobs := s.finished(img.Filename)
+	assert.Equal(s.T(), models.JobSucceeded, jobs[0].Status)
+	assert.Equal(s.T(), 2, jobs[0].Attempts)
+	assert.Equal(s.T(), models.JobFailed, jobs[1].Status)
+	assert.Equal(s.T(), "processor is no longer registered", jobs[1].Error)
+}
+
+func (s *ProcessingTestSuite) TestExternalProcessors() {
+	var received processing.Input
+	detector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(s.T(), json.NewDecoder(r.Body).Decode(&received))
+		_, _ = w.Write([]byte(`[{"x": 100, "y": 100, "width": 40, "height": 40, "confidence": 0.8,
+			"object": {"object_type": "emergent", "description": "Person waving"}}]`))
+	}))
+	defer detector.Close()
+
+	s.runner.Register(processing.NewHTTP("http", detector.URL))
+	s.runner.Register(processing.NewCommand("command", "sh", "-c",
+		`cat > /dev/null; echo '[{"x": 5, "y": 5, "width": 10, "height": 10, "confidence": 0.5, "object": {"object_type": "emergent", "lat": 49.25, "long": -123.23, "description": "Tent"}}]'`))
+	s.runner.Register(processing.NewCommand("failing", "sh", "-c", "echo 'no GPU found' >&2; exit 2"))
+	require.NoError(s.T(), s.runner.Start(3))
+	img := s.image(1714898080)
+	_, err := s.runner.Submit(img)
+	require.NoError(s.T(), err)
+
+	jobs := s.finished(img.Filename)
+	require.Len(s.T(), jobs, 3)
+	assert.Equal(s.T(), 1, jobs[0].Detections)
+	assert.Equal(s.T(), 1, jobs[1].Detections)
+	assert.Equal(s.T(), models.JobFailed, jobs[2].Status)
+	assert.Equal(s.T(), "exit status 2: no GPU found", jobs[2].Error)
+
+	assert.Equal(s.T(), img.Filename, received.Image.Filename)
+	assert.True(s.T(), filepath.IsAbs(received.Path))
+	assert.Equal(s.T(), img.Filename, filepath.Base(received.Path))
+}
+
+func (s *ProcessingTestSuite) TestUploadQueuesJobs() {
+	s.runner.Register(processing.NewFunc("shapes", func(ctx context.Context, input processing.Input) ([]processing.Result, error) {
+		return nil, nil
+	}))
+	require.NoError(s.T(), s.runner.Start(1))
+
+	var encoded bytes.Buffer
+	require.NoError(s.T(), png.Encode(&encoded, image.NewGray(image.Rect(0, 0, 60, 40))))
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "1714898090.png")
+	require.NoError(s.T(), err)
+	_, err = part.Write(encoded.Bytes())
+	require.NoError(s.T(), err)
+	require.NoError(s.T(), writer.Close())
+
+	var req = httptest.NewRequest(http.MethodPost, "/image", &body)
+	req.Header.Set(echo.HeaderContentType, writer.FormDataContentType())
+	var rec = httptest.NewRecorder()
+	var c = s.e.NewContext(req, rec)
+	c.Set("db", s.db)
+	c.Set("processing", s.runner)
+	require.NoError(s.T(), controllers.UploadImage(c))
+	require.Equal(s.T(), http.StatusAccepted, rec.Code, rec.Body.String())
+	s.finished("1714898090.png")
+
+	assert.Equal(s.T(), http.StatusNotFound, s.jobs("1714898099.png").Code)
+	rec = s.jobs("1714898090.png")
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	var jobs responses.MultipleResponse[models.ImageJob]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &jobs))
+	require.Len(s.T(), jobs.Models, 1)
+	assert.Equal(s.T(), "shapes", jobs.Models[0].Processor)
+	assert.Equal(s.T(), models.JobSucceeded, jobs.Models[0].Status)
+}
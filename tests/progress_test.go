@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"gcom-backend/configs"
+	"gcom-backend/controllers"
+	"gcom-backend/models"
+	"gcom-backend/progress"
+	"gcom-backend/responses"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type ProgressTestSuite struct {
+	suite.Suite
+	e       *echo.Echo
+	db      *gorm.DB
+	tracker *progress.Tracker
+}
+
+func TestRunProgressSuite(t *testing.T) {
+	suite.Run(t, new(ProgressTestSuite))
+}
+
+func (s *ProgressTestSuite) SetupSuite() {
+	s.db = configs.Connect(true)
+	s.e = echo.New()
+}
+
+func (s *ProgressTestSuite) TearDownSuite() {
+	sqlDB, _ := s.db.DB()
+	if err := sqlDB.Close(); err != nil {
+		fmt.Println("[Teardown] Error closing database connection!")
+	}
+}
+
+// Each test gets a fresh tracker with a two waypoint queue roughly 110m apart
+func (s *ProgressTestSuite) SetupTest() {
+	s.tracker = progress.NewTracker(s.db)
+	s.tracker.SetQueue([]models.Waypoint{
+		{ID: 1, Name: "Alpha", Latitude: 49.2600, Longitude: -123.2400, Altitude: 100, Radius: 10},
+		{ID: 2, Name: "Beta", Latitude: 49.2610, Longitude: -123.2400, Altitude: 100},
+	})
+}
+
+func (s *ProgressTestSuite) TearDownTest() {
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.WaypointProgress{})
+}
+
+func sample(timestamp int64, lat float64, long float64, alt float64) models.Drone {
+	return models.Drone{Timestamp: timestamp, Latitude: lat, Longitude: long, Altitude: alt}
+}
+
+func (s *ProgressTestSuite) TestWaypointReachedOnExit() {
+	assert.Empty(s.T(), s.tracker.Update(sample(1, 49.2595, -123.2400, 90)))  //~55m away
+	assert.Empty(s.T(), s.tracker.Update(sample(2, 49.26003, -123.2400, 98))) //~3m away, inside
+	assert.Empty(s.T(), s.tracker.Update(sample(3, 49.26001, -123.2400, 97))) //~1m away, closer
+
+	reached := s.tracker.Update(sample(4, 49.2602, -123.2400, 99)) //~22m away, left radius
+	require.Len(s.T(), reached, 1)
+	assert.Equal(s.T(), "Alpha", reached[0].Name)
+	assert.Equal(s.T(), int64(3), reached[0].ReachedAt)
+	assert.InDelta(s.T(), 1.1, reached[0].Distance, 0.1)
+	assert.InDelta(s.T(), -3, reached[0].AltitudeError, 0.001)
+
+	var stored []models.WaypointProgress
+	require.NoError(s.T(), s.db.Find(&stored).Error)
+	assert.Equal(s.T(), reached, stored)
+}
+
+func (s *ProgressTestSuite) TestWaypointsReachedInOrder() {
+	//Passing by Beta first does not count, Alpha is still the active waypoint
+	assert.Empty(s.T(), s.tracker.Update(sample(1, 49.2610, -123.2400, 100)))
+	assert.Empty(s.T(), s.tracker.Update(sample(2, 49.2605, -123.2400, 100)))
+
+	snapshot := s.tracker.Snapshot()
+	assert.Equal(s.T(), 0, snapshot.Reached)
+	assert.Equal(s.T(), progress.Active, snapshot.Waypoints[0].State)
+	assert.Equal(s.T(), progress.Pending, snapshot.Waypoints[1].State)
+}
+
+func (s *ProgressTestSuite) TestGetMissionProgress() {
+	s.tracker.Update(sample(1, 49.2600, -123.2400, 100))
+	s.tracker.Update(sample(2, 49.2605, -123.2400, 100))
+
+	var req = httptest.NewRequest(http.MethodGet, "/mission/progress", nil)
+	var rec = httptest.NewRecorder()
+	var c = s.e.NewContext(req, rec)
+	c.Set("progress", s.tracker)
+
+	require.NoError(s.T(), controllers.GetMissionProgress(c))
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+
+	var response responses.SingleResponse[progress.Snapshot]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(s.T(), 1, response.Model.Reached)
+	assert.Equal(s.T(), 2, response.Model.Total)
+	assert.Equal(s.T(), progress.Reached, response.Model.Waypoints[0].State)
+	assert.Equal(s.T(), progress.Active, response.Model.Waypoints[1].State)
+}
@@ -0,0 +1,231 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gcom-backend/configs"
+	"gcom-backend/controllers"
+	"gcom-backend/events"
+	"gcom-backend/models"
+	"gcom-backend/progress"
+	"gcom-backend/replay"
+	"gcom-backend/responses"
+	"gcom-backend/telemetry"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type ReplayTestSuite struct {
+	suite.Suite
+	e        *echo.Echo
+	db       *gorm.DB
+	tracker  *progress.Tracker
+	pipeline *telemetry.Pipeline
+	engine   *replay.Engine
+	flight   models.FlightSession
+
+	subscription *events.Subscription
+	mu           sync.Mutex
+	topics       *[]events.Topic
+}
+
+func TestRunReplaySuite(t *testing.T) {
+	suite.Run(t, new(ReplayTestSuite))
+}
+
+func (s *ReplayTestSuite) SetupSuite() {
+	s.db = configs.Connect(true)
+	s.e = echo.New()
+
+	//A flight heading north over a waypoint, one sample a second
+	s.flight = models.FlightSession{StartedAt: 1698544700, EndedAt: 1698544706}
+	require.NoError(s.T(), s.db.Create(&s.flight).Error)
+	for i, lat := range []float64{49.2590, 49.2595, 49.2600, 49.2605, 49.2610} {
+		sample := models.FlightSample{Drone: sample(1698544700+int64(i), lat, -123.24, 100), SessionID: s.flight.ID}
+		require.NoError(s.T(), s.db.Create(&sample).Error)
+	}
+}
+
+func (s *ReplayTestSuite) TearDownSuite() {
+	sqlDB, _ := s.db.DB()
+	if err := sqlDB.Close(); err != nil {
+		fmt.Println("[Teardown] Error closing database connection!")
+	}
+
+	if err := os.Remove("database.db"); err != nil {
+		fmt.Println("[Teardown] Error deleting database!")
+	}
+}
+
+func (s *ReplayTestSuite) SetupTest() {
+	s.tracker = progress.NewTracker(s.db)
+	s.tracker.SetQueue([]models.Waypoint{{ID: 1, Name: "Alpha", Latitude: 49.26, Longitude: -123.24, Altitude: 100}})
+	bus := events.NewBus()
+	s.pipeline = telemetry.NewPipeline(s.db, bus, s.tracker, nil)
+	s.engine = replay.NewEngine(s.db, s.pipeline)
+
+	//The handler of the last test may still be handing over its events, so
+	//every test counts into its own slice
+	topics := &[]events.Topic{}
+	s.mu.Lock()
+	s.topics = topics
+	s.mu.Unlock()
+	s.subscription = bus.Handle("test", events.Options{Policy: events.Block}, func(event events.Event) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		*topics = append(*topics, event.Topic)
+	})
+}
+
+func (s *ReplayTestSuite) TearDownTest() {
+	s.engine.Stop()
+	s.subscription.Close()
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.WaypointProgress{})
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.Drone{})
+}
+
+func (s *ReplayTestSuite) count(topic events.Topic) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for _, t := range *s.topics {
+		if t == topic {
+			count++
+		}
+	}
+	return count
+}
+
+func (s *ReplayTestSuite) request(handler echo.HandlerFunc, method string, body string) *httptest.ResponseRecorder {
+	var req = httptest.NewRequest(method, "/replay", bytes.NewReader([]byte(body)))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	var rec = httptest.NewRecorder()
+	var c = s.e.NewContext(req, rec)
+	c.Set("db", s.db)
+	c.Set("telemetry", s.pipeline)
+	c.Set("replay", s.engine)
+
+	require.NoError(s.T(), handler(c))
+	return rec
+}
+
+func (s *ReplayTestSuite) TestReplayBehavesLikeLive() {
+	rec := s.request(controllers.StartReplay, http.MethodPost, fmt.Sprintf(`{"flight_id": %d, "speed": 10}`, s.flight.ID))
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+
+	//Five samples 100ms apart at 10x
+	require.Eventually(s.T(), func() bool {
+		return s.count(events.Telemetry) == 5
+	}, 2*time.Second, 20*time.Millisecond)
+	require.Eventually(s.T(), func() bool {
+		state, err := s.engine.State()
+		return err == nil && !state.Playing
+	}, time.Second, 10*time.Millisecond)
+
+	require.Eventually(s.T(), func() bool {
+		return s.count(events.Progress) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	state, err := s.engine.State()
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(1698544704), state.Position)
+
+	//Live progress is put back once the replay finishes, and replayed
+	//waypoints are not stored
+	assert.Zero(s.T(), s.tracker.Snapshot().Reached)
+	var reached int64
+	s.db.Model(&models.WaypointProgress{}).Count(&reached)
+	assert.Zero(s.T(), reached)
+
+	//Replayed samples are not stored as live telemetry
+	var stored int64
+	s.db.Model(&models.Drone{}).Count(&stored)
+	assert.Zero(s.T(), stored)
+
+	//Live telemetry is published again once the replay finishes
+	assert.False(s.T(), s.pipeline.Replaying())
+	require.NoError(s.T(), s.pipeline.Ingest(sample(time.Now().Unix(), 49.25, -123.25, 10)))
+	latest, _ := s.pipeline.Latest()
+	assert.Equal(s.T(), 49.25, latest.Latitude)
+	assert.Len(s.T(), s.pipeline.History(), 1)
+}
+
+func (s *ReplayTestSuite) TestPauseAndSeek() {
+	_, err := s.engine.Start(s.flight.ID, 1)
+	require.NoError(s.T(), err)
+	require.Eventually(s.T(), func() bool {
+		return s.count(events.Telemetry) == 1
+	}, time.Second, 10*time.Millisecond)
+	state, err := s.engine.Pause()
+	require.NoError(s.T(), err)
+	assert.True(s.T(), state.Paused)
+
+	rec := s.request(controllers.SeekReplay, http.MethodPost, `{"timestamp": 1698544703}`)
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	var response responses.SingleResponse[replay.State]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(s.T(), 3, response.Model.Sample)
+
+	rec = s.request(controllers.SetReplaySpeed, http.MethodPost, `{"speed": 10}`)
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	_, err = s.engine.Resume()
+	require.NoError(s.T(), err)
+
+	//The first sample before pausing, then the last two after seeking
+	require.Eventually(s.T(), func() bool {
+		state, err := s.engine.State()
+		return err == nil && !state.Playing
+	}, 2*time.Second, 20*time.Millisecond)
+	state, err = s.engine.State()
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), int64(1698544704), state.Position)
+	assert.Equal(s.T(), 3, s.count(events.Telemetry))
+
+	rec = s.request(controllers.SetReplaySpeed, http.MethodPost, `{"speed": -1}`)
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+}
+
+func (s *ReplayTestSuite) TestLiveHeldDuringReplay() {
+	_, err := s.engine.Start(s.flight.ID, 1)
+	require.NoError(s.T(), err)
+	require.Eventually(s.T(), func() bool {
+		return s.count(events.Telemetry) == 1
+	}, time.Second, 10*time.Millisecond)
+	_, err = s.engine.Pause()
+	require.NoError(s.T(), err)
+
+	require.NoError(s.T(), s.pipeline.Ingest(sample(time.Now().Unix(), 49.25, -123.25, 10)))
+	rec := s.request(controllers.GetCurrentStatus, http.MethodGet, "")
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	var drone models.Drone
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &drone))
+	assert.Equal(s.T(), int64(1698544700), drone.Timestamp)
+
+	rec = s.request(controllers.StopReplay, http.MethodDelete, "")
+	assert.Equal(s.T(), http.StatusOK, rec.Code)
+	assert.False(s.T(), s.pipeline.Replaying())
+
+	rec = s.request(controllers.GetReplay, http.MethodGet, "")
+	assert.Equal(s.T(), http.StatusNotFound, rec.Code)
+
+	require.NoError(s.T(), s.pipeline.Ingest(sample(time.Now().Unix(), 49.25, -123.25, 10)))
+	latest, _ := s.pipeline.Latest()
+	assert.Equal(s.T(), 49.25, latest.Latitude)
+}
+
+func (s *ReplayTestSuite) TestNoSamples() {
+	rec := s.request(controllers.StartReplay, http.MethodPost, `{"flight_id": 999}`)
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gcom-backend/configs"
+	"gcom-backend/controllers"
+	"gcom-backend/events"
+	"gcom-backend/models"
+	"gcom-backend/responses"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type ReviewTestSuite struct {
+	suite.Suite
+	e            *echo.Echo
+	db           *gorm.DB
+	bus          *events.Bus
+	subscription *events.Subscription
+}
+
+func TestRunReviewSuite(t *testing.T) {
+	suite.Run(t, new(ReviewTestSuite))
+}
+
+func (s *ReviewTestSuite) SetupSuite() {
+	s.db = configs.Connect(true)
+	s.e = echo.New()
+}
+
+func (s *ReviewTestSuite) TearDownSuite() {
+	sqlDB, _ := s.db.DB()
+	if err := sqlDB.Close(); err != nil {
+		fmt.Println("[Teardown] Error closing database connection!")
+	}
+
+	if err := os.Remove("database.db"); err != nil {
+		fmt.Println("[Teardown] Error deleting database!")
+	}
+}
+
+func (s *ReviewTestSuite) SetupTest() {
+	s.bus = events.NewBus()
+	s.subscription = s.bus.Subscribe("test", events.Options{Topics: []events.Topic{events.GroundObjects}})
+}
+
+func (s *ReviewTestSuite) TearDownTest() {
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.GroundObject{})
+}
+
+func (s *ReviewTestSuite) request(handler echo.HandlerFunc, target string, body string, param string) *httptest.ResponseRecorder {
+	var req = httptest.NewRequest(http.MethodPost, target, bytes.NewReader([]byte(body)))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	var rec = httptest.NewRecorder()
+	var c = s.e.NewContext(req, rec)
+	c.Set("db", s.db)
+	c.Set("events", s.bus)
+	c.Set("user", models.User{Username: "reviewer", Role: models.Operator})
+	if param != "" {
+		c.SetParamNames("objectId")
+		c.SetParamValues(param)
+	}
+	require.NoError(s.T(), handler(c))
+	return rec
+}
+
+// create creates a white circle with a black alphanumeric
+func (s *ReviewTestSuite) create(text string, autonomous bool, confidence float64) models.GroundObject {
+	rec := s.request(controllers.CreateGroundObject, "/", fmt.Sprintf(`{"id": "-1", "object_type": "standard", "lat": 49.26,
+		"long": -123.24, "shape": "circle", "color": "white", "text": %q, "text_color": "black", "autonomous": %v, "confidence": %v}`,
+		text, autonomous, confidence), "")
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	var created responses.SingleResponse[models.GroundObject]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &created))
+	return created.Model
+}
+
+// published lists the names of the events published so far
+func (s *ReviewTestSuite) published() []string {
+	var names []string
+	for len(s.subscription.Events()) > 0 {
+		names = append(names, (<-s.subscription.Events()).Name())
+	}
+	return names
+}
+
+func (s *ReviewTestSuite) queue(query string) []models.GroundObject {
+	rec := s.request(controllers.GetReviewQueue, "/groundobjects/review"+query, "", "")
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	var objects responses.MultipleResponse[models.GroundObject]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &objects))
+	return objects.Models
+}
+
+func (s *ReviewTestSuite) TestNewObjects() {
+	manual := s.create("A", false, 0)
+	assert.Equal(s.T(), models.Approved, manual.ReviewStatus, "operators confirm what they enter")
+	assert.Equal(s.T(), "reviewer", manual.Reviewer)
+	assert.Equal(s.T(), []string{"groundobject_created"}, s.published())
+
+	detected := s.create("B", true, 0.4)
+	assert.Equal(s.T(), models.Pending, detected.ReviewStatus)
+	assert.Empty(s.T(), detected.Reviewer)
+	assert.Equal(s.T(), []string{"groundobject_created", "groundobject_queued"}, s.published())
+
+	rec := s.request(controllers.CreateGroundObject, "/", `{"id": "-1", "object_type": "emergent", "lat": 49.26, "long": -123.24,
+		"description": "Tent", "review_status": "approved"}`, "")
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	rec = s.request(controllers.EditGroundObject, "/", `{"review_status": "approved"}`, fmt.Sprint(detected.ID))
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code, "only by reviewing")
+}
+
+func (s *ReviewTestSuite) TestQueueAndDecisions() {
+	s.create("A", false, 0.1)
+	low := s.create("B", true, 0.3)
+	high := s.create("C", true, 0.9)
+	s.published()
+
+	queue := s.queue("")
+	require.Len(s.T(), queue, 2)
+	assert.Equal(s.T(), []int{high.ID, low.ID}, []int{queue[0].ID, queue[1].ID}, "most confident first")
+	assert.Equal(s.T(), http.StatusBadRequest, s.request(controllers.GetReviewQueue, "/groundobjects/review?status=maybe", "", "").Code)
+
+	//The reviewer corrects the alphanumeric as they approve it
+	rec := s.request(controllers.ApproveGroundObject, "/", `{"notes": "Looks like a G", "changes": {"text": "G"}}`, fmt.Sprint(high.ID))
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	var approved responses.SingleResponse[models.GroundObject]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &approved))
+	assert.Equal(s.T(), models.Approved, approved.Model.ReviewStatus)
+	assert.Equal(s.T(), "G", approved.Model.Text)
+	assert.Equal(s.T(), "reviewer", approved.Model.Reviewer)
+	assert.Equal(s.T(), "Looks like a G", approved.Model.ReviewNotes)
+	assert.NotZero(s.T(), approved.Model.ReviewedAt)
+	assert.Equal(s.T(), []string{"groundobject_approved"}, s.published())
+
+	rec = s.request(controllers.RejectGroundObject, "/", `{"changes": {"text": "gg"}}`, fmt.Sprint(low.ID))
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code, "changes must be valid")
+	rec = s.request(controllers.RejectGroundObject, "/", `{"changes": {"review_status": "approved"}}`, fmt.Sprint(low.ID))
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	rec = s.request(controllers.RejectGroundObject, "/", `{"notes": "Shadow"}`, fmt.Sprint(low.ID))
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(s.T(), []string{"groundobject_rejected"}, s.published())
+	assert.Equal(s.T(), http.StatusNotFound, s.request(controllers.ApproveGroundObject, "/", "", "999").Code)
+
+	assert.Empty(s.T(), s.queue(""))
+	rejected := s.queue("?status=rejected")
+	require.Len(s.T(), rejected, 1)
+	assert.Equal(s.T(), "Shadow", rejected[0].ReviewNotes)
+
+	//Only approved objects are submitted
+	rec = s.request(controllers.ExportGroundObjects, "/groundobjects/export?format=geojson", "", "")
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	assert.Equal(s.T(), 2, bytes.Count(rec.Body.Bytes(), []byte(`"Feature"`)))
+}
+
+func (s *ReviewTestSuite) TestMigration() {
+	require.NoError(s.T(), s.db.Exec(`INSERT INTO ground_objects (id, type, latitude, longitude, description, autonomous, review_status)
+		VALUES (60, 'emergent', 49.26, -123.24, 'Tent', true, ''), (61, 'emergent', 49.26, -123.24, 'Tent', false, '')`).Error)
+
+	models.Migrate(s.db)
+
+	var objects []models.GroundObject
+	require.NoError(s.T(), s.db.Order("id").Find(&objects, []int{60, 61}).Error)
+	require.Len(s.T(), objects, 2)
+	assert.Equal(s.T(), models.Pending, objects[0].ReviewStatus)
+	assert.Equal(s.T(), models.Approved, objects[1].ReviewStatus)
+}
@@ -0,0 +1,197 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gcom-backend/commands"
+	"gcom-backend/configs"
+	"gcom-backend/controllers"
+	"gcom-backend/geo"
+	"gcom-backend/models"
+	"gcom-backend/progress"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type SimulatorTestSuite struct {
+	suite.Suite
+	e   *echo.Echo
+	db  *gorm.DB
+	sim *configs.Simulator
+}
+
+func TestRunSimulatorSuite(t *testing.T) {
+	suite.Run(t, new(SimulatorTestSuite))
+}
+
+func (s *SimulatorTestSuite) SetupSuite() {
+	s.db = configs.Connect(true)
+	s.e = echo.New()
+}
+
+func (s *SimulatorTestSuite) TearDownSuite() {
+	sqlDB, _ := s.db.DB()
+	if err := sqlDB.Close(); err != nil {
+		fmt.Println("[Teardown] Error closing database connection!")
+	}
+
+	if err := os.Remove("database.db"); err != nil {
+		fmt.Println("[Teardown] Error deleting database!")
+	}
+}
+
+func (s *SimulatorTestSuite) SetupTest() {
+	s.sim = configs.NewSimulator(configs.DefaultSimulatorConfig())
+}
+
+// run steps the simulator a second at a time until done or the limit is hit
+func (s *SimulatorTestSuite) run(limit int, done func() bool) {
+	for i := 0; i < limit; i++ {
+		if done() {
+			return
+		}
+		s.sim.Step(time.Second)
+	}
+	require.True(s.T(), done(), "simulator did not finish within %d seconds", limit)
+}
+
+func (s *SimulatorTestSuite) takeoff(alt float64) {
+	require.True(s.T(), s.sim.Arm(1))
+	require.True(s.T(), s.sim.Takeoff(alt))
+	s.run(60, func() bool { return s.sim.Mode() == configs.SimHold })
+	assert.Equal(s.T(), alt, s.sim.GetStatus().Altitude)
+}
+
+func (s *SimulatorTestSuite) TestFlyQueue() {
+	home := configs.DefaultSimulatorConfig().Home
+	assert.False(s.T(), s.sim.Takeoff(30), "takeoff needs the drone armed")
+
+	s.takeoff(30)
+	assert.False(s.T(), s.sim.Arm(0), "disarming in the air")
+
+	lat, long := geo.Destination(home.Latitude, home.Longitude, 90, 300)
+	require.True(s.T(), s.sim.SetQueue([]models.Waypoint{{Name: "East", Latitude: lat, Longitude: long, Altitude: 50, Radius: 5}}))
+	assert.Equal(s.T(), configs.SimMission, s.sim.Mode())
+
+	s.sim.Step(time.Second)
+	status := s.sim.GetStatus()
+	assert.InDelta(s.T(), 90, status.Heading, 1)
+	assert.InDelta(s.T(), 15, status.Speed, 0.01)
+	assert.Equal(s.T(), 3.0, status.VerticalSpeed)
+
+	//300m at 15m/s
+	s.run(30, func() bool { return len(s.sim.GetQueue()) == 0 })
+	status = s.sim.GetStatus()
+	assert.LessOrEqual(s.T(), geo.Distance(status.Latitude, status.Longitude, lat, long), 5.0)
+	assert.Equal(s.T(), 50.0, status.Altitude)
+	assert.Less(s.T(), status.BatteryVoltage, 16.8)
+
+	require.True(s.T(), s.sim.ReturnHome(40))
+	s.run(120, func() bool { return s.sim.Mode() == configs.SimIdle })
+	status = s.sim.GetStatus()
+	assert.Zero(s.T(), status.Altitude)
+	assert.LessOrEqual(s.T(), geo.Distance(status.Latitude, status.Longitude, home.Latitude, home.Longitude), 1.0)
+	assert.True(s.T(), s.sim.Arm(0))
+}
+
+func (s *SimulatorTestSuite) TestRateOutOfRange() {
+	//No rate would panic and faster rates would repeat timestamps, so both
+	//send a sample a second
+	stop := make(chan struct{})
+	defer close(stop)
+	var mu sync.Mutex
+	timestamps := map[float64][]int64{}
+	for _, rate := range []float64{0, 10} {
+		rate := rate
+		config := configs.DefaultSimulatorConfig()
+		config.Rate = rate
+		configs.NewSimulator(config).Start(func(drone models.Drone) error {
+			mu.Lock()
+			defer mu.Unlock()
+			timestamps[rate] = append(timestamps[rate], drone.Timestamp)
+			return nil
+		}, stop)
+	}
+
+	require.Eventually(s.T(), func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(timestamps[0]) >= 2 && len(timestamps[10]) >= 2
+	}, 3*time.Second, 50*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, sent := range timestamps {
+		assert.Less(s.T(), sent[0], sent[1])
+	}
+}
+
+func (s *SimulatorTestSuite) TestLockAndWind() {
+	config := configs.DefaultSimulatorConfig()
+	config.WindSpeed = 5
+	config.WindDirection = 0
+	s.sim = configs.NewSimulator(config)
+	s.takeoff(20)
+
+	lat, long := geo.Destination(config.Home.Latitude, config.Home.Longitude, 90, 500)
+	s.sim.SetQueue([]models.Waypoint{{Latitude: lat, Longitude: long, Altitude: 20}})
+
+	//A crosswind from the north means pointing north of the track and
+	//flying slower over the ground
+	s.sim.Step(time.Second)
+	status := s.sim.GetStatus()
+	assert.InDelta(s.T(), config.Home.Latitude, status.Latitude, 0.00001)
+	assert.InDelta(s.T(), 70.53, status.Heading, 0.1)
+	assert.InDelta(s.T(), 14.14, status.Speed, 0.01)
+
+	require.True(s.T(), s.sim.Lock())
+	assert.False(s.T(), s.sim.Lock(), "already locked")
+	s.sim.Step(time.Second)
+	assert.Equal(s.T(), status.Latitude, s.sim.GetStatus().Latitude)
+	assert.Zero(s.T(), s.sim.GetStatus().Speed)
+
+	require.True(s.T(), s.sim.Unlock())
+	s.run(60, func() bool { return len(s.sim.GetQueue()) == 0 })
+
+	require.True(s.T(), s.sim.Land())
+	s.run(30, func() bool { return s.sim.Mode() == configs.SimIdle })
+}
+
+func (s *SimulatorTestSuite) TestControllers() {
+	tracker := progress.NewTracker(nil)
+	request := func(handler echo.HandlerFunc, body any) *httptest.ResponseRecorder {
+		bodyBytes, _ := json.Marshal(body)
+		var req = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(bodyBytes))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		var rec = httptest.NewRecorder()
+		var c = s.e.NewContext(req, rec)
+		c.Set("db", s.db)
+		c.Set("mp", configs.Autopilot(s.sim))
+		c.Set("progress", tracker)
+		c.Set("commands", commands.NewCommander(s.sim, nil, tracker, nil))
+		require.NoError(s.T(), handler(c))
+		return rec
+	}
+
+	home := models.Waypoint{ID: -1, Name: "Field", Latitude: 49.25, Longitude: -123.25, Altitude: 1}
+	assert.Equal(s.T(), http.StatusAccepted, request(controllers.PostHome, home).Code)
+	assert.Equal(s.T(), 49.25, s.sim.GetStatus().Latitude)
+
+	queue := []models.Waypoint{{ID: 1, Name: "Alpha", Latitude: 49.251, Longitude: -123.25, Altitude: 30}}
+	assert.Equal(s.T(), http.StatusAccepted, request(controllers.PostQueue, queue).Code)
+	assert.Len(s.T(), s.sim.GetQueue(), 1)
+
+	assert.Equal(s.T(), http.StatusInternalServerError, request(controllers.Takeoff, map[string]float64{"altitude": 30}).Code)
+	assert.Equal(s.T(), configs.SimIdle, s.sim.Mode(), "not armed so takeoff is refused")
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+	"fmt"
+	"gcom-backend/configs"
+	"gcom-backend/events"
+	"gcom-backend/models"
+	"gcom-backend/telemetry"
+	"math"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+type TelemetryTestSuite struct {
+	suite.Suite
+	db *gorm.DB
+}
+
+func TestRunTelemetrySuite(t *testing.T) {
+	suite.Run(t, new(TelemetryTestSuite))
+}
+
+func (s *TelemetryTestSuite) SetupSuite() {
+	s.db = configs.Connect(true)
+}
+
+func (s *TelemetryTestSuite) TearDownSuite() {
+	sqlDB, _ := s.db.DB()
+	if err := sqlDB.Close(); err != nil {
+		fmt.Println("[Teardown] Error closing database connection!")
+	}
+
+	if err := os.Remove("database.db"); err != nil {
+		fmt.Println("[Teardown] Error deleting database!")
+	}
+}
+
+func (s *TelemetryTestSuite) TearDownTest() {
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.Drone{})
+}
+
+func (s *TelemetryTestSuite) TestIngestStoresAndPublishes() {
+	bus := events.NewBus()
+	pipeline := telemetry.NewPipeline(s.db, bus, nil, nil)
+	subscription := bus.Subscribe("test", events.Options{})
+	defer subscription.Close()
+
+	now := time.Now().Unix()
+	//Zero speeds and heading are fine on the ground
+	require.NoError(s.T(), pipeline.Ingest(sample(now, 49.26, -123.24, 0)))
+	require.Len(s.T(), subscription.Events(), 1)
+	event := <-subscription.Events()
+	assert.Equal(s.T(), events.Telemetry, event.Topic)
+	assert.Equal(s.T(), events.Received, event.Action)
+
+	var stored models.Drone
+	require.NoError(s.T(), s.db.First(&stored, now).Error)
+	assert.Equal(s.T(), 49.26, stored.Latitude)
+}
+
+func (s *TelemetryTestSuite) TestIngestRejectsInvalid() {
+	pipeline := telemetry.NewPipeline(s.db, nil, nil, nil)
+	now := time.Now().Unix()
+
+	invalid := []models.Drone{
+		sample(0, 49.26, -123.24, 10),
+		sample(now, 91, -123.24, 10),
+		sample(now, 49.26, -181, 10),
+		sample(now, 0, 0, 10),
+		sample(now, 49.26, -123.24, math.NaN()),
+	}
+	for _, drone := range invalid {
+		assert.Error(s.T(), pipeline.Ingest(drone), "%+v", drone)
+	}
+
+	_, ok := pipeline.Latest()
+	assert.False(s.T(), ok)
+	var count int64
+	s.db.Model(&models.Drone{}).Count(&count)
+	assert.Zero(s.T(), count)
+}
+
+func (s *TelemetryTestSuite) TestThrottle() {
+	var mu sync.Mutex
+	var emitted []any
+	throttle := telemetry.NewThrottle(50*time.Millisecond, func(value any) {
+		mu.Lock()
+		defer mu.Unlock()
+		emitted = append(emitted, value)
+	})
+
+	//The first value goes straight out, the rest collapse to the latest
+	for i := 1; i <= 5; i++ {
+		throttle.Push(i)
+	}
+	mu.Lock()
+	assert.Equal(s.T(), []any{1}, emitted)
+	mu.Unlock()
+
+	require.Eventually(s.T(), func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(emitted) == 2
+	}, time.Second, 5*time.Millisecond)
+	mu.Lock()
+	assert.Equal(s.T(), []any{1, 5}, emitted)
+	mu.Unlock()
+}
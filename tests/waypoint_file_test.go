@@ -0,0 +1,268 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"gcom-backend/configs"
+	"gcom-backend/controllers"
+	"gcom-backend/formats"
+	"gcom-backend/models"
+	"gcom-backend/responses"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gorm.io/gorm"
+)
+
+const testWPL = `QGC WPL 110
+0	1	0	16	0	0	0	0	49.2600000	-123.2400000	80.000000	1
+1	0	3	22	0	0	0	0	0	0	30.000000	1
+2	0	3	16	0	5	0	0	49.2610000	-123.2410000	100.000000	1
+3	0	3	183	9	1500	0	0	0	0	0	1
+4	0	3	19	30	0	-25	0	49.2620000	-123.2420000	100.000000	1
+5	0	3	21	0	0	0	0	49.2600000	-123.2400000	0	1
+`
+
+const testPlan = `{
+    "fileType": "Plan",
+    "version": 1,
+    "mission": {
+        "items": [
+            {
+                "type": "SimpleItem",
+                "command": 22,
+                "frame": 3,
+                "params": [0, 0, 0, null, 0, 0, 30]
+            },
+            {
+                "type": "ComplexItem",
+                "complexItemType": "survey"
+            },
+            {
+                "type": "SimpleItem",
+                "command": 16,
+                "frame": 3,
+                "params": [0, 3, 0, null, 49.261, -123.241, 100]
+            }
+        ],
+        "plannedHomePosition": [49.26, -123.24, 80]
+    }
+}`
+
+type WaypointFileTestSuite struct {
+	suite.Suite
+	e  *echo.Echo
+	db *gorm.DB
+}
+
+func TestRunWaypointFileSuite(t *testing.T) {
+	suite.Run(t, new(WaypointFileTestSuite))
+}
+
+func (s *WaypointFileTestSuite) SetupSuite() {
+	s.db = configs.Connect(true)
+	s.e = echo.New()
+}
+
+func (s *WaypointFileTestSuite) TearDownSuite() {
+	sqlDB, _ := s.db.DB()
+	if err := sqlDB.Close(); err != nil {
+		fmt.Println("[Teardown] Error closing database connection!")
+	}
+
+	if err := os.Remove("database.db"); err != nil {
+		fmt.Println("[Teardown] Error deleting database!")
+	}
+}
+
+func (s *WaypointFileTestSuite) TearDownTest() {
+	s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&models.Waypoint{})
+}
+
+func (s *WaypointFileTestSuite) importFile(uri string, file string) responses.SingleResponse[formats.Import] {
+	var req = httptest.NewRequest(http.MethodPost, uri, strings.NewReader(file))
+	req.Header.Set(echo.HeaderContentType, echo.MIMETextPlain)
+	var rec = httptest.NewRecorder()
+	var c = s.e.NewContext(req, rec)
+	c.Set("db", s.db)
+
+	require.NoError(s.T(), controllers.ImportWaypoints(c))
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+
+	var response responses.SingleResponse[formats.Import]
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &response))
+	return response
+}
+
+func (s *WaypointFileTestSuite) TestImportWPL() {
+	response := s.importFile("/waypoints/import?name=Lap", testWPL)
+
+	imported := response.Model
+	assert.Equal(s.T(), "wpl", imported.Format)
+	require.Len(s.T(), imported.Waypoints, 4)
+
+	//Takeoff has no position so it takes home's
+	assert.Equal(s.T(), models.Launch, imported.Waypoints[0].Designation)
+	assert.Equal(s.T(), 49.26, imported.Waypoints[0].Latitude)
+	assert.Equal(s.T(), 30.0, imported.Waypoints[0].Altitude)
+
+	assert.Equal(s.T(), "Lap 2", imported.Waypoints[1].Name)
+	assert.Equal(s.T(), 5.0, imported.Waypoints[1].Radius)
+	assert.Equal(s.T(), models.Loiter, imported.Waypoints[2].Designation)
+	assert.Equal(s.T(), 25.0, imported.Waypoints[2].Radius)
+	assert.Equal(s.T(), models.Land, imported.Waypoints[3].Designation)
+
+	require.Len(s.T(), imported.Issues, 1)
+	assert.Equal(s.T(), 5, imported.Issues[0].Line)
+	assert.Equal(s.T(), 183, imported.Issues[0].Command)
+
+	var count int64
+	s.db.Model(&models.Waypoint{}).Count(&count)
+	assert.Equal(s.T(), int64(4), count)
+	assert.NotZero(s.T(), imported.Waypoints[0].ID)
+}
+
+func (s *WaypointFileTestSuite) TestImportPlanDryRun() {
+	response := s.importFile("/waypoints/import?dry_run=true", testPlan)
+
+	imported := response.Model
+	assert.Equal(s.T(), "plan", imported.Format)
+	require.Len(s.T(), imported.Waypoints, 2)
+	assert.Equal(s.T(), "WP 1", imported.Waypoints[0].Name)
+	assert.Equal(s.T(), -123.24, imported.Waypoints[0].Longitude)
+	assert.Equal(s.T(), 3.0, imported.Waypoints[1].Radius)
+
+	require.Len(s.T(), imported.Issues, 1)
+	assert.Equal(s.T(), 12, imported.Issues[0].Line)
+
+	var count int64
+	s.db.Model(&models.Waypoint{}).Count(&count)
+	assert.Equal(s.T(), int64(0), count)
+}
+
+func (s *WaypointFileTestSuite) TestImportMissingHeader() {
+	var req = httptest.NewRequest(http.MethodPost, "/waypoints/import?format=wpl", strings.NewReader("1\t0\t3\t16"))
+	var rec = httptest.NewRecorder()
+	var c = s.e.NewContext(req, rec)
+	c.Set("db", s.db)
+
+	require.NoError(s.T(), controllers.ImportWaypoints(c))
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+}
+
+func (s *WaypointFileTestSuite) TestImportBlankFile() {
+	var req = httptest.NewRequest(http.MethodPost, "/waypoints/import?format=wpl", strings.NewReader("\n  \n\n"))
+	var rec = httptest.NewRecorder()
+	var c = s.e.NewContext(req, rec)
+	c.Set("db", s.db)
+
+	require.NoError(s.T(), controllers.ImportWaypoints(c))
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+	assert.Contains(s.T(), rec.Body.String(), "header")
+}
+
+func (s *WaypointFileTestSuite) TestImportInvalidWaypoint() {
+	//Only landing may be at altitude 0, so the waypoint is skipped like an
+	//unsupported command and the landing is kept
+	file := strings.Replace(testWPL, "-123.2410000\t100.000000", "-123.2410000\t0", 1)
+	response := s.importFile("/waypoints/import?name=Lap", file)
+
+	imported := response.Model
+	require.Len(s.T(), imported.Waypoints, 3)
+	assert.Equal(s.T(), "Lap 2", imported.Waypoints[1].Name)
+	assert.Equal(s.T(), models.Loiter, imported.Waypoints[1].Designation)
+	assert.Equal(s.T(), models.Land, imported.Waypoints[2].Designation)
+	assert.Zero(s.T(), imported.Waypoints[2].Altitude)
+
+	require.Len(s.T(), imported.Issues, 2)
+	assert.Equal(s.T(), 4, imported.Issues[0].Line)
+	assert.Equal(s.T(), formats.CmdNavWaypoint, imported.Issues[0].Command)
+	assert.Equal(s.T(), 5, imported.Issues[1].Line)
+}
+
+func (s *WaypointFileTestSuite) exportFile(uri string, handler echo.HandlerFunc) *httptest.ResponseRecorder {
+	var req = httptest.NewRequest(http.MethodGet, uri, nil)
+	var rec = httptest.NewRecorder()
+	var c = s.e.NewContext(req, rec)
+	c.Set("db", s.db)
+
+	require.NoError(s.T(), handler(c))
+	return rec
+}
+
+func (s *WaypointFileTestSuite) TestExportRoundTrip() {
+	s.importFile("/waypoints/import", testWPL)
+
+	rec := s.exportFile("/waypoints/export?format=wpl", controllers.ExportWaypoints)
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+	assert.Contains(s.T(), rec.Header().Get(echo.HeaderContentDisposition), "waypoints.waypoints")
+
+	reimported, err := formats.ParseWPL(strings.NewReader(rec.Body.String()), "WP")
+	require.NoError(s.T(), err)
+	require.Len(s.T(), reimported.Waypoints, 4)
+	assert.Empty(s.T(), reimported.Issues)
+	assert.Equal(s.T(), models.Loiter, reimported.Waypoints[2].Designation)
+	assert.Equal(s.T(), 25.0, reimported.Waypoints[2].Radius)
+
+	rec = s.exportFile("/waypoints/export?format=plan", controllers.ExportWaypoints)
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	reimported, err = formats.ParsePlan(rec.Body.Bytes(), "WP")
+	require.NoError(s.T(), err)
+	assert.Len(s.T(), reimported.Waypoints, 4)
+}
+
+func (s *WaypointFileTestSuite) TestExportGeoJSON() {
+	s.importFile("/waypoints/import", testWPL)
+
+	var first models.Waypoint
+	s.db.Order("id").First(&first)
+	rec := s.exportFile(fmt.Sprintf("/waypoints/export?format=geojson&ids=%d", first.ID+1), controllers.ExportWaypoints)
+	require.Equal(s.T(), http.StatusOK, rec.Code, rec.Body.String())
+
+	var collection struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Geometry struct {
+				Type string `json:"type"`
+			} `json:"geometry"`
+			Properties map[string]any `json:"properties"`
+		} `json:"features"`
+	}
+	require.NoError(s.T(), json.Unmarshal(rec.Body.Bytes(), &collection))
+	assert.Equal(s.T(), "FeatureCollection", collection.Type)
+
+	//The waypoint and its radius ring
+	require.Len(s.T(), collection.Features, 2)
+	assert.Equal(s.T(), "Point", collection.Features[0].Geometry.Type)
+	assert.Equal(s.T(), "WP 2", collection.Features[0].Properties["name"])
+	assert.Equal(s.T(), "Polygon", collection.Features[1].Geometry.Type)
+}
+
+func (s *WaypointFileTestSuite) TestExportKMLAndErrors() {
+	s.importFile("/waypoints/import", testWPL)
+
+	rec := s.exportFile("/waypoints/export?format=kml", controllers.ExportWaypoints)
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	assert.Contains(s.T(), rec.Body.String(), "<kml")
+	assert.Contains(s.T(), rec.Body.String(), "WP 3")
+
+	rec = s.exportFile("/waypoints/export?format=gpx", controllers.ExportWaypoints)
+	assert.Equal(s.T(), http.StatusBadRequest, rec.Code)
+
+	rec = s.exportFile("/waypoints/export?format=kml&ids=999999", controllers.ExportWaypoints)
+	assert.Equal(s.T(), http.StatusNotFound, rec.Code)
+
+	rec = s.exportFile("/waypoints/export?format=kml&mission=missing", controllers.ExportWaypoints)
+	assert.Equal(s.T(), http.StatusNotFound, rec.Code)
+
+	rec = s.exportFile("/groundobjects/export?format=geojson", controllers.ExportGroundObjects)
+	require.Equal(s.T(), http.StatusOK, rec.Code)
+	assert.Contains(s.T(), rec.Body.String(), "FeatureCollection")
+}
@@ -0,0 +1,54 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"gcom-backend/audit"
+	"gcom-backend/models"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AuditMiddleware appends an entry to the audit log for every request which
+// may change state, that is everything but GET, HEAD and OPTIONS. Controllers
+// add what they changed to the "audit_request" they are given, which also
+// records read-only requests which changed something, such as GET /drone/land.
+func AuditMiddleware(log *audit.Log) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("audit", log)
+
+			request := &audit.Request{}
+			c.Set("audit_request", request)
+			err := next(c)
+
+			method := c.Request().Method
+			readOnly := method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+			if readOnly && request.Empty() {
+				return err
+			}
+
+			status := c.Response().Status
+			var httpErr *echo.HTTPError
+			if errors.As(err, &httpErr) {
+				status = httpErr.Code
+			} else if err != nil {
+				status = http.StatusInternalServerError
+			}
+
+			user, _ := c.Get("user").(models.User)
+			entries := request.Entries(models.AuditEntry{
+				Actor:    user.Username,
+				Method:   method,
+				Route:    c.Path(),
+				ClientIP: c.RealIP(),
+				Status:   status,
+			})
+			if appendErr := log.Append(entries...); appendErr != nil {
+				fmt.Println("[AUDIT] Unable to append to the audit log:", appendErr)
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package util
+
+import (
+	"gcom-backend/auth"
+	"gcom-backend/models"
+	"gcom-backend/responses"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// AuthMiddleware lets through requests carrying the token of a user whose
+// role allows the required role. It sets "user" to whoever made the request,
+// even if they are refused.
+func AuthMiddleware(authenticator *auth.Authenticator, role models.Role) echo.MiddlewareFunc {
+	return authMiddleware(authenticator, role, auth.RequestToken)
+}
+
+// StreamAuthMiddleware is AuthMiddleware for streams, which also accept the
+// token query param
+func StreamAuthMiddleware(authenticator *auth.Authenticator, role models.Role) echo.MiddlewareFunc {
+	return authMiddleware(authenticator, role, auth.StreamToken)
+}
+
+func authMiddleware(authenticator *auth.Authenticator, role models.Role, token func(*http.Request) string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			user, err := authenticator.Authenticate(token(c.Request()))
+			if err != nil {
+				return c.JSON(http.StatusUnauthorized, responses.ErrorResponse{
+					Message: "Unauthorized",
+					Data:    err.Error()})
+			}
+			//Set before checking the role so refusals are audited with who made them
+			c.Set("user", user)
+			if !user.Role.Allows(role) {
+				return c.JSON(http.StatusForbidden, responses.ErrorResponse{
+					Message: "Forbidden",
+					Data:    "this needs the " + string(role) + " role"})
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package util
+
+import (
+	"gcom-backend/auth"
+
+	"github.com/labstack/echo/v4"
+)
+
+func AuthenticatorMiddleware(authenticator *auth.Authenticator) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("auth", authenticator)
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package util
+
+import (
+	"gcom-backend/imagery"
+
+	"github.com/labstack/echo/v4"
+)
+
+// CameraMiddleware makes the camera on the drone available to controllers
+// georeferencing its images
+func CameraMiddleware(camera imagery.Camera) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("camera", camera)
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package util
+
+import (
+	"gcom-backend/commands"
+
+	"github.com/labstack/echo/v4"
+)
+
+func CommandsMiddleware(commander *commands.Commander) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("commands", commander)
+			return next(c)
+		}
+	}
+}
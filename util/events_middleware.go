@@ -0,0 +1,16 @@
+package util
+
+import (
+	"gcom-backend/events"
+
+	"github.com/labstack/echo/v4"
+)
+
+func EventsMiddleware(bus *events.Bus) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("events", bus)
+			return next(c)
+		}
+	}
+}
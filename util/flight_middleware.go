@@ -0,0 +1,16 @@
+package util
+
+import (
+	"gcom-backend/flight"
+
+	"github.com/labstack/echo/v4"
+)
+
+func FlightMiddleware(recorder *flight.Recorder) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("flight", recorder)
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package util
+
+import (
+	"gcom-backend/interop"
+
+	"github.com/labstack/echo/v4"
+)
+
+// InteropMiddleware makes the competition's interop server available to
+// controllers, if it is configured
+func InteropMiddleware(connection *interop.Interop) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if connection != nil {
+				c.Set("interop", connection)
+			}
+			return next(c)
+		}
+	}
+}
@@ -1,8 +1,11 @@
 package util
 
 import (
+	"bytes"
 	"log"
 	"os"
+
+	"github.com/labstack/echo/v4"
 )
 
 // Info writes logs in the color blue with "INFO: " as prefix
@@ -16,3 +19,18 @@ var Error = log.New(os.Stdout, "\u001b[31mERROR: \u001b[0m", log.LstdFlags|log.L
 
 // Debug writes logs in the color cyan with "DEBUG: " as prefix
 var Debug = log.New(os.Stdout, "\u001b[36mDEBUG: \u001B[0m", log.LstdFlags|log.Lshortfile)
+
+// RequestLogFormat is the format of the request log, with the URI written by
+// RedactURI
+const RequestLogFormat = "${time_rfc3339} method=${method} uri=${custom} status=${status} ping=${latency_human}\n"
+
+// RedactURI writes the request URI for the request log with the token query
+// param hidden, as event streams may be authenticated with it
+func RedactURI(c echo.Context, buf *bytes.Buffer) (int, error) {
+	uri := *c.Request().URL
+	if query := uri.Query(); query.Has("token") {
+		query.Set("token", "REDACTED")
+		uri.RawQuery = query.Encode()
+	}
+	return buf.WriteString(uri.RequestURI())
+}
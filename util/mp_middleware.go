@@ -6,7 +6,7 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
-func MPMiddleware(mp *configs.MissionPlanner) echo.MiddlewareFunc {
+func MPMiddleware(mp configs.Autopilot) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
 			c.Set("mp", mp)
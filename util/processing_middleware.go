@@ -0,0 +1,18 @@
+package util
+
+import (
+	"gcom-backend/processing"
+
+	"github.com/labstack/echo/v4"
+)
+
+// ProcessingMiddleware makes the runner looking for ground objects in new
+// images available to controllers
+func ProcessingMiddleware(runner *processing.Runner) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("processing", runner)
+			return next(c)
+		}
+	}
+}
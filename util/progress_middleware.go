@@ -0,0 +1,16 @@
+package util
+
+import (
+	"gcom-backend/progress"
+
+	"github.com/labstack/echo/v4"
+)
+
+func ProgressMiddleware(tracker *progress.Tracker) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("progress", tracker)
+			return next(c)
+		}
+	}
+}
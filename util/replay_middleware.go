@@ -0,0 +1,16 @@
+package util
+
+import (
+	"gcom-backend/replay"
+
+	"github.com/labstack/echo/v4"
+)
+
+func ReplayMiddleware(engine *replay.Engine) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("replay", engine)
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package util
+
+import (
+	"gcom-backend/telemetry"
+
+	"github.com/labstack/echo/v4"
+)
+
+func TelemetryMiddleware(pipeline *telemetry.Pipeline) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("telemetry", pipeline)
+			return next(c)
+		}
+	}
+}
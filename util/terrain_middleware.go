@@ -0,0 +1,18 @@
+package util
+
+import (
+	"gcom-backend/imagery"
+
+	"github.com/labstack/echo/v4"
+)
+
+// TerrainMiddleware makes the height of the ground available to controllers
+// projecting pixels onto it
+func TerrainMiddleware(terrain imagery.Terrain) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			c.Set("terrain", terrain)
+			return next(c)
+		}
+	}
+}